@@ -3,6 +3,7 @@ package blockchain
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"math/big"
@@ -12,6 +13,9 @@ import (
 	"backend-api/internal/config"
 )
 
+// ErrAddressNotWatched 地址未加入中间件的转账索引
+var ErrAddressNotWatched = errors.New("address is not watched by the transfer indexer")
+
 // Client 区块链客户端
 type Client struct {
 	baseURL    string
@@ -208,4 +212,48 @@ func (c *Client) GetNonce(chain ChainType, address string) (*NonceResponse, erro
 	}
 	
 	return &nonceResp, nil
-}
\ No newline at end of file
+}
+
+// GetTransfers 分页获取地址的转账记录，按区块倒序
+func (c *Client) GetTransfers(chain ChainType, address string, limit, offset int) (*TransferPage, error) {
+	url := fmt.Sprintf("%s/api/v1/chains/%s/accounts/%s/transfers?limit=%d&offset=%d", c.baseURL, chain, address, limit, offset)
+	
+	resp, err := c.httpClient.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call get transfers endpoint: %w", err)
+	}
+	defer resp.Body.Close()
+	
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrAddressNotWatched
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("get transfers failed with status %d: %s", resp.StatusCode, string(body))
+	}
+	
+	var page TransferPage
+	if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+		return nil, fmt.Errorf("failed to decode transfers response: %w", err)
+	}
+	
+	return &page, nil
+}
+
+// WatchAddress 把地址加入中间件的转账索引，之后的转账开始记录
+func (c *Client) WatchAddress(chain ChainType, address string) error {
+	url := fmt.Sprintf("%s/api/v1/chains/%s/accounts/%s/watch", c.baseURL, chain, address)
+	
+	resp, err := c.httpClient.Post(url, "application/json", bytes.NewBufferString("{}"))
+	if err != nil {
+		return fmt.Errorf("failed to call watch address endpoint: %w", err)
+	}
+	defer resp.Body.Close()
+	
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("watch address failed with status %d: %s", resp.StatusCode, string(body))
+	}
+	
+	return nil
+}
@@ -77,6 +77,34 @@ type ChainInfo struct {
 	Enabled     bool   `json:"enabled"`
 }
 
+// Transfer 中间件转账索引记录的一笔转账
+type Transfer struct {
+	TxHash      string   `json:"tx_hash"`
+	LogIndex    int64    `json:"log_index"` // 原生币转账为-1
+	BlockNumber uint64   `json:"block_number"`
+	Timestamp   int64    `json:"timestamp"`
+	Type        string   `json:"type"` // native、erc20、erc721
+	From        string   `json:"from"`
+	To          string   `json:"to"`
+	Value       *big.Int `json:"value,omitempty"`
+	Token       string   `json:"token,omitempty"`
+	TokenID     string   `json:"token_id,omitempty"`
+	Symbol      string   `json:"symbol,omitempty"`
+	Decimals    uint8    `json:"decimals"`
+	Success     bool     `json:"success"`
+	Direction   string   `json:"direction"` // in、out、self
+}
+
+// TransferPage 分页的转账记录
+type TransferPage struct {
+	Address      string     `json:"address"`
+	Transfers    []Transfer `json:"transfers"`
+	Total        int        `json:"total"`
+	Limit        int        `json:"limit"`
+	Offset       int        `json:"offset"`
+	IndexedBlock uint64     `json:"indexed_block"`
+}
+
 // APIResponse API响应包装器
 type APIResponse struct {
 	Success bool            `json:"success"`
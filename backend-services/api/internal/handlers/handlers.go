@@ -212,7 +212,7 @@ func (h *Handler) GetWalletTransactions(w http.ResponseWriter, r *http.Request)
 		}
 	}
 
-	transactions, err := h.walletService.GetWalletTransactions(id, limit, offset)
+	transactions, total, err := h.walletService.GetWalletTransactions(id, limit, offset)
 	if err != nil {
 		if err.Error() == "wallet not found" {
 			jsonResponse(w, map[string]string{"error": "Wallet not found"}, http.StatusNotFound)
@@ -226,7 +226,7 @@ func (h *Handler) GetWalletTransactions(w http.ResponseWriter, r *http.Request)
 		"transactions": transactions,
 		"limit":        limit,
 		"offset":       offset,
-		"total":        len(transactions),
+		"total":        total,
 	}
 	jsonResponse(w, response, http.StatusOK)
 }
@@ -1,6 +1,7 @@
 package server
 
 import (
+	"backend-api/internal/clients/blockchain"
 	"backend-api/internal/config"
 	"backend-api/internal/database"
 	"backend-api/internal/handlers"
@@ -67,7 +68,7 @@ func NewServer(cfg *config.Config) *Server {
 	// 初始化服务
 	userService := services.NewUserService(userRepo)
 	mpcService := services.NewMPCService(cfg)
-	walletService := services.NewWalletService(walletRepo, mpcService)
+	walletService := services.NewWalletService(walletRepo, mpcService, blockchain.NewClient(&cfg.Chain))
 	chainService := services.NewChainService(cfg)
 	passkeyService := services.NewPasskeyService()
 	
@@ -1,24 +1,29 @@
 package services
 
 import (
+	"backend-api/internal/clients/blockchain"
 	"backend-api/internal/models"
 	"backend-api/internal/repositories"
 	"backend-api/internal/utils"
+	"errors"
 	"fmt"
+	"strconv"
 	"time"
 )
 
 // WalletService 钱包服务
 type WalletService struct {
-	walletRepo repositories.WalletRepository
-	mpcService *MPCService
+	walletRepo  repositories.WalletRepository
+	mpcService  *MPCService
+	chainClient *blockchain.Client
 }
 
 // NewWalletService 创建钱包服务实例
-func NewWalletService(walletRepo repositories.WalletRepository, mpcService *MPCService) *WalletService {
+func NewWalletService(walletRepo repositories.WalletRepository, mpcService *MPCService, chainClient *blockchain.Client) *WalletService {
 	return &WalletService{
-		walletRepo: walletRepo,
-		mpcService: mpcService,
+		walletRepo:  walletRepo,
+		mpcService:  mpcService,
+		chainClient: chainClient,
 	}
 }
 
@@ -195,41 +200,59 @@ func (s *WalletService) SendTransaction(walletID, to, value, data string) (strin
 	return txHash, nil
 }
 
-// GetWalletTransactions 获取钱包交易记录
-func (s *WalletService) GetWalletTransactions(walletID string, limit, offset int) ([]map[string]interface{}, error) {
+// GetWalletTransactions 获取钱包交易记录，来自区块链中间件的转账索引，同时返回总数
+// 钱包地址尚未加入索引时在首次查询时加入，之后的转账开始记录。
+func (s *WalletService) GetWalletTransactions(walletID string, limit, offset int) ([]map[string]interface{}, int, error) {
 	if walletID == "" {
-		return nil, ErrInvalidRequest
+		return nil, 0, ErrInvalidRequest
 	}
 
 	wallet, err := s.walletRepo.FindByID(walletID)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
-	// 这里应该调用区块链服务查询交易记录
-	// 暂时返回模拟数据
-	transactions := []map[string]interface{}{
-		{
-			"hash":        "0x1234567890abcdef1234567890abcdef1234567890abcdef1234567890abcdef",
-			"from":        wallet.WalletAddress,
-			"to":          "0x9876543210fedcba9876543210fedcba9876543210fedcba9876543210fedcba",
-			"value":       "100000000000000000",
-			"gasUsed":     "21000",
-			"status":      "success",
-			"timestamp":   time.Now().Add(-time.Hour).Unix(),
-			"blockNumber": "12345678",
-		},
-		{
-			"hash":        "0xfedcba0987654321fedcba0987654321fedcba0987654321fedcba0987654321",
-			"from":        "0x9876543210fedcba9876543210fedcba9876543210fedcba9876543210fedcba",
-			"to":          wallet.WalletAddress,
-			"value":       "500000000000000000",
-			"gasUsed":     "21000",
-			"status":      "success",
-			"timestamp":   time.Now().Add(-2 * time.Hour).Unix(),
-			"blockNumber": "12345677",
-		},
-	}
-
-	return transactions, nil
-}
\ No newline at end of file
+	chain := blockchain.ChainType(wallet.ChainType)
+	page, err := s.chainClient.GetTransfers(chain, wallet.WalletAddress, limit, offset)
+	if errors.Is(err, blockchain.ErrAddressNotWatched) {
+		if err := s.chainClient.WatchAddress(chain, wallet.WalletAddress); err != nil {
+			return nil, 0, fmt.Errorf("failed to watch wallet address: %w", err)
+		}
+		return []map[string]interface{}{}, 0, nil
+	}
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get wallet transactions: %w", err)
+	}
+
+	transactions := make([]map[string]interface{}, 0, len(page.Transfers))
+	for _, transfer := range page.Transfers {
+		status := "success"
+		if !transfer.Success {
+			status = "failed"
+		}
+		tx := map[string]interface{}{
+			"hash":        transfer.TxHash,
+			"from":        transfer.From,
+			"to":          transfer.To,
+			"type":        transfer.Type,
+			"direction":   transfer.Direction,
+			"symbol":      transfer.Symbol,
+			"decimals":    transfer.Decimals,
+			"status":      status,
+			"timestamp":   transfer.Timestamp,
+			"blockNumber": strconv.FormatUint(transfer.BlockNumber, 10),
+		}
+		if transfer.Value != nil {
+			tx["value"] = transfer.Value.String()
+		}
+		if transfer.Token != "" {
+			tx["token"] = transfer.Token
+		}
+		if transfer.TokenID != "" {
+			tx["tokenId"] = transfer.TokenID
+		}
+		transactions = append(transactions, tx)
+	}
+
+	return transactions, page.Total, nil
+}
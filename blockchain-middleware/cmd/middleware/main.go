@@ -3,19 +3,38 @@ package main
 import (
 	"blockchain-middleware/internal/config"
 	"blockchain-middleware/internal/server"
+	"blockchain-middleware/pkg/telemetry"
+	"context"
+	"flag"
 	"log"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 )
 
 func main() {
+	// 配置文件路径：-config 参数优先，其次 MIDDLEWARE_CONFIG_FILE，都未设置时只使用内置默认值和环境变量
+	configPath := flag.String("config", os.Getenv("MIDDLEWARE_CONFIG_FILE"), "path to the YAML config file")
+	flag.Parse()
+
 	// 加载配置
-	cfg, err := config.LoadConfig()
+	cfg, err := config.LoadConfig(*configPath)
 	if err != nil {
 		log.Fatalf("Failed to load config: %v", err)
 	}
 
+	// 初始化链路追踪，未配置导出时仍向下游传递上游的 trace context
+	shutdownTracing, err := telemetry.InitTracing(telemetry.TracingConfig{
+		ServiceName: cfg.Telemetry.ServiceName,
+		Exporter:    cfg.Telemetry.TracesExporter,
+		File:        cfg.Telemetry.TracesFile,
+		SampleRatio: cfg.Telemetry.SampleRatio,
+	})
+	if err != nil {
+		log.Fatalf("Failed to initialize tracing: %v", err)
+	}
+
 	// 创建并启动服务器
 	srv, err := server.NewServer(cfg)
 	if err != nil {
@@ -31,10 +50,12 @@ func main() {
 
 	log.Printf("Blockchain middleware server started on %s", cfg.Server.Address)
 
-	// 等待终止信号
+	// 等待终止信号，SIGHUP 时重新加载配置
 	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+	for sig := <-quit; sig == syscall.SIGHUP; sig = <-quit {
+		reloadConfig(srv, *configPath)
+	}
 
 	log.Println("Shutting down server...")
 
@@ -43,5 +64,28 @@ func main() {
 		log.Fatalf("Failed to stop server gracefully: %v", err)
 	}
 
+	// 导出缓冲中尚未发送的span
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	if err := shutdownTracing(ctx); err != nil {
+		log.Printf("Failed to flush traces: %v", err)
+	}
+	cancel()
+
 	log.Println("Server stopped")
-}
\ No newline at end of file
+}
+
+// reloadConfig 重新加载配置文件（环境变量仍然优先），校验失败时保留当前配置
+func reloadConfig(srv *server.Server, path string) {
+	log.Println("Reloading config...")
+
+	cfg, err := config.LoadConfig(path)
+	if err != nil {
+		log.Printf("Config reload rejected, keeping current config: %v", err)
+		return
+	}
+	if err := srv.Reload(cfg); err != nil {
+		log.Printf("Config reload partially applied: %v", err)
+		return
+	}
+	log.Println("Config reloaded")
+}
@@ -0,0 +1,164 @@
+// mock-relayer 本地开发和联调用的跨链中继器
+// 在源链查到桥合约转出事件并达到确认数后，用配置的私钥对 bridge.AttestationDigest 签名作为证明。
+package main
+
+import (
+	"blockchain-middleware/pkg/bridge"
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/gorilla/mux"
+)
+
+// relayer 按路由查询源链事件并签发证明
+type relayer struct {
+	routes        map[string]*bridge.Route
+	clients       map[string]*ethclient.Client
+	confirmations uint64
+	key           *ecdsa.PrivateKey
+}
+
+func main() {
+	routes, err := bridge.LoadRoutes(getEnv("BRIDGE_ROUTES_FILE", "bridge-routes.json"))
+	if err != nil {
+		log.Fatalf("Failed to load routes: %v", err)
+	}
+	key, err := crypto.HexToECDSA(strings.TrimPrefix(os.Getenv("MOCK_RELAYER_KEY"), "0x"))
+	if err != nil {
+		log.Fatalf("Invalid MOCK_RELAYER_KEY: %v", err)
+	}
+	confirmations, err := strconv.ParseUint(getEnv("MOCK_RELAYER_CONFIRMATIONS", "1"), 10, 64)
+	if err != nil {
+		log.Fatalf("Invalid MOCK_RELAYER_CONFIRMATIONS: %v", err)
+	}
+
+	r := &relayer{
+		routes:        make(map[string]*bridge.Route),
+		clients:       make(map[string]*ethclient.Client),
+		confirmations: confirmations,
+		key:           key,
+	}
+	for _, route := range routes {
+		r.routes[route.Name] = route
+	}
+
+	// MOCK_RELAYER_RPC_URLS 格式为 ethereum=http://localhost:8545,polygon=http://localhost:8546
+	for _, entry := range strings.Split(os.Getenv("MOCK_RELAYER_RPC_URLS"), ",") {
+		name, url, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok {
+			continue
+		}
+		client, err := ethclient.Dial(url)
+		if err != nil {
+			log.Fatalf("Failed to connect to %s: %v", name, err)
+		}
+		r.clients[name] = client
+	}
+
+	router := mux.NewRouter()
+	router.HandleFunc("/attestations/{transferId}", r.attestation).Methods("GET")
+
+	address := getEnv("MOCK_RELAYER_ADDRESS", ":8090")
+	log.Printf("Mock relayer %s listening on %s", crypto.PubkeyToAddress(key.PublicKey).Hex(), address)
+	if err := http.ListenAndServe(address, router); err != nil {
+		log.Fatalf("Mock relayer stopped: %v", err)
+	}
+}
+
+// attestation 源链事件达到确认数时返回证明，否则返回404
+func (r *relayer) attestation(w http.ResponseWriter, req *http.Request) {
+	route, ok := r.routes[req.URL.Query().Get("route")]
+	if !ok {
+		http.Error(w, "unknown route", http.StatusBadRequest)
+		return
+	}
+	source, ok := r.clients[route.SourceChain]
+	if !ok {
+		http.Error(w, "no rpc url for "+route.SourceChain, http.StatusBadRequest)
+		return
+	}
+	target, ok := r.clients[route.TargetChain]
+	if !ok {
+		http.Error(w, "no rpc url for "+route.TargetChain, http.StatusBadRequest)
+		return
+	}
+	transferID := common.HexToHash(mux.Vars(req)["transferId"])
+
+	ctx, cancel := context.WithTimeout(req.Context(), 10*time.Second)
+	defer cancel()
+
+	attestation, err := r.attest(ctx, route, source, target, transferID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	if attestation == nil {
+		http.Error(w, "attestation not ready", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"attestation": hexutil.Encode(attestation)})
+}
+
+// attest 查找源链转出事件并对事件内容签名，事件不存在或确认数不足时返回nil
+func (r *relayer) attest(ctx context.Context, route *bridge.Route, source, target *ethclient.Client, transferID common.Hash) ([]byte, error) {
+	adapter, _ := bridge.LookupAdapter(route.Adapter)
+	sourceEvent, _, _ := adapter.Events()
+
+	logs, err := source.FilterLogs(ctx, ethereum.FilterQuery{
+		Addresses: []common.Address{common.HexToAddress(route.SourceBridge)},
+		Topics:    [][]common.Hash{{sourceEvent}, {transferID}},
+	})
+	if err != nil || len(logs) == 0 {
+		return nil, err
+	}
+	head, err := source.BlockNumber(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if head+1 < logs[0].BlockNumber+r.confirmations {
+		return nil, nil
+	}
+
+	event, err := adapter.ParseSource(logs[0])
+	if err != nil {
+		return nil, err
+	}
+	sourceChainID, err := source.ChainID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	targetChainID, err := target.ChainID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	digest := bridge.AttestationDigest(route, transferID, sourceChainID.Int64(), targetChainID.Int64(), event.Recipient, event.Amount)
+	sig, err := crypto.Sign(digest.Bytes(), r.key)
+	if err != nil {
+		return nil, err
+	}
+	// 与 ecrecover 一致，v 为27或28
+	sig[64] += 27
+	return sig, nil
+}
+
+// getEnv 获取环境变量，如果不存在则返回默认值
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
@@ -1,16 +1,34 @@
 package config
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"io"
+	"net/url"
 	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
 )
 
 // Config 配置结构
 type Config struct {
-	Server   ServerConfig   `yaml:"server"`
-	Chains   ChainsConfig   `yaml:"chains"`
-	Database DatabaseConfig `yaml:"database"`
-	Cache    CacheConfig    `yaml:"cache"`
+	Environment string `yaml:"environment"` // 运行环境，development/dev/test 为开发模式，只有开发模式允许明文私钥
+
+	Server    ServerConfig    `yaml:"server"`
+	Chains    ChainsConfig    `yaml:"chains"`
+	Database  DatabaseConfig  `yaml:"database"`
+	Cache     CacheConfig     `yaml:"cache"`
+	Signer    SignerConfig    `yaml:"signer"`
+	MPC       MPCConfig       `yaml:"mpc"`
+	Tracker   TrackerConfig   `yaml:"tracker"`
+	Indexer   IndexerConfig   `yaml:"indexer"`
+	Bridge    BridgeConfig    `yaml:"bridge"`
+	Contracts ContractsConfig `yaml:"contracts"`
+	Telemetry TelemetryConfig `yaml:"telemetry"`
 }
 
 // ServerConfig 服务器配置
@@ -21,25 +39,45 @@ type ServerConfig struct {
 
 // ChainsConfig 区块链配置
 type ChainsConfig struct {
-	Ethereum ChainConfig `yaml:"ethereum"`
-	Polygon  ChainConfig `yaml:"polygon"`
-	BSC      ChainConfig `yaml:"bsc"`
-	Bitcoin  ChainConfig `yaml:"bitcoin"`
+	EVM     []ChainConfig `yaml:"evm"` // 任意数量的EVM链，由通用EVM客户端驱动
+	Bitcoin ChainConfig   `yaml:"bitcoin"`
 }
 
 // ChainConfig 单个链配置
 type ChainConfig struct {
-	Enabled      bool   `yaml:"enabled"`
-	RPCURL       string `yaml:"rpc_url"`
-	ChainID      int64  `yaml:"chain_id"`
-	NetworkName  string `yaml:"network_name"`
-	WsURL        string `yaml:"ws_url"`
-	ExplorerURL  string `yaml:"explorer_url"`
-	PrivateKey   string `yaml:"private_key"` // 仅用于测试环境
+	Name        string `yaml:"name"` // 链名称，即API路径中的 {chain}
+	Enabled     bool   `yaml:"enabled"`
+	RPCURL      string `yaml:"rpc_url"`
+	ChainID     int64  `yaml:"chain_id"`
+	NetworkName string `yaml:"network_name"`
+	WsURL       string `yaml:"ws_url"`
+	ExplorerURL string `yaml:"explorer_url"`
+	RPCUser     string `yaml:"rpc_user"` // RPC基本认证（bitcoind需要）
+	RPCPassword string `yaml:"rpc_password"`
+
+	RPCURLs     []string `yaml:"rpc_urls"`      // 备用RPC节点，与 RPCURL 一起组成节点池
+	MaxBlockLag uint64   `yaml:"max_block_lag"` // 节点落后最高区块超过该值时被剔除，0表示默认值
+
+	RequestTimeout time.Duration `yaml:"request_timeout"` // 单次RPC请求超时，0表示默认值
+	RPCRateLimit   float64       `yaml:"rpc_rate_limit"`  // 每秒发往该链RPC节点的请求上限，0表示不限流
+
+	EIP1559        bool   `yaml:"eip1559"`         // 是否发送EIP-1559动态手续费交易
+	Confirmations  uint64 `yaml:"confirmations"`   // 视为最终确认所需的区块数
+	NativeSymbol   string `yaml:"native_symbol"`   // 原生币符号
+	NativeDecimals uint8  `yaml:"native_decimals"` // 原生币精度
+
+	EventConfirmations uint64   `yaml:"event_confirmations"` // 事件监听落后最新区块的深度，0表示处理到最新区块
+	Tokens             []string `yaml:"tokens"`              // 账户信息和代币组合查询默认包含的ERC-20代币地址
+	NFTContracts       []string `yaml:"nft_contracts"`       // NFT持有查询默认包含的ERC-721/ERC-1155合约地址，为空时查询所有合约（只能扫描最近的区块）
+	WatchAddresses     []string `yaml:"watch_addresses"`     // 启动时加入转账索引的地址，从当时的已索引区块开始记录
+
+	CacheEnabled      bool          `yaml:"cache_enabled"`       // 是否缓存余额、nonce、区块等链上读取
+	CacheHeadInterval time.Duration `yaml:"cache_head_interval"` // 最新区块号的复用时长，新区块到达后与最新区块相关的缓存失效
 }
 
 // DatabaseConfig 数据库配置
 type DatabaseConfig struct {
+	Enabled  bool   `yaml:"enabled"` // 关闭时使用内存存储，nonce等状态不能跨重启保留
 	Host     string `yaml:"host"`
 	Port     int    `yaml:"port"`
 	User     string `yaml:"user"`
@@ -50,62 +88,578 @@ type DatabaseConfig struct {
 
 // CacheConfig 缓存配置
 type CacheConfig struct {
-	RedisURL string `yaml:"redis_url"`
+	RedisURL   string        `yaml:"redis_url"`   // 为空或连接失败时使用进程内缓存
+	HeadTTL    time.Duration `yaml:"head_ttl"`    // 与最新区块相关的缓存条目最长保留时间，出块慢的链据此限制过期程度
+	MaxEntries int           `yaml:"max_entries"` // 进程内缓存的条目上限
+}
+
+// SignerConfig 签名器配置
+type SignerConfig struct {
+	Type             string   `yaml:"type"` // none: 只接受外部签名的交易; keystore: 加密keystore文件; private_key: 明文私钥，仅限开发模式
+	KeystoreDir      string   `yaml:"keystore_dir"`
+	KeystorePassword string   `yaml:"keystore_password"`
+	PrivateKeys      []string `yaml:"private_keys"` // private_key 签名器使用的十六进制私钥
+}
+
+// MPCConfig mpc-core 门限签名服务配置
+type MPCConfig struct {
+	URL          string        `yaml:"url"`          // mpc-core 地址，为空时不支持MPC签名
+	Timeout      time.Duration `yaml:"timeout"`      // 单次签名请求超时
+	Participants []string      `yaml:"participants"` // 默认签名参与者，请求中未指定时使用
 }
 
+// ContractsConfig 合约ABI配置
+type ContractsConfig struct {
+	ABIDir         string `yaml:"abi_dir"`         // 启动时加载的ABI目录，文件名（不含 .json）作为注册名称
+	SignaturesFile string `yaml:"signatures_file"` // 解码交易时使用的方法/事件文本签名文件，每行一个
+}
+
+// TrackerConfig 交易生命周期跟踪配置
+type TrackerConfig struct {
+	PollInterval  time.Duration `yaml:"poll_interval"`  // 轮询在途交易状态的间隔
+	DropTimeout   time.Duration `yaml:"drop_timeout"`   // 节点中查不到交易超过该时间视为被丢弃
+	WebhookURLs   []string      `yaml:"webhook_urls"`   // 状态变更推送地址
+	WebhookSecret string        `yaml:"webhook_secret"` // 非空时用HMAC-SHA256对推送内容签名
+}
+
+// IndexerConfig 地址转账索引配置
+type IndexerConfig struct {
+	Enabled      bool          `yaml:"enabled"`
+	PollInterval time.Duration `yaml:"poll_interval"` // 检查新区块的间隔
+	BatchBlocks  uint64        `yaml:"batch_blocks"`  // 每批索引的区块数
+}
+
+// BridgeConfig 跨链转账配置
+type BridgeConfig struct {
+	RoutesFile         string        `yaml:"routes_file"`         // 跨链路由定义（JSON数组），为空时不支持跨链转账
+	PollInterval       time.Duration `yaml:"poll_interval"`       // 推进在途跨链转账的间隔
+	AttestationTimeout time.Duration `yaml:"attestation_timeout"` // 源链确认后等待中继器证明的时长，超时后退款
+	SubmitTimeout      time.Duration `yaml:"submit_timeout"`      // 节点中查不到已提交交易超过该时间后重新提交
+}
+
+// TelemetryConfig 指标和链路追踪配置
+type TelemetryConfig struct {
+	MetricsEnabled bool    `yaml:"metrics_enabled"` // 是否提供 /metrics 供Prometheus抓取
+	ServiceName    string  `yaml:"service_name"`    // span中的服务名称
+	TracesExporter string  `yaml:"traces_exporter"` // none: 只传递上游的trace context; console: 以JSON格式输出span
+	TracesFile     string  `yaml:"traces_file"`     // console 导出的目标文件，为空时写到标准输出
+	SampleRatio    float64 `yaml:"sample_ratio"`    // 根span的采样比例（0~1），上游已采样的请求始终采样
+}
+
+// devEnvironments 视为开发模式的运行环境
+var devEnvironments = map[string]bool{"development": true, "dev": true, "test": true, "local": true}
+
 // LoadConfig 加载配置
-func LoadConfig() (*Config, error) {
-	// 从环境变量或配置文件加载
-	// 这里返回默认配置
+// 在内置默认值上依次叠加 path 指定的YAML配置文件（为空时跳过）和环境变量，返回前校验。
+func LoadConfig(path string) (*Config, error) {
+	cfg := defaultConfig()
+	if path != "" {
+		if err := cfg.loadFile(path); err != nil {
+			return nil, err
+		}
+	}
+	envErr := cfg.applyEnv()
+
+	// 环境变量格式错误和校验问题一起返回
+	if err := errors.Join(envErr, cfg.Validate()); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+	return cfg, nil
+}
+
+// loadFile 把YAML配置文件叠加到当前配置上
+// 文件中未出现的字段保持原值；chains.evm 按 name 合并到已有的链上，新名称的链追加在后面。
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	// 先按严格模式解码一遍，拼错的字段名直接报错而不是被忽略
+	strict := yaml.NewDecoder(bytes.NewReader(data))
+	strict.KnownFields(true)
+	if err := strict.Decode(&Config{}); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	if len(doc.Content) == 0 {
+		return nil
+	}
+
+	base := append([]ChainConfig(nil), c.Chains.EVM...)
+	if err := doc.Content[0].Decode(c); err != nil {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	chains, err := mergeEVMChains(base, mappingValue(mappingValue(doc.Content[0], "chains"), "evm"))
+	if err != nil {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	c.Chains.EVM = chains
+	return nil
+}
+
+// mergeEVMChains 把配置文件中的EVM链列表按名称合并到 chains 上
+// 同名的链只覆盖文件中出现的字段，新名称的链以 evmChainDefaults 为基础。
+func mergeEVMChains(chains []ChainConfig, list *yaml.Node) ([]ChainConfig, error) {
+	if list == nil || list.Kind != yaml.SequenceNode {
+		return chains, nil
+	}
+
+	for _, item := range list.Content {
+		var entry struct {
+			Name string `yaml:"name"`
+		}
+		if err := item.Decode(&entry); err != nil {
+			return nil, err
+		}
+
+		found := false
+		for i := range chains {
+			if chains[i].Name == entry.Name {
+				if err := item.Decode(&chains[i]); err != nil {
+					return nil, err
+				}
+				found = true
+				break
+			}
+		}
+		if !found {
+			chain := evmChainDefaults()
+			if err := item.Decode(&chain); err != nil {
+				return nil, err
+			}
+			chains = append(chains, chain)
+		}
+	}
+	return chains, nil
+}
+
+// mappingValue 获取YAML映射节点中 key 对应的值，不存在时返回nil
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// evmChainDefaults 配置文件中新增的EVM链的默认值
+func evmChainDefaults() ChainConfig {
+	return ChainConfig{
+		Enabled:           true,
+		RequestTimeout:    15 * time.Second,
+		EIP1559:           true,
+		Confirmations:     12,
+		NativeSymbol:      "ETH",
+		NativeDecimals:    18,
+		CacheEnabled:      true,
+		CacheHeadInterval: 2 * time.Second,
+	}
+}
+
+// applyEnv 用环境变量覆盖配置，返回所有格式错误的环境变量
+func (c *Config) applyEnv() error {
+	env := &envParser{}
+	c.Environment = getEnv("APP_ENV", c.Environment)
+
+	c.Server.Address = getEnv("HOST", c.Server.Address)
+	c.Server.Port = env.int("BLOCKCHAIN_MIDDLEWARE_PORT", c.Server.Port)
+
+	for i := range c.Chains.EVM {
+		c.Chains.EVM[i].applyEnv(env)
+	}
+	c.Chains.Bitcoin.applyEnv(env)
+
+	c.Database.Enabled = env.bool("DB_ENABLED", c.Database.Enabled)
+	c.Database.Host = getEnv("DB_HOST", c.Database.Host)
+	c.Database.Port = env.int("DB_PORT", c.Database.Port)
+	c.Database.User = getEnv("DB_USER", c.Database.User)
+	c.Database.Password = getEnv("DB_PASSWORD", c.Database.Password)
+	c.Database.Name = getEnv("DB_NAME", c.Database.Name)
+	c.Database.SSLMode = getEnv("DB_SSL_MODE", c.Database.SSLMode)
+
+	c.Cache.RedisURL = getEnv("REDIS_URL", c.Cache.RedisURL)
+	c.Cache.HeadTTL = env.duration("CACHE_HEAD_TTL", c.Cache.HeadTTL)
+	c.Cache.MaxEntries = env.int("CACHE_MAX_ENTRIES", c.Cache.MaxEntries)
+
+	c.Signer.Type = getEnv("SIGNER_TYPE", c.Signer.Type)
+	c.Signer.KeystoreDir = getEnv("SIGNER_KEYSTORE_DIR", c.Signer.KeystoreDir)
+	c.Signer.KeystorePassword = getEnv("SIGNER_KEYSTORE_PASSWORD", c.Signer.KeystorePassword)
+	c.Signer.PrivateKeys = getEnvList("SIGNER_PRIVATE_KEYS", c.Signer.PrivateKeys)
+
+	c.MPC.URL = getEnv("MPC_CORE_URL", c.MPC.URL)
+	c.MPC.Timeout = env.duration("MPC_SIGN_TIMEOUT", c.MPC.Timeout)
+	c.MPC.Participants = getEnvList("MPC_PARTICIPANTS", c.MPC.Participants)
+
+	c.Tracker.PollInterval = env.duration("TRACKER_POLL_INTERVAL", c.Tracker.PollInterval)
+	c.Tracker.DropTimeout = env.duration("TRACKER_DROP_TIMEOUT", c.Tracker.DropTimeout)
+	c.Tracker.WebhookURLs = getEnvList("TRACKER_WEBHOOK_URLS", c.Tracker.WebhookURLs)
+	c.Tracker.WebhookSecret = getEnv("TRACKER_WEBHOOK_SECRET", c.Tracker.WebhookSecret)
+
+	c.Indexer.Enabled = env.bool("INDEXER_ENABLED", c.Indexer.Enabled)
+	c.Indexer.PollInterval = env.duration("INDEXER_POLL_INTERVAL", c.Indexer.PollInterval)
+	c.Indexer.BatchBlocks = env.uint("INDEXER_BATCH_BLOCKS", c.Indexer.BatchBlocks)
+
+	c.Bridge.RoutesFile = getEnv("BRIDGE_ROUTES_FILE", c.Bridge.RoutesFile)
+	c.Bridge.PollInterval = env.duration("BRIDGE_POLL_INTERVAL", c.Bridge.PollInterval)
+	c.Bridge.AttestationTimeout = env.duration("BRIDGE_ATTESTATION_TIMEOUT", c.Bridge.AttestationTimeout)
+	c.Bridge.SubmitTimeout = env.duration("BRIDGE_SUBMIT_TIMEOUT", c.Bridge.SubmitTimeout)
+
+	c.Contracts.ABIDir = getEnv("CONTRACT_ABI_DIR", c.Contracts.ABIDir)
+	c.Contracts.SignaturesFile = getEnv("CONTRACT_SIGNATURES_FILE", c.Contracts.SignaturesFile)
+
+	c.Telemetry.MetricsEnabled = env.bool("ENABLE_METRICS", c.Telemetry.MetricsEnabled)
+	c.Telemetry.ServiceName = getEnv("OTEL_SERVICE_NAME", c.Telemetry.ServiceName)
+	c.Telemetry.TracesExporter = getEnv("OTEL_TRACES_EXPORTER", c.Telemetry.TracesExporter)
+	c.Telemetry.TracesFile = getEnv("OTEL_TRACES_FILE", c.Telemetry.TracesFile)
+	c.Telemetry.SampleRatio = env.float("OTEL_TRACES_SAMPLER_ARG", c.Telemetry.SampleRatio)
+	return errors.Join(env.errs...)
+}
+
+// applyEnv 用 <CHAIN>_ 前缀的环境变量覆盖单条链的配置，如 ETHEREUM_RPC_URL、SEPOLIA_CHAIN_ID
+// 前缀为大写的链名称，其中的 - 替换为 _。
+func (c *ChainConfig) applyEnv(env *envParser) {
+	prefix := strings.ToUpper(strings.ReplaceAll(c.Name, "-", "_")) + "_"
+
+	c.Enabled = env.bool(prefix+"ENABLED", c.Enabled)
+	c.RPCURL = getEnv(prefix+"RPC_URL", c.RPCURL)
+	c.RPCURLs = getEnvList(prefix+"RPC_URLS", c.RPCURLs)
+	c.RPCUser = getEnv(prefix+"RPC_USER", c.RPCUser)
+	c.RPCPassword = getEnv(prefix+"RPC_PASSWORD", c.RPCPassword)
+	c.RequestTimeout = env.duration(prefix+"RPC_TIMEOUT", c.RequestTimeout)
+	c.RPCRateLimit = env.float(prefix+"RPC_RATE_LIMIT", c.RPCRateLimit)
+	c.ChainID = env.int64(prefix+"CHAIN_ID", c.ChainID)
+	c.NetworkName = getEnv(prefix+"NETWORK", c.NetworkName)
+	c.WsURL = getEnv(prefix+"WS_URL", c.WsURL)
+	c.ExplorerURL = getEnv(prefix+"EXPLORER_URL", c.ExplorerURL)
+	c.Confirmations = env.uint(prefix+"CONFIRMATIONS", c.Confirmations)
+
+	c.EventConfirmations = env.uint(prefix+"EVENT_CONFIRMATIONS", c.EventConfirmations)
+	c.Tokens = getEnvList(prefix+"TOKENS", c.Tokens)
+	c.NFTContracts = getEnvList(prefix+"NFT_CONTRACTS", c.NFTContracts)
+	c.WatchAddresses = getEnvList(prefix+"WATCH_ADDRESSES", c.WatchAddresses)
+
+	c.CacheEnabled = env.bool(prefix+"CACHE_ENABLED", c.CacheEnabled)
+	c.CacheHeadInterval = env.duration(prefix+"CACHE_HEAD_INTERVAL", c.CacheHeadInterval)
+}
+
+// IsDevelopment 是否为开发模式
+func (c *Config) IsDevelopment() bool {
+	return devEnvironments[strings.ToLower(c.Environment)]
+}
+
+// Validate 校验配置，一次返回所有问题
+func (c *Config) Validate() error {
+	var errs []error
+	fail := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	if c.Server.Port <= 0 || c.Server.Port > 65535 {
+		fail("server.port %d is out of range", c.Server.Port)
+	}
+
+	names := make(map[string]string)
+	chainIDs := make(map[int64]string)
+	for i := range c.Chains.EVM {
+		chain := &c.Chains.EVM[i]
+		field := fmt.Sprintf("chains.evm[%d]", i)
+		errs = append(errs, chain.validate(field, "http", "https", "ws", "wss")...)
+
+		if chain.Name != "" {
+			if other, ok := names[chain.Name]; ok {
+				fail("%s: chain name %s is already used by %s", field, chain.Name, other)
+			}
+			names[chain.Name] = field
+		}
+		if !chain.Enabled {
+			continue
+		}
+		if chain.ChainID <= 0 {
+			fail("%s (%s): chain_id must be positive", field, chain.Name)
+		} else if other, ok := chainIDs[chain.ChainID]; ok {
+			fail("%s (%s): chain_id %d is already used by %s", field, chain.Name, chain.ChainID, other)
+		} else {
+			chainIDs[chain.ChainID] = chain.Name
+		}
+	}
+
+	errs = append(errs, c.Chains.Bitcoin.validate("chains.bitcoin", "http", "https")...)
+	if other, ok := names[c.Chains.Bitcoin.Name]; ok {
+		fail("chains.bitcoin: chain name %s is already used by %s", c.Chains.Bitcoin.Name, other)
+	}
+
+	if c.Database.Enabled && (c.Database.Port <= 0 || c.Database.Port > 65535) {
+		fail("database.port %d is out of range", c.Database.Port)
+	}
+	if err := checkURL("cache.redis_url", c.Cache.RedisURL, "redis", "rediss"); err != nil {
+		errs = append(errs, err)
+	}
+	if err := checkURL("mpc.url", c.MPC.URL, "http", "https"); err != nil {
+		errs = append(errs, err)
+	}
+	for i, u := range c.Tracker.WebhookURLs {
+		if err := checkURL(fmt.Sprintf("tracker.webhook_urls[%d]", i), u, "http", "https"); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	switch c.Signer.Type {
+	case "", "none", "keystore":
+	case "private_key":
+		if len(c.Signer.PrivateKeys) == 0 {
+			fail("signer.private_keys is required for the private_key signer")
+		}
+	default:
+		fail("signer.type %q is not supported", c.Signer.Type)
+	}
+	if (c.Signer.Type == "private_key" || len(c.Signer.PrivateKeys) > 0) && !c.IsDevelopment() {
+		fail("plaintext private keys are only allowed in development, environment is %q", c.Environment)
+	}
+
+	switch c.Telemetry.TracesExporter {
+	case "", "none", "console":
+	default:
+		fail("telemetry.traces_exporter %q is not supported", c.Telemetry.TracesExporter)
+	}
+	if c.Telemetry.SampleRatio < 0 || c.Telemetry.SampleRatio > 1 {
+		fail("telemetry.sample_ratio %g must be between 0 and 1", c.Telemetry.SampleRatio)
+	}
+
+	return errors.Join(errs...)
+}
+
+// validate 校验单条链的配置，schemes 为RPC地址允许的协议
+func (c *ChainConfig) validate(field string, schemes ...string) []error {
+	var errs []error
+	if c.Name == "" {
+		errs = append(errs, fmt.Errorf("%s: name is required", field))
+	} else {
+		field = fmt.Sprintf("%s (%s)", field, c.Name)
+	}
+
+	if c.Enabled && c.RPCURL == "" {
+		errs = append(errs, fmt.Errorf("%s: rpc_url is required", field))
+	}
+	if err := checkURL(field+".rpc_url", c.RPCURL, schemes...); err != nil {
+		errs = append(errs, err)
+	}
+	for i, u := range c.RPCURLs {
+		if err := checkURL(fmt.Sprintf("%s.rpc_urls[%d]", field, i), u, schemes...); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := checkURL(field+".ws_url", c.WsURL, "ws", "wss"); err != nil {
+		errs = append(errs, err)
+	}
+	if err := checkURL(field+".explorer_url", c.ExplorerURL, "http", "https"); err != nil {
+		errs = append(errs, err)
+	}
+
+	if c.RequestTimeout < 0 {
+		errs = append(errs, fmt.Errorf("%s: request_timeout must not be negative", field))
+	}
+	if c.RPCRateLimit < 0 {
+		errs = append(errs, fmt.Errorf("%s: rpc_rate_limit must not be negative", field))
+	}
+	return errs
+}
+
+// checkURL 校验地址可以解析且协议为 schemes 之一，空地址视为未配置
+// 错误中不包含地址本身，RPC地址中可能带有API密钥。
+func checkURL(field, raw string, schemes ...string) error {
+	if raw == "" {
+		return nil
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("%s is not a valid url", field)
+	}
+	for _, scheme := range schemes {
+		if u.Scheme == scheme {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s: scheme must be one of %s, got %q", field, strings.Join(schemes, "/"), u.Scheme)
+}
+
+// defaultConfig 内置默认配置，配置文件和环境变量在此基础上覆盖
+func defaultConfig() *Config {
 	return &Config{
+		Environment: "production",
 		Server: ServerConfig{
 			Address: "0.0.0.0",
 			Port:    8082,
 		},
 		Chains: ChainsConfig{
-			Ethereum: ChainConfig{
-				Enabled:     true,
-				RPCURL:      getEnv("ETHEREUM_RPC_URL", "http://localhost:8545"),
-				ChainID:     1,
-				NetworkName: "mainnet",
-				WsURL:       getEnv("ETHEREUM_WS_URL", "ws://localhost:8546"),
-				ExplorerURL: "https://etherscan.io",
-			},
-			Polygon: ChainConfig{
-				Enabled:     true,
-				RPCURL:      getEnv("POLYGON_RPC_URL", "https://polygon-rpc.com"),
-				ChainID:     137,
-				NetworkName: "polygon",
-				WsURL:       getEnv("POLYGON_WS_URL", "wss://polygon-rpc.com"),
-				ExplorerURL: "https://polygonscan.com",
-			},
-			BSC: ChainConfig{
-				Enabled:     true,
-				RPCURL:      getEnv("BSC_RPC_URL", "https://bsc-dataseed.binance.org"),
-				ChainID:     56,
-				NetworkName: "bsc",
-				WsURL:       getEnv("BSC_WS_URL", "wss://bsc-ws-node.nariox.org"),
-				ExplorerURL: "https://bscscan.com",
+			EVM: []ChainConfig{
+				{
+					Name:           "ethereum",
+					Enabled:        true,
+					RPCURL:         "http://localhost:8545",
+					RequestTimeout: 15 * time.Second,
+					ChainID:        1,
+					NetworkName:    "mainnet",
+					WsURL:          "ws://localhost:8546",
+					ExplorerURL:    "https://etherscan.io",
+					EIP1559:        true,
+					Confirmations:  12,
+					NativeSymbol:   "ETH",
+					NativeDecimals: 18,
+
+					CacheEnabled:      true,
+					CacheHeadInterval: 4 * time.Second,
+				},
+				{
+					Name:           "polygon",
+					Enabled:        true,
+					RPCURL:         "https://polygon-rpc.com",
+					RequestTimeout: 15 * time.Second,
+					ChainID:        137,
+					NetworkName:    "polygon",
+					WsURL:          "wss://polygon-rpc.com",
+					ExplorerURL:    "https://polygonscan.com",
+					EIP1559:        true,
+					Confirmations:  128,
+					NativeSymbol:   "MATIC",
+					NativeDecimals: 18,
+
+					CacheEnabled:      true,
+					CacheHeadInterval: 2 * time.Second,
+				},
+				{
+					Name:           "bsc",
+					Enabled:        true,
+					RPCURL:         "https://bsc-dataseed.binance.org",
+					RequestTimeout: 15 * time.Second,
+					ChainID:        56,
+					NetworkName:    "bsc",
+					WsURL:          "wss://bsc-ws-node.nariox.org",
+					ExplorerURL:    "https://bscscan.com",
+					EIP1559:        false,
+					Confirmations:  15,
+					NativeSymbol:   "BNB",
+					NativeDecimals: 18,
+
+					CacheEnabled:      true,
+					CacheHeadInterval: 2 * time.Second,
+				},
+				{
+					Name:           "arbitrum",
+					Enabled:        false,
+					RPCURL:         "https://arb1.arbitrum.io/rpc",
+					RequestTimeout: 15 * time.Second,
+					ChainID:        42161,
+					NetworkName:    "arbitrum-one",
+					ExplorerURL:    "https://arbiscan.io",
+					EIP1559:        true,
+					Confirmations:  20,
+					NativeSymbol:   "ETH",
+					NativeDecimals: 18,
+
+					CacheEnabled:      true,
+					CacheHeadInterval: 1 * time.Second,
+				},
+				{
+					Name:           "optimism",
+					Enabled:        false,
+					RPCURL:         "https://mainnet.optimism.io",
+					RequestTimeout: 15 * time.Second,
+					ChainID:        10,
+					NetworkName:    "optimism",
+					ExplorerURL:    "https://optimistic.etherscan.io",
+					EIP1559:        true,
+					Confirmations:  10,
+					NativeSymbol:   "ETH",
+					NativeDecimals: 18,
+
+					CacheEnabled:      true,
+					CacheHeadInterval: 2 * time.Second,
+				},
+				{
+					Name:           "base",
+					Enabled:        false,
+					RPCURL:         "https://mainnet.base.org",
+					RequestTimeout: 15 * time.Second,
+					ChainID:        8453,
+					NetworkName:    "base",
+					ExplorerURL:    "https://basescan.org",
+					EIP1559:        true,
+					Confirmations:  10,
+					NativeSymbol:   "ETH",
+					NativeDecimals: 18,
+
+					CacheEnabled:      true,
+					CacheHeadInterval: 2 * time.Second,
+				},
 			},
 			Bitcoin: ChainConfig{
-				Enabled:     false,
-				RPCURL:      getEnv("BITCOIN_RPC_URL", "http://localhost:8332"),
-				ChainID:     0,
-				NetworkName: "bitcoin",
+				Name:           "bitcoin",
+				Enabled:        false,
+				RPCURL:         "http://localhost:8332",
+				RequestTimeout: 60 * time.Second,
+				ChainID:        0,
+				NetworkName:    "bitcoin",
+
+				Confirmations:  6,
+				NativeSymbol:   "BTC",
+				NativeDecimals: 8,
+
+				CacheEnabled:      true,
+				CacheHeadInterval: 30 * time.Second,
 			},
 		},
 		Database: DatabaseConfig{
-			Host:     getEnv("DB_HOST", "localhost"),
+			Enabled:  true,
+			Host:     "localhost",
 			Port:     5432,
-			User:     getEnv("DB_USER", "mpc_user"),
-			Password: getEnv("DB_PASSWORD", "mpc_password"),
-			Name:     getEnv("DB_NAME", "mpc_wallet"),
-			SSLMode:  getEnv("DB_SSL_MODE", "disable"),
+			User:     "mpc_user",
+			Password: "mpc_password",
+			Name:     "mpc_wallet",
+			SSLMode:  "disable",
 		},
 		Cache: CacheConfig{
-			RedisURL: getEnv("REDIS_URL", "redis://localhost:6379"),
+			RedisURL:   "redis://localhost:6379",
+			HeadTTL:    30 * time.Second,
+			MaxEntries: 100000,
+		},
+		Signer: SignerConfig{
+			Type: "none",
+		},
+		MPC: MPCConfig{
+			URL:     "http://localhost:8080",
+			Timeout: 30 * time.Second,
 		},
-	}, nil
+		Tracker: TrackerConfig{
+			PollInterval: 5 * time.Second,
+			DropTimeout:  15 * time.Minute,
+		},
+		Indexer: IndexerConfig{
+			Enabled:      true,
+			PollInterval: 15 * time.Second,
+			BatchBlocks:  50,
+		},
+		Bridge: BridgeConfig{
+			PollInterval:       10 * time.Second,
+			AttestationTimeout: 30 * time.Minute,
+			SubmitTimeout:      10 * time.Minute,
+		},
+		Telemetry: TelemetryConfig{
+			MetricsEnabled: true,
+			ServiceName:    "blockchain-middleware",
+			TracesExporter: "none",
+			SampleRatio:    1,
+		},
+	}
 }
 
 // getEnv 获取环境变量，如果不存在则返回默认值
@@ -116,7 +670,111 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
+// getEnvList 获取逗号分隔的环境变量列表，不存在时返回默认值
+func getEnvList(key string, defaultValue []string) []string {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// envParser 解析非字符串类型的环境变量，收集格式错误
+type envParser struct {
+	errs []error
+}
+
+// fail 记录格式错误的环境变量
+func (p *envParser) fail(key string, err error) {
+	p.errs = append(p.errs, fmt.Errorf("invalid environment variable %s: %w", key, err))
+}
+
+// bool 获取布尔类型的环境变量，接受 strconv.ParseBool 支持的写法（1、t、TRUE 等），不存在时返回默认值
+func (p *envParser) bool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		b, err := strconv.ParseBool(value)
+		if err == nil {
+			return b
+		}
+		p.fail(key, err)
+	}
+	return defaultValue
+}
+
+// duration 获取时长类型的环境变量（如 "10s"），不存在时返回默认值
+func (p *envParser) duration(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		d, err := time.ParseDuration(value)
+		if err == nil {
+			return d
+		}
+		p.fail(key, err)
+	}
+	return defaultValue
+}
+
+// uint 获取非负整数类型的环境变量，不存在时返回默认值
+func (p *envParser) uint(key string, defaultValue uint64) uint64 {
+	if value := os.Getenv(key); value != "" {
+		n, err := strconv.ParseUint(value, 10, 64)
+		if err == nil {
+			return n
+		}
+		p.fail(key, err)
+	}
+	return defaultValue
+}
+
+// int 获取整数类型的环境变量，不存在时返回默认值
+func (p *envParser) int(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		n, err := strconv.Atoi(value)
+		if err == nil {
+			return n
+		}
+		p.fail(key, err)
+	}
+	return defaultValue
+}
+
+// int64 获取64位整数类型的环境变量，不存在时返回默认值
+func (p *envParser) int64(key string, defaultValue int64) int64 {
+	if value := os.Getenv(key); value != "" {
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err == nil {
+			return n
+		}
+		p.fail(key, err)
+	}
+	return defaultValue
+}
+
+// float 获取浮点类型的环境变量，不存在时返回默认值
+func (p *envParser) float(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		f, err := strconv.ParseFloat(value, 64)
+		if err == nil {
+			return f
+		}
+		p.fail(key, err)
+	}
+	return defaultValue
+}
+
+// DSN 获取PostgreSQL连接串
+func (c DatabaseConfig) DSN() string {
+	return fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
+		c.Host, c.Port, c.User, c.Password, c.Name, c.SSLMode)
+}
+
 // GetServerAddress 获取服务器地址
 func (c *Config) GetServerAddress() string {
 	return fmt.Sprintf("%s:%d", c.Server.Address, c.Server.Port)
-}
\ No newline at end of file
+}
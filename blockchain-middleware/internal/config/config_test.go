@@ -0,0 +1,318 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeConfig 把YAML写入临时配置文件
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// evmChain 按名称查找EVM链
+func evmChain(t *testing.T, cfg *Config, name string) ChainConfig {
+	t.Helper()
+	for _, c := range cfg.Chains.EVM {
+		if c.Name == name {
+			return c
+		}
+	}
+	t.Fatalf("chain %s not found", name)
+	return ChainConfig{}
+}
+
+func TestLoadConfigFile(t *testing.T) {
+	path := writeConfig(t, `
+server:
+  port: 9090
+chains:
+  evm:
+    - name: ethereum
+      rpc_url: https://mainnet.example.com/v3/KEY
+      rpc_rate_limit: 20
+    - name: polygon
+      enabled: false
+    - name: sepolia
+      rpc_url: https://sepolia.example.com
+      chain_id: 11155111
+      confirmations: 3
+`)
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Server.Port != 9090 || cfg.Server.Address != "0.0.0.0" {
+		t.Errorf("server = %+v", cfg.Server)
+	}
+
+	// 已有的链只覆盖文件中出现的字段
+	ethereum := evmChain(t, cfg, "ethereum")
+	if ethereum.RPCURL != "https://mainnet.example.com/v3/KEY" || ethereum.RPCRateLimit != 20 ||
+		ethereum.ChainID != 1 || ethereum.Confirmations != 12 || ethereum.WsURL != "ws://localhost:8546" {
+		t.Errorf("ethereum = %+v", ethereum)
+	}
+	if polygon := evmChain(t, cfg, "polygon"); polygon.Enabled || polygon.ChainID != 137 {
+		t.Errorf("polygon = %+v", polygon)
+	}
+
+	// 新名称的链以 evmChainDefaults 为基础，追加在内置链之后
+	sepolia := cfg.Chains.EVM[len(cfg.Chains.EVM)-1]
+	if sepolia.Name != "sepolia" || !sepolia.Enabled || sepolia.ChainID != 11155111 || sepolia.Confirmations != 3 ||
+		sepolia.RequestTimeout != 15*time.Second || sepolia.NativeSymbol != "ETH" || !sepolia.CacheEnabled {
+		t.Errorf("sepolia = %+v", sepolia)
+	}
+	if len(cfg.Chains.EVM) != len(defaultConfig().Chains.EVM)+1 {
+		t.Errorf("%d evm chains", len(cfg.Chains.EVM))
+	}
+}
+
+func TestLoadConfigEnvOverridesFile(t *testing.T) {
+	path := writeConfig(t, `
+chains:
+  evm:
+    - name: base-sepolia
+      rpc_url: https://base-sepolia.example.com
+      chain_id: 84532
+`)
+	t.Setenv("BASE_SEPOLIA_CHAIN_ID", "84533")
+	t.Setenv("ETHEREUM_RPC_URLS", "https://a.example.com, ,https://b.example.com")
+	t.Setenv("BLOCKCHAIN_MIDDLEWARE_PORT", "7070")
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if chain := evmChain(t, cfg, "base-sepolia"); chain.ChainID != 84533 {
+		t.Errorf("base-sepolia chain id = %d", chain.ChainID)
+	}
+	if urls := evmChain(t, cfg, "ethereum").RPCURLs; strings.Join(urls, ",") != "https://a.example.com,https://b.example.com" {
+		t.Errorf("ethereum rpc urls = %v", urls)
+	}
+	if cfg.Server.Port != 7070 {
+		t.Errorf("port = %d", cfg.Server.Port)
+	}
+}
+
+func TestLoadConfigEnvValues(t *testing.T) {
+	t.Setenv("ETHEREUM_ENABLED", "0")
+	t.Setenv("POLYGON_ENABLED", "TRUE")
+	t.Setenv("BSC_ENABLED", "1")
+	t.Setenv("INDEXER_ENABLED", "t")
+
+	cfg, err := LoadConfig("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if evmChain(t, cfg, "ethereum").Enabled || !evmChain(t, cfg, "polygon").Enabled || !evmChain(t, cfg, "bsc").Enabled || !cfg.Indexer.Enabled {
+		t.Errorf("enabled: ethereum %v, polygon %v, bsc %v, indexer %v", evmChain(t, cfg, "ethereum").Enabled,
+			evmChain(t, cfg, "polygon").Enabled, evmChain(t, cfg, "bsc").Enabled, cfg.Indexer.Enabled)
+	}
+}
+
+func TestLoadConfigEnvErrors(t *testing.T) {
+	t.Setenv("DB_PORT", "abc")
+	t.Setenv("TRACKER_POLL_INTERVAL", "5")
+	t.Setenv("ETHEREUM_ENABLED", "yes")
+	t.Setenv("POLYGON_CONFIRMATIONS", "-1")
+	t.Setenv("OTEL_TRACES_SAMPLER_ARG", "2")
+
+	// 格式错误和校验问题一起返回
+	_, err := LoadConfig("")
+	if err == nil {
+		t.Fatal("LoadConfig() succeeded")
+	}
+	for _, want := range []string{
+		`invalid environment variable DB_PORT: strconv.Atoi: parsing "abc": invalid syntax`,
+		`invalid environment variable TRACKER_POLL_INTERVAL: time: missing unit in duration "5"`,
+		`invalid environment variable ETHEREUM_ENABLED: strconv.ParseBool: parsing "yes": invalid syntax`,
+		`invalid environment variable POLYGON_CONFIRMATIONS: strconv.ParseUint: parsing "-1": invalid syntax`,
+		"telemetry.sample_ratio 2 must be between 0 and 1",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("LoadConfig() = %v\nwant %q", err, want)
+		}
+	}
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		yaml    string
+		wantErr string
+	}{
+		{"misspelled field", "chains:\n  evm:\n    - name: ethereum\n      rpc_ulr: http://localhost:8545\n", "field rpc_ulr not found"},
+		{"wrong type", "server:\n  port: eighty\n", "failed to parse config file"},
+		{"new chain without chain id", "chains:\n  evm:\n    - name: holesky\n      rpc_url: https://holesky.example.com\n", "chains.evm[6] (holesky): chain_id must be positive"},
+		{"invalid after merge", "chains:\n  evm:\n    - name: bsc\n      chain_id: 1\n", "chain_id 1 is already used by ethereum"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadConfig(writeConfig(t, tt.yaml))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("LoadConfig() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	if _, err := LoadConfig(writeConfig(t, "")); err != nil {
+		t.Errorf("LoadConfig() of an empty file: %v", err)
+	}
+	if _, err := LoadConfig(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("LoadConfig() of a missing file succeeded")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(c *Config)
+		wantErr []string
+	}{
+		{
+			name:   "defaults",
+			modify: func(c *Config) {},
+		},
+		{
+			name:    "duplicate chain id",
+			modify:  func(c *Config) { c.Chains.EVM[1].ChainID = 1 },
+			wantErr: []string{"chains.evm[1] (polygon): chain_id 1 is already used by ethereum"},
+		},
+		{
+			name: "duplicate chain id on a disabled chain",
+			modify: func(c *Config) {
+				c.Chains.EVM[1].ChainID = 1
+				c.Chains.EVM[1].Enabled = false
+			},
+		},
+		{
+			name:    "duplicate chain name",
+			modify:  func(c *Config) { c.Chains.Bitcoin.Name = "ethereum" },
+			wantErr: []string{"chains.bitcoin: chain name ethereum is already used by chains.evm[0]"},
+		},
+		{
+			name:    "enabled chain without rpc url",
+			modify:  func(c *Config) { c.Chains.EVM[0].RPCURL = "" },
+			wantErr: []string{"chains.evm[0] (ethereum): rpc_url is required"},
+		},
+		{
+			name: "unparseable urls",
+			modify: func(c *Config) {
+				c.Chains.EVM[0].RPCURLs = []string{"https://ok.example.com", "localhost:8545"}
+				c.Chains.EVM[0].WsURL = "https://mainnet.example.com"
+				c.Cache.RedisURL = "http://redis:6379"
+			},
+			wantErr: []string{
+				"chains.evm[0] (ethereum).rpc_urls[1] is not a valid url",
+				`chains.evm[0] (ethereum).ws_url: scheme must be one of ws/wss, got "https"`,
+				`cache.redis_url: scheme must be one of redis/rediss, got "http"`,
+			},
+		},
+		{
+			name:    "bitcoin does not accept websocket rpc",
+			modify:  func(c *Config) { c.Chains.Bitcoin.RPCURL = "ws://localhost:8332" },
+			wantErr: []string{"chains.bitcoin (bitcoin).rpc_url: scheme must be one of http/https"},
+		},
+		{
+			name: "negative limits",
+			modify: func(c *Config) {
+				c.Chains.EVM[2].RPCRateLimit = -1
+				c.Telemetry.SampleRatio = 1.5
+				c.Server.Port = 0
+			},
+			wantErr: []string{
+				"chains.evm[2] (bsc): rpc_rate_limit must not be negative",
+				"telemetry.sample_ratio 1.5 must be between 0 and 1",
+				"server.port 0 is out of range",
+			},
+		},
+		{
+			name: "private keys in production",
+			modify: func(c *Config) {
+				c.Signer.Type = "private_key"
+				c.Signer.PrivateKeys = []string{"0x01"}
+			},
+			wantErr: []string{`plaintext private keys are only allowed in development, environment is "production"`},
+		},
+		{
+			name: "private keys with another signer",
+			modify: func(c *Config) {
+				c.Signer.PrivateKeys = []string{"0x01"}
+			},
+			wantErr: []string{"plaintext private keys are only allowed in development"},
+		},
+		{
+			name: "private keys in development",
+			modify: func(c *Config) {
+				c.Environment = "Dev"
+				c.Signer.Type = "private_key"
+				c.Signer.PrivateKeys = []string{"0x01"}
+			},
+		},
+		{
+			name:    "private key signer without keys",
+			modify:  func(c *Config) { c.Environment, c.Signer.Type = "test", "private_key" },
+			wantErr: []string{"signer.private_keys is required"},
+		},
+		{
+			name:    "unknown signer",
+			modify:  func(c *Config) { c.Signer.Type = "hsm" },
+			wantErr: []string{`signer.type "hsm" is not supported`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := defaultConfig()
+			tt.modify(cfg)
+
+			err := cfg.Validate()
+			if len(tt.wantErr) == 0 {
+				if err != nil {
+					t.Fatalf("Validate() = %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("Validate() succeeded")
+			}
+			for _, want := range tt.wantErr {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("Validate() = %v\nwant %q", err, want)
+				}
+			}
+			if got := len(strings.Split(err.Error(), "\n")); got != len(tt.wantErr) {
+				t.Errorf("Validate() returned %d errors, want %d:\n%v", got, len(tt.wantErr), err)
+			}
+		})
+	}
+}
+
+func TestValidateDoesNotLeakURLs(t *testing.T) {
+	cfg := defaultConfig()
+	cfg.Chains.EVM[0].RPCURL = "ftp://mainnet.example.com/v3/SECRET"
+	cfg.Chains.EVM[0].RPCURLs = []string{"://SECRET"}
+
+	err := cfg.Validate()
+	if err == nil || strings.Contains(err.Error(), "SECRET") {
+		t.Errorf("Validate() = %v", err)
+	}
+}
+
+func TestExampleConfigIsValid(t *testing.T) {
+	cfg, err := LoadConfig(filepath.Join("..", "..", "config.example.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if chain := evmChain(t, cfg, "sepolia"); chain.ChainID != 11155111 || chain.CacheHeadInterval != 4*time.Second {
+		t.Errorf("sepolia = %+v", chain)
+	}
+}
@@ -4,7 +4,11 @@ import (
 	"blockchain-middleware/internal/config"
 	"blockchain-middleware/pkg/handler"
 	"blockchain-middleware/pkg/service"
+	"blockchain-middleware/pkg/telemetry"
+	"bufio"
+	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"time"
 
@@ -66,6 +70,11 @@ func (s *Server) Start() error {
 	return s.srv.ListenAndServe()
 }
 
+// Reload 应用重新加载的配置，监听地址等服务器参数需要重启后生效
+func (s *Server) Reload(cfg *config.Config) error {
+	return s.services.Reload(cfg)
+}
+
 // Stop 停止服务器
 func (s *Server) Stop() error {
 	log.Println("Stopping blockchain middleware server...")
@@ -93,21 +102,46 @@ func (s *Server) registerRoutes() {
 	// 链信息相关
 	api.HandleFunc("/chains", h.GetSupportedChains).Methods("GET")
 	api.HandleFunc("/chains/{chain}/info", h.GetChainInfo).Methods("GET")
+	api.HandleFunc("/chains/{chain}/fees", h.GetFees).Methods("GET")
 
 	// 账户相关
 	api.HandleFunc("/chains/{chain}/accounts/{address}/balance", h.GetBalance).Methods("GET")
 	api.HandleFunc("/chains/{chain}/accounts/{address}/info", h.GetAccountInfo).Methods("GET")
+	api.HandleFunc("/chains/{chain}/accounts/{address}/tokens", h.GetTokenPortfolio).Methods("GET")
+	api.HandleFunc("/chains/{chain}/accounts/{address}/nfts", h.GetNFTs).Methods("GET")
+	api.HandleFunc("/chains/{chain}/accounts/{address}/transfers", h.GetTransfers).Methods("GET")
+	api.HandleFunc("/chains/{chain}/accounts/{address}/watch", h.WatchAddress).Methods("POST")
 	api.HandleFunc("/chains/{chain}/accounts/{address}/nonce", h.GetNonce).Methods("GET")
+	api.HandleFunc("/chains/{chain}/accounts/{address}/nonce/status", h.GetNonceStatus).Methods("GET")
+	api.HandleFunc("/chains/{chain}/accounts/{address}/nonce/reserve", h.ReserveNonce).Methods("POST")
+	api.HandleFunc("/chains/{chain}/accounts/{address}/nonce/release", h.ReleaseNonce).Methods("POST")
 
 	// 交易相关
 	api.HandleFunc("/chains/{chain}/transactions", h.SendTransaction).Methods("POST")
+	api.HandleFunc("/chains/{chain}/transactions/raw", h.SendRawTransaction).Methods("POST")
 	api.HandleFunc("/chains/{chain}/transactions/{txHash}", h.GetTransaction).Methods("GET")
+	api.HandleFunc("/chains/{chain}/transactions/{txHash}/status", h.GetTransactionStatus).Methods("GET")
+	api.HandleFunc("/chains/{chain}/transactions/{txHash}/track", h.TrackTransaction).Methods("POST")
 	api.HandleFunc("/chains/{chain}/transactions/estimate", h.EstimateGas).Methods("POST")
+	api.HandleFunc("/chains/{chain}/transactions/simulate", h.SimulateTransaction).Methods("POST")
 
 	// 合约相关
 	api.HandleFunc("/chains/{chain}/contracts/call", h.CallContract).Methods("POST")
+	api.HandleFunc("/chains/{chain}/contracts/encode", h.EncodeContractCall).Methods("POST")
 	api.HandleFunc("/chains/{chain}/contracts/{contract}/tokens/{address}/balance", h.GetTokenBalance).Methods("GET")
 
+	// NFT相关
+	api.HandleFunc("/chains/{chain}/nfts/transfer", h.EncodeNFTTransfer).Methods("POST")
+	api.HandleFunc("/chains/{chain}/nfts/{contract}", h.GetNFTContract).Methods("GET")
+	api.HandleFunc("/chains/{chain}/nfts/{contract}/{tokenId}", h.GetNFT).Methods("GET")
+
+	// 合约ABI和签名注册
+	api.HandleFunc("/abis", h.ListABIs).Methods("GET")
+	api.HandleFunc("/abis", h.RegisterABI).Methods("POST")
+	api.HandleFunc("/abis/{name}", h.GetABI).Methods("GET")
+	api.HandleFunc("/signatures", h.AddSignatures).Methods("POST")
+	api.HandleFunc("/signatures/{hash}", h.LookupSignatures).Methods("GET")
+
 	// 区块相关
 	api.HandleFunc("/chains/{chain}/blocks/latest", h.GetLatestBlock).Methods("GET")
 	api.HandleFunc("/chains/{chain}/blocks/{blockNumber}", h.GetBlockByNumber).Methods("GET")
@@ -115,6 +149,12 @@ func (s *Server) registerRoutes() {
 	// 事件监听
 	api.HandleFunc("/chains/{chain}/events/subscribe", h.SubscribeEvents).Methods("POST")
 	api.HandleFunc("/chains/{chain}/events/{subscriptionId}", h.UnsubscribeEvents).Methods("DELETE")
+	api.HandleFunc("/chains/{chain}/events/{subscriptionId}/stream", h.StreamEvents).Methods("GET")
+
+	// 比特币UTXO/PSBT相关
+	api.HandleFunc("/chains/{chain}/accounts/{address}/utxos", h.ListUTXOs).Methods("GET")
+	api.HandleFunc("/chains/{chain}/psbt", h.BuildPSBT).Methods("POST")
+	api.HandleFunc("/chains/{chain}/psbt/finalize", h.FinalizePSBT).Methods("POST")
 
 	// MPC相关
 	api.HandleFunc("/mpc/transactions/sign", h.SignMPCTransaction).Methods("POST")
@@ -123,9 +163,21 @@ func (s *Server) registerRoutes() {
 	// 跨链相关
 	api.HandleFunc("/cross-chain/transfer", h.CrossChainTransfer).Methods("POST")
 	api.HandleFunc("/cross-chain/status/{transferId}", h.GetCrossChainStatus).Methods("GET")
+	api.HandleFunc("/cross-chain/routes", h.ListBridgeRoutes).Methods("GET")
+
+	// 缓存统计
+	api.HandleFunc("/cache/stats", h.GetCacheStats).Methods("GET")
+
+	// Prometheus指标，不在 /api/v1 下，与 monitoring/prometheus.yml 的 metrics_path 一致
+	if s.config.Telemetry.MetricsEnabled {
+		s.router.Handle(metricsPath, telemetry.Handler()).Methods("GET")
+	}
 
 	// 中间件：日志记录
 	s.router.Use(s.loggingMiddleware)
+
+	// 中间件：请求延迟指标和链路追踪
+	s.router.Use(s.telemetryMiddleware)
 }
 
 // getHandler 获取HTTP处理器（包含CORS配置）
@@ -134,7 +186,7 @@ func (s *Server) getHandler() http.Handler {
 	corsHandler := cors.New(cors.Options{
 		AllowedOrigins:   []string{"*"}, // 生产环境应该限制域名
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Content-Type", "Authorization", "X-Requested-With"},
+		AllowedHeaders:   []string{"Content-Type", "Authorization", "X-Requested-With", "Last-Event-ID", "traceparent", "tracestate"},
 		AllowCredentials: true,
 		MaxAge:           300, // 5分钟
 	})
@@ -164,6 +216,35 @@ func (s *Server) loggingMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+// metricsPath Prometheus抓取路径
+const metricsPath = "/metrics"
+
+// telemetryMiddleware 按路由模板记录请求延迟，并为请求创建span
+// 请求头中的 traceparent 作为父span，之后 ServiceManager 和链客户端的span都挂在这个请求下面。
+func (s *Server) telemetryMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// 中间件只在路由匹配后执行，CurrentRoute 不会为nil
+		route, err := mux.CurrentRoute(r).GetPathTemplate()
+		if err != nil {
+			route = "unknown"
+		}
+		// 抓取请求不记录span，避免每次抓取都产生一条链路
+		if route == metricsPath {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx, span := telemetry.StartHTTPSpan(r, route)
+		ww := &responseWriter{w, http.StatusOK}
+		start := time.Now()
+
+		next.ServeHTTP(ww, r.WithContext(ctx))
+
+		telemetry.ObserveHTTP(r.Method, route, ww.status, time.Since(start))
+		telemetry.EndHTTPSpan(span, ww.status)
+	})
+}
+
 // responseWriter 响应包装器
 type responseWriter struct {
 	http.ResponseWriter
@@ -173,4 +254,26 @@ type responseWriter struct {
 func (rw *responseWriter) WriteHeader(code int) {
 	rw.status = code
 	rw.ResponseWriter.WriteHeader(code)
+}
+
+// Flush 支持SSE逐条推送
+func (rw *responseWriter) Flush() {
+	if f, ok := rw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack 支持WebSocket升级
+func (rw *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := rw.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("response writer does not support hijacking")
+	}
+	rw.status = http.StatusSwitchingProtocols
+	return h.Hijack()
+}
+
+// Unwrap 供 http.ResponseController 访问底层连接（如设置写超时）
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
 }
\ No newline at end of file
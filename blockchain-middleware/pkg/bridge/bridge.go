@@ -0,0 +1,293 @@
+package bridge
+
+import (
+	"blockchain-middleware/pkg/types"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// 内置的跨链协议
+const (
+	// AdapterLockMint 源链桥合约锁定资产，目标链桥合约铸造映射代币
+	AdapterLockMint = "lock_mint"
+	// AdapterBurnRelease 源链销毁映射代币，目标链桥合约释放锁定的资产
+	AdapterBurnRelease = "burn_release"
+)
+
+// Route 跨链路由，即一对桥合约之间的一种资产
+type Route struct {
+	Name         string `json:"name"`
+	Adapter      string `json:"adapter"` // lock_mint、burn_release 或通过 RegisterAdapter 注册的协议
+	SourceChain  string `json:"source_chain"`
+	TargetChain  string `json:"target_chain"`
+	SourceBridge string `json:"source_bridge"`          // 源链桥合约
+	TargetBridge string `json:"target_bridge"`          // 目标链桥合约
+	SourceToken  string `json:"source_token,omitempty"` // 为空表示原生币
+	TargetToken  string `json:"target_token,omitempty"` // 为空表示原生币
+	Executor     string `json:"executor"`               // 提交目标链交易和源链退款交易的账户，须在签名器中
+	RelayerURL   string `json:"relayer_url"`            // 提供跨链证明的中继器
+}
+
+// LoadRoutes 从JSON文件加载跨链路由
+func LoadRoutes(path string) ([]*Route, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bridge routes: %w", err)
+	}
+	var routes []*Route
+	if err := json.Unmarshal(raw, &routes); err != nil {
+		return nil, fmt.Errorf("failed to parse bridge routes: %w", err)
+	}
+
+	names := make(map[string]bool, len(routes))
+	for _, route := range routes {
+		if err := route.validate(); err != nil {
+			return nil, fmt.Errorf("invalid bridge route %q: %w", route.Name, err)
+		}
+		if names[route.Name] {
+			return nil, fmt.Errorf("duplicate bridge route %q", route.Name)
+		}
+		names[route.Name] = true
+	}
+	return routes, nil
+}
+
+// validate 校验路由字段并统一地址格式
+func (r *Route) validate() error {
+	if r.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	adapter, ok := LookupAdapter(r.Adapter)
+	if !ok {
+		return fmt.Errorf("unknown adapter %q", r.Adapter)
+	}
+	if r.SourceChain == "" || r.TargetChain == "" || r.SourceChain == r.TargetChain {
+		return fmt.Errorf("source_chain and target_chain must be two different chains")
+	}
+	if r.RelayerURL == "" {
+		return fmt.Errorf("relayer_url is required")
+	}
+
+	for _, field := range []struct {
+		name     string
+		value    *string
+		optional bool
+	}{
+		{"source_bridge", &r.SourceBridge, false},
+		{"target_bridge", &r.TargetBridge, false},
+		{"executor", &r.Executor, false},
+		{"source_token", &r.SourceToken, true},
+		{"target_token", &r.TargetToken, true},
+	} {
+		if *field.value == "" && field.optional {
+			continue
+		}
+		if !common.IsHexAddress(*field.value) {
+			return fmt.Errorf("invalid %s: %q", field.name, *field.value)
+		}
+		*field.value = common.HexToAddress(*field.value).Hex()
+	}
+	return adapter.Validate(r)
+}
+
+// SourceEvent 源链桥合约转出资产时发出的事件
+type SourceEvent struct {
+	TransferID    common.Hash
+	Sender        common.Address
+	Token         common.Address
+	Amount        *big.Int
+	TargetChainID *big.Int
+	Recipient     common.Address
+}
+
+// Adapter 跨链协议适配器，把跨链转账映射为两条链上桥合约的调用和事件
+// 各调用的第一个参数和各事件的第一个索引参数都是转账ID，引擎据此在重启或重复提交后找回已上链的交易，
+// 桥合约须拒绝重复的转账ID。
+type Adapter interface {
+	// Validate 检查路由是否适用于该协议
+	Validate(route *Route) error
+	// SourceCall 源链转出资产的调用数据和随交易发送的原生币金额
+	SourceCall(route *Route, transfer *types.CrossChainStatus, targetChainID int64) ([]byte, *big.Int, error)
+	// ParseSource 解析源链转出事件
+	ParseSource(eventLog ethtypes.Log) (*SourceEvent, error)
+	// TargetCall 目标链凭证明转入资产的调用数据
+	TargetCall(route *Route, transfer *types.CrossChainStatus, attestation []byte) ([]byte, error)
+	// RefundCall 源链退还资产的调用数据
+	RefundCall(route *Route, transfer *types.CrossChainStatus) ([]byte, error)
+	// Events 源链转出、目标链转入和源链退款事件的主题
+	Events() (source, target, refund common.Hash)
+}
+
+var (
+	adaptersMu sync.RWMutex
+	adapters   = map[string]Adapter{
+		AdapterLockMint:    mustContractAdapter("lock", "Locked", "mint", "Minted", false, true),
+		AdapterBurnRelease: mustContractAdapter("burn", "Burned", "release", "Released", true, false),
+	}
+)
+
+// RegisterAdapter 注册跨链协议，须在加载路由前调用
+func RegisterAdapter(name string, adapter Adapter) {
+	adaptersMu.Lock()
+	defer adaptersMu.Unlock()
+	adapters[name] = adapter
+}
+
+// LookupAdapter 按名称获取跨链协议
+func LookupAdapter(name string) (Adapter, bool) {
+	adaptersMu.RLock()
+	defer adaptersMu.RUnlock()
+	adapter, ok := adapters[name]
+	return adapter, ok
+}
+
+// bridgeABITemplate 内置协议的桥合约接口，按协议替换转出和转入的方法、事件名称
+const bridgeABITemplate = `[
+	{"type":"function","name":"%[1]s","stateMutability":"payable","inputs":[
+		{"name":"transferId","type":"bytes32"},{"name":"token","type":"address"},{"name":"amount","type":"uint256"},
+		{"name":"targetChainId","type":"uint256"},{"name":"recipient","type":"address"}],"outputs":[]},
+	{"type":"event","name":"%[2]s","anonymous":false,"inputs":[
+		{"name":"transferId","type":"bytes32","indexed":true},{"name":"sender","type":"address","indexed":true},
+		{"name":"token","type":"address","indexed":false},{"name":"amount","type":"uint256","indexed":false},
+		{"name":"targetChainId","type":"uint256","indexed":false},{"name":"recipient","type":"address","indexed":false}]},
+	{"type":"function","name":"%[3]s","stateMutability":"nonpayable","inputs":[
+		{"name":"transferId","type":"bytes32"},{"name":"token","type":"address"},{"name":"recipient","type":"address"},
+		{"name":"amount","type":"uint256"},{"name":"attestation","type":"bytes"}],"outputs":[]},
+	{"type":"event","name":"%[4]s","anonymous":false,"inputs":[
+		{"name":"transferId","type":"bytes32","indexed":true},{"name":"recipient","type":"address","indexed":true},
+		{"name":"token","type":"address","indexed":false},{"name":"amount","type":"uint256","indexed":false}]},
+	{"type":"function","name":"refund","stateMutability":"nonpayable","inputs":[
+		{"name":"transferId","type":"bytes32"}],"outputs":[]},
+	{"type":"event","name":"Refunded","anonymous":false,"inputs":[
+		{"name":"transferId","type":"bytes32","indexed":true},{"name":"sender","type":"address","indexed":true},
+		{"name":"amount","type":"uint256","indexed":false}]}
+]`
+
+// contractAdapter 内置协议的实现，lock_mint 与 burn_release 的合约接口相同，只是方法名和代币要求不同
+type contractAdapter struct {
+	abi                abi.ABI
+	sourceMethod       string
+	sourceEvent        string
+	targetMethod       string
+	targetEvent        string
+	requireSourceToken bool // 源链销毁的映射代币
+	requireTargetToken bool // 目标链铸造的映射代币
+}
+
+// mustContractAdapter 创建内置协议，ABI模板有误时panic
+func mustContractAdapter(sourceMethod, sourceEvent, targetMethod, targetEvent string, requireSourceToken, requireTargetToken bool) *contractAdapter {
+	parsed, err := abi.JSON(strings.NewReader(fmt.Sprintf(bridgeABITemplate, sourceMethod, sourceEvent, targetMethod, targetEvent)))
+	if err != nil {
+		panic(fmt.Sprintf("invalid bridge ABI: %v", err))
+	}
+	return &contractAdapter{
+		abi:                parsed,
+		sourceMethod:       sourceMethod,
+		sourceEvent:        sourceEvent,
+		targetMethod:       targetMethod,
+		targetEvent:        targetEvent,
+		requireSourceToken: requireSourceToken,
+		requireTargetToken: requireTargetToken,
+	}
+}
+
+// Validate 检查路由的代币配置
+func (a *contractAdapter) Validate(route *Route) error {
+	if a.requireSourceToken && route.SourceToken == "" {
+		return fmt.Errorf("source_token is required")
+	}
+	if a.requireTargetToken && route.TargetToken == "" {
+		return fmt.Errorf("target_token is required")
+	}
+	return nil
+}
+
+// SourceCall 编码源链转出调用，原生币随交易发送
+func (a *contractAdapter) SourceCall(route *Route, transfer *types.CrossChainStatus, targetChainID int64) ([]byte, *big.Int, error) {
+	data, err := a.abi.Pack(a.sourceMethod, common.HexToHash(transfer.TransferID), tokenAddress(route.SourceToken),
+		transfer.Amount, big.NewInt(targetChainID), common.HexToAddress(transfer.To))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to encode %s call: %w", a.sourceMethod, err)
+	}
+	value := new(big.Int)
+	if route.SourceToken == "" {
+		value.Set(transfer.Amount)
+	}
+	return data, value, nil
+}
+
+// ParseSource 解析源链转出事件
+func (a *contractAdapter) ParseSource(eventLog ethtypes.Log) (*SourceEvent, error) {
+	event := a.abi.Events[a.sourceEvent]
+	if len(eventLog.Topics) != 3 || eventLog.Topics[0] != event.ID {
+		return nil, fmt.Errorf("log is not a %s event", a.sourceEvent)
+	}
+	values, err := event.Inputs.NonIndexed().Unpack(eventLog.Data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s event: %w", a.sourceEvent, err)
+	}
+	return &SourceEvent{
+		TransferID:    eventLog.Topics[1],
+		Sender:        common.BytesToAddress(eventLog.Topics[2].Bytes()),
+		Token:         values[0].(common.Address),
+		Amount:        values[1].(*big.Int),
+		TargetChainID: values[2].(*big.Int),
+		Recipient:     values[3].(common.Address),
+	}, nil
+}
+
+// TargetCall 编码目标链转入调用
+func (a *contractAdapter) TargetCall(route *Route, transfer *types.CrossChainStatus, attestation []byte) ([]byte, error) {
+	data, err := a.abi.Pack(a.targetMethod, common.HexToHash(transfer.TransferID), tokenAddress(route.TargetToken),
+		common.HexToAddress(transfer.To), transfer.Amount, attestation)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s call: %w", a.targetMethod, err)
+	}
+	return data, nil
+}
+
+// RefundCall 编码源链退款调用
+func (a *contractAdapter) RefundCall(route *Route, transfer *types.CrossChainStatus) ([]byte, error) {
+	data, err := a.abi.Pack("refund", common.HexToHash(transfer.TransferID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode refund call: %w", err)
+	}
+	return data, nil
+}
+
+// Events 转出、转入和退款事件的主题
+func (a *contractAdapter) Events() (source, target, refund common.Hash) {
+	return a.abi.Events[a.sourceEvent].ID, a.abi.Events[a.targetEvent].ID, a.abi.Events["Refunded"].ID
+}
+
+// tokenAddress 代币地址，原生币为零地址
+func tokenAddress(token string) common.Address {
+	if token == "" {
+		return common.Address{}
+	}
+	return common.HexToAddress(token)
+}
+
+// AttestationDigest 中继器签名的摘要，目标链桥合约据此校验证明
+// keccak256(abi.encode(transferId, sourceChainId, targetChainId, targetBridge, targetToken, recipient, amount))
+func AttestationDigest(route *Route, transferID common.Hash, sourceChainID, targetChainID int64, recipient common.Address, amount *big.Int) common.Hash {
+	word := func(b []byte) []byte { return common.LeftPadBytes(b, 32) }
+	var buf []byte
+	buf = append(buf, transferID.Bytes()...)
+	buf = append(buf, word(big.NewInt(sourceChainID).Bytes())...)
+	buf = append(buf, word(big.NewInt(targetChainID).Bytes())...)
+	buf = append(buf, word(common.HexToAddress(route.TargetBridge).Bytes())...)
+	buf = append(buf, word(tokenAddress(route.TargetToken).Bytes())...)
+	buf = append(buf, word(recipient.Bytes())...)
+	buf = append(buf, word(amount.Bytes())...)
+	return crypto.Keccak256Hash(buf)
+}
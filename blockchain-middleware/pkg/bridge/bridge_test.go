@@ -0,0 +1,102 @@
+package bridge
+
+import (
+	"blockchain-middleware/pkg/types"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestLoadRoutes(t *testing.T) {
+	const route = `{"name":"eth-bsc","adapter":"lock_mint","source_chain":"ethereum","target_chain":"bsc",
+		"source_bridge":"0x1000000000000000000000000000000000000001","target_bridge":"0x2000000000000000000000000000000000000002",
+		"target_token":"0x4000000000000000000000000000000000000004","executor":"0x5000000000000000000000000000000000000005",
+		"relayer_url":"http://relayer"`
+
+	tests := []struct {
+		name    string
+		json    string
+		wantErr string
+	}{
+		{"valid", `[` + route + `}]`, ""},
+		{"duplicate name", `[` + route + `},` + route + `}]`, `duplicate bridge route "eth-bsc"`},
+		{"unknown adapter", `[` + route + `,"adapter":"teleport"}]`, `unknown adapter "teleport"`},
+		{"same chain", `[` + route + `,"target_chain":"ethereum"}]`, "two different chains"},
+		{"missing relayer", `[` + route + `,"relayer_url":""}]`, "relayer_url is required"},
+		{"invalid bridge address", `[` + route + `,"source_bridge":"0x1234"}]`, `invalid source_bridge: "0x1234"`},
+		{"lock_mint without target token", `[` + route + `,"target_token":""}]`, "target_token is required"},
+		{"burn_release without source token", `[` + route + `,"adapter":"burn_release","target_token":""}]`, "source_token is required"},
+		{"malformed", `{`, "failed to parse bridge routes"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "routes.json")
+			if err := os.WriteFile(path, []byte(tt.json), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			routes, err := LoadRoutes(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("LoadRoutes() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(routes) != 1 || routes[0].SourceToken != "" {
+				t.Fatalf("routes = %+v", routes)
+			}
+		})
+	}
+}
+
+func TestRouteValidateChecksumsAddresses(t *testing.T) {
+	route := &Route{
+		Name:         "eth-bsc",
+		Adapter:      AdapterBurnRelease,
+		SourceChain:  "ethereum",
+		TargetChain:  "bsc",
+		SourceBridge: "0xabcdefabcdefabcdefabcdefabcdefabcdefabcd",
+		TargetBridge: testTargetBridge,
+		SourceToken:  strings.ToLower(testSourceToken),
+		Executor:     testExecutor,
+		RelayerURL:   "http://relayer",
+	}
+	if err := route.validate(); err != nil {
+		t.Fatal(err)
+	}
+	if route.SourceBridge != "0xABcdEFABcdEFabcdEfAbCdefabcdeFABcDEFabCD" || route.TargetToken != "" {
+		t.Errorf("route = %+v", route)
+	}
+}
+
+func TestContractAdapterParseSource(t *testing.T) {
+	adapter, _ := LookupAdapter(AdapterLockMint)
+	b := newTestBridge(t, "")
+	transfer := b.at(types.BridgeStateSourceSubmitted)
+
+	event, err := adapter.ParseSource(lockedLog(t, transfer))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event.TransferID != common.HexToHash(transfer.TransferID) || event.Sender != common.HexToAddress(testSender) ||
+		event.Token != (common.Address{}) || event.Amount.Cmp(testAmount) != 0 ||
+		event.TargetChainID.Int64() != 56 || event.Recipient != common.HexToAddress(testRecipient) {
+		t.Errorf("ParseSource() = %+v", event)
+	}
+
+	// 同一转账ID的目标链事件不是源链事件
+	if _, err := adapter.ParseSource(mintedLog(t, transfer)); err == nil {
+		t.Error("ParseSource() accepted a Minted event")
+	}
+	truncated := lockedLog(t, transfer)
+	truncated.Data = truncated.Data[:64]
+	if _, err := adapter.ParseSource(truncated); err == nil {
+		t.Error("ParseSource() accepted truncated event data")
+	}
+}
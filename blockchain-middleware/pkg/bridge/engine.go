@@ -0,0 +1,903 @@
+package bridge
+
+import (
+	"blockchain-middleware/internal/config"
+	"blockchain-middleware/pkg/chain"
+	"blockchain-middleware/pkg/contract"
+	"blockchain-middleware/pkg/types"
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+)
+
+const (
+	defaultPollInterval       = 10 * time.Second
+	defaultAttestationTimeout = 30 * time.Minute
+	defaultSubmitTimeout      = 10 * time.Minute
+	// finishedRetention 进入最终状态的转账在内存中保留的时间，之后从存储查询
+	finishedRetention = time.Hour
+	// maxStepsPerPoll 单个转账在一次轮询中最多推进的步数
+	maxStepsPerPoll = 8
+)
+
+// ErrTransferNotFound 跨链转账不存在
+var ErrTransferNotFound = errors.New("cross-chain transfer not found")
+
+// erc20ABI 授权桥合约转移代币用到的ERC-20方法
+var erc20ABI = func() abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(`[
+		{"type":"function","name":"allowance","stateMutability":"view","inputs":[{"name":"owner","type":"address"},{"name":"spender","type":"address"}],"outputs":[{"name":"","type":"uint256"}]},
+		{"type":"function","name":"approve","stateMutability":"nonpayable","inputs":[{"name":"spender","type":"address"},{"name":"amount","type":"uint256"}],"outputs":[{"name":"","type":"bool"}]}
+	]`))
+	if err != nil {
+		panic(fmt.Sprintf("invalid ERC-20 ABI: %v", err))
+	}
+	return parsed
+}()
+
+// ChainLookup 按链名称获取链客户端和链配置
+type ChainLookup func(chainName string) (chain.ChainClient, config.ChainConfig, error)
+
+// Engine 跨链转账引擎
+// 每笔转账按 created → source_submitted → source_confirmed → attested → target_submitted → completed 推进，
+// 目标链失败或证明超时时转入 refunding → refund_submitted → refunded。每次状态变更先持久化，
+// 提交交易前按转账ID查找桥合约事件，重启或重复提交都不会让同一步骤在链上执行两次。
+type Engine struct {
+	pollInterval       time.Duration
+	attestationTimeout time.Duration
+	submitTimeout      time.Duration
+	routes             []*Route
+	store              Store
+	chains             ChainLookup
+
+	mu        sync.Mutex
+	relayers  map[string]Relayer
+	transfers map[string]*types.CrossChainStatus
+
+	wake   chan struct{}
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewEngine 创建跨链转账引擎，每条路由默认通过 RelayerURL 查询证明
+func NewEngine(cfg config.BridgeConfig, routes []*Route, store Store, chains ChainLookup) *Engine {
+	pollInterval := cfg.PollInterval
+	if pollInterval <= 0 {
+		pollInterval = defaultPollInterval
+	}
+	attestationTimeout := cfg.AttestationTimeout
+	if attestationTimeout <= 0 {
+		attestationTimeout = defaultAttestationTimeout
+	}
+	submitTimeout := cfg.SubmitTimeout
+	if submitTimeout <= 0 {
+		submitTimeout = defaultSubmitTimeout
+	}
+
+	relayers := make(map[string]Relayer, len(routes))
+	for _, route := range routes {
+		relayers[route.Name] = NewHTTPRelayer(route.RelayerURL)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Engine{
+		pollInterval:       pollInterval,
+		attestationTimeout: attestationTimeout,
+		submitTimeout:      submitTimeout,
+		routes:             routes,
+		store:              store,
+		chains:             chains,
+		relayers:           relayers,
+		transfers:          make(map[string]*types.CrossChainStatus),
+		wake:               make(chan struct{}, 1),
+		ctx:                ctx,
+		cancel:             cancel,
+	}
+}
+
+// SetRelayer 替换路由的证明来源
+func (e *Engine) SetRelayer(routeName string, relayer Relayer) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.relayers[routeName] = relayer
+}
+
+// Start 从存储恢复在途转账并启动轮询
+func (e *Engine) Start(ctx context.Context) error {
+	active, err := e.store.ListActive(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to restore cross-chain transfers: %w", err)
+	}
+
+	e.mu.Lock()
+	for _, transfer := range active {
+		e.transfers[normalizeID(transfer.TransferID)] = transfer
+	}
+	e.mu.Unlock()
+
+	e.wg.Add(1)
+	go e.run()
+
+	log.Printf("Bridge engine started with %d routes, %d transfers restored", len(e.routes), len(active))
+	return nil
+}
+
+// Stop 停止轮询
+func (e *Engine) Stop() {
+	e.cancel()
+	e.wg.Wait()
+}
+
+// Routes 列出已配置的跨链路由
+func (e *Engine) Routes() []Route {
+	routes := make([]Route, 0, len(e.routes))
+	for _, route := range e.routes {
+		routes = append(routes, *route)
+	}
+	return routes
+}
+
+// Transfer 登记跨链转账并立即提交源链交易（ERC-20需要授权时先提交授权交易）
+// 源链调用回滚或被节点拒绝时转账记为 failed 并返回错误，此时没有交易广播出去。
+// 广播结果不确定时转账保持 created 交由后台推进，交易上链后由源链事件接续。
+func (e *Engine) Transfer(ctx context.Context, req *types.CrossChainRequest) (*types.CrossChainStatus, error) {
+	route, err := e.matchRoute(req)
+	if err != nil {
+		return nil, err
+	}
+	if !common.IsHexAddress(req.From) {
+		return nil, fmt.Errorf("invalid from address: %s", req.From)
+	}
+	if !common.IsHexAddress(req.To) {
+		return nil, fmt.Errorf("invalid to address: %s", req.To)
+	}
+	if req.Amount == nil || req.Amount.Sign() <= 0 {
+		return nil, fmt.Errorf("amount must be positive")
+	}
+
+	source, err := e.side(route.SourceChain)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := e.side(route.TargetChain); err != nil {
+		return nil, err
+	}
+	head, err := source.client.GetBlockNumber(ctx)
+	if err != nil {
+		return nil, err
+	}
+	transferID, err := newTransferID()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	transfer := &types.CrossChainStatus{
+		TransferID:     transferID,
+		Status:         types.BridgeStateCreated,
+		Route:          route.Name,
+		Adapter:        route.Adapter,
+		FromChain:      route.SourceChain,
+		ToChain:        route.TargetChain,
+		From:           common.HexToAddress(req.From).Hex(),
+		To:             common.HexToAddress(req.To).Hex(),
+		Token:          route.SourceToken,
+		TargetToken:    route.TargetToken,
+		Amount:         new(big.Int).Set(req.Amount),
+		History:        []types.BridgeStep{{State: types.BridgeStateCreated, At: now}},
+		SourceScanFrom: head,
+		StateAt:        now,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	if err := e.store.Save(ctx, transfer); err != nil {
+		return nil, err
+	}
+
+	if _, err := e.advance(ctx, transfer); errors.Is(err, chain.ErrBroadcastUncertain) {
+		log.Printf("Broadcast of cross-chain transfer %s is uncertain, leaving it to the poller: %v", transferID, err)
+		e.record(transfer, "", err.Error())
+	} else if err != nil {
+		e.transition(transfer, types.BridgeStateFailed, "", err.Error())
+	}
+	if err := e.store.Save(ctx, transfer); err != nil {
+		return nil, err
+	}
+	if transfer.Status == types.BridgeStateFailed {
+		return nil, fmt.Errorf("cross-chain transfer %s failed: %s", transferID, transfer.Error)
+	}
+
+	e.mu.Lock()
+	e.transfers[normalizeID(transferID)] = clone(transfer)
+	e.mu.Unlock()
+
+	select {
+	case e.wake <- struct{}{}:
+	default:
+	}
+	return transfer, nil
+}
+
+// Get 获取跨链转账状态
+func (e *Engine) Get(ctx context.Context, transferID string) (*types.CrossChainStatus, error) {
+	e.mu.Lock()
+	transfer, ok := e.transfers[normalizeID(transferID)]
+	if ok {
+		transfer = clone(transfer)
+	}
+	e.mu.Unlock()
+	if ok {
+		return transfer, nil
+	}
+
+	stored, err := e.store.Get(ctx, transferID)
+	if err != nil {
+		return nil, err
+	}
+	if stored == nil {
+		return nil, ErrTransferNotFound
+	}
+	return stored, nil
+}
+
+// matchRoute 按名称或链和代币选择路由
+func (e *Engine) matchRoute(req *types.CrossChainRequest) (*Route, error) {
+	if req.Route != "" {
+		for _, route := range e.routes {
+			if route.Name != req.Route {
+				continue
+			}
+			if req.FromChain != "" && req.FromChain != route.SourceChain || req.ToChain != "" && req.ToChain != route.TargetChain {
+				return nil, fmt.Errorf("route %s bridges %s to %s", route.Name, route.SourceChain, route.TargetChain)
+			}
+			return route, nil
+		}
+		return nil, fmt.Errorf("unknown bridge route: %s", req.Route)
+	}
+
+	token := ""
+	if req.Token != "" && common.HexToAddress(req.Token) != (common.Address{}) {
+		if !common.IsHexAddress(req.Token) {
+			return nil, fmt.Errorf("invalid token address: %s", req.Token)
+		}
+		token = common.HexToAddress(req.Token).Hex()
+	}
+
+	var matched []*Route
+	for _, route := range e.routes {
+		if route.SourceChain == req.FromChain && route.TargetChain == req.ToChain && route.SourceToken == token {
+			matched = append(matched, route)
+		}
+	}
+	switch len(matched) {
+	case 0:
+		if token == "" {
+			token = "native"
+		}
+		return nil, fmt.Errorf("no bridge route from %s to %s for %s", req.FromChain, req.ToChain, token)
+	case 1:
+		return matched[0], nil
+	default:
+		return nil, fmt.Errorf("multiple bridge routes from %s to %s, specify route", req.FromChain, req.ToChain)
+	}
+}
+
+// route 按名称获取路由
+func (e *Engine) route(name string) *Route {
+	for _, route := range e.routes {
+		if route.Name == name {
+			return route
+		}
+	}
+	return nil
+}
+
+// run 轮询循环
+func (e *Engine) run() {
+	defer e.wg.Done()
+
+	ticker := time.NewTicker(e.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-e.ctx.Done():
+			return
+		case <-ticker.C:
+		case <-e.wake:
+		}
+		e.poll()
+	}
+}
+
+// poll 依次推进在途转账，并清理已结束较久的记录
+func (e *Engine) poll() {
+	now := time.Now()
+	var active []*types.CrossChainStatus
+
+	e.mu.Lock()
+	for key, transfer := range e.transfers {
+		if transfer.IsFinal() {
+			if now.Sub(transfer.UpdatedAt) > finishedRetention {
+				delete(e.transfers, key)
+			}
+			continue
+		}
+		active = append(active, clone(transfer))
+	}
+	e.mu.Unlock()
+
+	for _, transfer := range active {
+		if e.ctx.Err() != nil {
+			return
+		}
+		e.process(transfer)
+	}
+}
+
+// process 推进转账直到需要等待链上确认或中继器，每步状态变更后先持久化
+// 出错时保留当前状态，下次轮询重试。
+func (e *Engine) process(transfer *types.CrossChainStatus) {
+	for i := 0; i < maxStepsPerPoll && !transfer.IsFinal(); i++ {
+		changed, err := e.advance(e.ctx, transfer)
+		if err != nil {
+			log.Printf("Failed to advance cross-chain transfer %s (%s): %v", transfer.TransferID, transfer.Status, err)
+			return
+		}
+		if !changed {
+			return
+		}
+		if err := e.store.Save(e.ctx, transfer); err != nil {
+			log.Printf("Failed to save cross-chain transfer %s: %v", transfer.TransferID, err)
+			return
+		}
+
+		e.mu.Lock()
+		e.transfers[normalizeID(transfer.TransferID)] = clone(transfer)
+		e.mu.Unlock()
+	}
+}
+
+// advance 按当前状态推进一步，返回转账是否有变化
+func (e *Engine) advance(ctx context.Context, transfer *types.CrossChainStatus) (bool, error) {
+	route := e.route(transfer.Route)
+	if route == nil {
+		return false, fmt.Errorf("bridge route %s is no longer configured", transfer.Route)
+	}
+	adapter, ok := LookupAdapter(route.Adapter)
+	if !ok {
+		return false, fmt.Errorf("unknown adapter %q", route.Adapter)
+	}
+
+	switch transfer.Status {
+	case types.BridgeStateCreated:
+		return e.submitSource(ctx, route, adapter, transfer)
+	case types.BridgeStateSourceSubmitted:
+		return e.checkSource(ctx, route, adapter, transfer)
+	case types.BridgeStateSourceConfirmed:
+		return e.attest(ctx, route, transfer)
+	case types.BridgeStateAttested:
+		return e.submitTarget(ctx, route, adapter, transfer)
+	case types.BridgeStateTargetSubmitted:
+		return e.checkTarget(ctx, route, adapter, transfer)
+	case types.BridgeStateRefunding:
+		return e.submitRefund(ctx, route, adapter, transfer)
+	case types.BridgeStateRefundSubmitted:
+		return e.checkRefund(ctx, route, adapter, transfer)
+	}
+	return false, nil
+}
+
+// submitSource 提交源链转出交易
+func (e *Engine) submitSource(ctx context.Context, route *Route, adapter Adapter, transfer *types.CrossChainStatus) (bool, error) {
+	source, err := e.side(route.SourceChain)
+	if err != nil {
+		return false, err
+	}
+	target, err := e.side(route.TargetChain)
+	if err != nil {
+		return false, err
+	}
+
+	// 重启前或超时重提前的交易已经上链
+	sourceEvent, _, _ := adapter.Events()
+	eventLog, err := e.findEvent(ctx, source, route.SourceBridge, sourceEvent, transfer.TransferID, transfer.SourceScanFrom)
+	if err != nil {
+		return false, err
+	}
+	if eventLog != nil {
+		transfer.SourceTx = eventLog.TxHash.Hex()
+		e.transition(transfer, types.BridgeStateSourceSubmitted, transfer.SourceTx, "")
+		return true, nil
+	}
+
+	if route.SourceToken != "" {
+		changed, ready, err := e.ensureAllowance(ctx, source, route, transfer)
+		if err != nil || !ready {
+			return changed, err
+		}
+	}
+
+	data, value, err := adapter.SourceCall(route, transfer, target.client.GetChainID())
+	if err != nil {
+		return false, err
+	}
+	txHash, err := e.submit(ctx, source, &types.TransactionRequest{
+		From:  transfer.From,
+		To:    route.SourceBridge,
+		Value: value,
+		Data:  data,
+	})
+	if err != nil {
+		if reason, reverted := revertReason(err); reverted {
+			e.transition(transfer, types.BridgeStateFailed, "", "source call reverted: "+reason)
+			return true, nil
+		}
+		return false, fmt.Errorf("failed to submit source transaction: %w", err)
+	}
+	transfer.SourceTx = txHash
+	e.transition(transfer, types.BridgeStateSourceSubmitted, txHash, "")
+	return true, nil
+}
+
+// ensureAllowance 确保桥合约可以转移发送方的代币，额度不足时提交授权交易并等待上链
+func (e *Engine) ensureAllowance(ctx context.Context, source *chainSide, route *Route, transfer *types.CrossChainStatus) (changed, ready bool, err error) {
+	owner := common.HexToAddress(transfer.From)
+	spender := common.HexToAddress(route.SourceBridge)
+	data, err := erc20ABI.Pack("allowance", owner, spender)
+	if err != nil {
+		return false, false, fmt.Errorf("failed to encode allowance call: %w", err)
+	}
+	out, err := source.client.CallContract(ctx, &types.ContractCallRequest{
+		From:            owner,
+		ContractAddress: common.HexToAddress(route.SourceToken),
+		Data:            data,
+	})
+	if err != nil {
+		return false, false, fmt.Errorf("failed to query allowance: %w", err)
+	}
+	if len(out) < 32 {
+		return false, false, fmt.Errorf("invalid allowance response from %s", route.SourceToken)
+	}
+	if new(big.Int).SetBytes(out[:32]).Cmp(transfer.Amount) >= 0 {
+		return false, true, nil
+	}
+
+	if transfer.ApproveTx != "" {
+		outcome, err := e.outcome(ctx, source, transfer.ApproveTx, transfer.StateAt)
+		if err != nil {
+			return false, false, err
+		}
+		switch outcome {
+		case txPending:
+			return false, false, nil
+		case txReverted:
+			e.transition(transfer, types.BridgeStateFailed, "", "approve transaction reverted")
+			return true, false, nil
+		case txConfirmed:
+			e.transition(transfer, types.BridgeStateFailed, "", "allowance is insufficient after approval")
+			return true, false, nil
+		}
+		// 授权交易被丢弃，重新提交
+	}
+
+	approve, err := erc20ABI.Pack("approve", spender, transfer.Amount)
+	if err != nil {
+		return false, false, fmt.Errorf("failed to encode approve call: %w", err)
+	}
+	txHash, err := e.submit(ctx, source, &types.TransactionRequest{
+		From: transfer.From,
+		To:   route.SourceToken,
+		Data: approve,
+	})
+	if err != nil {
+		if reason, reverted := revertReason(err); reverted {
+			e.transition(transfer, types.BridgeStateFailed, "", "approve call reverted: "+reason)
+			return true, false, nil
+		}
+		return false, false, fmt.Errorf("failed to submit approve transaction: %w", err)
+	}
+	transfer.ApproveTx = txHash
+	e.record(transfer, txHash, "")
+	return true, false, nil
+}
+
+// checkSource 等待源链交易达到确认数，并核对桥合约事件与转账一致
+func (e *Engine) checkSource(ctx context.Context, route *Route, adapter Adapter, transfer *types.CrossChainStatus) (bool, error) {
+	source, err := e.side(route.SourceChain)
+	if err != nil {
+		return false, err
+	}
+	target, err := e.side(route.TargetChain)
+	if err != nil {
+		return false, err
+	}
+
+	sourceEvent, _, _ := adapter.Events()
+	outcome, eventLog, err := e.settle(ctx, source, route.SourceBridge, sourceEvent, transfer, &transfer.SourceTx, transfer.SourceScanFrom)
+	if err != nil {
+		return false, err
+	}
+	switch outcome {
+	case txPending:
+		return false, nil
+	case txAdopted:
+		return true, nil
+	case txMissing:
+		e.transition(transfer, types.BridgeStateCreated, "", "source transaction not found, resubmitting")
+		return true, nil
+	case txReverted:
+		e.transition(transfer, types.BridgeStateFailed, "", "source transaction reverted")
+		return true, nil
+	}
+
+	if eventLog == nil {
+		e.transition(transfer, types.BridgeStateFailed, "", "source transaction did not emit a bridge event")
+		return true, nil
+	}
+	transfer.SourceBlock = eventLog.BlockNumber
+
+	event, err := adapter.ParseSource(*eventLog)
+	if err != nil {
+		e.transition(transfer, types.BridgeStateRefunding, "", err.Error())
+		return true, nil
+	}
+	if event.Amount.Cmp(transfer.Amount) != 0 ||
+		event.Recipient != common.HexToAddress(transfer.To) ||
+		event.Token != tokenAddress(route.SourceToken) ||
+		event.TargetChainID.Cmp(big.NewInt(target.client.GetChainID())) != 0 {
+		e.transition(transfer, types.BridgeStateRefunding, "", "source event does not match the transfer")
+		return true, nil
+	}
+
+	// 只在第一次确认时记录，之后不能越过可能已上链的目标链交易
+	if transfer.TargetScanFrom == 0 {
+		head, err := target.client.GetBlockNumber(ctx)
+		if err != nil {
+			return false, err
+		}
+		transfer.TargetScanFrom = head
+	}
+	e.transition(transfer, types.BridgeStateSourceConfirmed, transfer.SourceTx, "")
+	return true, nil
+}
+
+// attest 向中继器获取证明，超时未取得时退款
+func (e *Engine) attest(ctx context.Context, route *Route, transfer *types.CrossChainStatus) (bool, error) {
+	e.mu.Lock()
+	relayer := e.relayers[route.Name]
+	e.mu.Unlock()
+
+	attestation, err := relayer.Attestation(ctx, route, transfer)
+	if err == nil && attestation != nil {
+		transfer.Attestation = hexutil.Encode(attestation)
+		e.transition(transfer, types.BridgeStateAttested, "", "")
+		return true, nil
+	}
+	if time.Since(transfer.StateAt) > e.attestationTimeout {
+		e.transition(transfer, types.BridgeStateRefunding, "", "attestation timed out")
+		return true, nil
+	}
+	return false, err
+}
+
+// submitTarget 凭证明提交目标链转入交易，调用回滚时退款
+func (e *Engine) submitTarget(ctx context.Context, route *Route, adapter Adapter, transfer *types.CrossChainStatus) (bool, error) {
+	target, err := e.side(route.TargetChain)
+	if err != nil {
+		return false, err
+	}
+
+	_, targetEvent, _ := adapter.Events()
+	eventLog, err := e.findEvent(ctx, target, route.TargetBridge, targetEvent, transfer.TransferID, transfer.TargetScanFrom)
+	if err != nil {
+		return false, err
+	}
+	if eventLog != nil {
+		transfer.TargetTx = eventLog.TxHash.Hex()
+		e.transition(transfer, types.BridgeStateTargetSubmitted, transfer.TargetTx, "")
+		return true, nil
+	}
+
+	attestation, err := hexutil.Decode(transfer.Attestation)
+	if err != nil {
+		return false, fmt.Errorf("invalid attestation: %w", err)
+	}
+	data, err := adapter.TargetCall(route, transfer, attestation)
+	if err != nil {
+		return false, err
+	}
+	txHash, err := e.submit(ctx, target, &types.TransactionRequest{
+		From: route.Executor,
+		To:   route.TargetBridge,
+		Data: data,
+	})
+	if err != nil {
+		if reason, reverted := revertReason(err); reverted {
+			e.transition(transfer, types.BridgeStateRefunding, "", "target call reverted: "+reason)
+			return true, nil
+		}
+		return false, fmt.Errorf("failed to submit target transaction: %w", err)
+	}
+	transfer.TargetTx = txHash
+	e.transition(transfer, types.BridgeStateTargetSubmitted, txHash, "")
+	return true, nil
+}
+
+// checkTarget 等待目标链交易达到确认数
+func (e *Engine) checkTarget(ctx context.Context, route *Route, adapter Adapter, transfer *types.CrossChainStatus) (bool, error) {
+	target, err := e.side(route.TargetChain)
+	if err != nil {
+		return false, err
+	}
+
+	_, targetEvent, _ := adapter.Events()
+	outcome, eventLog, err := e.settle(ctx, target, route.TargetBridge, targetEvent, transfer, &transfer.TargetTx, transfer.TargetScanFrom)
+	if err != nil {
+		return false, err
+	}
+	switch outcome {
+	case txPending:
+		return false, nil
+	case txAdopted:
+		return true, nil
+	case txMissing:
+		e.transition(transfer, types.BridgeStateAttested, "", "target transaction not found, resubmitting")
+	case txReverted:
+		e.transition(transfer, types.BridgeStateRefunding, "", "target transaction reverted")
+	case txConfirmed:
+		if eventLog == nil {
+			// 交易成功却没有转入事件，不能确定资产是否已转入，交由人工处理
+			e.transition(transfer, types.BridgeStateFailed, "", "target transaction did not emit a bridge event")
+			return true, nil
+		}
+		e.transition(transfer, types.BridgeStateCompleted, transfer.TargetTx, "")
+	}
+	return true, nil
+}
+
+// submitRefund 提交源链退款交易
+func (e *Engine) submitRefund(ctx context.Context, route *Route, adapter Adapter, transfer *types.CrossChainStatus) (bool, error) {
+	source, err := e.side(route.SourceChain)
+	if err != nil {
+		return false, err
+	}
+
+	_, _, refundEvent := adapter.Events()
+	eventLog, err := e.findEvent(ctx, source, route.SourceBridge, refundEvent, transfer.TransferID, transfer.SourceScanFrom)
+	if err != nil {
+		return false, err
+	}
+	if eventLog != nil {
+		transfer.RefundTx = eventLog.TxHash.Hex()
+		e.transition(transfer, types.BridgeStateRefundSubmitted, transfer.RefundTx, "")
+		return true, nil
+	}
+
+	data, err := adapter.RefundCall(route, transfer)
+	if err != nil {
+		return false, err
+	}
+	txHash, err := e.submit(ctx, source, &types.TransactionRequest{
+		From: route.Executor,
+		To:   route.SourceBridge,
+		Data: data,
+	})
+	if err != nil {
+		if reason, reverted := revertReason(err); reverted {
+			e.transition(transfer, types.BridgeStateFailed, "", "refund call reverted: "+reason)
+			return true, nil
+		}
+		return false, fmt.Errorf("failed to submit refund transaction: %w", err)
+	}
+	transfer.RefundTx = txHash
+	e.transition(transfer, types.BridgeStateRefundSubmitted, txHash, "")
+	return true, nil
+}
+
+// checkRefund 等待源链退款交易达到确认数
+func (e *Engine) checkRefund(ctx context.Context, route *Route, adapter Adapter, transfer *types.CrossChainStatus) (bool, error) {
+	source, err := e.side(route.SourceChain)
+	if err != nil {
+		return false, err
+	}
+
+	_, _, refundEvent := adapter.Events()
+	outcome, _, err := e.settle(ctx, source, route.SourceBridge, refundEvent, transfer, &transfer.RefundTx, transfer.SourceScanFrom)
+	if err != nil {
+		return false, err
+	}
+	switch outcome {
+	case txPending:
+		return false, nil
+	case txMissing:
+		e.transition(transfer, types.BridgeStateRefunding, "", "refund transaction not found, resubmitting")
+	case txReverted:
+		e.transition(transfer, types.BridgeStateFailed, "", "refund transaction reverted")
+	case txConfirmed:
+		e.transition(transfer, types.BridgeStateRefunded, transfer.RefundTx, "")
+	}
+	return true, nil
+}
+
+// transition 进入新状态，failed 和 refunding 的原因记入 Error
+func (e *Engine) transition(transfer *types.CrossChainStatus, state, txHash, reason string) {
+	transfer.Status = state
+	if state == types.BridgeStateFailed || state == types.BridgeStateRefunding {
+		transfer.Error = reason
+	}
+	e.record(transfer, txHash, reason)
+	log.Printf("Cross-chain transfer %s is %s", transfer.TransferID, state)
+}
+
+// record 记录一次状态变更并重新开始当前状态的计时
+func (e *Engine) record(transfer *types.CrossChainStatus, txHash, reason string) {
+	now := time.Now()
+	transfer.History = append(transfer.History, types.BridgeStep{
+		State:  transfer.Status,
+		TxHash: txHash,
+		Error:  reason,
+		At:     now,
+	})
+	transfer.StateAt = now
+	transfer.UpdatedAt = now
+}
+
+// chainSide 跨链转账一侧的链
+type chainSide struct {
+	client        chain.ChainClient
+	status        chain.TransactionStatusProvider
+	logs          chain.LogWatcher
+	confirmations uint64
+}
+
+// side 获取链客户端，链须支持交易状态查询和日志查询
+func (e *Engine) side(chainName string) (*chainSide, error) {
+	client, cfg, err := e.chains(chainName)
+	if err != nil {
+		return nil, err
+	}
+	status, ok := chain.As[chain.TransactionStatusProvider](client)
+	if !ok {
+		return nil, fmt.Errorf("chain %s does not support cross-chain transfers", chainName)
+	}
+	logs, ok := chain.As[chain.LogWatcher](client)
+	if !ok {
+		return nil, fmt.Errorf("chain %s does not support cross-chain transfers", chainName)
+	}
+	confirmations := cfg.Confirmations
+	if confirmations == 0 {
+		confirmations = 1
+	}
+	return &chainSide{client: client, status: status, logs: logs, confirmations: confirmations}, nil
+}
+
+// txOutcome 已提交交易的链上结果
+type txOutcome int
+
+const (
+	txPending   txOutcome = iota // 在交易池中或确认数不足
+	txConfirmed                  // 执行成功并达到确认数
+	txReverted                   // 执行失败并达到确认数
+	txMissing                    // 节点中查不到且超过提交超时
+	txAdopted                    // 改为跟踪同一转账ID事件所在的交易
+)
+
+// outcome 查询交易的链上结果，since 为提交时间
+func (e *Engine) outcome(ctx context.Context, side *chainSide, txHash string, since time.Time) (txOutcome, error) {
+	status, err := side.status.TransactionStatus(ctx, txHash, "")
+	if err != nil {
+		return txPending, err
+	}
+	switch {
+	case !status.Found:
+		if time.Since(since) > e.submitTimeout {
+			return txMissing, nil
+		}
+		return txPending, nil
+	case status.Pending || status.HeadBlock+1 < status.BlockNumber+side.confirmations:
+		return txPending, nil
+	case !status.Success:
+		return txReverted, nil
+	}
+	return txConfirmed, nil
+}
+
+// settle 查询已提交交易的结果，成功时一并返回桥合约事件
+// 交易失败或丢失时，同一转账ID的事件可能已由之前提交的交易发出（合约拒绝了重复提交），
+// 此时改为跟踪该交易。
+func (e *Engine) settle(ctx context.Context, side *chainSide, contractAddress string, topic common.Hash,
+	transfer *types.CrossChainStatus, txHash *string, scanFrom uint64) (txOutcome, *ethtypes.Log, error) {
+	outcome, err := e.outcome(ctx, side, *txHash, transfer.StateAt)
+	if err != nil || outcome == txPending {
+		return outcome, nil, err
+	}
+
+	eventLog, err := e.findEvent(ctx, side, contractAddress, topic, transfer.TransferID, scanFrom)
+	if err != nil {
+		return txPending, nil, err
+	}
+	if outcome != txConfirmed && eventLog != nil && eventLog.TxHash.Hex() != *txHash {
+		*txHash = eventLog.TxHash.Hex()
+		e.record(transfer, *txHash, "transaction with the same transfer id found on chain")
+		return txAdopted, eventLog, nil
+	}
+	return outcome, eventLog, nil
+}
+
+// findEvent 从 fromBlock 到最新区块查找桥合约中转账ID对应的事件，不存在时返回nil
+func (e *Engine) findEvent(ctx context.Context, side *chainSide, contractAddress string, topic common.Hash, transferID string, fromBlock uint64) (*ethtypes.Log, error) {
+	head, err := side.client.GetBlockNumber(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if fromBlock == 0 {
+		// WatchLogs 的起始区块为0时表示从下一个区块开始
+		fromBlock = 1
+	}
+	if fromBlock > head {
+		return nil, nil
+	}
+
+	var found *ethtypes.Log
+	filter := types.EventFilter{
+		ContractAddress: contractAddress,
+		Topics:          []string{topic.Hex(), transferID},
+		FromBlock:       fromBlock,
+		ToBlock:         head,
+	}
+	err = side.logs.WatchLogs(ctx, filter, chain.EventHandlerFunc(func(eventLog ethtypes.Log) error {
+		if !eventLog.Removed && found == nil {
+			found = &eventLog
+		}
+		return nil
+	}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query bridge events: %w", err)
+	}
+	return found, nil
+}
+
+// submit 估算gas后由签名器签名并广播交易，合约调用会回滚时估算即返回错误，不会上链
+func (e *Engine) submit(ctx context.Context, side *chainSide, req *types.TransactionRequest) (string, error) {
+	gas, err := side.client.EstimateGas(ctx, req)
+	if err != nil {
+		return "", err
+	}
+	// 预留20%余量，避免状态变化导致gas不足
+	req.GasLimit = gas + gas/5
+	return side.client.SendTransaction(ctx, req)
+}
+
+// revertReason 判断gas估算是否因合约回滚失败，并解码回滚原因
+func revertReason(err error) (string, bool) {
+	data, reverted := chain.RevertData(err)
+	if !reverted {
+		return "", false
+	}
+	if reason := contract.DecodeRevert(nil, data).Reason; reason != "" {
+		return reason, true
+	}
+	return "execution reverted", true
+}
+
+// newTransferID 生成随机的32字节转账ID
+func newTransferID() (string, error) {
+	var id [32]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return "", fmt.Errorf("failed to generate transfer id: %w", err)
+	}
+	return hexutil.Encode(id[:]), nil
+}
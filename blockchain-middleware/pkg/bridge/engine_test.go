@@ -0,0 +1,703 @@
+package bridge
+
+import (
+	"blockchain-middleware/internal/config"
+	"blockchain-middleware/pkg/chain"
+	"blockchain-middleware/pkg/types"
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+)
+
+const (
+	testSourceBridge = "0x1000000000000000000000000000000000000001"
+	testTargetBridge = "0x2000000000000000000000000000000000000002"
+	testSourceToken  = "0x3000000000000000000000000000000000000003"
+	testTargetToken  = "0x4000000000000000000000000000000000000004"
+	testExecutor     = "0x5000000000000000000000000000000000000005"
+	testSender       = "0x6000000000000000000000000000000000000006"
+	testRecipient    = "0x7000000000000000000000000000000000000007"
+)
+
+var testAmount = big.NewInt(1e18)
+
+// fakeChain 内存中的链，记录提交的交易，按预设返回交易状态和桥合约日志
+type fakeChain struct {
+	chain.ChainClient // 引擎用不到的方法
+
+	chainID       int64
+	head          uint64
+	confirmations uint64
+	allowance     *big.Int
+	estimateErr   error
+	sendErr       error // 交易仍记入 sent，由调用方决定是否上链
+	sent          []*types.TransactionRequest
+	statuses      map[string]*types.TxChainStatus
+	logs          []ethtypes.Log
+}
+
+func newFakeChain(chainID int64, head uint64) *fakeChain {
+	return &fakeChain{chainID: chainID, head: head, allowance: new(big.Int), statuses: make(map[string]*types.TxChainStatus)}
+}
+
+func (c *fakeChain) GetChainID() int64 { return c.chainID }
+
+func (c *fakeChain) GetBlockNumber(ctx context.Context) (uint64, error) { return c.head, nil }
+
+func (c *fakeChain) EstimateGas(ctx context.Context, req *types.TransactionRequest) (uint64, error) {
+	return 50000, c.estimateErr
+}
+
+func (c *fakeChain) SendTransaction(ctx context.Context, req *types.TransactionRequest) (string, error) {
+	c.sent = append(c.sent, req)
+	txHash := fmt.Sprintf("0x%064x", c.chainID<<32|int64(len(c.sent)))
+	if c.sendErr != nil {
+		return "", c.sendErr
+	}
+	c.statuses[txHash] = &types.TxChainStatus{Found: true, Pending: true}
+	return txHash, nil
+}
+
+func (c *fakeChain) CallContract(ctx context.Context, req *types.ContractCallRequest) ([]byte, error) {
+	return common.LeftPadBytes(c.allowance.Bytes(), 32), nil
+}
+
+func (c *fakeChain) TransactionStatus(ctx context.Context, txHash, from string) (*types.TxChainStatus, error) {
+	status, ok := c.statuses[txHash]
+	if !ok {
+		return &types.TxChainStatus{HeadBlock: c.head}, nil
+	}
+	s := *status
+	s.HeadBlock = c.head
+	return &s, nil
+}
+
+func (c *fakeChain) WatchLogs(ctx context.Context, filter types.EventFilter, handler chain.EventHandler) error {
+	for _, eventLog := range c.logs {
+		if eventLog.Address != common.HexToAddress(filter.ContractAddress) ||
+			eventLog.Topics[0] != common.HexToHash(filter.Topics[0]) ||
+			eventLog.Topics[1] != common.HexToHash(filter.Topics[1]) ||
+			eventLog.BlockNumber < filter.FromBlock || eventLog.BlockNumber > filter.ToBlock {
+			continue
+		}
+		if err := handler.HandleEvent(eventLog); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mine 把交易打包进新区块，logs 为交易发出的事件
+func (c *fakeChain) mine(txHash string, success bool, logs ...ethtypes.Log) {
+	c.head++
+	c.statuses[txHash] = &types.TxChainStatus{Found: true, BlockNumber: c.head, Success: success}
+	for _, eventLog := range logs {
+		eventLog.TxHash = common.HexToHash(txHash)
+		eventLog.BlockNumber = c.head
+		c.logs = append(c.logs, eventLog)
+	}
+}
+
+// lastSent 最近一次提交的交易
+func (c *fakeChain) lastSent(t *testing.T) *types.TransactionRequest {
+	t.Helper()
+	if len(c.sent) == 0 {
+		t.Fatal("no transaction was sent")
+	}
+	return c.sent[len(c.sent)-1]
+}
+
+// bridgeLog 内置协议桥合约发出的事件，indexed 为转账ID之后的索引参数
+func bridgeLog(t *testing.T, name, contractAddress, transferID string, indexed common.Address, args ...interface{}) ethtypes.Log {
+	t.Helper()
+	adapter, _ := LookupAdapter(AdapterLockMint)
+	event := adapter.(*contractAdapter).abi.Events[name]
+	data, err := event.Inputs.NonIndexed().Pack(args...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ethtypes.Log{
+		Address: common.HexToAddress(contractAddress),
+		Topics:  []common.Hash{event.ID, common.HexToHash(transferID), common.BytesToHash(indexed.Bytes())},
+		Data:    data,
+	}
+}
+
+// lockedLog 与转账一致的源链 Locked 事件
+func lockedLog(t *testing.T, transfer *types.CrossChainStatus) ethtypes.Log {
+	return bridgeLog(t, "Locked", testSourceBridge, transfer.TransferID, common.HexToAddress(transfer.From),
+		common.Address{}, transfer.Amount, big.NewInt(56), common.HexToAddress(transfer.To))
+}
+
+func mintedLog(t *testing.T, transfer *types.CrossChainStatus) ethtypes.Log {
+	return bridgeLog(t, "Minted", testTargetBridge, transfer.TransferID, common.HexToAddress(transfer.To),
+		common.HexToAddress(testTargetToken), transfer.Amount)
+}
+
+func refundedLog(t *testing.T, transfer *types.CrossChainStatus) ethtypes.Log {
+	return bridgeLog(t, "Refunded", testSourceBridge, transfer.TransferID, common.HexToAddress(transfer.From), transfer.Amount)
+}
+
+// revertError 节点在gas估算时返回的回滚错误，附带 Error(string) 编码的原因
+type revertError struct {
+	reason string
+}
+
+func (e revertError) Error() string { return "execution reverted: " + e.reason }
+
+func (e revertError) ErrorData() interface{} {
+	stringType, _ := abi.NewType("string", "", nil)
+	data, _ := abi.Arguments{{Type: stringType}}.Pack(e.reason)
+	return hexutil.Encode(append([]byte{0x08, 0xc3, 0x79, 0xa0}, data...))
+}
+
+// fakeRelayer 返回预设证明的中继器
+type fakeRelayer struct {
+	attestation []byte
+	err         error
+}
+
+func (r *fakeRelayer) Attestation(ctx context.Context, route *Route, transfer *types.CrossChainStatus) ([]byte, error) {
+	return r.attestation, r.err
+}
+
+// testBridge 以太坊（源链）到BSC（目标链）的 lock_mint 路由和两条假链
+type testBridge struct {
+	engine  *Engine
+	store   *MemoryStore
+	route   *Route
+	source  *fakeChain
+	target  *fakeChain
+	relayer *fakeRelayer
+}
+
+func newTestBridge(t *testing.T, sourceToken string) *testBridge {
+	t.Helper()
+	route := &Route{
+		Name:         "eth-bsc",
+		Adapter:      AdapterLockMint,
+		SourceChain:  "ethereum",
+		TargetChain:  "bsc",
+		SourceBridge: testSourceBridge,
+		TargetBridge: testTargetBridge,
+		SourceToken:  sourceToken,
+		TargetToken:  testTargetToken,
+		Executor:     testExecutor,
+		RelayerURL:   "http://relayer.invalid",
+	}
+	if err := route.validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	b := &testBridge{
+		store:   NewMemoryStore(),
+		route:   route,
+		source:  newFakeChain(1, 100),
+		target:  newFakeChain(56, 200),
+		relayer: &fakeRelayer{},
+	}
+	chains := func(chainName string) (chain.ChainClient, config.ChainConfig, error) {
+		switch chainName {
+		case "ethereum":
+			return b.source, config.ChainConfig{Name: chainName, Confirmations: b.source.confirmations}, nil
+		case "bsc":
+			return b.target, config.ChainConfig{Name: chainName, Confirmations: b.target.confirmations}, nil
+		}
+		return nil, config.ChainConfig{}, fmt.Errorf("chain %s not found", chainName)
+	}
+	b.engine = NewEngine(config.BridgeConfig{}, []*Route{route}, b.store, chains)
+	b.engine.SetRelayer(route.Name, b.relayer)
+	return b
+}
+
+// transfer 登记一笔转账，返回时源链交易已提交
+func (b *testBridge) transfer(t *testing.T) *types.CrossChainStatus {
+	t.Helper()
+	transfer, err := b.engine.Transfer(context.Background(), &types.CrossChainRequest{
+		Route:  b.route.Name,
+		From:   testSender,
+		To:     testRecipient,
+		Amount: testAmount,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return transfer
+}
+
+// at 构造处于指定状态的转账，已提交的交易哈希由调用方设置
+func (b *testBridge) at(state string) *types.CrossChainStatus {
+	now := time.Now()
+	return &types.CrossChainStatus{
+		TransferID:     hexutil.Encode(common.LeftPadBytes([]byte{0xab}, 32)),
+		Status:         state,
+		Route:          b.route.Name,
+		Adapter:        b.route.Adapter,
+		FromChain:      b.route.SourceChain,
+		ToChain:        b.route.TargetChain,
+		From:           common.HexToAddress(testSender).Hex(),
+		To:             common.HexToAddress(testRecipient).Hex(),
+		Amount:         new(big.Int).Set(testAmount),
+		Attestation:    "0x01",
+		SourceScanFrom: b.source.head,
+		TargetScanFrom: b.target.head,
+		StateAt:        now,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+}
+
+// step 推进一步并检查结果
+func (b *testBridge) step(t *testing.T, transfer *types.CrossChainStatus, wantChanged bool, wantState string) {
+	t.Helper()
+	changed, err := b.engine.advance(context.Background(), transfer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changed != wantChanged || transfer.Status != wantState {
+		t.Fatalf("advance() = %v, %s (%s), want %v, %s", changed, transfer.Status, transfer.Error, wantChanged, wantState)
+	}
+}
+
+func TestEngineCompletesTransfer(t *testing.T) {
+	b := newTestBridge(t, "")
+	transfer := b.transfer(t)
+
+	if transfer.Status != types.BridgeStateSourceSubmitted || transfer.SourceScanFrom != 100 {
+		t.Fatalf("transfer = %s, source scan from %d", transfer.Status, transfer.SourceScanFrom)
+	}
+	lock := b.source.lastSent(t)
+	if lock.From != transfer.From || lock.To != testSourceBridge || lock.Value.Cmp(testAmount) != 0 || lock.GasLimit != 60000 {
+		t.Errorf("source transaction = %+v", lock)
+	}
+	if stored, _ := b.store.Get(context.Background(), "0x"+strings.ToUpper(transfer.TransferID[2:])); stored == nil || stored.Status != types.BridgeStateSourceSubmitted {
+		t.Errorf("stored transfer = %+v", stored)
+	}
+
+	b.step(t, transfer, false, types.BridgeStateSourceSubmitted)
+	b.source.mine(transfer.SourceTx, true, lockedLog(t, transfer))
+	b.step(t, transfer, true, types.BridgeStateSourceConfirmed)
+	if transfer.SourceBlock != 101 || transfer.TargetScanFrom != 200 {
+		t.Errorf("source block %d, target scan from %d", transfer.SourceBlock, transfer.TargetScanFrom)
+	}
+
+	b.step(t, transfer, false, types.BridgeStateSourceConfirmed)
+	b.relayer.attestation = []byte{0xa7}
+	b.step(t, transfer, true, types.BridgeStateAttested)
+	if transfer.Attestation != "0xa7" {
+		t.Errorf("attestation = %s", transfer.Attestation)
+	}
+
+	b.step(t, transfer, true, types.BridgeStateTargetSubmitted)
+	mint := b.target.lastSent(t)
+	if mint.From != testExecutor || mint.To != testTargetBridge || transfer.TargetTx == "" {
+		t.Errorf("target transaction = %+v (%s)", mint, transfer.TargetTx)
+	}
+	b.step(t, transfer, false, types.BridgeStateTargetSubmitted)
+	b.target.mine(transfer.TargetTx, true, mintedLog(t, transfer))
+	b.step(t, transfer, true, types.BridgeStateCompleted)
+
+	var states []string
+	for _, step := range transfer.History {
+		states = append(states, step.State)
+	}
+	want := "created source_submitted source_confirmed attested target_submitted completed"
+	if strings.Join(states, " ") != want {
+		t.Errorf("history = %v, want %s", states, want)
+	}
+	if transfer.Error != "" {
+		t.Errorf("error = %q", transfer.Error)
+	}
+}
+
+func TestEngineCheckSource(t *testing.T) {
+	tests := []struct {
+		name          string
+		confirmations uint64
+		prepare       func(t *testing.T, b *testBridge, transfer *types.CrossChainStatus)
+		wantChanged   bool
+		wantState     string
+		wantError     string
+	}{
+		{
+			name:      "in mempool",
+			prepare:   func(t *testing.T, b *testBridge, transfer *types.CrossChainStatus) {},
+			wantState: types.BridgeStateSourceSubmitted,
+		},
+		{
+			name:          "not enough confirmations",
+			confirmations: 3,
+			prepare: func(t *testing.T, b *testBridge, transfer *types.CrossChainStatus) {
+				b.source.mine(transfer.SourceTx, true, lockedLog(t, transfer))
+				b.source.head++
+			},
+			wantState: types.BridgeStateSourceSubmitted,
+		},
+		{
+			name:          "enough confirmations",
+			confirmations: 3,
+			prepare: func(t *testing.T, b *testBridge, transfer *types.CrossChainStatus) {
+				b.source.mine(transfer.SourceTx, true, lockedLog(t, transfer))
+				b.source.head += 2
+			},
+			wantChanged: true,
+			wantState:   types.BridgeStateSourceConfirmed,
+		},
+		{
+			name: "dropped within submit timeout",
+			prepare: func(t *testing.T, b *testBridge, transfer *types.CrossChainStatus) {
+				delete(b.source.statuses, transfer.SourceTx)
+			},
+			wantState: types.BridgeStateSourceSubmitted,
+		},
+		{
+			name: "dropped after submit timeout",
+			prepare: func(t *testing.T, b *testBridge, transfer *types.CrossChainStatus) {
+				delete(b.source.statuses, transfer.SourceTx)
+				transfer.StateAt = time.Now().Add(-defaultSubmitTimeout - time.Minute)
+			},
+			wantChanged: true,
+			wantState:   types.BridgeStateCreated,
+		},
+		{
+			name: "reverted",
+			prepare: func(t *testing.T, b *testBridge, transfer *types.CrossChainStatus) {
+				b.source.mine(transfer.SourceTx, false)
+			},
+			wantChanged: true,
+			wantState:   types.BridgeStateFailed,
+			wantError:   "source transaction reverted",
+		},
+		{
+			name: "confirmed without bridge event",
+			prepare: func(t *testing.T, b *testBridge, transfer *types.CrossChainStatus) {
+				b.source.mine(transfer.SourceTx, true)
+			},
+			wantChanged: true,
+			wantState:   types.BridgeStateFailed,
+			wantError:   "source transaction did not emit a bridge event",
+		},
+		{
+			name: "event amount differs",
+			prepare: func(t *testing.T, b *testBridge, transfer *types.CrossChainStatus) {
+				b.source.mine(transfer.SourceTx, true, bridgeLog(t, "Locked", testSourceBridge, transfer.TransferID, common.HexToAddress(transfer.From),
+					common.Address{}, big.NewInt(1), big.NewInt(56), common.HexToAddress(transfer.To)))
+			},
+			wantChanged: true,
+			wantState:   types.BridgeStateRefunding,
+			wantError:   "source event does not match the transfer",
+		},
+		{
+			name: "event for another target chain",
+			prepare: func(t *testing.T, b *testBridge, transfer *types.CrossChainStatus) {
+				b.source.mine(transfer.SourceTx, true, bridgeLog(t, "Locked", testSourceBridge, transfer.TransferID, common.HexToAddress(transfer.From),
+					common.Address{}, transfer.Amount, big.NewInt(137), common.HexToAddress(transfer.To)))
+			},
+			wantChanged: true,
+			wantState:   types.BridgeStateRefunding,
+			wantError:   "source event does not match the transfer",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newTestBridge(t, "")
+			b.source.confirmations = tt.confirmations
+			transfer := b.transfer(t)
+			tt.prepare(t, b, transfer)
+
+			b.step(t, transfer, tt.wantChanged, tt.wantState)
+			if transfer.Error != tt.wantError {
+				t.Errorf("error = %q, want %q", transfer.Error, tt.wantError)
+			}
+		})
+	}
+}
+
+func TestEngineAdoptsEarlierSubmission(t *testing.T) {
+	b := newTestBridge(t, "")
+	transfer := b.transfer(t)
+	first := transfer.SourceTx
+
+	// 超时后重新提交，合约拒绝了重复的转账ID，事件由第一次提交的交易发出
+	delete(b.source.statuses, first)
+	transfer.StateAt = time.Now().Add(-defaultSubmitTimeout - time.Minute)
+	b.step(t, transfer, true, types.BridgeStateCreated)
+	b.step(t, transfer, true, types.BridgeStateSourceSubmitted)
+	second := transfer.SourceTx
+	if second == first || len(b.source.sent) != 2 {
+		t.Fatalf("resubmitted %s after %s, %d transactions sent", second, first, len(b.source.sent))
+	}
+
+	b.source.mine(first, true, lockedLog(t, transfer))
+	b.source.mine(second, false)
+	b.step(t, transfer, true, types.BridgeStateSourceSubmitted)
+	if transfer.SourceTx != first {
+		t.Fatalf("source tx = %s, want %s", transfer.SourceTx, first)
+	}
+	b.step(t, transfer, true, types.BridgeStateSourceConfirmed)
+}
+
+func TestEngineResumesSubmittedSteps(t *testing.T) {
+	// 重启前交易已上链但状态未保存，引擎按转账ID找回交易而不重复提交
+	tests := []struct {
+		state     string
+		onTarget  bool
+		log       func(t *testing.T, transfer *types.CrossChainStatus) ethtypes.Log
+		wantState string
+		txHash    func(transfer *types.CrossChainStatus) string
+	}{
+		{types.BridgeStateCreated, false, lockedLog, types.BridgeStateSourceSubmitted, func(s *types.CrossChainStatus) string { return s.SourceTx }},
+		{types.BridgeStateAttested, true, mintedLog, types.BridgeStateTargetSubmitted, func(s *types.CrossChainStatus) string { return s.TargetTx }},
+		{types.BridgeStateRefunding, false, refundedLog, types.BridgeStateRefundSubmitted, func(s *types.CrossChainStatus) string { return s.RefundTx }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.state, func(t *testing.T) {
+			b := newTestBridge(t, "")
+			transfer := b.at(tt.state)
+			side := b.source
+			if tt.onTarget {
+				side = b.target
+			}
+			txHash := fmt.Sprintf("0x%064x", 0xbeef)
+			side.mine(txHash, true, tt.log(t, transfer))
+
+			b.step(t, transfer, true, tt.wantState)
+			if got := tt.txHash(transfer); got != txHash {
+				t.Errorf("tx = %s, want %s", got, txHash)
+			}
+			if len(b.source.sent)+len(b.target.sent) != 0 {
+				t.Errorf("%d transactions sent", len(b.source.sent)+len(b.target.sent))
+			}
+		})
+	}
+}
+
+func TestEngineAttest(t *testing.T) {
+	unavailable := errors.New("relayer returned 500")
+	expired := time.Now().Add(-defaultAttestationTimeout - time.Minute)
+
+	tests := []struct {
+		name        string
+		relayer     fakeRelayer
+		stateAt     time.Time
+		wantChanged bool
+		wantErr     bool
+		wantState   string
+	}{
+		{"not ready", fakeRelayer{}, time.Now(), false, false, types.BridgeStateSourceConfirmed},
+		{"relayer error", fakeRelayer{err: unavailable}, time.Now(), false, true, types.BridgeStateSourceConfirmed},
+		{"ready", fakeRelayer{attestation: []byte{1, 2}}, time.Now(), true, false, types.BridgeStateAttested},
+		{"ready after timeout", fakeRelayer{attestation: []byte{1, 2}}, expired, true, false, types.BridgeStateAttested},
+		{"timed out", fakeRelayer{}, expired, true, false, types.BridgeStateRefunding},
+		{"relayer error after timeout", fakeRelayer{err: unavailable}, expired, true, false, types.BridgeStateRefunding},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newTestBridge(t, "")
+			*b.relayer = tt.relayer
+			transfer := b.at(types.BridgeStateSourceConfirmed)
+			transfer.StateAt = tt.stateAt
+
+			changed, err := b.engine.advance(context.Background(), transfer)
+			if (err != nil) != tt.wantErr || changed != tt.wantChanged || transfer.Status != tt.wantState {
+				t.Fatalf("advance() = %v, %v, state %s, want %v, error %v, state %s", changed, err, transfer.Status, tt.wantChanged, tt.wantErr, tt.wantState)
+			}
+			if tt.wantState == types.BridgeStateRefunding && transfer.Error != "attestation timed out" {
+				t.Errorf("error = %q", transfer.Error)
+			}
+		})
+	}
+}
+
+func TestEngineCheckTarget(t *testing.T) {
+	tests := []struct {
+		name      string
+		prepare   func(t *testing.T, b *testBridge, transfer *types.CrossChainStatus)
+		wantState string
+		wantError string
+	}{
+		{
+			name: "dropped after submit timeout",
+			prepare: func(t *testing.T, b *testBridge, transfer *types.CrossChainStatus) {
+				transfer.StateAt = time.Now().Add(-defaultSubmitTimeout - time.Minute)
+			},
+			wantState: types.BridgeStateAttested,
+		},
+		{
+			name: "reverted",
+			prepare: func(t *testing.T, b *testBridge, transfer *types.CrossChainStatus) {
+				b.target.mine(transfer.TargetTx, false)
+			},
+			wantState: types.BridgeStateRefunding,
+			wantError: "target transaction reverted",
+		},
+		{
+			name: "confirmed without bridge event",
+			prepare: func(t *testing.T, b *testBridge, transfer *types.CrossChainStatus) {
+				b.target.mine(transfer.TargetTx, true)
+			},
+			wantState: types.BridgeStateFailed,
+			wantError: "target transaction did not emit a bridge event",
+		},
+		{
+			name: "reverted after another submission minted",
+			prepare: func(t *testing.T, b *testBridge, transfer *types.CrossChainStatus) {
+				b.target.mine(fmt.Sprintf("0x%064x", 0xbeef), true, mintedLog(t, transfer))
+				b.target.mine(transfer.TargetTx, false)
+			},
+			wantState: types.BridgeStateTargetSubmitted,
+		},
+		{
+			name: "confirmed",
+			prepare: func(t *testing.T, b *testBridge, transfer *types.CrossChainStatus) {
+				b.target.mine(transfer.TargetTx, true, mintedLog(t, transfer))
+			},
+			wantState: types.BridgeStateCompleted,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newTestBridge(t, "")
+			transfer := b.at(types.BridgeStateTargetSubmitted)
+			transfer.TargetTx = fmt.Sprintf("0x%064x", 0xfeed)
+			tt.prepare(t, b, transfer)
+
+			b.step(t, transfer, true, tt.wantState)
+			if transfer.Error != tt.wantError {
+				t.Errorf("error = %q, want %q", transfer.Error, tt.wantError)
+			}
+		})
+	}
+}
+
+func TestEngineRefundsRevertedTarget(t *testing.T) {
+	b := newTestBridge(t, "")
+	transfer := b.at(types.BridgeStateAttested)
+
+	b.target.estimateErr = revertError{"invalid attestation"}
+	b.step(t, transfer, true, types.BridgeStateRefunding)
+	if transfer.Error != "target call reverted: invalid attestation" || len(b.target.sent) != 0 {
+		t.Fatalf("error = %q, %d target transactions sent", transfer.Error, len(b.target.sent))
+	}
+
+	b.step(t, transfer, true, types.BridgeStateRefundSubmitted)
+	refund := b.source.lastSent(t)
+	if refund.From != testExecutor || refund.To != testSourceBridge || transfer.RefundTx == "" {
+		t.Errorf("refund transaction = %+v (%s)", refund, transfer.RefundTx)
+	}
+
+	b.step(t, transfer, false, types.BridgeStateRefundSubmitted)
+	b.source.mine(transfer.RefundTx, true, refundedLog(t, transfer))
+	b.step(t, transfer, true, types.BridgeStateRefunded)
+	// 退款原因保留在 Error 中
+	if transfer.Error != "target call reverted: invalid attestation" {
+		t.Errorf("error = %q", transfer.Error)
+	}
+
+	t.Run("refund reverted", func(t *testing.T) {
+		b := newTestBridge(t, "")
+		transfer := b.at(types.BridgeStateRefundSubmitted)
+		transfer.RefundTx = fmt.Sprintf("0x%064x", 0xfeed)
+		b.source.mine(transfer.RefundTx, false)
+		b.step(t, transfer, true, types.BridgeStateFailed)
+	})
+}
+
+func TestEngineTransferSourceReverted(t *testing.T) {
+	b := newTestBridge(t, "")
+	b.source.estimateErr = revertError{"paused"}
+
+	_, err := b.engine.Transfer(context.Background(), &types.CrossChainRequest{
+		Route: b.route.Name, From: testSender, To: testRecipient, Amount: testAmount,
+	})
+	if err == nil || !strings.Contains(err.Error(), "source call reverted: paused") {
+		t.Fatalf("Transfer() error = %v", err)
+	}
+
+	active, _ := b.store.ListActive(context.Background())
+	if len(active) != 0 || len(b.source.sent) != 0 {
+		t.Errorf("%d active transfers, %d transactions sent", len(active), len(b.source.sent))
+	}
+}
+
+func TestEngineTransferBroadcastUncertain(t *testing.T) {
+	b := newTestBridge(t, "")
+	b.source.sendErr = fmt.Errorf("failed to send transaction: %w: %w", chain.ErrBroadcastUncertain, context.DeadlineExceeded)
+
+	// 交易可能已经进入交易池，转账不能记为失败
+	transfer := b.transfer(t)
+	if transfer.Status != types.BridgeStateCreated || transfer.SourceTx != "" || len(b.source.sent) != 1 {
+		t.Fatalf("transfer = %s (%s), source tx %q, %d transactions sent", transfer.Status, transfer.Error, transfer.SourceTx, len(b.source.sent))
+	}
+	if active, _ := b.store.ListActive(context.Background()); len(active) != 1 {
+		t.Errorf("%d active transfers", len(active))
+	}
+	if _, ok := b.engine.transfers[normalizeID(transfer.TransferID)]; !ok {
+		t.Error("transfer was not handed to the poller")
+	}
+
+	// 交易实际上链后，下一轮轮询从源链事件接续而不是重新提交
+	b.source.sendErr = nil
+	txHash := fmt.Sprintf("0x%064x", b.source.chainID<<32|1)
+	b.source.mine(txHash, true, lockedLog(t, transfer))
+	b.step(t, transfer, true, types.BridgeStateSourceSubmitted)
+	if transfer.SourceTx != txHash || len(b.source.sent) != 1 {
+		t.Errorf("source tx = %s, %d transactions sent", transfer.SourceTx, len(b.source.sent))
+	}
+}
+
+func TestEngineTransferSourceRejected(t *testing.T) {
+	b := newTestBridge(t, "")
+	b.source.sendErr = errors.New("failed to send transaction: insufficient funds for gas * price + value")
+
+	_, err := b.engine.Transfer(context.Background(), &types.CrossChainRequest{
+		Route: b.route.Name, From: testSender, To: testRecipient, Amount: testAmount,
+	})
+	if err == nil || !strings.Contains(err.Error(), "insufficient funds") {
+		t.Fatalf("Transfer() error = %v", err)
+	}
+	if active, _ := b.store.ListActive(context.Background()); len(active) != 0 || len(b.engine.transfers) != 0 {
+		t.Errorf("%d active transfers, %d polled", len(active), len(b.engine.transfers))
+	}
+}
+
+func TestEngineApprovesToken(t *testing.T) {
+	b := newTestBridge(t, testSourceToken)
+	transfer := b.transfer(t)
+
+	if transfer.Status != types.BridgeStateCreated || transfer.ApproveTx == "" {
+		t.Fatalf("transfer = %s, approve tx %q", transfer.Status, transfer.ApproveTx)
+	}
+	approve := b.source.lastSent(t)
+	if approve.To != b.route.SourceToken || approve.Value != nil {
+		t.Errorf("approve transaction = %+v", approve)
+	}
+
+	b.step(t, transfer, false, types.BridgeStateCreated)
+	b.source.mine(transfer.ApproveTx, true)
+	b.source.allowance = testAmount
+	b.step(t, transfer, true, types.BridgeStateSourceSubmitted)
+	lock := b.source.lastSent(t)
+	if lock.To != testSourceBridge || lock.Value.Sign() != 0 {
+		t.Errorf("source transaction = %+v", lock)
+	}
+
+	t.Run("allowance still insufficient", func(t *testing.T) {
+		b := newTestBridge(t, testSourceToken)
+		transfer := b.transfer(t)
+		b.source.mine(transfer.ApproveTx, true)
+		b.step(t, transfer, true, types.BridgeStateFailed)
+		if transfer.Error != "allowance is insufficient after approval" {
+			t.Errorf("error = %q", transfer.Error)
+		}
+	})
+}
@@ -0,0 +1,110 @@
+package bridge
+
+import (
+	"blockchain-middleware/pkg/types"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+)
+
+// PostgresStore 基于PostgreSQL的跨链转账存储，服务重启后继续推进在途转账
+type PostgresStore struct {
+	db *sql.DB
+}
+
+// NewPostgresStore 创建PostgreSQL存储并确保表结构存在
+func NewPostgresStore(ctx context.Context, db *sql.DB) (*PostgresStore, error) {
+	_, err := db.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS middleware_bridge_transfers (
+			transfer_id VARCHAR(128) PRIMARY KEY,
+			route VARCHAR(128) NOT NULL,
+			state VARCHAR(32) NOT NULL,
+			data JSONB NOT NULL,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+		)`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create bridge transfer table: %w", err)
+	}
+
+	_, err = db.ExecContext(ctx, `
+		CREATE INDEX IF NOT EXISTS idx_middleware_bridge_transfers_state
+		ON middleware_bridge_transfers (state)`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create bridge transfer index: %w", err)
+	}
+
+	return &PostgresStore{db: db}, nil
+}
+
+// Save 保存跨链转账
+func (s *PostgresStore) Save(ctx context.Context, transfer *types.CrossChainStatus) error {
+	raw, err := json.Marshal(transfer)
+	if err != nil {
+		return fmt.Errorf("failed to encode bridge transfer: %w", err)
+	}
+
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO middleware_bridge_transfers (transfer_id, route, state, data, updated_at)
+		VALUES ($1, $2, $3, $4, CURRENT_TIMESTAMP)
+		ON CONFLICT (transfer_id)
+		DO UPDATE SET state = EXCLUDED.state, data = EXCLUDED.data, updated_at = EXCLUDED.updated_at`,
+		normalizeID(transfer.TransferID), transfer.Route, transfer.Status, string(raw))
+	if err != nil {
+		return fmt.Errorf("failed to save bridge transfer: %w", err)
+	}
+	return nil
+}
+
+// Get 获取跨链转账
+func (s *PostgresStore) Get(ctx context.Context, transferID string) (*types.CrossChainStatus, error) {
+	var raw []byte
+	err := s.db.QueryRowContext(ctx,
+		`SELECT data FROM middleware_bridge_transfers WHERE transfer_id = $1`,
+		normalizeID(transferID)).Scan(&raw)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load bridge transfer: %w", err)
+	}
+	return decodeTransfer(raw)
+}
+
+// ListActive 列出尚未进入最终状态的跨链转账
+func (s *PostgresStore) ListActive(ctx context.Context) ([]*types.CrossChainStatus, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT data FROM middleware_bridge_transfers WHERE state NOT IN ($1, $2, $3)`,
+		types.BridgeStateCompleted, types.BridgeStateRefunded, types.BridgeStateFailed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list bridge transfers: %w", err)
+	}
+	defer rows.Close()
+
+	var active []*types.CrossChainStatus
+	for rows.Next() {
+		var raw []byte
+		if err := rows.Scan(&raw); err != nil {
+			return nil, fmt.Errorf("failed to scan bridge transfer: %w", err)
+		}
+		transfer, err := decodeTransfer(raw)
+		if err != nil {
+			return nil, err
+		}
+		active = append(active, transfer)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list bridge transfers: %w", err)
+	}
+	return active, nil
+}
+
+// decodeTransfer 解码跨链转账
+func decodeTransfer(raw []byte) (*types.CrossChainStatus, error) {
+	var transfer types.CrossChainStatus
+	if err := json.Unmarshal(raw, &transfer); err != nil {
+		return nil, fmt.Errorf("failed to decode bridge transfer: %w", err)
+	}
+	return &transfer, nil
+}
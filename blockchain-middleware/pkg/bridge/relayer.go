@@ -0,0 +1,79 @@
+package bridge
+
+import (
+	"blockchain-middleware/pkg/types"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// relayerTimeout 单次查询中继器的超时
+const relayerTimeout = 10 * time.Second
+
+// Relayer 跨链证明来源
+type Relayer interface {
+	// Attestation 获取源链转出交易的证明，尚未就绪时返回nil
+	Attestation(ctx context.Context, route *Route, transfer *types.CrossChainStatus) ([]byte, error)
+}
+
+// HTTPRelayer 通过HTTP查询证明的中继器
+// GET {relayer_url}/attestations/{transfer_id}?route=&source_tx= 就绪时返回200 {"attestation":"0x..."}，
+// 尚未就绪时返回404或202。
+type HTTPRelayer struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewHTTPRelayer 创建HTTP中继器客户端
+func NewHTTPRelayer(baseURL string) *HTTPRelayer {
+	return &HTTPRelayer{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: relayerTimeout},
+	}
+}
+
+// Attestation 查询证明
+func (r *HTTPRelayer) Attestation(ctx context.Context, route *Route, transfer *types.CrossChainStatus) ([]byte, error) {
+	query := url.Values{}
+	query.Set("route", route.Name)
+	query.Set("source_tx", transfer.SourceTx)
+	endpoint := fmt.Sprintf("%s/attestations/%s?%s", r.baseURL, transfer.TransferID, query.Encode())
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create relayer request: %w", err)
+	}
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query relayer: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound, http.StatusAccepted:
+		return nil, nil
+	default:
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("relayer returned %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var result struct {
+		Attestation string `json:"attestation"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode relayer response: %w", err)
+	}
+	attestation, err := hexutil.Decode(result.Attestation)
+	if err != nil || len(attestation) == 0 {
+		return nil, fmt.Errorf("invalid attestation from relayer: %q", result.Attestation)
+	}
+	return attestation, nil
+}
@@ -0,0 +1,80 @@
+package bridge
+
+import (
+	"blockchain-middleware/pkg/types"
+	"context"
+	"math/big"
+	"strings"
+	"sync"
+)
+
+// Store 跨链转账持久化接口，引擎每次状态变更后保存，重启后据此恢复
+type Store interface {
+	// Save 保存跨链转账
+	Save(ctx context.Context, transfer *types.CrossChainStatus) error
+	// Get 获取跨链转账，不存在时返回nil
+	Get(ctx context.Context, transferID string) (*types.CrossChainStatus, error)
+	// ListActive 列出尚未进入最终状态的跨链转账
+	ListActive(ctx context.Context) ([]*types.CrossChainStatus, error)
+}
+
+// MemoryStore 内存存储，仅用于未启用数据库的开发环境
+type MemoryStore struct {
+	mu        sync.Mutex
+	transfers map[string]*types.CrossChainStatus
+}
+
+// NewMemoryStore 创建内存存储
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{transfers: make(map[string]*types.CrossChainStatus)}
+}
+
+// Save 保存跨链转账
+func (s *MemoryStore) Save(ctx context.Context, transfer *types.CrossChainStatus) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.transfers[normalizeID(transfer.TransferID)] = clone(transfer)
+	return nil
+}
+
+// Get 获取跨链转账
+func (s *MemoryStore) Get(ctx context.Context, transferID string) (*types.CrossChainStatus, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	transfer, ok := s.transfers[normalizeID(transferID)]
+	if !ok {
+		return nil, nil
+	}
+	return clone(transfer), nil
+}
+
+// ListActive 列出尚未进入最终状态的跨链转账
+func (s *MemoryStore) ListActive(ctx context.Context) ([]*types.CrossChainStatus, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var active []*types.CrossChainStatus
+	for _, transfer := range s.transfers {
+		if !transfer.IsFinal() {
+			active = append(active, clone(transfer))
+		}
+	}
+	return active, nil
+}
+
+// clone 深拷贝跨链转账
+func clone(transfer *types.CrossChainStatus) *types.CrossChainStatus {
+	c := *transfer
+	if transfer.Amount != nil {
+		c.Amount = new(big.Int).Set(transfer.Amount)
+	}
+	c.History = append([]types.BridgeStep(nil), transfer.History...)
+	return &c
+}
+
+// normalizeID 转账ID统一小写
+func normalizeID(transferID string) string {
+	return strings.ToLower(transferID)
+}
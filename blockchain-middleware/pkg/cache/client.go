@@ -0,0 +1,386 @@
+package cache
+
+import (
+	"blockchain-middleware/internal/config"
+	"blockchain-middleware/pkg/chain"
+	"blockchain-middleware/pkg/types"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+)
+
+// Client 链客户端缓存层
+// 已达到确认数的区块和交易（含收据）、代币符号和精度不过期；余额、nonce、尚未最终确认的区块和交易按最新区块号分键，
+// 新区块到达后即失效。其余能力接口通过 chain.As 交给被包装的客户端。
+type Client struct {
+	chain.ChainClient
+
+	name          string
+	confirmations uint64
+	headInterval  time.Duration
+	headTTL       time.Duration
+	store         Store
+	stats         *Stats
+
+	headMu sync.Mutex
+	head   uint64
+	headAt time.Time
+}
+
+// tokenClient 为支持ERC-20的客户端额外缓存代币余额
+type tokenClient struct {
+	*Client
+	tokens chain.TokenBalanceProvider
+}
+
+// NewClient 为链客户端加上缓存层，headTTL 限制与最新区块相关的条目在出块慢的链上的保留时间
+func NewClient(inner chain.ChainClient, cfg config.ChainConfig, store Store, stats *Stats, headTTL time.Duration) chain.ChainClient {
+	c := &Client{
+		ChainClient:   inner,
+		name:          cfg.Name,
+		confirmations: cfg.Confirmations,
+		headInterval:  cfg.CacheHeadInterval,
+		headTTL:       headTTL,
+		store:         store,
+		stats:         stats,
+	}
+	if c.confirmations == 0 {
+		c.confirmations = 1
+	}
+
+	if tokens, ok := inner.(chain.TokenBalanceProvider); ok {
+		return &tokenClient{Client: c, tokens: tokens}
+	}
+	return c
+}
+
+// Unwrap 返回被包装的客户端
+func (c *Client) Unwrap() chain.ChainClient {
+	return c.ChainClient
+}
+
+// GetBlockNumber 获取最新区块号，距上次查询不足 CacheHeadInterval 时直接返回
+func (c *Client) GetBlockNumber(ctx context.Context) (uint64, error) {
+	head, hit, err := c.currentHead(ctx)
+	if err != nil {
+		return 0, err
+	}
+	c.stats.hit(c.name, KindBlockNumber, hit)
+	return head, nil
+}
+
+// currentHead 获取最新区块号，节点池中各节点高度不同时只前进不后退
+func (c *Client) currentHead(ctx context.Context) (uint64, bool, error) {
+	c.headMu.Lock()
+	defer c.headMu.Unlock()
+
+	if c.head > 0 && time.Since(c.headAt) < c.headInterval {
+		return c.head, true, nil
+	}
+
+	head, err := c.ChainClient.GetBlockNumber(ctx)
+	if err != nil {
+		return 0, false, err
+	}
+	if head > c.head {
+		c.head = head
+	}
+	c.headAt = time.Now()
+	return c.head, false, nil
+}
+
+// GetBalance 获取余额，按最新区块缓存
+func (c *Client) GetBalance(ctx context.Context, address string) (*big.Int, error) {
+	head, _, err := c.currentHead(ctx)
+	if err != nil {
+		return c.ChainClient.GetBalance(ctx, address)
+	}
+
+	key := c.headKey(KindBalance, normalizeAddress(address), head)
+	var balance *big.Int
+	if c.get(ctx, KindBalance, key, &balance) && balance != nil {
+		c.stats.hit(c.name, KindBalance, true)
+		return balance, nil
+	}
+	c.stats.hit(c.name, KindBalance, false)
+
+	balance, err = c.ChainClient.GetBalance(ctx, address)
+	if err != nil {
+		return nil, err
+	}
+	c.set(ctx, KindBalance, key, balance, c.headTTL)
+	return balance, nil
+}
+
+// GetNonce 获取nonce，按最新区块缓存，经本客户端发送交易后失效
+func (c *Client) GetNonce(ctx context.Context, address string) (uint64, error) {
+	head, _, err := c.currentHead(ctx)
+	if err != nil {
+		return c.ChainClient.GetNonce(ctx, address)
+	}
+
+	key := c.headKey(KindNonce, normalizeAddress(address), head)
+	var nonce uint64
+	if c.get(ctx, KindNonce, key, &nonce) {
+		c.stats.hit(c.name, KindNonce, true)
+		return nonce, nil
+	}
+	c.stats.hit(c.name, KindNonce, false)
+
+	nonce, err = c.ChainClient.GetNonce(ctx, address)
+	if err != nil {
+		return 0, err
+	}
+	c.set(ctx, KindNonce, key, nonce, c.headTTL)
+	return nonce, nil
+}
+
+// GetBlockByNumber 获取区块，达到确认数的区块不过期，其余按最新区块缓存
+func (c *Client) GetBlockByNumber(ctx context.Context, blockNumber uint64) (*types.Block, error) {
+	id := fmt.Sprintf("%d", blockNumber)
+	finalKey := c.key(KindBlock, id)
+
+	var block types.Block
+	if c.get(ctx, KindBlock, finalKey, &block) {
+		c.stats.hit(c.name, KindBlock, true)
+		return &block, nil
+	}
+
+	head, _, err := c.currentHead(ctx)
+	if err != nil {
+		c.stats.hit(c.name, KindBlock, false)
+		return c.ChainClient.GetBlockByNumber(ctx, blockNumber)
+	}
+
+	final := c.isFinal(blockNumber, head)
+	key := finalKey
+	if !final {
+		key = c.headKey(KindBlock, id, head)
+		if c.get(ctx, KindBlock, key, &block) {
+			c.stats.hit(c.name, KindBlock, true)
+			return &block, nil
+		}
+	}
+	c.stats.hit(c.name, KindBlock, false)
+
+	result, err := c.ChainClient.GetBlockByNumber(ctx, blockNumber)
+	if err != nil {
+		return nil, err
+	}
+	if final {
+		c.set(ctx, KindBlock, key, result, 0)
+	} else {
+		c.set(ctx, KindBlock, key, result, c.headTTL)
+	}
+	return result, nil
+}
+
+// GetTransaction 获取交易及收据，所在区块达到确认数后不过期，其余按最新区块缓存
+func (c *Client) GetTransaction(ctx context.Context, txHash string) (*types.Transaction, error) {
+	id := strings.ToLower(txHash)
+	finalKey := c.key(KindTransaction, id)
+
+	var tx types.Transaction
+	if c.get(ctx, KindTransaction, finalKey, &tx) {
+		c.stats.hit(c.name, KindTransaction, true)
+		return &tx, nil
+	}
+
+	head, _, err := c.currentHead(ctx)
+	if err != nil {
+		c.stats.hit(c.name, KindTransaction, false)
+		return c.ChainClient.GetTransaction(ctx, txHash)
+	}
+
+	headKey := c.headKey(KindTransaction, id, head)
+	if c.get(ctx, KindTransaction, headKey, &tx) {
+		c.stats.hit(c.name, KindTransaction, true)
+		return &tx, nil
+	}
+	c.stats.hit(c.name, KindTransaction, false)
+
+	result, err := c.ChainClient.GetTransaction(ctx, txHash)
+	if err != nil {
+		return nil, err
+	}
+	if !result.Pending && result.BlockNumber > 0 && c.isFinal(result.BlockNumber, head) {
+		c.set(ctx, KindTransaction, finalKey, result, 0)
+	} else {
+		c.set(ctx, KindTransaction, headKey, result, c.headTTL)
+	}
+	return result, nil
+}
+
+// SendTransaction 发送交易，成功后发送方的nonce和余额缓存失效
+func (c *Client) SendTransaction(ctx context.Context, req *types.TransactionRequest) (string, error) {
+	txHash, err := c.ChainClient.SendTransaction(ctx, req)
+	if err != nil {
+		return "", err
+	}
+	c.invalidateAccount(ctx, req.From)
+	return txHash, nil
+}
+
+// SendRawTransaction 广播已签名交易，能解码出EVM交易发送方时使其nonce和余额缓存失效
+func (c *Client) SendRawTransaction(ctx context.Context, rawTx []byte) (string, error) {
+	txHash, err := c.ChainClient.SendRawTransaction(ctx, rawTx)
+	if err != nil {
+		return "", err
+	}
+
+	tx := new(ethtypes.Transaction)
+	if tx.UnmarshalBinary(rawTx) == nil {
+		if sender, err := ethtypes.Sender(ethtypes.LatestSignerForChainID(tx.ChainId()), tx); err == nil {
+			c.invalidateAccount(ctx, sender.Hex())
+		}
+	}
+	return txHash, nil
+}
+
+// invalidateAccount 删除地址在当前最新区块下的nonce和余额缓存
+func (c *Client) invalidateAccount(ctx context.Context, address string) {
+	c.headMu.Lock()
+	head := c.head
+	c.headMu.Unlock()
+	if head == 0 {
+		return
+	}
+
+	id := normalizeAddress(address)
+	if err := c.store.Delete(ctx, c.headKey(KindNonce, id, head), c.headKey(KindBalance, id, head)); err != nil {
+		c.stats.fail(c.name, KindNonce)
+	}
+}
+
+// GetTokenBalance 获取ERC-20代币余额及符号、精度
+func (c *tokenClient) GetTokenBalance(ctx context.Context, tokenAddress, holderAddress string) (*types.TokenBalance, error) {
+	balances, err := c.GetTokenBalances(ctx, holderAddress, []string{tokenAddress})
+	if err != nil {
+		return nil, err
+	}
+	if balances[0].Error != "" {
+		return nil, fmt.Errorf("failed to query token %s: %s", tokenAddress, balances[0].Error)
+	}
+	return &balances[0], nil
+}
+
+// tokenMetadata 缓存的代币符号和精度
+type tokenMetadata struct {
+	Symbol   string `json:"symbol"`
+	Decimals uint8  `json:"decimals"`
+}
+
+// GetTokenBalances 批量获取代币余额，代币符号和精度不过期，余额按最新区块缓存，只查询未命中的代币
+func (c *tokenClient) GetTokenBalances(ctx context.Context, holderAddress string, tokenAddresses []string) ([]types.TokenBalance, error) {
+	head, _, err := c.currentHead(ctx)
+	if err != nil {
+		return c.tokens.GetTokenBalances(ctx, holderAddress, tokenAddresses)
+	}
+
+	holder := normalizeAddress(holderAddress)
+	balances := make([]types.TokenBalance, len(tokenAddresses))
+	var missing []string
+	var missingIndex []int
+	for i, token := range tokenAddresses {
+		if !common.IsHexAddress(token) {
+			missing = append(missing, token)
+			missingIndex = append(missingIndex, i)
+			continue
+		}
+
+		id := normalizeAddress(token)
+		var meta tokenMetadata
+		var balance *big.Int
+		if c.get(ctx, KindTokenBalance, c.key("token", id), &meta) &&
+			c.get(ctx, KindTokenBalance, c.headKey(KindTokenBalance, holder+":"+id, head), &balance) && balance != nil {
+			c.stats.hit(c.name, KindTokenBalance, true)
+			balances[i] = types.TokenBalance{
+				ContractAddress: common.HexToAddress(token).Hex(),
+				Symbol:          meta.Symbol,
+				Decimals:        meta.Decimals,
+				Balance:         balance,
+			}
+			continue
+		}
+		c.stats.hit(c.name, KindTokenBalance, false)
+		missing = append(missing, token)
+		missingIndex = append(missingIndex, i)
+	}
+	if len(missing) == 0 {
+		return balances, nil
+	}
+
+	fetched, err := c.tokens.GetTokenBalances(ctx, holderAddress, missing)
+	if err != nil {
+		return nil, err
+	}
+	for j, balance := range fetched {
+		balances[missingIndex[j]] = balance
+		if balance.Error != "" || balance.Balance == nil {
+			continue
+		}
+		id := normalizeAddress(balance.ContractAddress)
+		c.set(ctx, KindTokenBalance, c.key("token", id), tokenMetadata{Symbol: balance.Symbol, Decimals: balance.Decimals}, 0)
+		c.set(ctx, KindTokenBalance, c.headKey(KindTokenBalance, holder+":"+id, head), balance.Balance, c.headTTL)
+	}
+	return balances, nil
+}
+
+// isFinal 区块是否已达到链配置的确认数
+func (c *Client) isFinal(blockNumber, head uint64) bool {
+	return blockNumber+c.confirmations <= head
+}
+
+// key 不过期条目的缓存键
+func (c *Client) key(kind, id string) string {
+	return c.name + ":" + kind + ":" + id
+}
+
+// headKey 与最新区块相关的缓存键，新区块到达后不再命中
+func (c *Client) headKey(kind, id string, head uint64) string {
+	return fmt.Sprintf("%s:%s:%s@%d", c.name, kind, id, head)
+}
+
+// get 读取并解码缓存，读取失败按未命中处理
+func (c *Client) get(ctx context.Context, kind, key string, out interface{}) bool {
+	raw, ok, err := c.store.Get(ctx, key)
+	if err != nil {
+		c.stats.fail(c.name, kind)
+		return false
+	}
+	if !ok {
+		return false
+	}
+	if err := json.Unmarshal(raw, out); err != nil {
+		c.stats.fail(c.name, kind)
+		return false
+	}
+	return true
+}
+
+// set 编码并写入缓存，写入失败只计数
+func (c *Client) set(ctx context.Context, kind, key string, value interface{}, ttl time.Duration) {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		c.stats.fail(c.name, kind)
+		return
+	}
+	if err := c.store.Set(ctx, key, raw, ttl); err != nil {
+		c.stats.fail(c.name, kind)
+	}
+}
+
+// normalizeAddress EVM地址统一小写，其他地址（如比特币base58）区分大小写，保持原样
+func normalizeAddress(address string) string {
+	if common.IsHexAddress(address) {
+		return strings.ToLower(common.HexToAddress(address).Hex())
+	}
+	return address
+}
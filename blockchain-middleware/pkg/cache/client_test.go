@@ -0,0 +1,385 @@
+package cache
+
+import (
+	"blockchain-middleware/internal/config"
+	"blockchain-middleware/pkg/chain"
+	"blockchain-middleware/pkg/types"
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+const (
+	testAccount = "0x71C7656EC7ab88b098defB751B7401B5f6d8976F"
+	testToken   = "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"
+	testBadCode = "0x0000000000000000000000000000000000000bad"
+)
+
+// fakeClient 统计各方法调用次数的链客户端
+type fakeClient struct {
+	chain.ChainClient // 缓存层用不到的方法
+
+	head   uint64
+	calls  map[string]int
+	txs    map[string]*types.Transaction
+	tokens [][]string // 每次 GetTokenBalances 查询的代币
+}
+
+func newFakeClient(head uint64) *fakeClient {
+	return &fakeClient{head: head, calls: make(map[string]int), txs: make(map[string]*types.Transaction)}
+}
+
+func (c *fakeClient) GetBlockNumber(ctx context.Context) (uint64, error) {
+	c.calls["GetBlockNumber"]++
+	return c.head, nil
+}
+
+func (c *fakeClient) GetBalance(ctx context.Context, address string) (*big.Int, error) {
+	c.calls["GetBalance"]++
+	return big.NewInt(int64(c.head)), nil
+}
+
+func (c *fakeClient) GetNonce(ctx context.Context, address string) (uint64, error) {
+	c.calls["GetNonce"]++
+	return c.head, nil
+}
+
+func (c *fakeClient) GetBlockByNumber(ctx context.Context, blockNumber uint64) (*types.Block, error) {
+	c.calls["GetBlockByNumber"]++
+	return &types.Block{Number: blockNumber, Hash: fmt.Sprintf("0x%064x", blockNumber)}, nil
+}
+
+func (c *fakeClient) GetTransaction(ctx context.Context, txHash string) (*types.Transaction, error) {
+	c.calls["GetTransaction"]++
+	tx, ok := c.txs[txHash]
+	if !ok {
+		return nil, fmt.Errorf("transaction %s not found", txHash)
+	}
+	return tx, nil
+}
+
+func (c *fakeClient) SendTransaction(ctx context.Context, req *types.TransactionRequest) (string, error) {
+	c.calls["SendTransaction"]++
+	return "0x01", nil
+}
+
+func (c *fakeClient) SendRawTransaction(ctx context.Context, rawTx []byte) (string, error) {
+	c.calls["SendRawTransaction"]++
+	return "0x02", nil
+}
+
+func (c *fakeClient) GetTokenBalance(ctx context.Context, tokenAddress, holderAddress string) (*types.TokenBalance, error) {
+	return nil, errors.New("not used")
+}
+
+func (c *fakeClient) GetTokenBalances(ctx context.Context, holderAddress string, tokenAddresses []string) ([]types.TokenBalance, error) {
+	c.tokens = append(c.tokens, tokenAddresses)
+	balances := make([]types.TokenBalance, len(tokenAddresses))
+	for i, token := range tokenAddresses {
+		balances[i].ContractAddress = token
+		if strings.EqualFold(token, testBadCode) {
+			balances[i].Error = "execution reverted"
+			continue
+		}
+		balances[i].Symbol = "USDC"
+		balances[i].Decimals = 6
+		balances[i].Balance = big.NewInt(int64(c.head))
+	}
+	return balances, nil
+}
+
+// newTestClient 每次都向节点查询最新区块号的缓存层
+func newTestClient(inner *fakeClient, confirmations uint64) (*Client, *Stats) {
+	stats := NewStats()
+	client := NewClient(inner, config.ChainConfig{Name: "test", Confirmations: confirmations}, NewMemoryStore(0), stats, time.Minute)
+	if tokens, ok := client.(*tokenClient); ok {
+		return tokens.Client, stats
+	}
+	return client.(*Client), stats
+}
+
+// statsFor 单个读取类型的命中统计
+func statsFor(stats *Stats, kind string) types.CacheStats {
+	for _, s := range stats.Snapshot() {
+		if s.Kind == kind {
+			return s
+		}
+	}
+	return types.CacheStats{}
+}
+
+func TestClientAccountReadsFollowHead(t *testing.T) {
+	tests := []struct {
+		kind   string
+		method string
+		read   func(c *Client, address string) (string, error)
+	}{
+		{KindBalance, "GetBalance", func(c *Client, address string) (string, error) {
+			balance, err := c.GetBalance(context.Background(), address)
+			return fmt.Sprint(balance), err
+		}},
+		{KindNonce, "GetNonce", func(c *Client, address string) (string, error) {
+			nonce, err := c.GetNonce(context.Background(), address)
+			return fmt.Sprint(nonce), err
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.kind, func(t *testing.T) {
+			inner := newFakeClient(100)
+			c, stats := newTestClient(inner, 1)
+
+			read := func(address, want string, wantCalls int) {
+				t.Helper()
+				got, err := tt.read(c, address)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got != want || inner.calls[tt.method] != wantCalls {
+					t.Fatalf("%s(%s) = %s after %d node calls, want %s after %d", tt.method, address, got, inner.calls[tt.method], want, wantCalls)
+				}
+			}
+
+			read(testAccount, "100", 1)
+			// 地址大小写不同也命中同一条目
+			read(strings.ToLower(testAccount), "100", 1)
+			inner.head = 101
+			read(testAccount, "101", 2)
+			read(testAccount, "101", 2)
+
+			if s := statsFor(stats, tt.kind); s.Hits != 2 || s.Misses != 2 || s.HitRate != 0.5 {
+				t.Errorf("stats = %+v", s)
+			}
+		})
+	}
+}
+
+func TestClientSendInvalidatesSender(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sender := crypto.PubkeyToAddress(key.PublicKey).Hex()
+	signer := ethtypes.LatestSignerForChainID(big.NewInt(1337))
+	rawTx, err := ethtypes.MustSignNewTx(key, signer, &ethtypes.DynamicFeeTx{ChainID: big.NewInt(1337), Gas: 21000}).MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		address string
+		send    func(c *Client) error
+	}{
+		{"send transaction", testAccount, func(c *Client) error {
+			_, err := c.SendTransaction(context.Background(), &types.TransactionRequest{From: strings.ToLower(testAccount)})
+			return err
+		}},
+		{"send raw transaction", sender, func(c *Client) error {
+			_, err := c.SendRawTransaction(context.Background(), rawTx)
+			return err
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inner := newFakeClient(100)
+			c, _ := newTestClient(inner, 1)
+			ctx := context.Background()
+
+			for i := 0; i < 2; i++ {
+				if _, err := c.GetNonce(ctx, tt.address); err != nil {
+					t.Fatal(err)
+				}
+				if _, err := c.GetBalance(ctx, tt.address); err != nil {
+					t.Fatal(err)
+				}
+				if _, err := c.GetNonce(ctx, testBadCode); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := tt.send(c); err != nil {
+				t.Fatal(err)
+			}
+			c.GetNonce(ctx, tt.address)
+			c.GetBalance(ctx, tt.address)
+			c.GetNonce(ctx, testBadCode)
+
+			// 同一区块内只有发送方的条目失效
+			if inner.calls["GetNonce"] != 3 || inner.calls["GetBalance"] != 2 {
+				t.Errorf("node calls = %v", inner.calls)
+			}
+		})
+	}
+}
+
+func TestClientBlocksBecomeImmutableAfterConfirmations(t *testing.T) {
+	inner := newFakeClient(100)
+	c, stats := newTestClient(inner, 3)
+	ctx := context.Background()
+
+	steps := []struct {
+		head      uint64
+		block     uint64
+		wantCalls int
+	}{
+		{100, 98, 1},  // 未最终确认，按最新区块缓存
+		{100, 98, 1},  // 同一区块内命中
+		{101, 98, 2},  // 新区块到达后重新查询，已达到确认数
+		{150, 98, 2},  // 不再过期
+		{150, 147, 3}, // 首次查询时已达到确认数
+		{151, 147, 3},
+		{151, 150, 4}, // 最新区块
+		{152, 150, 5},
+	}
+	for i, step := range steps {
+		inner.head = step.head
+		block, err := c.GetBlockByNumber(ctx, step.block)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if block.Number != step.block || inner.calls["GetBlockByNumber"] != step.wantCalls {
+			t.Fatalf("step %d: block %d after %d node calls, want %d after %d", i, block.Number, inner.calls["GetBlockByNumber"], step.block, step.wantCalls)
+		}
+	}
+	if s := statsFor(stats, KindBlock); s.Hits != 3 || s.Misses != 5 {
+		t.Errorf("stats = %+v", s)
+	}
+}
+
+func TestClientTransactions(t *testing.T) {
+	tests := []struct {
+		name        string
+		tx          types.Transaction
+		nextHead    uint64
+		wantRefetch bool
+	}{
+		{"final", types.Transaction{BlockNumber: 90}, 110, false},
+		{"pending", types.Transaction{Pending: true}, 101, true},
+		{"not enough confirmations", types.Transaction{BlockNumber: 99}, 101, true},
+		{"pending within the same head", types.Transaction{Pending: true}, 100, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inner := newFakeClient(100)
+			c, _ := newTestClient(inner, 3)
+			txHash := "0xABCDEF"
+			tt.tx.Hash = txHash
+			inner.txs[txHash] = &tt.tx
+
+			if _, err := c.GetTransaction(context.Background(), txHash); err != nil {
+				t.Fatal(err)
+			}
+			inner.head = tt.nextHead
+			got, err := c.GetTransaction(context.Background(), txHash)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Hash != txHash {
+				t.Errorf("hash = %s", got.Hash)
+			}
+			if refetched := inner.calls["GetTransaction"] == 2; refetched != tt.wantRefetch {
+				t.Errorf("refetched = %v, want %v", refetched, tt.wantRefetch)
+			}
+		})
+	}
+}
+
+func TestClientHead(t *testing.T) {
+	inner := newFakeClient(100)
+	c, _ := newTestClient(inner, 1)
+	ctx := context.Background()
+
+	// 节点池中落后的节点不会让最新区块号回退
+	c.GetBlockNumber(ctx)
+	inner.head = 99
+	if head, _ := c.GetBlockNumber(ctx); head != 100 {
+		t.Errorf("head = %d after a lagging node, want 100", head)
+	}
+
+	c.headInterval = time.Hour
+	inner.head = 101
+	if head, _ := c.GetBlockNumber(ctx); head != 100 || inner.calls["GetBlockNumber"] != 2 {
+		t.Errorf("head = %d after %d node calls within head interval", head, inner.calls["GetBlockNumber"])
+	}
+}
+
+func TestTokenClientCachesPerToken(t *testing.T) {
+	inner := newFakeClient(100)
+	client := NewClient(inner, config.ChainConfig{Name: "test", Confirmations: 1}, NewMemoryStore(0), NewStats(), time.Minute)
+	tokens, ok := chain.As[chain.TokenBalanceProvider](client)
+	if !ok || tokens == chain.TokenBalanceProvider(inner) {
+		t.Fatal("cache layer does not provide token balances")
+	}
+	ctx := context.Background()
+	query := []string{testToken, testBadCode}
+
+	query1, err := tokens.GetTokenBalances(ctx, testAccount, query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	query2, _ := tokens.GetTokenBalances(ctx, strings.ToLower(testAccount), query)
+	inner.head = 101
+	query3, _ := tokens.GetTokenBalances(ctx, testAccount, query)
+
+	// 查询失败的代币不缓存，新区块到达后余额重新查询
+	want := []string{testToken + "," + testBadCode, testBadCode, testToken + "," + testBadCode}
+	if len(inner.tokens) != len(want) {
+		t.Fatalf("node queries = %v", inner.tokens)
+	}
+	for i := range want {
+		if got := strings.Join(inner.tokens[i], ","); got != want[i] {
+			t.Errorf("query %d = %s, want %s", i+1, got, want[i])
+		}
+	}
+
+	for i, balances := range [][]types.TokenBalance{query1, query2, query3} {
+		if balances[0].Symbol != "USDC" || balances[0].Decimals != 6 || balances[1].Error == "" {
+			t.Errorf("query %d = %+v", i+1, balances)
+		}
+	}
+	if query2[0].Balance.Int64() != 100 || query3[0].Balance.Int64() != 101 {
+		t.Errorf("balances = %s, %s", query2[0].Balance, query3[0].Balance)
+	}
+}
+
+// failingStore 读写都失败的缓存存储
+type failingStore struct{}
+
+func (failingStore) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	return nil, false, errors.New("connection refused")
+}
+
+func (failingStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return errors.New("connection refused")
+}
+
+func (failingStore) Delete(ctx context.Context, keys ...string) error {
+	return errors.New("connection refused")
+}
+
+func (failingStore) Backend() string { return "failing" }
+
+func TestClientStoreFailures(t *testing.T) {
+	inner := newFakeClient(100)
+	stats := NewStats()
+	c := NewClient(inner, config.ChainConfig{Name: "test"}, failingStore{}, stats, time.Minute)
+
+	for i := 0; i < 2; i++ {
+		balance, err := c.GetBalance(context.Background(), testAccount)
+		if err != nil || balance.Int64() != 100 {
+			t.Fatalf("GetBalance() = %v, %v", balance, err)
+		}
+	}
+	if inner.calls["GetBalance"] != 2 {
+		t.Errorf("node calls = %d", inner.calls["GetBalance"])
+	}
+	if s := statsFor(stats, KindBalance); s.Misses != 2 || s.Errors != 4 {
+		t.Errorf("stats = %+v", s)
+	}
+}
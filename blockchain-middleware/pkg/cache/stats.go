@@ -0,0 +1,93 @@
+package cache
+
+import (
+	"blockchain-middleware/pkg/types"
+	"sort"
+	"sync"
+)
+
+// 缓存的读取类型
+const (
+	KindBalance      = "balance"
+	KindNonce        = "nonce"
+	KindBlockNumber  = "block_number"
+	KindBlock        = "block"
+	KindTransaction  = "transaction"
+	KindTokenBalance = "token_balance"
+)
+
+// counter 单个(链, 类型)的命中计数
+type counter struct {
+	hits, misses, errors uint64
+}
+
+// Stats 按链和读取类型统计缓存命中情况
+type Stats struct {
+	mu       sync.Mutex
+	counters map[[2]string]*counter
+}
+
+// NewStats 创建命中统计
+func NewStats() *Stats {
+	return &Stats{counters: make(map[[2]string]*counter)}
+}
+
+// hit 记录一次命中或未命中
+func (s *Stats) hit(chainName, kind string, hit bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c := s.counter(chainName, kind)
+	if hit {
+		c.hits++
+	} else {
+		c.misses++
+	}
+}
+
+// fail 记录一次缓存读写失败
+func (s *Stats) fail(chainName, kind string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.counter(chainName, kind).errors++
+}
+
+// counter 获取计数器，调用方持有锁
+func (s *Stats) counter(chainName, kind string) *counter {
+	key := [2]string{chainName, kind}
+	c, ok := s.counters[key]
+	if !ok {
+		c = &counter{}
+		s.counters[key] = c
+	}
+	return c
+}
+
+// Snapshot 返回当前统计，按链名和类型排序
+func (s *Stats) Snapshot() []types.CacheStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := make([]types.CacheStats, 0, len(s.counters))
+	for key, c := range s.counters {
+		stat := types.CacheStats{
+			Chain:  key[0],
+			Kind:   key[1],
+			Hits:   c.hits,
+			Misses: c.misses,
+			Errors: c.errors,
+		}
+		if total := c.hits + c.misses; total > 0 {
+			stat.HitRate = float64(c.hits) / float64(total)
+		}
+		stats = append(stats, stat)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Chain != stats[j].Chain {
+			return stats[i].Chain < stats[j].Chain
+		}
+		return stats[i].Kind < stats[j].Kind
+	})
+	return stats
+}
@@ -0,0 +1,122 @@
+package cache
+
+import (
+	"blockchain-middleware/internal/config"
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// Store 缓存存储接口，值为JSON编码的链上数据
+type Store interface {
+	// Get 读取缓存，不存在或已过期时 ok 为false
+	Get(ctx context.Context, key string) (value []byte, ok bool, err error)
+	// Set 写入缓存，ttl 为0表示不过期
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// Delete 删除缓存
+	Delete(ctx context.Context, keys ...string) error
+	// Backend 存储类型，用于统计展示
+	Backend() string
+}
+
+// NewStore 按配置创建缓存存储，未配置Redis或连接失败时使用进程内缓存
+func NewStore(ctx context.Context, cfg config.CacheConfig) Store {
+	if cfg.RedisURL != "" {
+		store, err := NewRedisStore(ctx, cfg.RedisURL)
+		if err == nil {
+			return store
+		}
+		log.Printf("Redis unavailable, falling back to in-memory cache: %v", err)
+	}
+	return NewMemoryStore(cfg.MaxEntries)
+}
+
+// memoryEntry 进程内缓存条目
+type memoryEntry struct {
+	value     []byte
+	expiresAt time.Time // 零值表示不过期
+}
+
+// MemoryStore 进程内缓存，未配置Redis或Redis不可用时使用
+// 条目达到上限时先清理过期条目，仍然不足则随机淘汰。
+type MemoryStore struct {
+	mu         sync.Mutex
+	entries    map[string]memoryEntry
+	maxEntries int
+}
+
+// NewMemoryStore 创建进程内缓存
+func NewMemoryStore(maxEntries int) *MemoryStore {
+	if maxEntries <= 0 {
+		maxEntries = 100000
+	}
+	return &MemoryStore{entries: make(map[string]memoryEntry), maxEntries: maxEntries}
+}
+
+// Get 读取缓存
+func (s *MemoryStore) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	if !entry.expiresAt.IsZero() && time.Now().After(entry.expiresAt) {
+		delete(s.entries, key)
+		return nil, false, nil
+	}
+	return entry.value, true, nil
+}
+
+// Set 写入缓存
+func (s *MemoryStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.entries[key]; !exists && len(s.entries) >= s.maxEntries {
+		s.evict()
+	}
+
+	entry := memoryEntry{value: value}
+	if ttl > 0 {
+		entry.expiresAt = time.Now().Add(ttl)
+	}
+	s.entries[key] = entry
+	return nil
+}
+
+// Delete 删除缓存
+func (s *MemoryStore) Delete(ctx context.Context, keys ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, key := range keys {
+		delete(s.entries, key)
+	}
+	return nil
+}
+
+// Backend 存储类型
+func (s *MemoryStore) Backend() string {
+	return "memory"
+}
+
+// evict 清理过期条目，仍然超过上限的90%时随机淘汰到90%
+func (s *MemoryStore) evict() {
+	now := time.Now()
+	for key, entry := range s.entries {
+		if !entry.expiresAt.IsZero() && now.After(entry.expiresAt) {
+			delete(s.entries, key)
+		}
+	}
+
+	target := s.maxEntries * 9 / 10
+	for key := range s.entries {
+		if len(s.entries) <= target {
+			break
+		}
+		delete(s.entries, key)
+	}
+}
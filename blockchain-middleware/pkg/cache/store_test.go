@@ -0,0 +1,78 @@
+package cache
+
+import (
+	"blockchain-middleware/internal/config"
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestMemoryStoreExpiry(t *testing.T) {
+	s := NewMemoryStore(0)
+	ctx := context.Background()
+
+	s.Set(ctx, "head", []byte("1"), time.Minute)
+	s.Set(ctx, "final", []byte("2"), 0)
+	s.mu.Lock()
+	entry := s.entries["head"]
+	entry.expiresAt = time.Now().Add(-time.Second)
+	s.entries["head"] = entry
+	s.mu.Unlock()
+
+	if _, ok, _ := s.Get(ctx, "head"); ok {
+		t.Error("expired entry was returned")
+	}
+	if value, ok, _ := s.Get(ctx, "final"); !ok || string(value) != "2" {
+		t.Errorf("Get(final) = %q, %v", value, ok)
+	}
+
+	s.Delete(ctx, "final", "missing")
+	if _, ok, _ := s.Get(ctx, "final"); ok || len(s.entries) != 0 {
+		t.Errorf("%d entries left after delete", len(s.entries))
+	}
+}
+
+func TestMemoryStoreEviction(t *testing.T) {
+	s := NewMemoryStore(10)
+	ctx := context.Background()
+
+	s.Set(ctx, "expired", []byte("x"), time.Minute)
+	for i := 0; i < 9; i++ {
+		s.Set(ctx, fmt.Sprintf("key%d", i), []byte("x"), 0)
+	}
+	s.entries["expired"] = memoryEntry{expiresAt: time.Now().Add(-time.Second)}
+
+	// 先清理过期条目
+	s.Set(ctx, "new", []byte("x"), 0)
+	if len(s.entries) != 10 {
+		t.Fatalf("%d entries after evicting expired entries, want 10", len(s.entries))
+	}
+	if _, ok := s.entries["expired"]; ok {
+		t.Error("expired entry was kept")
+	}
+
+	// 仍然不足时淘汰到上限的90%
+	s.Set(ctx, "newer", []byte("x"), 0)
+	if len(s.entries) != 10 {
+		t.Errorf("%d entries after eviction, want 10", len(s.entries))
+	}
+	if _, ok, _ := s.Get(ctx, "newer"); !ok {
+		t.Error("new entry was evicted")
+	}
+
+	// 覆盖已有条目不触发淘汰
+	s.Set(ctx, "newer", []byte("y"), 0)
+	if len(s.entries) != 10 {
+		t.Errorf("%d entries after overwrite, want 10", len(s.entries))
+	}
+}
+
+func TestNewStoreFallsBackToMemory(t *testing.T) {
+	if backend := NewStore(context.Background(), config.CacheConfig{}).Backend(); backend != "memory" {
+		t.Errorf("backend without redis = %s", backend)
+	}
+	if backend := NewStore(context.Background(), config.CacheConfig{RedisURL: "not a url"}).Backend(); backend != "memory" {
+		t.Errorf("backend with invalid redis url = %s", backend)
+	}
+}
@@ -0,0 +1,535 @@
+package chain
+
+import (
+	"blockchain-middleware/internal/config"
+	"blockchain-middleware/pkg/signer"
+	"blockchain-middleware/pkg/types"
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+const (
+	// defaultBitcoinFeeRate 无法估算手续费时使用的费率（sat/vB），regtest 下 estimatesmartfee 通常没有数据
+	defaultBitcoinFeeRate = 10
+	// bitcoinFeeConfTarget 手续费估算的目标确认区块数
+	bitcoinFeeConfTarget = 6
+	// utxoLockTimeout PSBT占用UTXO的最长时间，超时后UTXO可被重新选择
+	utxoLockTimeout = 10 * time.Minute
+)
+
+// BitcoinClient 比特币客户端
+type BitcoinClient struct {
+	config config.ChainConfig
+	signer signer.Signer
+	rpc    *bitcoinRPC
+	params *chaincfg.Params
+
+	mu     sync.Mutex
+	scanMu sync.Mutex                  // bitcoind 同一时间只允许一个 scantxoutset
+	utxos  map[string][]types.UTXO     // 按地址跟踪的UTXO
+	locked map[wire.OutPoint]time.Time // 被未完成PSBT占用的UTXO
+	spent  map[wire.OutPoint]string    // 已广播花费但尚未确认的UTXO -> 地址
+}
+
+// GetChainID 获取链ID
+func (c *BitcoinClient) GetChainID() int64 {
+	return c.config.ChainID
+}
+
+// GetNetworkName 获取网络名称
+func (c *BitcoinClient) GetNetworkName() string {
+	return c.config.NetworkName
+}
+
+// Reconfigure 替换bitcoind地址、认证信息、请求超时和限流
+func (c *BitcoinClient) Reconfigure(cfg config.ChainConfig) error {
+	if cfg.RPCURL == "" {
+		return fmt.Errorf("no rpc endpoint configured for %s", c.config.Name)
+	}
+	c.rpc.reconfigure(cfg.RPCURL, cfg.RPCUser, cfg.RPCPassword, cfg.RequestTimeout, cfg.RPCRateLimit)
+	return nil
+}
+
+// GetBalance 获取余额（单位: satoshi，不含已被本服务花费的UTXO）
+func (c *BitcoinClient) GetBalance(ctx context.Context, address string) (*big.Int, error) {
+	utxos, err := c.ListUTXOs(ctx, address)
+	if err != nil {
+		return nil, err
+	}
+
+	var total int64
+	for _, u := range utxos {
+		total += u.Amount
+	}
+	return big.NewInt(total), nil
+}
+
+// GetNonce 获取Nonce，比特币使用UTXO模型，没有nonce概念
+func (c *BitcoinClient) GetNonce(ctx context.Context, address string) (uint64, error) {
+	return 0, nil
+}
+
+// SendTransaction 发送交易
+// Value 为转账金额（satoshi），GasPrice 为费率（sat/vB，可为空）。由签名器对 From 地址的各输入签名。
+func (c *BitcoinClient) SendTransaction(ctx context.Context, req *types.TransactionRequest) (string, error) {
+	if c.signer == nil {
+		return "", fmt.Errorf("no signer configured for %s, use /psbt and /psbt/finalize or /transactions/raw", c.config.Name)
+	}
+
+	psbtReq, err := c.psbtRequestFromTx(req)
+	if err != nil {
+		return "", err
+	}
+
+	built, err := c.BuildPSBT(ctx, psbtReq)
+	if err != nil {
+		return "", err
+	}
+
+	finalizeReq, err := signPSBTSighashes(ctx, built, c.signer, req.From)
+	if err != nil {
+		c.unlockPSBT(built.PSBT)
+		return "", err
+	}
+
+	txID, err := c.FinalizePSBT(ctx, finalizeReq)
+	if err != nil {
+		c.unlockPSBT(built.PSBT)
+		return "", err
+	}
+	return txID, nil
+}
+
+// SendRawTransaction 广播已签名的原始交易（hex解码后的字节）
+func (c *BitcoinClient) SendRawTransaction(ctx context.Context, rawTx []byte) (string, error) {
+	tx := wire.NewMsgTx(wire.TxVersion)
+	if err := tx.Deserialize(bytes.NewReader(rawTx)); err != nil {
+		return "", fmt.Errorf("failed to decode transaction: %w", err)
+	}
+	if len(tx.TxIn) == 0 || len(tx.TxOut) == 0 {
+		return "", fmt.Errorf("transaction has no inputs or outputs")
+	}
+
+	return c.BroadcastRawTransaction(ctx, tx)
+}
+
+// GetTransaction 获取交易信息
+func (c *BitcoinClient) GetTransaction(ctx context.Context, txHash string) (*types.Transaction, error) {
+	tx, err := c.getRawTransaction(ctx, txHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transaction: %w", err)
+	}
+
+	result := &types.Transaction{
+		Hash:     tx.TxID,
+		ChainID:  c.config.ChainID,
+		GasUsed:  tx.VSize,
+		GasLimit: tx.VSize,
+		Value:    big.NewInt(0),
+		Pending:  tx.BlockHash == "",
+	}
+	if !result.Pending {
+		height, err := c.blockHeight(ctx, tx.BlockHash)
+		if err != nil {
+			return nil, err
+		}
+		result.Status = 1
+		result.BlockNumber = height
+		result.Timestamp = tx.BlockTime
+	}
+
+	if len(tx.Vout) > 0 {
+		result.To = tx.Vout[0].ScriptPubKey.Address
+		amount, err := btcutil.NewAmount(tx.Vout[0].Value)
+		if err == nil {
+			result.Value = big.NewInt(int64(amount))
+		}
+	}
+
+	// 发送方取第一个输入所花费的输出地址
+	if len(tx.Vin) > 0 && tx.Vin[0].TxID != "" {
+		prev, err := c.getRawTransaction(ctx, tx.Vin[0].TxID)
+		if err == nil && int(tx.Vin[0].Vout) < len(prev.Vout) {
+			result.From = prev.Vout[tx.Vin[0].Vout].ScriptPubKey.Address
+		}
+	}
+
+	return result, nil
+}
+
+// TransactionStatus 查询交易在节点中的状态
+// 比特币没有账户nonce，被替换（RBF）的交易表现为从交易池中消失，由调用方按丢弃处理。
+func (c *BitcoinClient) TransactionStatus(ctx context.Context, txHash, from string) (*types.TxChainStatus, error) {
+	var head uint64
+	if err := c.rpc.call(ctx, "getblockcount", nil, &head); err != nil {
+		return nil, fmt.Errorf("failed to get block count: %w", err)
+	}
+	status := &types.TxChainStatus{HeadBlock: head}
+
+	tx, err := c.getRawTransaction(ctx, txHash)
+	if err != nil {
+		var rpcErr *bitcoinRPCError
+		if errors.As(err, &rpcErr) && rpcErr.Code == bitcoindErrNotFound {
+			return status, nil
+		}
+		return nil, fmt.Errorf("failed to get transaction: %w", err)
+	}
+
+	status.Found = true
+	status.Pending = tx.BlockHash == ""
+	if !status.Pending {
+		height, err := c.blockHeight(ctx, tx.BlockHash)
+		if err != nil {
+			return nil, err
+		}
+		status.BlockNumber = height
+		status.BlockHash = tx.BlockHash
+		status.Success = true
+	}
+	return status, nil
+}
+
+// EstimateGas 预估交易大小（vbytes）
+func (c *BitcoinClient) EstimateGas(ctx context.Context, req *types.TransactionRequest) (uint64, error) {
+	psbtReq, err := c.psbtRequestFromTx(req)
+	if err != nil {
+		return 0, err
+	}
+
+	toScript, changeScript, err := c.outputScripts(psbtReq)
+	if err != nil {
+		return 0, err
+	}
+
+	feeRate, err := c.resolveFeeRate(ctx, psbtReq.FeeRate)
+	if err != nil {
+		return 0, err
+	}
+
+	utxos, err := c.ListUTXOs(ctx, psbtReq.From)
+	if err != nil {
+		return 0, err
+	}
+
+	selection, err := selectCoins(unlockedUTXOs(utxos), psbtReq.Amount, feeRate, toScript, changeScript)
+	if err != nil {
+		return 0, err
+	}
+
+	return uint64(selection.vsize), nil
+}
+
+// GetBlockNumber 获取最新区块号
+func (c *BitcoinClient) GetBlockNumber(ctx context.Context) (uint64, error) {
+	var count uint64
+	if err := c.rpc.call(ctx, "getblockcount", nil, &count); err != nil {
+		return 0, fmt.Errorf("failed to get block number: %w", err)
+	}
+	return count, nil
+}
+
+// GetBlockByNumber 根据区块号获取区块
+func (c *BitcoinClient) GetBlockByNumber(ctx context.Context, blockNumber uint64) (*types.Block, error) {
+	var blockHash string
+	if err := c.rpc.call(ctx, "getblockhash", []interface{}{blockNumber}, &blockHash); err != nil {
+		return nil, fmt.Errorf("failed to get block hash: %w", err)
+	}
+
+	var block struct {
+		Hash              string   `json:"hash"`
+		Height            uint64   `json:"height"`
+		PreviousBlockHash string   `json:"previousblockhash"`
+		Time              uint64   `json:"time"`
+		Tx                []string `json:"tx"`
+		Size              uint64   `json:"size"`
+		Weight            uint64   `json:"weight"`
+		Difficulty        float64  `json:"difficulty"`
+	}
+	if err := c.rpc.call(ctx, "getblock", []interface{}{blockHash, 1}, &block); err != nil {
+		return nil, fmt.Errorf("failed to get block: %w", err)
+	}
+
+	difficulty, _ := new(big.Float).SetFloat64(block.Difficulty).Int(nil)
+
+	return &types.Block{
+		Number:       block.Height,
+		Hash:         block.Hash,
+		ParentHash:   block.PreviousBlockHash,
+		Timestamp:    block.Time,
+		Transactions: block.Tx,
+		Difficulty:   difficulty,
+		Size:         block.Size,
+	}, nil
+}
+
+// CallContract 调用合约，比特币不支持
+func (c *BitcoinClient) CallContract(ctx context.Context, req *types.ContractCallRequest) ([]byte, error) {
+	return nil, fmt.Errorf("contract calls are not supported on bitcoin")
+}
+
+// Close 关闭连接
+func (c *BitcoinClient) Close() error {
+	return nil
+}
+
+// ListUTXOs 查询地址的UTXO并更新本地跟踪状态
+// 已被本服务广播花费的UTXO会被排除，被未完成PSBT占用的UTXO标记为Locked。
+// scantxoutset 只扫描已确认的UTXO集合，内存池中的输出（包括本服务交易的找零）在确认前不会返回，也不能被选币花费。
+func (c *BitcoinClient) ListUTXOs(ctx context.Context, address string) ([]types.UTXO, error) {
+	if _, err := btcutil.DecodeAddress(address, c.params); err != nil {
+		return nil, fmt.Errorf("invalid bitcoin address %s: %w", address, err)
+	}
+
+	var scan struct {
+		Success  bool `json:"success"`
+		Unspents []struct {
+			TxID         string  `json:"txid"`
+			Vout         uint32  `json:"vout"`
+			ScriptPubKey string  `json:"scriptPubKey"`
+			Amount       float64 `json:"amount"`
+			Height       int64   `json:"height"`
+		} `json:"unspents"`
+	}
+
+	c.scanMu.Lock()
+	err := c.rpc.call(ctx, "scantxoutset", []interface{}{"start", []string{"addr(" + address + ")"}}, &scan)
+	c.scanMu.Unlock()
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan utxos: %w", err)
+	}
+	if !scan.Success {
+		return nil, fmt.Errorf("utxo scan for %s did not complete", address)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	seen := make(map[wire.OutPoint]struct{}, len(scan.Unspents))
+	utxos := make([]types.UTXO, 0, len(scan.Unspents))
+	for _, u := range scan.Unspents {
+		outPoint, err := parseOutPoint(u.TxID, u.Vout)
+		if err != nil {
+			return nil, err
+		}
+		seen[*outPoint] = struct{}{}
+
+		if _, isSpent := c.spent[*outPoint]; isSpent {
+			continue
+		}
+
+		amount, err := btcutil.NewAmount(u.Amount)
+		if err != nil {
+			return nil, fmt.Errorf("invalid utxo amount %v: %w", u.Amount, err)
+		}
+
+		lockedAt, isLocked := c.locked[*outPoint]
+		if isLocked && now.Sub(lockedAt) > utxoLockTimeout {
+			delete(c.locked, *outPoint)
+			isLocked = false
+		}
+
+		utxos = append(utxos, types.UTXO{
+			TxID:         u.TxID,
+			Vout:         u.Vout,
+			Address:      address,
+			Amount:       int64(amount),
+			ScriptPubKey: u.ScriptPubKey,
+			Height:       u.Height,
+			Locked:       isLocked,
+		})
+	}
+
+	// 花费交易确认后，UTXO从链上集合中消失，不再需要跟踪
+	for outPoint, addr := range c.spent {
+		if _, stillUnspent := seen[outPoint]; addr == address && !stillUnspent {
+			delete(c.spent, outPoint)
+		}
+	}
+
+	c.utxos[address] = utxos
+	return utxos, nil
+}
+
+// EstimateFeeRate 估算手续费率（sat/vB）
+func (c *BitcoinClient) EstimateFeeRate(ctx context.Context, confTarget int) (int64, error) {
+	var estimate struct {
+		FeeRate float64  `json:"feerate"` // BTC/kvB
+		Errors  []string `json:"errors"`
+	}
+	if err := c.rpc.call(ctx, "estimatesmartfee", []interface{}{confTarget}, &estimate); err != nil {
+		return 0, fmt.Errorf("failed to estimate fee: %w", err)
+	}
+
+	// 节点数据不足时回退到默认费率
+	if len(estimate.Errors) > 0 || estimate.FeeRate <= 0 {
+		return defaultBitcoinFeeRate, nil
+	}
+
+	satPerVByte := int64(math.Ceil(estimate.FeeRate * btcutil.SatoshiPerBitcoin / 1000))
+	if satPerVByte < 1 {
+		satPerVByte = 1
+	}
+	return satPerVByte, nil
+}
+
+// BroadcastRawTransaction 广播已签名的原始交易
+func (c *BitcoinClient) BroadcastRawTransaction(ctx context.Context, tx *wire.MsgTx) (string, error) {
+	rawTx, err := serializeMsgTx(tx)
+	if err != nil {
+		return "", err
+	}
+
+	var txID string
+	if err := c.rpc.call(ctx, "sendrawtransaction", []interface{}{hex.EncodeToString(rawTx)}, &txID); err != nil {
+		return "", fmt.Errorf("failed to broadcast transaction: %w", err)
+	}
+	return txID, nil
+}
+
+// psbtRequestFromTx 将通用交易请求转换为PSBT请求
+func (c *BitcoinClient) psbtRequestFromTx(req *types.TransactionRequest) (*types.PSBTRequest, error) {
+	if req.Value == nil || !req.Value.IsInt64() || req.Value.Sign() <= 0 {
+		return nil, fmt.Errorf("invalid bitcoin amount: %v", req.Value)
+	}
+
+	psbtReq := &types.PSBTRequest{
+		From:   req.From,
+		To:     req.To,
+		Amount: req.Value.Int64(),
+	}
+	if req.GasPrice != nil {
+		psbtReq.FeeRate = req.GasPrice.Int64()
+	}
+	return psbtReq, nil
+}
+
+// resolveFeeRate 返回请求指定的费率，未指定时自动估算
+func (c *BitcoinClient) resolveFeeRate(ctx context.Context, feeRate int64) (int64, error) {
+	if feeRate > 0 {
+		return feeRate, nil
+	}
+	return c.EstimateFeeRate(ctx, bitcoinFeeConfTarget)
+}
+
+// outputScripts 解析收款地址和找零地址的锁定脚本
+func (c *BitcoinClient) outputScripts(req *types.PSBTRequest) ([]byte, []byte, error) {
+	toScript, err := c.addressScript(req.To)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	changeAddress := req.ChangeAddress
+	if changeAddress == "" {
+		changeAddress = req.From
+	}
+	changeScript, err := c.addressScript(changeAddress)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return toScript, changeScript, nil
+}
+
+// addressScript 将地址转换为锁定脚本
+func (c *BitcoinClient) addressScript(address string) ([]byte, error) {
+	addr, err := btcutil.DecodeAddress(address, c.params)
+	if err != nil {
+		return nil, fmt.Errorf("invalid bitcoin address %s: %w", address, err)
+	}
+	if !addr.IsForNet(c.params) {
+		return nil, fmt.Errorf("address %s is not for network %s", address, c.params.Name)
+	}
+	return txscript.PayToAddrScript(addr)
+}
+
+// bitcoinRawTransaction getrawtransaction 详细结果
+type bitcoinRawTransaction struct {
+	TxID string `json:"txid"`
+	Hex  string `json:"hex"`
+	Vin  []struct {
+		TxID string `json:"txid"`
+		Vout uint32 `json:"vout"`
+	} `json:"vin"`
+	Vout []struct {
+		Value        float64 `json:"value"`
+		N            uint32  `json:"n"`
+		ScriptPubKey struct {
+			Hex     string `json:"hex"`
+			Address string `json:"address"`
+		} `json:"scriptPubKey"`
+	} `json:"vout"`
+	VSize         uint64 `json:"vsize"`
+	BlockHash     string `json:"blockhash"`
+	Confirmations int64  `json:"confirmations"`
+	BlockTime     uint64 `json:"blocktime"`
+}
+
+// getRawTransaction 查询交易详情（非钱包交易需要节点开启 txindex）
+func (c *BitcoinClient) getRawTransaction(ctx context.Context, txID string) (*bitcoinRawTransaction, error) {
+	var tx bitcoinRawTransaction
+	if err := c.rpc.call(ctx, "getrawtransaction", []interface{}{txID, true}, &tx); err != nil {
+		return nil, err
+	}
+	return &tx, nil
+}
+
+// blockHeight 查询区块高度
+func (c *BitcoinClient) blockHeight(ctx context.Context, blockHash string) (uint64, error) {
+	var header struct {
+		Height uint64 `json:"height"`
+	}
+	if err := c.rpc.call(ctx, "getblockheader", []interface{}{blockHash}, &header); err != nil {
+		return 0, fmt.Errorf("failed to get block header: %w", err)
+	}
+	return header.Height, nil
+}
+
+// bitcoinNetParams 根据网络名称获取链参数
+func bitcoinNetParams(networkName string) (*chaincfg.Params, error) {
+	switch networkName {
+	case "", "bitcoin", "mainnet":
+		return &chaincfg.MainNetParams, nil
+	case "testnet", "testnet3":
+		return &chaincfg.TestNet3Params, nil
+	case "signet":
+		return &chaincfg.SigNetParams, nil
+	case "regtest":
+		return &chaincfg.RegressionNetParams, nil
+	default:
+		return nil, fmt.Errorf("unsupported bitcoin network: %s", networkName)
+	}
+}
+
+// parseOutPoint 解析交易输出引用
+func parseOutPoint(txID string, vout uint32) (*wire.OutPoint, error) {
+	hash, err := chainhash.NewHashFromStr(txID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid txid %s: %w", txID, err)
+	}
+	return wire.NewOutPoint(hash, vout), nil
+}
+
+// unlockedUTXOs 过滤掉被占用的UTXO
+func unlockedUTXOs(utxos []types.UTXO) []types.UTXO {
+	result := make([]types.UTXO, 0, len(utxos))
+	for _, u := range utxos {
+		if !u.Locked {
+			result = append(result, u)
+		}
+	}
+	return result
+}
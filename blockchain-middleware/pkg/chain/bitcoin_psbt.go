@@ -0,0 +1,543 @@
+package chain
+
+import (
+	"blockchain-middleware/pkg/signer"
+	"blockchain-middleware/pkg/types"
+	"bytes"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/ecdsa"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/psbt"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+const (
+	// 交易各部分的虚拟大小估算（vbytes）
+	txOverheadVSize   = 11  // 版本、锁定时间、输入输出计数及隔离见证标记
+	p2wpkhInputVSize  = 68  // 含见证数据
+	p2pkhInputVSize   = 148 // 含 scriptSig
+	txOutputBaseVSize = 9   // 金额(8) + 脚本长度(1)
+
+	// dustThreshold 低于该值的找零直接计入手续费
+	dustThreshold = 546
+
+	// rbfSequence 启用RBF的输入序列号，便于手续费不足时替换
+	rbfSequence = wire.MaxTxInSequenceNum - 2
+)
+
+// coinSelection 选币结果
+type coinSelection struct {
+	inputs []types.UTXO
+	fee    int64
+	change int64
+	vsize  int64
+}
+
+// selectCoins 选择足够支付金额和手续费的UTXO
+// 按确认优先、金额从大到小累加，找零低于粉尘阈值时并入手续费。
+func selectCoins(utxos []types.UTXO, amount, feeRate int64, toScript, changeScript []byte) (*coinSelection, error) {
+	if amount < dustThreshold {
+		return nil, fmt.Errorf("amount %d is below dust threshold", amount)
+	}
+
+	candidates := make([]types.UTXO, 0, len(utxos))
+	for _, u := range utxos {
+		if _, err := inputVSize(u.ScriptPubKey); err == nil {
+			candidates = append(candidates, u)
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		iConfirmed, jConfirmed := candidates[i].Height > 0, candidates[j].Height > 0
+		if iConfirmed != jConfirmed {
+			return iConfirmed
+		}
+		return candidates[i].Amount > candidates[j].Amount
+	})
+
+	baseVSize := int64(txOverheadVSize + txOutputBaseVSize + len(toScript))
+	changeVSize := int64(txOutputBaseVSize + len(changeScript))
+
+	var (
+		selected  []types.UTXO
+		total     int64
+		inputSize int64
+	)
+	for _, u := range candidates {
+		size, _ := inputVSize(u.ScriptPubKey)
+		selected = append(selected, u)
+		total += u.Amount
+		inputSize += size
+
+		vsize := baseVSize + inputSize
+		if total < amount+vsize*feeRate {
+			continue
+		}
+
+		// 优先带找零输出
+		withChange := vsize + changeVSize
+		change := total - amount - withChange*feeRate
+		if change >= dustThreshold {
+			return &coinSelection{
+				inputs: selected,
+				fee:    withChange * feeRate,
+				change: change,
+				vsize:  withChange,
+			}, nil
+		}
+
+		return &coinSelection{
+			inputs: selected,
+			fee:    total - amount,
+			vsize:  vsize,
+		}, nil
+	}
+
+	return nil, fmt.Errorf("insufficient funds: have %d satoshi in confirmed outputs, need %d plus fee (unconfirmed change is not spendable until it confirms)", total, amount)
+}
+
+// inputVSize 根据锁定脚本类型返回花费该输出的虚拟大小
+func inputVSize(scriptPubKeyHex string) (int64, error) {
+	script, err := hex.DecodeString(scriptPubKeyHex)
+	if err != nil {
+		return 0, fmt.Errorf("invalid script: %w", err)
+	}
+
+	switch {
+	case txscript.IsPayToWitnessPubKeyHash(script):
+		return p2wpkhInputVSize, nil
+	case txscript.IsPayToPubKeyHash(script):
+		return p2pkhInputVSize, nil
+	default:
+		return 0, fmt.Errorf("unsupported script type for ECDSA signing")
+	}
+}
+
+// BuildPSBT 构建未签名的PSBT并返回每个输入的待签名哈希
+// 选中的UTXO会被占用，直到 FinalizePSBT 广播成功或超时。
+func (c *BitcoinClient) BuildPSBT(ctx context.Context, req *types.PSBTRequest) (*types.PSBTResponse, error) {
+	toScript, changeScript, err := c.outputScripts(req)
+	if err != nil {
+		return nil, err
+	}
+
+	feeRate, err := c.resolveFeeRate(ctx, req.FeeRate)
+	if err != nil {
+		return nil, err
+	}
+
+	utxos, err := c.ListUTXOs(ctx, req.From)
+	if err != nil {
+		return nil, err
+	}
+
+	selection, err := c.selectAndLock(utxos, req.Amount, feeRate, toScript, changeScript)
+	if err != nil {
+		return nil, err
+	}
+
+	packet, err := c.newPacket(ctx, selection, req.Amount, toScript, changeScript)
+	if err != nil {
+		c.unlockInputs(selection.inputs)
+		return nil, err
+	}
+
+	sighashes, err := computeSighashes(packet)
+	if err != nil {
+		c.unlockInputs(selection.inputs)
+		return nil, err
+	}
+
+	encoded, err := packet.B64Encode()
+	if err != nil {
+		c.unlockInputs(selection.inputs)
+		return nil, fmt.Errorf("failed to encode psbt: %w", err)
+	}
+
+	return &types.PSBTResponse{
+		PSBT:      encoded,
+		Sighashes: sighashes,
+		Fee:       selection.fee,
+		FeeRate:   feeRate,
+		VSize:     selection.vsize,
+		Change:    selection.change,
+	}, nil
+}
+
+// FinalizePSBT 将MPC签名写入PSBT，完成最终化并广播
+func (c *BitcoinClient) FinalizePSBT(ctx context.Context, req *types.PSBTFinalizeRequest) (string, error) {
+	packet, err := psbt.NewFromRawBytes(strings.NewReader(req.PSBT), true)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode psbt: %w", err)
+	}
+
+	pubKeyBytes, err := hex.DecodeString(strings.TrimPrefix(req.PubKey, "0x"))
+	if err != nil {
+		return "", fmt.Errorf("invalid public key: %w", err)
+	}
+	pubKey, err := btcec.ParsePubKey(pubKeyBytes)
+	if err != nil {
+		return "", fmt.Errorf("invalid public key: %w", err)
+	}
+	pubKeyBytes = pubKey.SerializeCompressed()
+
+	sighashes, err := computeSighashes(packet)
+	if err != nil {
+		return "", err
+	}
+	if len(req.Signatures) != len(sighashes) {
+		return "", fmt.Errorf("expected %d signatures, got %d", len(sighashes), len(req.Signatures))
+	}
+
+	updater, err := psbt.NewUpdater(packet)
+	if err != nil {
+		return "", fmt.Errorf("failed to create psbt updater: %w", err)
+	}
+
+	for _, s := range req.Signatures {
+		if s.InputIndex < 0 || s.InputIndex >= len(sighashes) {
+			return "", fmt.Errorf("signature input index %d out of range", s.InputIndex)
+		}
+
+		prevOut, err := psbtPrevOut(packet, s.InputIndex)
+		if err != nil {
+			return "", err
+		}
+		if !scriptMatchesPubKey(prevOut.PkScript, pubKeyBytes) {
+			return "", fmt.Errorf("public key does not own input %d", s.InputIndex)
+		}
+
+		sig, err := parseECDSASignature(s.Signature)
+		if err != nil {
+			return "", fmt.Errorf("invalid signature for input %d: %w", s.InputIndex, err)
+		}
+
+		sighash, _ := hex.DecodeString(sighashes[s.InputIndex].Sighash)
+		if !sig.Verify(sighash, pubKey) {
+			return "", fmt.Errorf("signature verification failed for input %d", s.InputIndex)
+		}
+
+		sigBytes := append(sig.Serialize(), byte(txscript.SigHashAll))
+		outcome, err := updater.Sign(s.InputIndex, sigBytes, pubKeyBytes, nil, nil)
+		if err != nil || outcome != psbt.SignSuccesful {
+			return "", fmt.Errorf("failed to add signature for input %d: %v", s.InputIndex, err)
+		}
+	}
+
+	if err := psbt.MaybeFinalizeAll(packet); err != nil {
+		return "", fmt.Errorf("failed to finalize psbt: %w", err)
+	}
+
+	tx, err := psbt.Extract(packet)
+	if err != nil {
+		return "", fmt.Errorf("failed to extract transaction: %w", err)
+	}
+
+	txID, err := c.BroadcastRawTransaction(ctx, tx)
+	if err != nil {
+		return "", err
+	}
+
+	c.markSpent(packet)
+	return txID, nil
+}
+
+// selectAndLock 在锁内选币并占用选中的UTXO，避免并发构建的PSBT花费同一输出
+func (c *BitcoinClient) selectAndLock(utxos []types.UTXO, amount, feeRate int64, toScript, changeScript []byte) (*coinSelection, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	available := make([]types.UTXO, 0, len(utxos))
+	for _, u := range utxos {
+		outPoint, err := parseOutPoint(u.TxID, u.Vout)
+		if err != nil {
+			return nil, err
+		}
+		if _, isLocked := c.locked[*outPoint]; isLocked {
+			continue
+		}
+		if _, isSpent := c.spent[*outPoint]; isSpent {
+			continue
+		}
+		available = append(available, u)
+	}
+
+	selection, err := selectCoins(available, amount, feeRate, toScript, changeScript)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	for _, u := range selection.inputs {
+		outPoint, _ := parseOutPoint(u.TxID, u.Vout)
+		c.locked[*outPoint] = now
+	}
+	return selection, nil
+}
+
+// newPacket 根据选币结果创建PSBT
+func (c *BitcoinClient) newPacket(ctx context.Context, selection *coinSelection, amount int64, toScript, changeScript []byte) (*psbt.Packet, error) {
+	outPoints := make([]*wire.OutPoint, len(selection.inputs))
+	sequences := make([]uint32, len(selection.inputs))
+	for i, u := range selection.inputs {
+		outPoint, err := parseOutPoint(u.TxID, u.Vout)
+		if err != nil {
+			return nil, err
+		}
+		outPoints[i] = outPoint
+		sequences[i] = rbfSequence
+	}
+
+	outputs := []*wire.TxOut{wire.NewTxOut(amount, toScript)}
+	if selection.change > 0 {
+		outputs = append(outputs, wire.NewTxOut(selection.change, changeScript))
+	}
+
+	packet, err := psbt.New(outPoints, outputs, 2, 0, sequences)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create psbt: %w", err)
+	}
+
+	for i, u := range selection.inputs {
+		script, err := hex.DecodeString(u.ScriptPubKey)
+		if err != nil {
+			return nil, fmt.Errorf("invalid script for input %d: %w", i, err)
+		}
+
+		if txscript.IsPayToWitnessPubKeyHash(script) {
+			packet.Inputs[i].WitnessUtxo = wire.NewTxOut(u.Amount, script)
+		} else {
+			// 非隔离见证输入需要完整的前序交易
+			prevTx, err := c.getPrevTransaction(ctx, u.TxID)
+			if err != nil {
+				return nil, err
+			}
+			packet.Inputs[i].NonWitnessUtxo = prevTx
+		}
+		packet.Inputs[i].SighashType = txscript.SigHashAll
+	}
+
+	return packet, nil
+}
+
+// getPrevTransaction 获取并解码前序交易
+func (c *BitcoinClient) getPrevTransaction(ctx context.Context, txID string) (*wire.MsgTx, error) {
+	var rawHex string
+	if err := c.rpc.call(ctx, "getrawtransaction", []interface{}{txID, false}, &rawHex); err != nil {
+		return nil, fmt.Errorf("failed to get previous transaction %s: %w", txID, err)
+	}
+
+	raw, err := hex.DecodeString(rawHex)
+	if err != nil {
+		return nil, fmt.Errorf("invalid previous transaction %s: %w", txID, err)
+	}
+
+	var tx wire.MsgTx
+	if err := tx.Deserialize(bytes.NewReader(raw)); err != nil {
+		return nil, fmt.Errorf("failed to decode previous transaction %s: %w", txID, err)
+	}
+	return &tx, nil
+}
+
+// unlockInputs 释放被占用的UTXO
+func (c *BitcoinClient) unlockInputs(inputs []types.UTXO) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, u := range inputs {
+		if outPoint, err := parseOutPoint(u.TxID, u.Vout); err == nil {
+			delete(c.locked, *outPoint)
+		}
+	}
+}
+
+// unlockPSBT 释放PSBT占用的UTXO
+func (c *BitcoinClient) unlockPSBT(encoded string) {
+	packet, err := psbt.NewFromRawBytes(strings.NewReader(encoded), true)
+	if err != nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, in := range packet.UnsignedTx.TxIn {
+		delete(c.locked, in.PreviousOutPoint)
+	}
+}
+
+// markSpent 将已广播交易的输入标记为已花费
+func (c *BitcoinClient) markSpent(packet *psbt.Packet) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for i, in := range packet.UnsignedTx.TxIn {
+		delete(c.locked, in.PreviousOutPoint)
+
+		address := ""
+		if prevOut, err := psbtPrevOut(packet, i); err == nil {
+			_, addrs, _, err := txscript.ExtractPkScriptAddrs(prevOut.PkScript, c.params)
+			if err == nil && len(addrs) > 0 {
+				address = addrs[0].EncodeAddress()
+			}
+		}
+		c.spent[in.PreviousOutPoint] = address
+
+		utxos := c.utxos[address]
+		for j, u := range utxos {
+			if u.TxID == in.PreviousOutPoint.Hash.String() && u.Vout == in.PreviousOutPoint.Index {
+				c.utxos[address] = append(utxos[:j:j], utxos[j+1:]...)
+				break
+			}
+		}
+	}
+}
+
+// computeSighashes 计算PSBT每个输入的签名哈希（SIGHASH_ALL）
+func computeSighashes(packet *psbt.Packet) ([]types.PSBTSighash, error) {
+	tx := packet.UnsignedTx
+
+	prevOuts := make(map[wire.OutPoint]*wire.TxOut, len(tx.TxIn))
+	for i, in := range tx.TxIn {
+		prevOut, err := psbtPrevOut(packet, i)
+		if err != nil {
+			return nil, err
+		}
+		prevOuts[in.PreviousOutPoint] = prevOut
+	}
+	sigHashes := txscript.NewTxSigHashes(tx, txscript.NewMultiPrevOutFetcher(prevOuts))
+
+	result := make([]types.PSBTSighash, len(tx.TxIn))
+	for i, in := range tx.TxIn {
+		prevOut := prevOuts[in.PreviousOutPoint]
+
+		var (
+			hash []byte
+			err  error
+		)
+		switch {
+		case txscript.IsPayToWitnessPubKeyHash(prevOut.PkScript):
+			hash, err = txscript.CalcWitnessSigHash(prevOut.PkScript, sigHashes, txscript.SigHashAll, tx, i, prevOut.Value)
+		case txscript.IsPayToPubKeyHash(prevOut.PkScript):
+			hash, err = txscript.CalcSignatureHash(prevOut.PkScript, txscript.SigHashAll, tx, i)
+		default:
+			err = fmt.Errorf("unsupported script type")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to compute sighash for input %d: %w", i, err)
+		}
+
+		result[i] = types.PSBTSighash{
+			InputIndex: i,
+			Sighash:    hex.EncodeToString(hash),
+			Amount:     prevOut.Value,
+		}
+	}
+
+	return result, nil
+}
+
+// psbtPrevOut 获取PSBT输入花费的前序输出
+func psbtPrevOut(packet *psbt.Packet, index int) (*wire.TxOut, error) {
+	input := packet.Inputs[index]
+	if input.WitnessUtxo != nil {
+		return input.WitnessUtxo, nil
+	}
+
+	if input.NonWitnessUtxo != nil {
+		outPoint := packet.UnsignedTx.TxIn[index].PreviousOutPoint
+		if input.NonWitnessUtxo.TxHash() != outPoint.Hash || int(outPoint.Index) >= len(input.NonWitnessUtxo.TxOut) {
+			return nil, fmt.Errorf("previous transaction mismatch for input %d", index)
+		}
+		return input.NonWitnessUtxo.TxOut[outPoint.Index], nil
+	}
+
+	return nil, fmt.Errorf("missing utxo information for input %d", index)
+}
+
+// scriptMatchesPubKey 检查锁定脚本是否属于该公钥
+func scriptMatchesPubKey(script, pubKey []byte) bool {
+	pubKeyHash := btcutil.Hash160(pubKey)
+
+	switch {
+	case txscript.IsPayToWitnessPubKeyHash(script):
+		return bytes.Equal(script[2:22], pubKeyHash)
+	case txscript.IsPayToPubKeyHash(script):
+		return bytes.Equal(script[3:23], pubKeyHash)
+	default:
+		return false
+	}
+}
+
+// parseECDSASignature 解析64字节r||s（MPC输出，可带恢复ID）或DER格式的签名
+func parseECDSASignature(sigHex string) (*ecdsa.Signature, error) {
+	sigBytes, err := hex.DecodeString(strings.TrimPrefix(sigHex, "0x"))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sigBytes) == 64 || len(sigBytes) == 65 {
+		var r, s btcec.ModNScalar
+		if overflow := r.SetByteSlice(sigBytes[:32]); overflow {
+			return nil, fmt.Errorf("signature r overflows curve order")
+		}
+		if overflow := s.SetByteSlice(sigBytes[32:64]); overflow {
+			return nil, fmt.Errorf("signature s overflows curve order")
+		}
+		return ecdsa.NewSignature(&r, &s), nil
+	}
+
+	return ecdsa.ParseDERSignature(sigBytes)
+}
+
+// signPSBTSighashes 使用签名器对PSBT的全部输入签名，公钥从签名中恢复
+func signPSBTSighashes(ctx context.Context, built *types.PSBTResponse, txSigner signer.Signer, from string) (*types.PSBTFinalizeRequest, error) {
+	req := &types.PSBTFinalizeRequest{PSBT: built.PSBT}
+
+	for _, h := range built.Sighashes {
+		hash, err := hex.DecodeString(h.Sighash)
+		if err != nil {
+			return nil, fmt.Errorf("invalid sighash for input %d: %w", h.InputIndex, err)
+		}
+
+		sig, err := txSigner.SignHash(ctx, from, hash)
+		if err != nil {
+			return nil, fmt.Errorf("failed to sign input %d: %w", h.InputIndex, err)
+		}
+		pubKey, err := crypto.SigToPub(hash, sig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to recover public key for input %d: %w", h.InputIndex, err)
+		}
+
+		pubKeyHex := hex.EncodeToString(crypto.CompressPubkey(pubKey))
+		if req.PubKey == "" {
+			req.PubKey = pubKeyHex
+		} else if req.PubKey != pubKeyHex {
+			return nil, fmt.Errorf("signer used different keys for inputs of %s", from)
+		}
+
+		req.Signatures = append(req.Signatures, types.PSBTSignature{
+			InputIndex: h.InputIndex,
+			Signature:  hex.EncodeToString(sig[:64]),
+		})
+	}
+
+	return req, nil
+}
+
+// serializeMsgTx 序列化交易
+func serializeMsgTx(tx *wire.MsgTx) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := tx.Serialize(&buf); err != nil {
+		return nil, fmt.Errorf("failed to serialize transaction: %w", err)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,422 @@
+package chain
+
+import (
+	"blockchain-middleware/internal/config"
+	"blockchain-middleware/pkg/types"
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/ecdsa"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/psbt"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// testKeyScripts 返回公钥对应的 P2WPKH 和 P2PKH 锁定脚本
+func testKeyScripts(t *testing.T, pubKey *btcec.PublicKey) (p2wpkh, p2pkh []byte) {
+	t.Helper()
+	hash := btcutil.Hash160(pubKey.SerializeCompressed())
+
+	wpkh, err := btcutil.NewAddressWitnessPubKeyHash(hash, &chaincfg.RegressionNetParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkh, err := btcutil.NewAddressPubKeyHash(hash, &chaincfg.RegressionNetParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p2wpkh, err = txscript.PayToAddrScript(wpkh); err != nil {
+		t.Fatal(err)
+	}
+	if p2pkh, err = txscript.PayToAddrScript(pkh); err != nil {
+		t.Fatal(err)
+	}
+	return p2wpkh, p2pkh
+}
+
+// testUTXO 构造第 n 个测试UTXO，height 为0表示未确认
+func testUTXO(n int, amount int64, script []byte, height int64) types.UTXO {
+	return types.UTXO{
+		TxID:         fmt.Sprintf("%064x", n),
+		Amount:       amount,
+		ScriptPubKey: hex.EncodeToString(script),
+		Height:       height,
+	}
+}
+
+func TestSelectCoins(t *testing.T) {
+	key, err := btcec.NewPrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	wpkh, pkh := testKeyScripts(t, key.PubKey())
+	p2sh := append([]byte{txscript.OP_HASH160, txscript.OP_DATA_20}, append(make([]byte, 20), txscript.OP_EQUAL)...)
+
+	// 收款和找零都是 P2WPKH：基础大小 11+9+22=42，找零输出 9+22=31，费率 1 sat/vB
+	const (
+		amount     = 20000
+		baseVSize  = 42
+		changeSize = 31
+	)
+
+	tests := []struct {
+		name       string
+		utxos      []types.UTXO
+		amount     int64
+		wantInputs []int // 按选中顺序的UTXO编号
+		wantFee    int64
+		wantChange int64
+		wantErr    string
+	}{
+		{
+			name:       "largest first",
+			utxos:      []types.UTXO{testUTXO(1, 10000, wpkh, 100), testUTXO(2, 50000, wpkh, 100), testUTXO(3, 30000, wpkh, 100)},
+			amount:     amount,
+			wantInputs: []int{2},
+			wantFee:    baseVSize + p2wpkhInputVSize + changeSize,
+			wantChange: 50000 - amount - (baseVSize + p2wpkhInputVSize + changeSize),
+		},
+		{
+			name:       "accumulates until covered",
+			utxos:      []types.UTXO{testUTXO(1, 10000, wpkh, 100), testUTXO(2, 15000, wpkh, 100)},
+			amount:     amount,
+			wantInputs: []int{2, 1},
+			wantFee:    baseVSize + 2*p2wpkhInputVSize + changeSize,
+			wantChange: 25000 - amount - (baseVSize + 2*p2wpkhInputVSize + changeSize),
+		},
+		{
+			name:       "confirmed before unconfirmed",
+			utxos:      []types.UTXO{testUTXO(1, 100000, wpkh, 0), testUTXO(2, 30000, wpkh, 100)},
+			amount:     amount,
+			wantInputs: []int{2},
+			wantFee:    baseVSize + p2wpkhInputVSize + changeSize,
+			wantChange: 30000 - amount - (baseVSize + p2wpkhInputVSize + changeSize),
+		},
+		{
+			name:       "p2pkh input size",
+			utxos:      []types.UTXO{testUTXO(1, 30000, pkh, 100)},
+			amount:     amount,
+			wantInputs: []int{1},
+			wantFee:    baseVSize + p2pkhInputVSize + changeSize,
+			wantChange: 30000 - amount - (baseVSize + p2pkhInputVSize + changeSize),
+		},
+		{
+			name:       "change exactly at dust threshold",
+			utxos:      []types.UTXO{testUTXO(1, amount+baseVSize+p2wpkhInputVSize+changeSize+dustThreshold, wpkh, 100)},
+			amount:     amount,
+			wantInputs: []int{1},
+			wantFee:    baseVSize + p2wpkhInputVSize + changeSize,
+			wantChange: dustThreshold,
+		},
+		{
+			name:       "dust change goes to fee",
+			utxos:      []types.UTXO{testUTXO(1, amount+baseVSize+p2wpkhInputVSize+changeSize+dustThreshold-1, wpkh, 100)},
+			amount:     amount,
+			wantInputs: []int{1},
+			wantFee:    baseVSize + p2wpkhInputVSize + changeSize + dustThreshold - 1,
+			wantChange: 0,
+		},
+		{
+			name:       "unsupported scripts are skipped",
+			utxos:      []types.UTXO{testUTXO(1, 1000000, p2sh, 100), testUTXO(2, 30000, wpkh, 100)},
+			amount:     amount,
+			wantInputs: []int{2},
+			wantFee:    baseVSize + p2wpkhInputVSize + changeSize,
+			wantChange: 30000 - amount - (baseVSize + p2wpkhInputVSize + changeSize),
+		},
+		{
+			name:    "amount below dust",
+			utxos:   []types.UTXO{testUTXO(1, 30000, wpkh, 100)},
+			amount:  dustThreshold - 1,
+			wantErr: "below dust threshold",
+		},
+		{
+			name:    "insufficient funds",
+			utxos:   []types.UTXO{testUTXO(1, amount, wpkh, 100)},
+			amount:  amount,
+			wantErr: "insufficient funds",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			selection, err := selectCoins(tt.utxos, tt.amount, 1, wpkh, wpkh)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("selectCoins() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("selectCoins() error = %v", err)
+			}
+
+			var got []int
+			for _, u := range selection.inputs {
+				var n int
+				fmt.Sscanf(u.TxID, "%x", &n)
+				got = append(got, n)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.wantInputs) {
+				t.Errorf("inputs = %v, want %v", got, tt.wantInputs)
+			}
+			if selection.fee != tt.wantFee {
+				t.Errorf("fee = %d, want %d", selection.fee, tt.wantFee)
+			}
+			if selection.change != tt.wantChange {
+				t.Errorf("change = %d, want %d", selection.change, tt.wantChange)
+			}
+
+			var total int64
+			for _, u := range selection.inputs {
+				total += u.Amount
+			}
+			if total != tt.amount+selection.fee+selection.change {
+				t.Errorf("inputs %d != amount %d + fee %d + change %d", total, tt.amount, selection.fee, selection.change)
+			}
+		})
+	}
+}
+
+// newTestBitcoinClient 创建连接到测试bitcoind的regtest客户端
+func newTestBitcoinClient(t *testing.T, url string) *BitcoinClient {
+	t.Helper()
+	client, err := NewBitcoinClient(config.ChainConfig{Name: "bitcoin", NetworkName: "regtest", RPCURL: url, RequestTimeout: time.Second}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client.(*BitcoinClient)
+}
+
+func TestSelectAndLockExcludesLockedAndSpent(t *testing.T) {
+	key, err := btcec.NewPrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	wpkh, _ := testKeyScripts(t, key.PubKey())
+	c := newTestBitcoinClient(t, "http://127.0.0.1:0")
+
+	locked, spent, free := testUTXO(1, 90000, wpkh, 100), testUTXO(2, 80000, wpkh, 100), testUTXO(3, 30000, wpkh, 100)
+	lockedOutPoint, _ := parseOutPoint(locked.TxID, locked.Vout)
+	spentOutPoint, _ := parseOutPoint(spent.TxID, spent.Vout)
+	c.locked[*lockedOutPoint] = time.Now()
+	c.spent[*spentOutPoint] = "bcrt1qtest"
+
+	utxos := []types.UTXO{locked, spent, free}
+	selection, err := c.selectAndLock(utxos, 20000, 1, wpkh, wpkh)
+	if err != nil {
+		t.Fatalf("selectAndLock() error = %v", err)
+	}
+	if len(selection.inputs) != 1 || selection.inputs[0].TxID != free.TxID {
+		t.Fatalf("selected %v, want only the free utxo", selection.inputs)
+	}
+
+	// 选中的UTXO被占用，并发的第二次构建不能再选到它
+	if _, err := c.selectAndLock(utxos, 20000, 1, wpkh, wpkh); err == nil || !strings.Contains(err.Error(), "insufficient funds") {
+		t.Fatalf("second selectAndLock() error = %v, want insufficient funds", err)
+	}
+
+	c.unlockInputs(selection.inputs)
+	if _, err := c.selectAndLock(utxos, 20000, 1, wpkh, wpkh); err != nil {
+		t.Fatalf("selectAndLock() after unlock error = %v", err)
+	}
+}
+
+// fakeBitcoind 记录 sendrawtransaction 收到的交易
+type fakeBitcoind struct {
+	mu        sync.Mutex
+	broadcast []string
+}
+
+func newFakeBitcoind(t *testing.T) (*fakeBitcoind, string) {
+	t.Helper()
+	b := &fakeBitcoind{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req bitcoinRPCRequest
+		json.NewDecoder(r.Body).Decode(&req)
+
+		resp := map[string]interface{}{"id": req.ID, "error": nil}
+		switch req.Method {
+		case "sendrawtransaction":
+			rawHex := req.Params[0].(string)
+			b.mu.Lock()
+			b.broadcast = append(b.broadcast, rawHex)
+			b.mu.Unlock()
+
+			var tx wire.MsgTx
+			raw, _ := hex.DecodeString(rawHex)
+			tx.Deserialize(bytes.NewReader(raw))
+			resp["result"] = tx.TxHash().String()
+		default:
+			resp["error"] = bitcoinRPCError{Code: -32601, Message: "Method not found"}
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(srv.Close)
+	return b, srv.URL
+}
+
+// testPSBT 构建花费一个 P2WPKH 输入和一个 P2PKH 输入的PSBT，返回每个输入的前序输出
+func testPSBT(t *testing.T, pubKey *btcec.PublicKey) (*psbt.Packet, []*wire.TxOut) {
+	t.Helper()
+	wpkh, pkh := testKeyScripts(t, pubKey)
+
+	// P2PKH 输入需要完整的前序交易
+	prevTx := wire.NewMsgTx(2)
+	prevTx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{1}, 0), nil, nil))
+	prevTx.AddTxOut(wire.NewTxOut(40000, pkh))
+	prevHash := prevTx.TxHash()
+
+	prevOuts := []*wire.TxOut{wire.NewTxOut(60000, wpkh), prevTx.TxOut[0]}
+	outPoints := []*wire.OutPoint{wire.NewOutPoint(&chainhash.Hash{2}, 1), wire.NewOutPoint(&prevHash, 0)}
+	outputs := []*wire.TxOut{wire.NewTxOut(90000, wpkh), wire.NewTxOut(9000, wpkh)}
+
+	packet, err := psbt.New(outPoints, outputs, 2, 0, []uint32{rbfSequence, rbfSequence})
+	if err != nil {
+		t.Fatal(err)
+	}
+	packet.Inputs[0].WitnessUtxo = prevOuts[0]
+	packet.Inputs[0].SighashType = txscript.SigHashAll
+	packet.Inputs[1].NonWitnessUtxo = prevTx
+	packet.Inputs[1].SighashType = txscript.SigHashAll
+	return packet, prevOuts
+}
+
+// signTestPSBT 签名PSBT的全部输入：第一个输入用MPC输出的 r||s||v 格式，其余用DER格式
+func signTestPSBT(t *testing.T, packet *psbt.Packet, key *btcec.PrivateKey) []types.PSBTSignature {
+	t.Helper()
+	sighashes, err := computeSighashes(packet)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sigs := make([]types.PSBTSignature, len(sighashes))
+	for i, h := range sighashes {
+		hash, _ := hex.DecodeString(h.Sighash)
+		var sig []byte
+		if i == 0 {
+			if sig, err = crypto.Sign(hash, key.ToECDSA()); err != nil {
+				t.Fatal(err)
+			}
+		} else {
+			sig = ecdsa.Sign(key, hash).Serialize()
+		}
+		sigs[i] = types.PSBTSignature{InputIndex: h.InputIndex, Signature: hex.EncodeToString(sig)}
+	}
+	return sigs
+}
+
+func TestFinalizePSBT(t *testing.T) {
+	key, err := btcec.NewPrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := btcec.NewPrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubKeyHex := hex.EncodeToString(key.PubKey().SerializeCompressed())
+
+	tests := []struct {
+		name    string
+		pubKey  string
+		signer  *btcec.PrivateKey
+		mutate  func([]types.PSBTSignature) []types.PSBTSignature
+		wantErr string
+	}{
+		{name: "p2wpkh and p2pkh", pubKey: pubKeyHex, signer: key},
+		{name: "signature from another key", pubKey: pubKeyHex, signer: other, wantErr: "signature verification failed"},
+		{name: "public key does not own inputs", pubKey: hex.EncodeToString(other.PubKey().SerializeCompressed()), signer: other, wantErr: "does not own input"},
+		{
+			name: "missing signature", pubKey: pubKeyHex, signer: key,
+			mutate:  func(sigs []types.PSBTSignature) []types.PSBTSignature { return sigs[:1] },
+			wantErr: "expected 2 signatures",
+		},
+		{
+			name: "input index out of range", pubKey: pubKeyHex, signer: key,
+			mutate: func(sigs []types.PSBTSignature) []types.PSBTSignature {
+				sigs[1].InputIndex = 5
+				return sigs
+			},
+			wantErr: "out of range",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node, url := newFakeBitcoind(t)
+			c := newTestBitcoinClient(t, url)
+
+			packet, prevOuts := testPSBT(t, key.PubKey())
+			sigs := signTestPSBT(t, packet, tt.signer)
+			if tt.mutate != nil {
+				sigs = tt.mutate(sigs)
+			}
+			encoded, err := packet.B64Encode()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			txID, err := c.FinalizePSBT(context.Background(), &types.PSBTFinalizeRequest{PSBT: encoded, PubKey: tt.pubKey, Signatures: sigs})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("FinalizePSBT() error = %v, want %q", err, tt.wantErr)
+				}
+				if len(node.broadcast) != 0 {
+					t.Fatalf("rejected psbt was broadcast")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("FinalizePSBT() error = %v", err)
+			}
+			if len(node.broadcast) != 1 {
+				t.Fatalf("broadcast %d transactions, want 1", len(node.broadcast))
+			}
+
+			raw, _ := hex.DecodeString(node.broadcast[0])
+			var tx wire.MsgTx
+			if err := tx.Deserialize(bytes.NewReader(raw)); err != nil {
+				t.Fatal(err)
+			}
+			if tx.TxHash().String() != txID {
+				t.Errorf("txid = %s, want %s", txID, tx.TxHash())
+			}
+
+			// 用脚本引擎验证每个输入的签名
+			fetcher := txscript.NewMultiPrevOutFetcher(nil)
+			for i, in := range tx.TxIn {
+				fetcher.AddPrevOut(in.PreviousOutPoint, prevOuts[i])
+			}
+			sigHashes := txscript.NewTxSigHashes(&tx, fetcher)
+			for i, prevOut := range prevOuts {
+				vm, err := txscript.NewEngine(prevOut.PkScript, &tx, i, txscript.StandardVerifyFlags, nil, sigHashes, prevOut.Value, fetcher)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := vm.Execute(); err != nil {
+					t.Errorf("input %d does not verify: %v", i, err)
+				}
+			}
+
+			// 已广播的输入记为已花费，不会再被选币
+			for _, in := range tx.TxIn {
+				if _, ok := c.spent[in.PreviousOutPoint]; !ok {
+					t.Errorf("input %s not marked spent", in.PreviousOutPoint)
+				}
+			}
+		})
+	}
+}
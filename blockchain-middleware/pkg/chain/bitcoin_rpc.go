@@ -0,0 +1,173 @@
+package chain
+
+import (
+	"blockchain-middleware/pkg/telemetry"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+)
+
+// bitcoinRPC bitcoind JSON-RPC客户端
+type bitcoinRPC struct {
+	chainName  string
+	mu         sync.RWMutex // 保护连接参数，配置热加载时替换
+	url        string
+	user       string
+	password   string
+	timeout    time.Duration
+	limiter    *rateLimiter
+	httpClient *http.Client
+	nextID     uint64
+}
+
+// bitcoinRPCRequest JSON-RPC请求
+type bitcoinRPCRequest struct {
+	JSONRPC string        `json:"jsonrpc"`
+	ID      uint64        `json:"id"`
+	Method  string        `json:"method"`
+	Params  []interface{} `json:"params"`
+}
+
+// bitcoinRPCResponse JSON-RPC响应
+type bitcoinRPCResponse struct {
+	Result json.RawMessage  `json:"result"`
+	Error  *bitcoinRPCError `json:"error"`
+	ID     uint64           `json:"id"`
+}
+
+// bitcoinRPCError JSON-RPC错误
+type bitcoinRPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// bitcoindErrNotFound 查询的交易或区块不存在（RPC_INVALID_ADDRESS_OR_KEY）
+const bitcoindErrNotFound = -5
+
+func (e *bitcoinRPCError) Error() string {
+	return fmt.Sprintf("bitcoind error %d: %s", e.Code, e.Message)
+}
+
+// newBitcoinRPC 创建bitcoind RPC客户端
+// chainName 用于指标和span；timeout 为单次请求超时，0表示默认值（scantxoutset 在主网上较慢，需要留足时间）；rateLimit 为每秒请求上限，0表示不限流。
+func newBitcoinRPC(chainName, url, user, password string, timeout time.Duration, rateLimit float64) *bitcoinRPC {
+	r := &bitcoinRPC{
+		chainName:  chainName,
+		limiter:    newRateLimiter(rateLimit),
+		httpClient: &http.Client{},
+	}
+	r.reconfigure(url, user, password, timeout, rateLimit)
+	return r
+}
+
+// reconfigure 替换节点地址、认证信息、超时和限流，之后的请求生效
+func (r *bitcoinRPC) reconfigure(url, user, password string, timeout time.Duration, rateLimit float64) {
+	if timeout <= 0 {
+		timeout = 60 * time.Second
+	}
+
+	r.mu.Lock()
+	r.url = url
+	r.user = user
+	r.password = password
+	r.timeout = timeout
+	r.mu.Unlock()
+
+	r.limiter.setRate(rateLimit)
+}
+
+// call 调用RPC方法，并将结果解码到result中（result可为nil）
+func (r *bitcoinRPC) call(ctx context.Context, method string, params []interface{}, result interface{}) (err error) {
+	ctx, span := telemetry.StartRPCSpan(ctx, r.chainName, method)
+	defer func() { telemetry.EndSpan(span, err) }()
+
+	if params == nil {
+		params = []interface{}{}
+	}
+
+	body, err := json.Marshal(bitcoinRPCRequest{
+		JSONRPC: "1.0",
+		ID:      atomic.AddUint64(&r.nextID, 1),
+		Method:  method,
+		Params:  params,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode %s request: %w", method, err)
+	}
+
+	if err := r.limiter.wait(ctx); err != nil {
+		return err
+	}
+	r.mu.RLock()
+	endpoint, user, password, timeout := r.url, r.user, r.password, r.timeout
+	r.mu.RUnlock()
+	span.SetAttributes(semconv.ServerAddress(redactURL(endpoint)))
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	defer func() { telemetry.ObserveRPC(r.chainName, method, bitcoinRPCResult(err), time.Since(start)) }()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create %s request: %w", method, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if user != "" {
+		req.SetBasicAuth(user, password)
+	}
+
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call %s: %w", method, err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read %s response: %w", method, err)
+	}
+
+	// bitcoind 出错时返回 500 状态码，但响应体仍是JSON-RPC格式
+	var rpcResp bitcoinRPCResponse
+	if err := json.Unmarshal(respBody, &rpcResp); err != nil {
+		return fmt.Errorf("bitcoind %s: unexpected response (%s): %s", method, resp.Status, string(respBody))
+	}
+	if rpcResp.Error != nil {
+		return rpcResp.Error
+	}
+
+	if result == nil {
+		return nil
+	}
+	if err := json.Unmarshal(rpcResp.Result, result); err != nil {
+		return fmt.Errorf("failed to decode %s result: %w", method, err)
+	}
+	return nil
+}
+
+// bitcoinRPCResult 按错误类型给出指标中的调用结果
+// bitcoind 返回的JSON-RPC错误属于业务错误（查询对象不存在视为成功），其余错误是请求本身失败。
+func bitcoinRPCResult(err error) string {
+	if err == nil {
+		return telemetry.RPCSuccess
+	}
+	var rpcErr *bitcoinRPCError
+	if errors.As(err, &rpcErr) {
+		if rpcErr.Code == bitcoindErrNotFound {
+			return telemetry.RPCSuccess
+		}
+		return telemetry.RPCError
+	}
+	return telemetry.RPCFailure
+}
@@ -2,10 +2,12 @@ package chain
 
 import (
 	"blockchain-middleware/internal/config"
+	"blockchain-middleware/pkg/checkpoint"
 	"context"
 	"fmt"
 	"log"
 	"math/big"
+	"sync/atomic"
 	"time"
 
 	"github.com/ethereum/go-ethereum"
@@ -19,14 +21,49 @@ type EventHandler interface {
 	HandleEvent(log ethtypes.Log) error
 }
 
+// EventHandlerFunc 函数形式的事件处理器
+type EventHandlerFunc func(log ethtypes.Log) error
+
+// HandleEvent 处理事件
+func (f EventHandlerFunc) HandleEvent(log ethtypes.Log) error {
+	return f(log)
+}
+
+// logFilterer 事件监听所需的节点接口，*ethclient.Client 与节点池适配器均实现
+type logFilterer interface {
+	BlockNumber(ctx context.Context) (uint64, error)
+	HeaderByNumber(ctx context.Context, number *big.Int) (*ethtypes.Header, error)
+	FilterLogs(ctx context.Context, q ethereum.FilterQuery) ([]ethtypes.Log, error)
+}
+
+// checkpointTimeout 读写检查点的超时
+const checkpointTimeout = 10 * time.Second
+
 // EventWatcher 事件监听器
 type EventWatcher struct {
-	client      *ethclient.Client
-	config      config.ChainConfig
-	handlers    map[common.Hash]EventHandler
-	running     bool
-	stopChan    chan struct{}
-	lastBlock   uint64
+	client     logFilterer
+	conn       *ethclient.Client // NewEventWatcher 自建的连接，Close 时关闭
+	config     config.ChainConfig
+	handlers   map[common.Hash]EventHandler
+	logHandler EventHandler // 接收没有专用处理器的日志
+	addresses  []common.Address
+	topics     [][]common.Hash
+	fromBlock  uint64 // 起始区块，0表示从启动时的最新区块之后开始
+	toBlock    uint64 // 结束区块，0表示持续监听
+
+	confirmations uint64           // 处理落后最新区块的深度
+	checkpoints   checkpoint.Store // 为nil时不持久化进度
+	watcherID     string
+
+	running   atomic.Bool
+	ctx       context.Context // Stop 时取消，中断进行中的节点请求
+	cancel    context.CancelFunc
+	doneChan  chan struct{}
+	lastBlock uint64
+	recent    blockWindow // 最近已处理的区块，用于检测链重组
+
+	ranges              logRange // 补齐历史区块时的查询跨度
+	backfillConcurrency int
 }
 
 // NewEventWatcher 创建新的事件监听器
@@ -36,12 +73,24 @@ func NewEventWatcher(config config.ChainConfig) (*EventWatcher, error) {
 		return nil, fmt.Errorf("failed to connect to node: %w", err)
 	}
 
+	w := newEventWatcher(config, client)
+	w.conn = client
+	return w, nil
+}
+
+// newEventWatcher 使用已有的节点连接创建事件监听器
+func newEventWatcher(config config.ChainConfig, client logFilterer) *EventWatcher {
+	ctx, cancel := context.WithCancel(context.Background())
 	return &EventWatcher{
-		client:   client,
-		config:   config,
-		handlers: make(map[common.Hash]EventHandler),
-		stopChan: make(chan struct{}),
-	}, nil
+		client:              client,
+		config:              config,
+		handlers:            make(map[common.Hash]EventHandler),
+		confirmations:       config.EventConfirmations,
+		backfillConcurrency: 1,
+		ctx:                 ctx,
+		cancel:              cancel,
+		doneChan:            make(chan struct{}),
+	}
 }
 
 // RegisterEventHandler 注册事件处理器
@@ -49,102 +98,250 @@ func (w *EventWatcher) RegisterEventHandler(eventSig common.Hash, handler EventH
 	w.handlers[eventSig] = handler
 }
 
+// RegisterLogHandler 注册默认处理器，接收所有没有专用处理器的日志
+func (w *EventWatcher) RegisterLogHandler(handler EventHandler) {
+	w.logHandler = handler
+}
+
+// SetFilter 设置合约地址和主题过滤条件，为空表示不过滤
+func (w *EventWatcher) SetFilter(addresses []common.Address, topics [][]common.Hash) {
+	w.addresses = addresses
+	w.topics = topics
+}
+
+// SetBlockRange 设置监听的区块范围，toBlock 为0表示持续监听
+func (w *EventWatcher) SetBlockRange(fromBlock, toBlock uint64) {
+	w.fromBlock = fromBlock
+	w.toBlock = toBlock
+}
+
+// SetConfirmations 设置确认深度，只处理落后最新区块 depth 个区块以上的区块
+func (w *EventWatcher) SetConfirmations(depth uint64) {
+	w.confirmations = depth
+}
+
+// SetCheckpointStore 设置检查点存储，每处理完一个区块保存进度，重启后从检查点继续
+// watcherID 区分同一条链上的多个监听器。
+func (w *EventWatcher) SetCheckpointStore(store checkpoint.Store, watcherID string) {
+	w.checkpoints = store
+	w.watcherID = watcherID
+}
+
 // Start 开始监听事件
+// 起始位置依次取检查点、SetBlockRange 的起始区块、当前已确认的最新区块。
 func (w *EventWatcher) Start() error {
-	if w.running {
+	if !w.running.CompareAndSwap(false, true) {
 		return fmt.Errorf("event watcher already running")
 	}
 
-	// 获取当前区块号
-	blockNumber, err := w.client.BlockNumber(context.Background())
+	lastBlock, err := w.startBlock(w.ctx)
 	if err != nil {
-		return fmt.Errorf("failed to get block number: %w", err)
+		w.running.Store(false)
+		return err
 	}
-	w.lastBlock = blockNumber
+	w.lastBlock = lastBlock
 
-	w.running = true
-	go w.watchLoop()
+	go w.watchLoop(w.ctx)
 	return nil
 }
 
-// Stop 停止监听事件
+// startBlock 计算启动时视为已处理的最后一个区块
+func (w *EventWatcher) startBlock(ctx context.Context) (uint64, error) {
+	if w.checkpoints != nil {
+		ctx, cancel := context.WithTimeout(ctx, checkpointTimeout)
+		defer cancel()
+
+		// 检查点读取失败时不能退回最新区块，否则会跳过停机期间的事件
+		block, found, err := w.checkpoints.Load(ctx, w.config.Name, w.watcherID)
+		if err != nil {
+			return 0, err
+		}
+		if found {
+			return block, nil
+		}
+	}
+
+	if w.fromBlock > 0 {
+		return w.fromBlock - 1, nil
+	}
+
+	// 获取当前区块号
+	blockNumber, err := w.client.BlockNumber(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get block number: %w", err)
+	}
+	if blockNumber < w.confirmations {
+		return 0, nil
+	}
+	return blockNumber - w.confirmations, nil
+}
+
+// Stop 停止监听事件，进行中的节点请求随之取消
 func (w *EventWatcher) Stop() {
-	if !w.running {
-		return
+	if w.running.CompareAndSwap(true, false) {
+		w.cancel()
 	}
+}
 
-	w.running = false
-	close(w.stopChan)
+// Done 监听结束（到达结束区块或被停止）时关闭
+func (w *EventWatcher) Done() <-chan struct{} {
+	return w.doneChan
 }
 
 // watchLoop 事件监听循环
-func (w *EventWatcher) watchLoop() {
+func (w *EventWatcher) watchLoop(ctx context.Context) {
+	defer close(w.doneChan)
+
 	ticker := time.NewTicker(5 * time.Second)
 	defer ticker.Stop()
 
+	// 启动时立即补齐起始区块之后的事件
+	if w.checkNewEvents(ctx) {
+		return
+	}
+
 	for {
 		select {
-		case <-w.stopChan:
+		case <-ctx.Done():
 			return
 		case <-ticker.C:
-			w.checkNewEvents()
+			if w.checkNewEvents(ctx) {
+				return
+			}
 		}
 	}
 }
 
-// checkNewEvents 检查新事件
-func (w *EventWatcher) checkNewEvents() {
-	// 获取最新区块号
-	currentBlock, err := w.client.BlockNumber(context.Background())
+// checkNewEvents 检查新事件，已处理到结束区块时返回true
+// 落后最新区块超过重组窗口的区块按范围批量查询，窗口内的区块逐个处理并校验父哈希。
+// 区块处理失败时停在该区块，下个周期重试，不会跳过；
+// 检测到链重组时先回撤被孤立区块的事件，再从分叉点继续处理新的主链；监听停止时返回true。
+func (w *EventWatcher) checkNewEvents(ctx context.Context) bool {
+	// 获取最新区块号，只处理达到确认深度的区块
+	headBlock, err := w.client.BlockNumber(ctx)
+	if ctx.Err() != nil {
+		return true
+	}
 	if err != nil {
 		log.Printf("Failed to get block number: %v", err)
-		return
+		return false
+	}
+	if headBlock < w.confirmations {
+		return false
+	}
+	currentBlock := headBlock - w.confirmations
+	if w.toBlock > 0 && currentBlock > w.toBlock {
+		currentBlock = w.toBlock
 	}
 
 	// 如果没有新块，跳过
 	if currentBlock <= w.lastBlock {
-		return
+		return w.finished()
 	}
 
-	// 查询新块中的事件
-	for blockNum := w.lastBlock + 1; blockNum <= currentBlock; blockNum++ {
-		err := w.processBlockEvents(blockNum)
+	// 超出重组窗口、可以批量查询的最后一个区块
+	var rangeEnd uint64
+	if headBlock > reorgWindowSize {
+		rangeEnd = headBlock - reorgWindowSize
+	}
+	if rangeEnd > currentBlock {
+		rangeEnd = currentBlock
+	}
+
+	// 查询新块中的事件，重组回撤后 lastBlock 会回退到分叉点
+	for w.lastBlock < currentBlock {
+		if ctx.Err() != nil {
+			return true
+		}
+
+		blockNum := w.lastBlock + 1
+		var err error
+		if blockNum <= rangeEnd {
+			err = w.processRanges(ctx, blockNum, rangeEnd)
+		} else {
+			err = w.processBlockEvents(ctx, blockNum)
+		}
+		if ctx.Err() != nil {
+			return true
+		}
 		if err != nil {
-			log.Printf("Failed to process block %d: %v", blockNum, err)
-			// 继续处理下一个块
+			log.Printf("Failed to process block %d on %s, will retry: %v", w.lastBlock+1, w.config.Name, err)
+			return false
 		}
 	}
 
-	w.lastBlock = currentBlock
+	return w.finished()
+}
+
+// saveCheckpoint 保存处理进度，失败时只记录日志：重启后从较早的检查点重新处理，事件可能重复但不会丢失
+func (w *EventWatcher) saveCheckpoint(block uint64) {
+	if w.checkpoints == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), checkpointTimeout)
+	defer cancel()
+
+	if err := w.checkpoints.Save(ctx, w.config.Name, w.watcherID, block); err != nil {
+		log.Printf("Failed to save checkpoint for %s/%s at block %d: %v", w.config.Name, w.watcherID, block, err)
+	}
+}
+
+// finished 是否已处理到结束区块
+func (w *EventWatcher) finished() bool {
+	return w.toBlock > 0 && w.lastBlock >= w.toBlock
 }
 
-// processBlockEvents 处理区块中的事件
-func (w *EventWatcher) processBlockEvents(blockNumber uint64) error {
-	// 构建过滤器查询
-	query := ethereum.FilterQuery{
-		FromBlock: big.NewInt(int64(blockNumber)),
-		ToBlock:   big.NewInt(int64(blockNumber)),
-		Addresses: []common.Address{}, // 为空表示监听所有合约
-		Topics:    [][]common.Hash{},
+// processBlockEvents 处理区块中的事件，成功后推进 lastBlock
+// 区块的父哈希与已处理的上一个区块不一致时改为回撤重组的区块。
+func (w *EventWatcher) processBlockEvents(ctx context.Context, blockNumber uint64) error {
+	header, err := w.client.HeaderByNumber(ctx, new(big.Int).SetUint64(blockNumber))
+	if err != nil {
+		return fmt.Errorf("failed to get block header: %w", err)
+	}
+	if parent, ok := w.recent.get(blockNumber - 1); ok && header.ParentHash != parent.hash {
+		return w.rollback(ctx)
 	}
 
-	logs, err := w.client.FilterLogs(context.Background(), query)
+	// 按区块哈希查询，保证日志与记录的区块一致
+	blockHash := header.Hash()
+	query := w.logQuery()
+	query.BlockHash = &blockHash
+
+	logs, err := w.client.FilterLogs(ctx, query)
 	if err != nil {
 		return fmt.Errorf("failed to filter logs: %w", err)
 	}
 
-	// 处理每个事件
+	// 处理每个事件，处理器失败时整个区块重试，处理器需要保证幂等
 	for _, eventLog := range logs {
-		// 查找对应的事件处理器
-		if handler, ok := w.handlers[eventLog.Topics[0]]; ok {
-			err := handler.HandleEvent(eventLog)
-			if err != nil {
-				log.Printf("Failed to handle event: %v", err)
-				// 继续处理下一个事件
-			}
+		if err := w.dispatch(eventLog); err != nil {
+			return err
 		}
 	}
 
+	w.recent.push(blockRecord{number: blockNumber, hash: blockHash, logs: logs})
+	w.lastBlock = blockNumber
+	w.saveCheckpoint(blockNumber)
+	return nil
+}
+
+// dispatch 把日志交给对应的事件处理器
+func (w *EventWatcher) dispatch(eventLog ethtypes.Log) error {
+	// 查找对应的事件处理器，匿名事件没有主题
+	handler := w.logHandler
+	if len(eventLog.Topics) > 0 {
+		if h, ok := w.handlers[eventLog.Topics[0]]; ok {
+			handler = h
+		}
+	}
+	if handler == nil {
+		return nil
+	}
+
+	if err := handler.HandleEvent(eventLog); err != nil {
+		return fmt.Errorf("failed to handle event in tx %s: %w", eventLog.TxHash.Hex(), err)
+	}
 	return nil
 }
 
@@ -156,13 +353,14 @@ func (w *EventWatcher) GetLastBlock() uint64 {
 // SetLastBlock 设置最后处理的区块号
 func (w *EventWatcher) SetLastBlock(blockNumber uint64) {
 	w.lastBlock = blockNumber
+	w.recent.reset()
 }
 
 // Close 关闭事件监听器
 func (w *EventWatcher) Close() error {
 	w.Stop()
-	if w.client != nil {
-		w.client.Close()
+	if w.conn != nil {
+		w.conn.Close()
 	}
 	return nil
-}
\ No newline at end of file
+}
@@ -0,0 +1,224 @@
+package chain
+
+import (
+	"blockchain-middleware/internal/config"
+	"context"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+)
+
+// fakeChain 内存中的链，实现 logFilterer，可以替换区块模拟重组
+type fakeChain struct {
+	mu      sync.Mutex
+	headers []*ethtypes.Header // 按区块号索引的主链
+	logs    map[common.Hash][]ethtypes.Log
+	block   chan struct{} // 非nil时 BlockNumber 阻塞到该通道关闭或 ctx 取消
+}
+
+// newFakeChain 创建包含 0..head 区块的链
+func newFakeChain(head uint64) *fakeChain {
+	c := &fakeChain{logs: make(map[common.Hash][]ethtypes.Log)}
+	for n := uint64(0); n <= head; n++ {
+		c.setBlock(n, 0)
+	}
+	return c
+}
+
+// setBlock 用分叉编号 fork 的区块替换主链上的区块 n，并清空它的日志
+// 替换后的区块哈希不同；后续区块需要一并替换才能保持父哈希连续。
+func (c *fakeChain) setBlock(n uint64, fork byte) common.Hash {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	header := &ethtypes.Header{Number: new(big.Int).SetUint64(n), Difficulty: big.NewInt(1), Extra: []byte{fork}}
+	if n > 0 {
+		header.ParentHash = c.headers[n-1].Hash()
+	}
+	if n < uint64(len(c.headers)) {
+		c.headers[n] = header
+	} else {
+		c.headers = append(c.headers, header)
+	}
+	return header.Hash()
+}
+
+// addLog 在主链区块 n 中添加一条日志
+func (c *fakeChain) addLog(n uint64, index uint) ethtypes.Log {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	hash := c.headers[n].Hash()
+	l := ethtypes.Log{
+		Address:     common.HexToAddress(testTo),
+		Topics:      []common.Hash{{0xdd}},
+		BlockNumber: n,
+		BlockHash:   hash,
+		TxHash:      common.BigToHash(new(big.Int).SetUint64(n<<8 | uint64(index))),
+		Index:       index,
+	}
+	c.logs[hash] = append(c.logs[hash], l)
+	return l
+}
+
+func (c *fakeChain) BlockNumber(ctx context.Context) (uint64, error) {
+	c.mu.Lock()
+	block := c.block
+	head := uint64(len(c.headers) - 1)
+	c.mu.Unlock()
+
+	if block != nil {
+		select {
+		case <-block:
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
+	}
+	return head, ctx.Err()
+}
+
+func (c *fakeChain) HeaderByNumber(ctx context.Context, number *big.Int) (*ethtypes.Header, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if number == nil {
+		return c.headers[len(c.headers)-1], nil
+	}
+	if number.Uint64() >= uint64(len(c.headers)) {
+		return nil, ethereum.NotFound
+	}
+	return c.headers[number.Uint64()], nil
+}
+
+func (c *fakeChain) FilterLogs(ctx context.Context, q ethereum.FilterQuery) ([]ethtypes.Log, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if q.BlockHash != nil {
+		return append([]ethtypes.Log(nil), c.logs[*q.BlockHash]...), nil
+	}
+	var result []ethtypes.Log
+	for n := q.FromBlock.Uint64(); n <= q.ToBlock.Uint64() && n < uint64(len(c.headers)); n++ {
+		result = append(result, c.logs[c.headers[n].Hash()]...)
+	}
+	return result, nil
+}
+
+// logRecorder 记录处理器收到的日志
+type logRecorder struct {
+	mu   sync.Mutex
+	logs []ethtypes.Log
+}
+
+func (c *logRecorder) HandleEvent(l ethtypes.Log) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.logs = append(c.logs, l)
+	return nil
+}
+
+func TestEventWatcherStartStopRace(t *testing.T) {
+	chain := newFakeChain(10)
+	for i := 0; i < 50; i++ {
+		w := newEventWatcher(config.ChainConfig{Name: "test"}, chain)
+
+		var (
+			wg       sync.WaitGroup
+			startErr error
+		)
+		wg.Add(3)
+		go func() {
+			defer wg.Done()
+			startErr = w.Start()
+		}()
+		go func() {
+			defer wg.Done()
+			w.Stop()
+		}()
+		go func() {
+			defer wg.Done()
+			w.Stop()
+		}()
+		wg.Wait()
+		w.Stop()
+
+		if startErr != nil {
+			continue
+		}
+		select {
+		case <-w.Done():
+		case <-time.After(5 * time.Second):
+			t.Fatalf("watcher did not stop")
+		}
+	}
+}
+
+func TestEventWatcherStartTwice(t *testing.T) {
+	w := newEventWatcher(config.ChainConfig{Name: "test"}, newFakeChain(10))
+	if err := w.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer w.Stop()
+
+	if err := w.Start(); err == nil {
+		t.Fatal("second Start() succeeded")
+	}
+}
+
+func TestEventWatcherStopCancelsRPC(t *testing.T) {
+	chain := newFakeChain(10)
+	// 节点不再响应，监听循环阻塞在 BlockNumber 中，只有取消 ctx 才能返回
+	chain.block = make(chan struct{})
+	defer close(chain.block)
+
+	w := newEventWatcher(config.ChainConfig{Name: "test"}, chain)
+	// 指定起始区块，启动时不查询最新区块
+	w.SetBlockRange(1, 0)
+	if err := w.Start(); err != nil {
+		t.Fatal(err)
+	}
+	w.Stop()
+
+	select {
+	case <-w.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("Stop() did not cancel the in-flight block number request")
+	}
+}
+
+func TestEventWatcherDeliversRange(t *testing.T) {
+	chain := newFakeChain(10)
+	want := []ethtypes.Log{chain.addLog(2, 0), chain.addLog(2, 1), chain.addLog(7, 0)}
+	chain.addLog(9, 0) // 超出结束区块
+
+	w := newEventWatcher(config.ChainConfig{Name: "test"}, chain)
+	w.SetBlockRange(1, 8)
+	recorder := &logRecorder{}
+	w.RegisterLogHandler(recorder)
+	if err := w.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer w.Stop()
+
+	select {
+	case <-w.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("watcher did not finish at the end block")
+	}
+	if w.GetLastBlock() != 8 {
+		t.Errorf("last block = %d, want 8", w.GetLastBlock())
+	}
+	if len(recorder.logs) != len(want) {
+		t.Fatalf("got %d logs, want %d", len(recorder.logs), len(want))
+	}
+	for i := range want {
+		if recorder.logs[i].TxHash != want[i].TxHash {
+			t.Errorf("log %d = %s, want %s", i, recorder.logs[i].TxHash, want[i].TxHash)
+		}
+	}
+}
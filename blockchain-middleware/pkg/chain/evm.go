@@ -0,0 +1,652 @@
+package chain
+
+import (
+	"blockchain-middleware/internal/config"
+	"blockchain-middleware/pkg/nonce"
+	"blockchain-middleware/pkg/signer"
+	"blockchain-middleware/pkg/types"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// EVMClient 通用EVM链客户端，链参数全部来自配置
+type EVMClient struct {
+	config config.ChainConfig
+	pool   *ProviderPool
+	signer signer.Signer
+	nonces *nonce.Manager
+
+	tokenMu    sync.Mutex
+	tokenMetas map[common.Address]tokenMetadata // 代币符号和精度不会变化，查询一次后缓存
+	noMulti    bool                             // 链上未部署 Multicall3
+
+	nftStandards map[common.Address]string // 合约的NFT标准，检测一次后缓存，由 tokenMu 保护
+	nftRanges    logRange                  // NFT持有查询扫描日志的自适应跨度
+
+	transferRanges logRange // 转账索引扫描日志的自适应跨度
+
+	feeMu sync.Mutex
+	fees  *types.FeeEstimates // 最近一次手续费估算，同一区块内复用
+}
+
+// GetChainID 获取链ID
+func (c *EVMClient) GetChainID() int64 {
+	return c.config.ChainID
+}
+
+// GetNetworkName 获取网络名称
+func (c *EVMClient) GetNetworkName() string {
+	return c.config.NetworkName
+}
+
+// ProviderStatus 获取RPC节点池状态
+func (c *EVMClient) ProviderStatus() []types.ProviderStatus {
+	return c.pool.Status()
+}
+
+// Reconfigure 替换RPC节点池的节点列表、请求超时和限流
+func (c *EVMClient) Reconfigure(cfg config.ChainConfig) error {
+	return c.pool.Reconfigure(append([]string{cfg.RPCURL}, cfg.RPCURLs...), cfg.MaxBlockLag, cfg.RequestTimeout, cfg.RPCRateLimit)
+}
+
+// GetBalance 获取余额
+func (c *EVMClient) GetBalance(ctx context.Context, address string) (*big.Int, error) {
+	addr := common.HexToAddress(address)
+
+	var balance *big.Int
+	err := c.pool.Call(ctx, "eth_getBalance", func(ctx context.Context, client *ethclient.Client) error {
+		var err error
+		balance, err = client.BalanceAt(ctx, addr, nil)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get balance: %w", err)
+	}
+
+	return balance, nil
+}
+
+// GetNonce 获取Nonce
+func (c *EVMClient) GetNonce(ctx context.Context, address string) (uint64, error) {
+	addr := common.HexToAddress(address)
+
+	var nonce uint64
+	err := c.pool.Call(ctx, "eth_getTransactionCount", func(ctx context.Context, client *ethclient.Client) error {
+		var err error
+		nonce, err = client.PendingNonceAt(ctx, addr)
+		return err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to get nonce: %w", err)
+	}
+
+	return nonce, nil
+}
+
+// SendTransaction 发送交易，由签名器签名
+func (c *EVMClient) SendTransaction(ctx context.Context, req *types.TransactionRequest) (string, error) {
+	if c.signer == nil {
+		return "", fmt.Errorf("no signer configured for %s, submit signed transactions to /transactions/raw", c.config.Name)
+	}
+	return c.SendTransactionWithSigner(ctx, req, c.signer)
+}
+
+// SendTransactionWithSigner 发送交易，由调用方提供的签名器（如MPC会话）签名
+func (c *EVMClient) SendTransactionWithSigner(ctx context.Context, req *types.TransactionRequest, txSigner signer.Signer) (string, error) {
+	if err := validateTxRequest(c.config, req); err != nil {
+		return "", err
+	}
+
+	// 未指定nonce时由nonce管理器分配，确定没有广播出去时释放
+	if req.Nonce == nil {
+		n, err := c.ReserveNonce(ctx, req.From)
+		if err != nil {
+			return "", err
+		}
+		withNonce := *req
+		withNonce.Nonce = &n
+		req = &withNonce
+
+		txHash, err := c.signAndSend(ctx, req, txSigner)
+		if errors.Is(err, ErrBroadcastUncertain) {
+			// 节点可能已经收到交易，保留预留，由下次分配前的同步处理
+			log.Printf("Broadcast of nonce %d of %s on %s is uncertain, keeping it reserved: %v", n, req.From, c.config.Name, err)
+		} else if err != nil {
+			if releaseErr := c.ReleaseNonce(context.Background(), req.From, n); releaseErr != nil {
+				log.Printf("Failed to release nonce %d of %s on %s: %v", n, req.From, c.config.Name, releaseErr)
+			}
+		}
+		return txHash, err
+	}
+
+	return c.signAndSend(ctx, req, txSigner)
+}
+
+// signAndSend 构建交易、由签名器签名，校验签名者后广播
+func (c *EVMClient) signAndSend(ctx context.Context, req *types.TransactionRequest, txSigner signer.Signer) (string, error) {
+	req = c.withDefaultFees(ctx, req)
+	tx, chainSigner, err := c.buildTransaction(ctx, req)
+	if err != nil {
+		return "", err
+	}
+
+	// 签名交易
+	signature, err := txSigner.SignHash(ctx, req.From, chainSigner.Hash(tx).Bytes())
+	if err != nil {
+		return "", fmt.Errorf("failed to sign transaction: %w", err)
+	}
+
+	signedTx, err := attachSignature(tx, chainSigner, req.From, signature)
+	if err != nil {
+		return "", err
+	}
+
+	return c.broadcast(ctx, common.HexToAddress(req.From), signedTx)
+}
+
+// BroadcastSignedTransaction 广播外部签名（如MPC）的交易
+// 签名覆盖了全部交易字段，因此 nonce、gas上限和手续费必须与签名时一致，不能由节点建议值补全。
+func (c *EVMClient) BroadcastSignedTransaction(ctx context.Context, req *types.TransactionRequest, signature []byte) (string, error) {
+	if req.ChainID != 0 && req.ChainID != c.config.ChainID {
+		return "", fmt.Errorf("transaction chain id %d does not match %s chain id %d", req.ChainID, c.config.Name, c.config.ChainID)
+	}
+	if req.Nonce == nil {
+		return "", fmt.Errorf("nonce is required for externally signed transactions")
+	}
+	if req.GasLimit == 0 {
+		return "", fmt.Errorf("gas_limit is required for externally signed transactions")
+	}
+	if req.GasPrice == nil && (req.MaxFeePerGas == nil || req.MaxPriorityFeePerGas == nil) {
+		return "", fmt.Errorf("gas_price or max_fee_per_gas and max_priority_fee_per_gas are required for externally signed transactions")
+	}
+
+	tx, chainSigner, err := c.buildTransaction(ctx, req)
+	if err != nil {
+		return "", err
+	}
+
+	signedTx, err := attachSignature(tx, chainSigner, req.From, signature)
+	if err != nil {
+		return "", err
+	}
+
+	return c.broadcast(ctx, common.HexToAddress(req.From), signedTx)
+}
+
+// SendRawTransaction 校验并广播RLP编码的已签名交易
+func (c *EVMClient) SendRawTransaction(ctx context.Context, rawTx []byte) (string, error) {
+	tx := new(ethtypes.Transaction)
+	if err := tx.UnmarshalBinary(rawTx); err != nil {
+		return "", fmt.Errorf("failed to decode transaction: %w", err)
+	}
+
+	// 拒绝没有链ID保护的交易，防止跨链重放
+	if !tx.Protected() {
+		return "", fmt.Errorf("transaction is not replay-protected (missing EIP-155 chain id)")
+	}
+	if tx.ChainId().Cmp(big.NewInt(c.config.ChainID)) != 0 {
+		return "", fmt.Errorf("transaction chain id %s does not match %s chain id %d", tx.ChainId(), c.config.Name, c.config.ChainID)
+	}
+
+	sender, err := ethtypes.Sender(ethtypes.LatestSignerForChainID(tx.ChainId()), tx)
+	if err != nil {
+		return "", fmt.Errorf("invalid transaction signature: %w", err)
+	}
+
+	if err := c.checkNonce(ctx, sender, tx.Nonce()); err != nil {
+		return "", err
+	}
+
+	return c.broadcast(ctx, sender, tx)
+}
+
+// ReserveNonce 为地址预留下一个nonce，供外部签名（如MPC）的交易使用
+func (c *EVMClient) ReserveNonce(ctx context.Context, address string) (uint64, error) {
+	if !common.IsHexAddress(address) {
+		return 0, fmt.Errorf("invalid address: %s", address)
+	}
+	addr := common.HexToAddress(address)
+
+	if c.nonces == nil {
+		_, pending, err := c.accountNonces(ctx, addr)
+		return pending, err
+	}
+	return c.nonces.Reserve(ctx, c.config.Name, addr.Hex(), func(ctx context.Context) (uint64, uint64, error) {
+		return c.accountNonces(ctx, addr)
+	})
+}
+
+// ReleaseNonce 释放预留后未使用的nonce
+func (c *EVMClient) ReleaseNonce(ctx context.Context, address string, n uint64) error {
+	if c.nonces == nil {
+		return nil
+	}
+	return c.nonces.Release(ctx, c.config.Name, common.HexToAddress(address).Hex(), n)
+}
+
+// NonceStatus 获取地址的nonce分配状态
+func (c *EVMClient) NonceStatus(ctx context.Context, address string) (*types.NonceStatus, error) {
+	if c.nonces == nil {
+		return nil, fmt.Errorf("nonce manager not configured for %s", c.config.Name)
+	}
+	return c.nonces.Status(ctx, c.config.Name, common.HexToAddress(address).Hex())
+}
+
+// broadcast 广播已签名交易，并在nonce管理器中记录为在途交易
+func (c *EVMClient) broadcast(ctx context.Context, sender common.Address, tx *ethtypes.Transaction) (string, error) {
+	txHash, err := c.sendTransactionWithRetry(ctx, tx, 3)
+	if err != nil {
+		return "", err
+	}
+
+	if c.nonces != nil {
+		// 交易已经广播，记录失败只影响后续缺口检测，不返回错误
+		if err := c.nonces.MarkSent(context.Background(), c.config.Name, sender.Hex(), tx.Nonce(), txHash); err != nil {
+			log.Printf("Failed to track nonce %d of %s on %s: %v", tx.Nonce(), sender.Hex(), c.config.Name, err)
+		}
+	}
+	return txHash, nil
+}
+
+// buildTransaction 构建未签名交易（支持EIP-1559的链使用动态手续费交易）
+// 请求在查询节点之前校验，无效请求不会占用节点池的重试和故障计数。
+func (c *EVMClient) buildTransaction(ctx context.Context, req *types.TransactionRequest) (*ethtypes.Transaction, ethtypes.Signer, error) {
+	if req.Nonce == nil {
+		return nil, nil, fmt.Errorf("nonce is required")
+	}
+	if err := validateTxRequest(c.config, req); err != nil {
+		return nil, nil, err
+	}
+
+	var (
+		tx          *ethtypes.Transaction
+		chainSigner ethtypes.Signer
+	)
+	err := c.pool.Call(ctx, "buildTransaction", func(ctx context.Context, client *ethclient.Client) error {
+		var err error
+		tx, chainSigner, err = buildUnsignedTx(ctx, client, c.config, req)
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return tx, chainSigner, nil
+}
+
+// accountNonces 查询地址的已确认nonce和包含交易池的待处理nonce
+func (c *EVMClient) accountNonces(ctx context.Context, addr common.Address) (confirmed, pending uint64, err error) {
+	err = c.pool.Call(ctx, "eth_getTransactionCount", func(ctx context.Context, client *ethclient.Client) error {
+		var err error
+		if confirmed, err = client.NonceAt(ctx, addr, nil); err != nil {
+			return err
+		}
+		pending, err = client.PendingNonceAt(ctx, addr)
+		return err
+	})
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get nonce: %w", err)
+	}
+	return confirmed, pending, nil
+}
+
+// checkNonce 校验交易nonce：不能低于已确认nonce，也不能超出待处理nonce太多
+// 节点只负责返回账户nonce，比较在节点池调用之外进行，nonce越界不会计入节点故障。
+func (c *EVMClient) checkNonce(ctx context.Context, sender common.Address, nonce uint64) error {
+	confirmed, pending, err := c.accountNonces(ctx, sender)
+	if err != nil {
+		return err
+	}
+
+	if nonce < confirmed {
+		return fmt.Errorf("nonce too low: transaction nonce %d, account nonce %d", nonce, confirmed)
+	}
+	if nonce > pending+maxPendingNonceGap {
+		return fmt.Errorf("nonce too high: transaction nonce %d, pending nonce %d", nonce, pending)
+	}
+	return nil
+}
+
+// ErrBroadcastUncertain 交易已发往节点但没有得到明确结果（超时、网络错误），节点可能已经收到交易
+var ErrBroadcastUncertain = errors.New("transaction may have reached the node")
+
+// sendTransactionWithRetry 带重试机制的发送交易
+// 节点池已在单次调用内完成故障切换，这里的重试用于所有节点都暂时不可用的情况；
+// 交易被节点拒绝（如nonce过低、余额不足）时不重试，退避等待随请求上下文取消。
+// 只有节点明确拒绝时返回普通错误，其他失败都包装 ErrBroadcastUncertain。
+func (c *EVMClient) sendTransactionWithRetry(ctx context.Context, tx *ethtypes.Transaction, maxRetries int) (string, error) {
+	var lastErr error
+	for i := 0; i < maxRetries; i++ {
+		err := c.pool.Call(ctx, "eth_sendRawTransaction", func(ctx context.Context, client *ethclient.Client) error {
+			return client.SendTransaction(ctx, tx)
+		})
+		// 切换节点后重发同一笔交易时，节点可能已经收到过
+		if err == nil || strings.Contains(err.Error(), "already known") {
+			return tx.Hash().Hex(), nil
+		}
+		if isNodeResponse(err) && !isProviderError(err) {
+			return "", fmt.Errorf("failed to send transaction: %w", err)
+		}
+		if !isProviderError(err) || ctx.Err() != nil {
+			return "", fmt.Errorf("failed to send transaction: %w: %w", ErrBroadcastUncertain, err)
+		}
+		lastErr = err
+
+		if i == maxRetries-1 {
+			break
+		}
+		timer := time.NewTimer(time.Second << i) // 指数退避
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return "", fmt.Errorf("failed to send transaction: %w: %w", ErrBroadcastUncertain, ctx.Err())
+		case <-timer.C:
+		}
+	}
+	return "", fmt.Errorf("after %d retries, %w, last error: %w", maxRetries, ErrBroadcastUncertain, lastErr)
+}
+
+// GetTransaction 获取交易信息
+func (c *EVMClient) GetTransaction(ctx context.Context, txHash string) (*types.Transaction, error) {
+	hash := common.HexToHash(txHash)
+
+	var (
+		tx        *ethtypes.Transaction
+		receipt   *ethtypes.Receipt
+		header    *ethtypes.Header
+		isPending bool
+	)
+	err := c.pool.Call(ctx, "eth_getTransactionByHash", func(ctx context.Context, client *ethclient.Client) error {
+		var err error
+		tx, isPending, err = client.TransactionByHash(ctx, hash)
+		if err != nil {
+			return fmt.Errorf("failed to get transaction: %w", err)
+		}
+		if isPending {
+			return nil
+		}
+
+		// 获取交易收据
+		receipt, err = client.TransactionReceipt(ctx, hash)
+		if err != nil {
+			return fmt.Errorf("failed to get transaction receipt: %w", err)
+		}
+
+		// 区块时间和基础费用
+		header, err = client.HeaderByHash(ctx, receipt.BlockHash)
+		if err != nil {
+			return fmt.Errorf("failed to get block header: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// 转换为自定义类型
+	result := &types.Transaction{
+		Hash:     tx.Hash().Hex(),
+		Value:    tx.Value(),
+		GasPrice: tx.GasPrice(),
+		GasLimit: tx.Gas(),
+		Type:     tx.Type(),
+		Nonce:    tx.Nonce(),
+		Data:     tx.Data(),
+		ChainID:  c.config.ChainID,
+		Pending:  isPending,
+	}
+	if sender, err := c.txSender(tx); err == nil {
+		result.From = sender.Hex()
+	}
+	if tx.To() != nil {
+		result.To = tx.To().Hex()
+	}
+	if receipt != nil {
+		result.Status = receipt.Status
+		result.BlockNumber = receipt.BlockNumber.Uint64()
+		result.BlockHash = receipt.BlockHash.Hex()
+		result.TransactionIndex = receipt.TransactionIndex
+		result.GasUsed = receipt.GasUsed
+		result.CumulativeGasUsed = receipt.CumulativeGasUsed
+		result.Logs = receipt.Logs
+		result.Timestamp = header.Time
+		if tx.To() == nil {
+			result.ContractAddress = receipt.ContractAddress.Hex()
+		}
+
+		result.EffectiveGasPrice = receipt.EffectiveGasPrice
+		if result.EffectiveGasPrice == nil {
+			result.EffectiveGasPrice = effectiveGasPrice(tx, header.BaseFee)
+		}
+		result.Fee = new(big.Int).Mul(result.EffectiveGasPrice, new(big.Int).SetUint64(receipt.GasUsed))
+	}
+	if tx.Type() != ethtypes.LegacyTxType && tx.Type() != ethtypes.AccessListTxType {
+		result.MaxFeePerGas = tx.GasFeeCap()
+		result.MaxPriorityFeePerGas = tx.GasTipCap()
+	}
+
+	return result, nil
+}
+
+// txSender 从交易签名中恢复发送方
+// 签名器支持所有交易类型，未启用EIP-155重放保护的传统交易按 Homestead 规则恢复。
+func (c *EVMClient) txSender(tx *ethtypes.Transaction) (common.Address, error) {
+	return ethtypes.Sender(ethtypes.LatestSignerForChainID(big.NewInt(c.config.ChainID)), tx)
+}
+
+// effectiveGasPrice 计算交易实际支付的单位Gas价格，用于收据中没有 effectiveGasPrice 的旧节点
+// 动态手续费交易为 min(maxFeePerGas, baseFee + maxPriorityFeePerGas)。
+func effectiveGasPrice(tx *ethtypes.Transaction, baseFee *big.Int) *big.Int {
+	if baseFee == nil || tx.Type() == ethtypes.LegacyTxType || tx.Type() == ethtypes.AccessListTxType {
+		return tx.GasPrice()
+	}
+	price := new(big.Int).Add(baseFee, tx.GasTipCap())
+	if price.Cmp(tx.GasFeeCap()) > 0 {
+		return new(big.Int).Set(tx.GasFeeCap())
+	}
+	return price
+}
+
+// TransactionStatus 查询交易在节点中的状态
+// 交易已打包时返回所在区块及执行结果；from 为空时使用交易签名中恢复的发送方查询已上链的nonce。
+func (c *EVMClient) TransactionStatus(ctx context.Context, txHash, from string) (*types.TxChainStatus, error) {
+	hash := common.HexToHash(txHash)
+
+	var status *types.TxChainStatus
+	err := c.pool.Call(ctx, "transactionStatus", func(ctx context.Context, client *ethclient.Client) error {
+		status = &types.TxChainStatus{}
+		account := from
+
+		head, err := client.BlockNumber(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to get block number: %w", err)
+		}
+		status.HeadBlock = head
+
+		tx, isPending, err := client.TransactionByHash(ctx, hash)
+		switch {
+		case errors.Is(err, ethereum.NotFound):
+		case err != nil:
+			return fmt.Errorf("failed to get transaction: %w", err)
+		default:
+			status.Found = true
+			status.Pending = isPending
+			if sender, err := c.txSender(tx); err == nil {
+				nonce := tx.Nonce()
+				status.From = sender.Hex()
+				status.Nonce = &nonce
+				if account == "" {
+					account = status.From
+				}
+			}
+		}
+
+		if status.Found && !status.Pending {
+			receipt, err := client.TransactionReceipt(ctx, hash)
+			switch {
+			case errors.Is(err, ethereum.NotFound):
+				// 节点尚未索引收据，按交易池中处理
+				status.Pending = true
+			case err != nil:
+				return fmt.Errorf("failed to get transaction receipt: %w", err)
+			default:
+				status.BlockNumber = receipt.BlockNumber.Uint64()
+				status.BlockHash = receipt.BlockHash.Hex()
+				status.Success = receipt.Status == ethtypes.ReceiptStatusSuccessful
+			}
+		}
+
+		if account != "" {
+			status.AccountNonce, err = client.NonceAt(ctx, common.HexToAddress(account), nil)
+			if err != nil {
+				return fmt.Errorf("failed to get account nonce: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+// EstimateGas 预估Gas
+func (c *EVMClient) EstimateGas(ctx context.Context, req *types.TransactionRequest) (uint64, error) {
+	// 创建消息调用，接收地址为空时预估合约创建
+	to, err := parseRecipient(req.To)
+	if err != nil {
+		return 0, err
+	}
+	msg := ethereum.CallMsg{
+		From:  common.HexToAddress(req.From),
+		To:    to,
+		Gas:   req.GasLimit,
+		Value: req.Value,
+		Data:  req.Data,
+	}
+	if req.MaxFeePerGas != nil || req.MaxPriorityFeePerGas != nil {
+		msg.GasFeeCap = req.MaxFeePerGas
+		msg.GasTipCap = req.MaxPriorityFeePerGas
+	} else {
+		msg.GasPrice = req.GasPrice
+	}
+
+	var gas uint64
+	err = c.pool.Call(ctx, "eth_estimateGas", func(ctx context.Context, client *ethclient.Client) error {
+		var err error
+		gas, err = client.EstimateGas(ctx, msg)
+		return err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to estimate gas: %w", err)
+	}
+
+	return gas, nil
+}
+
+// GetBlockNumber 获取最新区块号
+func (c *EVMClient) GetBlockNumber(ctx context.Context) (uint64, error) {
+	var blockNumber uint64
+	err := c.pool.Call(ctx, "eth_blockNumber", func(ctx context.Context, client *ethclient.Client) error {
+		var err error
+		blockNumber, err = client.BlockNumber(ctx)
+		return err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to get block number: %w", err)
+	}
+
+	return blockNumber, nil
+}
+
+// GetBlockByNumber 根据区块号获取区块
+func (c *EVMClient) GetBlockByNumber(ctx context.Context, blockNumber uint64) (*types.Block, error) {
+	var block *ethtypes.Block
+	err := c.pool.Call(ctx, "eth_getBlockByNumber", func(ctx context.Context, client *ethclient.Client) error {
+		var err error
+		block, err = client.BlockByNumber(ctx, new(big.Int).SetUint64(blockNumber))
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get block: %w", err)
+	}
+
+	// 转换为自定义类型
+	// 收集交易哈希
+	txHashes := make([]string, len(block.Transactions()))
+	for i, tx := range block.Transactions() {
+		txHashes[i] = tx.Hash().Hex()
+	}
+
+	return &types.Block{
+		Number:       block.Number().Uint64(),
+		Hash:         block.Hash().Hex(),
+		ParentHash:   block.ParentHash().Hex(),
+		Timestamp:    block.Time(),
+		Transactions: txHashes,
+		GasUsed:      block.GasUsed(),
+		GasLimit:     block.GasLimit(),
+		Difficulty:   block.Difficulty(),
+	}, nil
+}
+
+// CallContract 调用合约
+func (c *EVMClient) CallContract(ctx context.Context, req *types.ContractCallRequest) ([]byte, error) {
+	msg := ethereum.CallMsg{
+		From:     req.From,
+		To:       &req.ContractAddress,
+		Gas:      req.GasLimit,
+		GasPrice: req.GasPrice,
+		Value:    req.Value,
+		Data:     req.Data,
+	}
+
+	// 区块号为0时在最新区块上调用
+	var blockNumber *big.Int
+	if req.BlockNumber > 0 {
+		blockNumber = new(big.Int).SetUint64(req.BlockNumber)
+	}
+
+	var result []byte
+	err := c.pool.Call(ctx, "eth_call", func(ctx context.Context, client *ethclient.Client) error {
+		var err error
+		result, err = client.CallContract(ctx, msg, blockNumber)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to call contract: %w", err)
+	}
+
+	return result, nil
+}
+
+// RevertData 从合约调用的错误中取出回滚数据，reverted 表示错误是合约回滚
+func RevertData(err error) (data []byte, reverted bool) {
+	var dataErr rpc.DataError
+	if errors.As(err, &dataErr) {
+		if s, ok := dataErr.ErrorData().(string); ok {
+			if data, err := hexutil.Decode(s); err == nil {
+				return data, true
+			}
+		}
+	}
+	return nil, strings.Contains(err.Error(), "execution reverted")
+}
+
+// Close 关闭连接
+func (c *EVMClient) Close() error {
+	c.pool.Close()
+	return nil
+}
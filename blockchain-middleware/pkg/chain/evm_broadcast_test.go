@@ -0,0 +1,170 @@
+package chain
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"blockchain-middleware/internal/config"
+	"blockchain-middleware/pkg/nonce"
+	"blockchain-middleware/pkg/signer"
+	"blockchain-middleware/pkg/types"
+
+	"github.com/ethereum/go-ethereum/common"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// signedRawTx 用随机私钥签名一笔传统交易，返回RLP编码
+func signedRawTx(t *testing.T, chainID int64, nonce uint64) []byte {
+	t.Helper()
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	to := common.HexToAddress(testTo)
+	tx := ethtypes.NewTx(&ethtypes.LegacyTx{Nonce: nonce, GasPrice: big.NewInt(1e9), Gas: 21000, To: &to, Value: big.NewInt(1)})
+	signed, err := ethtypes.SignTx(tx, ethtypes.NewEIP155Signer(big.NewInt(chainID)), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := signed.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+func TestSendRawTransactionRejectsWrongChainBeforeRPC(t *testing.T) {
+	nodeA, urlA := newFakeNode(t)
+	nodeB, urlB := newFakeNode(t)
+	c := newTestEVMClient(t, config.ChainConfig{ChainID: 1337}, urlA, urlB)
+
+	for i := 0; i < maxConsecutiveFailures+1; i++ {
+		_, err := c.SendRawTransaction(context.Background(), signedRawTx(t, 1, 0))
+		if err == nil || !strings.Contains(err.Error(), "does not match") {
+			t.Fatalf("SendRawTransaction() error = %v, want chain id mismatch", err)
+		}
+	}
+
+	for _, node := range []*fakeNode{nodeA, nodeB} {
+		for _, method := range []string{"eth_getTransactionCount", "eth_sendRawTransaction"} {
+			if n := node.callCount(method); n != 0 {
+				t.Errorf("%s called %d times for a wrong-chain transaction", method, n)
+			}
+		}
+	}
+	assertNoProviderFailures(t, c.pool)
+}
+
+func TestSendRawTransactionNonceWindow(t *testing.T) {
+	tests := []struct {
+		name    string
+		nonce   uint64
+		wantErr string
+	}{
+		{"next nonce", 5, ""},
+		{"queued within window", 5 + maxPendingNonceGap, ""},
+		{"already confirmed", 2, "nonce too low"},
+		{"too far ahead", 6 + maxPendingNonceGap, "nonce too high"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node, url := newFakeNode(t)
+			node.handle("eth_getTransactionCount", func([]json.RawMessage) (interface{}, error) { return "0x5", nil })
+			node.handle("eth_sendRawTransaction", func(params []json.RawMessage) (interface{}, error) {
+				return common.Hash{}.Hex(), nil
+			})
+			c := newTestEVMClient(t, config.ChainConfig{ChainID: 1337}, url)
+
+			_, err := c.SendRawTransaction(context.Background(), signedRawTx(t, 1337, tt.nonce))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("SendRawTransaction() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("SendRawTransaction() error = %v, want %q", err, tt.wantErr)
+			}
+			if n := node.callCount("eth_sendRawTransaction"); n != 0 {
+				t.Errorf("transaction outside the nonce window was broadcast")
+			}
+			assertNoProviderFailures(t, c.pool)
+		})
+	}
+}
+
+func TestBroadcastSignedTransactionRejectsWrongChainBeforeRPC(t *testing.T) {
+	node, url := newFakeNode(t)
+	c := newTestEVMClient(t, config.ChainConfig{ChainID: 1337}, url)
+
+	nonce := uint64(0)
+	req := &types.TransactionRequest{From: testFrom, To: testTo, ChainID: 1, Nonce: &nonce, GasLimit: 21000, GasPrice: big.NewInt(1e9)}
+	_, err := c.BroadcastSignedTransaction(context.Background(), req, make([]byte, 65))
+	if err == nil || !strings.Contains(err.Error(), "does not match") {
+		t.Fatalf("BroadcastSignedTransaction() error = %v, want chain id mismatch", err)
+	}
+	if n := node.callCount("eth_sendRawTransaction"); n != 0 {
+		t.Errorf("eth_sendRawTransaction called %d times", n)
+	}
+	assertNoProviderFailures(t, c.pool)
+}
+
+func TestSendTransactionReleasesNonceOnlyWhenRejected(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	txSigner, err := signer.NewPrivateKeySigner([]string{hex.EncodeToString(crypto.FromECDSA(key))})
+	if err != nil {
+		t.Fatal(err)
+	}
+	from := crypto.PubkeyToAddress(key.PublicKey).Hex()
+
+	tests := []struct {
+		name         string
+		sendErr      error
+		wantInFlight []uint64
+		wantNext     uint64
+	}{
+		// 节点明确拒绝，交易没有进入交易池，nonce可以立即重新分配
+		{"rejected by node", &fakeRPCError{Code: -32000, Message: "insufficient funds for gas * price + value"}, nil, 0},
+		// 节点故障，交易可能已经进入交易池，保留预留等待同步
+		{"provider failure", &fakeRPCError{Code: -32603, Message: "internal error"}, []uint64{0}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node, url := newFakeNode(t)
+			node.handle("eth_getTransactionCount", func([]json.RawMessage) (interface{}, error) { return "0x0", nil })
+			node.handle("eth_sendRawTransaction", func([]json.RawMessage) (interface{}, error) { return nil, tt.sendErr })
+
+			c := newTestEVMClient(t, config.ChainConfig{ChainID: 1337}, url)
+			c.nonces = nonce.NewManager(nonce.NewMemoryStore())
+
+			// 重试退避期间超时返回
+			ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+			defer cancel()
+			req := &types.TransactionRequest{From: from, To: testTo, Value: big.NewInt(1), GasLimit: 21000, GasPrice: big.NewInt(1e9)}
+			if _, err := c.SendTransactionWithSigner(ctx, req, txSigner); err == nil {
+				t.Fatal("SendTransactionWithSigner() succeeded")
+			}
+
+			status, err := c.NonceStatus(context.Background(), from)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if status.Next != tt.wantNext {
+				t.Errorf("next nonce = %d, want %d", status.Next, tt.wantNext)
+			}
+			if fmt.Sprint(status.InFlight) != fmt.Sprint(append([]uint64{}, tt.wantInFlight...)) {
+				t.Errorf("inflight = %v, want %v", status.InFlight, tt.wantInFlight)
+			}
+		})
+	}
+}
@@ -0,0 +1,260 @@
+package chain
+
+import (
+	"blockchain-middleware/pkg/types"
+	"context"
+	"fmt"
+	"log"
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// feeHistoryBlocks 手续费估算采样的区块数
+const feeHistoryBlocks = 20
+
+// feeTier 速度档位：EIP-1559 链取采样区块小费的百分位，传统链按 eth_gasPrice 的倍数（百分比）
+type feeTier struct {
+	percentile float64
+	multiplier int64
+	blocks     uint64
+}
+
+// feeTiers 慢速、标准、快速三档
+var feeTiers = [3]feeTier{
+	{percentile: 10, multiplier: 90, blocks: 6},
+	{percentile: 50, multiplier: 100, blocks: 3},
+	{percentile: 90, multiplier: 125, blocks: 1},
+}
+
+// baseFeeTrendThreshold 下一区块 baseFee 偏离采样均值超过该比例（百分比）时视为上涨或下跌
+const baseFeeTrendThreshold = 5
+
+// EstimateFees 估算慢速、标准、快速三档手续费，结果按区块缓存
+// 配置启用EIP-1559且链上有 baseFee 时使用 eth_feeHistory，否则使用 eth_gasPrice。
+func (c *EVMClient) EstimateFees(ctx context.Context) (*types.FeeEstimates, error) {
+	var estimates *types.FeeEstimates
+	err := c.pool.Call(ctx, "eth_feeHistory", func(ctx context.Context, client *ethclient.Client) error {
+		head, err := client.HeaderByNumber(ctx, nil)
+		if err != nil {
+			return fmt.Errorf("failed to get latest header: %w", err)
+		}
+
+		c.feeMu.Lock()
+		cached := c.fees
+		c.feeMu.Unlock()
+		if cached != nil && cached.BlockNumber == head.Number.Uint64() {
+			estimates = cached
+			return nil
+		}
+
+		blockTime, err := averageBlockTime(ctx, client, head)
+		if err != nil {
+			return err
+		}
+		if c.config.EIP1559 && head.BaseFee != nil {
+			estimates, err = dynamicFeeEstimates(ctx, client, head)
+		} else {
+			estimates, err = legacyFeeEstimates(ctx, client, head)
+		}
+		if err != nil {
+			return err
+		}
+
+		estimates.BlockTime = blockTime
+		for _, tier := range []*types.FeeTier{&estimates.Slow, &estimates.Standard, &estimates.Fast} {
+			tier.EstimatedSeconds = uint64(float64(tier.EstimatedBlocks)*blockTime + 0.5)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	c.feeMu.Lock()
+	if c.fees == nil || c.fees.BlockNumber < estimates.BlockNumber {
+		c.fees = estimates
+	}
+	c.feeMu.Unlock()
+	return estimates, nil
+}
+
+// SuggestGasPrice 获取节点建议的Gas价格
+func (c *EVMClient) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
+	var gasPrice *big.Int
+	err := c.pool.Call(ctx, "eth_gasPrice", func(ctx context.Context, client *ethclient.Client) error {
+		var err error
+		gasPrice, err = client.SuggestGasPrice(ctx)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to suggest gas price: %w", err)
+	}
+	return gasPrice, nil
+}
+
+// withDefaultFees 请求未指定任何手续费时按标准档位填充，估算失败时保持原样由节点建议值补全
+func (c *EVMClient) withDefaultFees(ctx context.Context, req *types.TransactionRequest) *types.TransactionRequest {
+	if req.GasPrice != nil || req.MaxFeePerGas != nil || req.MaxPriorityFeePerGas != nil {
+		return req
+	}
+
+	estimates, err := c.EstimateFees(ctx)
+	if err != nil {
+		log.Printf("Failed to estimate fees on %s, using node suggestion: %v", c.config.Name, err)
+		return req
+	}
+
+	withFees := *req
+	if estimates.EIP1559 {
+		withFees.MaxFeePerGas = estimates.Standard.MaxFeePerGas
+		withFees.MaxPriorityFeePerGas = estimates.Standard.MaxPriorityFeePerGas
+	} else {
+		withFees.GasPrice = estimates.Standard.GasPrice
+	}
+	return &withFees
+}
+
+// dynamicFeeEstimates 按 eth_feeHistory 计算 EIP-1559 手续费
+// 各档小费取采样区块（排除空块）对应百分位的中位数，手续费上限为 2*nextBaseFee+小费。
+func dynamicFeeEstimates(ctx context.Context, client *ethclient.Client, head *ethtypes.Header) (*types.FeeEstimates, error) {
+	percentiles := make([]float64, len(feeTiers))
+	for i, tier := range feeTiers {
+		percentiles[i] = tier.percentile
+	}
+
+	history, err := client.FeeHistory(ctx, feeHistoryBlocks, head.Number, percentiles)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get fee history: %w", err)
+	}
+	if len(history.BaseFee) == 0 {
+		return nil, fmt.Errorf("fee history returned no blocks")
+	}
+
+	nextBaseFee := history.BaseFee[len(history.BaseFee)-1]
+	estimates := &types.FeeEstimates{
+		BlockNumber:  head.Number.Uint64(),
+		EIP1559:      true,
+		BaseFee:      head.BaseFee,
+		NextBaseFee:  nextBaseFee,
+		BaseFeeTrend: baseFeeTrend(history.BaseFee[:len(history.BaseFee)-1], nextBaseFee),
+		GasUsedRatio: averageRatio(history.GasUsedRatio),
+	}
+
+	var fallbackTip *big.Int
+	tiers := []*types.FeeTier{&estimates.Slow, &estimates.Standard, &estimates.Fast}
+	for i, tier := range tiers {
+		tip := medianReward(history, i)
+		if tip == nil {
+			// 采样区块都是空块，没有小费数据
+			if fallbackTip == nil {
+				if fallbackTip, err = client.SuggestGasTipCap(ctx); err != nil {
+					return nil, fmt.Errorf("failed to suggest gas tip cap: %w", err)
+				}
+			}
+			tip = fallbackTip
+		}
+		// 快档位的小费不低于慢档位
+		if i > 0 && tip.Cmp(tiers[i-1].MaxPriorityFeePerGas) < 0 {
+			tip = tiers[i-1].MaxPriorityFeePerGas
+		}
+
+		tier.MaxPriorityFeePerGas = tip
+		tier.MaxFeePerGas = new(big.Int).Add(new(big.Int).Mul(nextBaseFee, big.NewInt(2)), tip)
+		tier.GasPrice = new(big.Int).Add(nextBaseFee, tip)
+		tier.EstimatedBlocks = feeTiers[i].blocks
+	}
+	return estimates, nil
+}
+
+// legacyFeeEstimates 按 eth_gasPrice 计算传统交易手续费
+func legacyFeeEstimates(ctx context.Context, client *ethclient.Client, head *ethtypes.Header) (*types.FeeEstimates, error) {
+	gasPrice, err := client.SuggestGasPrice(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to suggest gas price: %w", err)
+	}
+
+	estimates := &types.FeeEstimates{BlockNumber: head.Number.Uint64()}
+	for i, tier := range []*types.FeeTier{&estimates.Slow, &estimates.Standard, &estimates.Fast} {
+		tier.GasPrice = new(big.Int).Div(new(big.Int).Mul(gasPrice, big.NewInt(feeTiers[i].multiplier)), big.NewInt(100))
+		tier.EstimatedBlocks = feeTiers[i].blocks
+	}
+	return estimates, nil
+}
+
+// averageBlockTime 最近 feeHistoryBlocks 个区块的平均出块间隔（秒）
+// 创世区块的时间戳通常与后续区块无关，不参与计算。
+func averageBlockTime(ctx context.Context, client *ethclient.Client, head *ethtypes.Header) (float64, error) {
+	if head.Number.Uint64() < 2 {
+		return 0, nil
+	}
+
+	span := uint64(feeHistoryBlocks)
+	if head.Number.Uint64()-1 < span {
+		span = head.Number.Uint64() - 1
+	}
+	oldest, err := client.HeaderByNumber(ctx, new(big.Int).Sub(head.Number, new(big.Int).SetUint64(span)))
+	if err != nil {
+		return 0, fmt.Errorf("failed to get header: %w", err)
+	}
+	if head.Time <= oldest.Time {
+		return 0, nil
+	}
+	return float64(head.Time-oldest.Time) / float64(span), nil
+}
+
+// medianReward 采样区块中第 i 个百分位小费的中位数，所有区块都为空块时返回nil
+func medianReward(history *ethereum.FeeHistory, i int) *big.Int {
+	var rewards []*big.Int
+	for block, reward := range history.Reward {
+		if block < len(history.GasUsedRatio) && history.GasUsedRatio[block] == 0 {
+			continue
+		}
+		if i < len(reward) && reward[i] != nil {
+			rewards = append(rewards, reward[i])
+		}
+	}
+	if len(rewards) == 0 {
+		return nil
+	}
+
+	sort.Slice(rewards, func(a, b int) bool { return rewards[a].Cmp(rewards[b]) < 0 })
+	return rewards[len(rewards)/2]
+}
+
+// baseFeeTrend 下一区块 baseFee 相对采样区块均值的趋势
+func baseFeeTrend(baseFees []*big.Int, next *big.Int) string {
+	if len(baseFees) == 0 {
+		return types.BaseFeeStable
+	}
+
+	sum := new(big.Int)
+	for _, fee := range baseFees {
+		sum.Add(sum, fee)
+	}
+	// 比较 next*100*n 与 sum*(100±阈值)，避免整数除法的精度损失
+	scaled := new(big.Int).Mul(next, big.NewInt(int64(100*len(baseFees))))
+	switch {
+	case scaled.Cmp(new(big.Int).Mul(sum, big.NewInt(100+baseFeeTrendThreshold))) > 0:
+		return types.BaseFeeRising
+	case scaled.Cmp(new(big.Int).Mul(sum, big.NewInt(100-baseFeeTrendThreshold))) < 0:
+		return types.BaseFeeFalling
+	default:
+		return types.BaseFeeStable
+	}
+}
+
+// averageRatio 平均值
+func averageRatio(ratios []float64) float64 {
+	if len(ratios) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, r := range ratios {
+		sum += r
+	}
+	return sum / float64(len(ratios))
+}
@@ -0,0 +1,268 @@
+package chain
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"blockchain-middleware/internal/config"
+	"blockchain-middleware/pkg/types"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+)
+
+// feeHistoryBlock eth_feeHistory 中一个区块的数据，rewards 依次为10、50、90百分位小费（gwei）
+type feeHistoryBlock struct {
+	baseFee      int64
+	gasUsedRatio float64
+	rewards      [3]int64
+}
+
+// serveFeeHistory 让节点返回每12秒一个区块、最新区块为 head 的链和手续费历史
+func serveFeeHistory(node *fakeNode, head uint64, blocks []feeHistoryBlock, nextBaseFee int64) {
+	header := func(n uint64) *ethtypes.Header {
+		return &ethtypes.Header{Number: new(big.Int).SetUint64(n), Time: 1700000000 + 12*n, Difficulty: big.NewInt(0), BaseFee: big.NewInt(nextBaseFee * gwei)}
+	}
+	node.handle("eth_getBlockByNumber", func(params []json.RawMessage) (interface{}, error) {
+		var tag string
+		json.Unmarshal(params[0], &tag)
+		if tag == "latest" {
+			return header(head), nil
+		}
+		n, err := hexutil.DecodeUint64(tag)
+		if err != nil {
+			return nil, err
+		}
+		return header(n), nil
+	})
+	node.handle("eth_feeHistory", func([]json.RawMessage) (interface{}, error) {
+		var history struct {
+			OldestBlock  hexutil.Uint64   `json:"oldestBlock"`
+			Reward       [][]*hexutil.Big `json:"reward"`
+			BaseFee      []*hexutil.Big   `json:"baseFeePerGas"`
+			GasUsedRatio []float64        `json:"gasUsedRatio"`
+		}
+		history.OldestBlock = hexutil.Uint64(head + 1 - uint64(len(blocks)))
+		for _, b := range blocks {
+			var reward []*hexutil.Big
+			for _, r := range b.rewards {
+				reward = append(reward, (*hexutil.Big)(big.NewInt(r*gwei)))
+			}
+			history.Reward = append(history.Reward, reward)
+			history.BaseFee = append(history.BaseFee, (*hexutil.Big)(big.NewInt(b.baseFee*gwei)))
+			history.GasUsedRatio = append(history.GasUsedRatio, b.gasUsedRatio)
+		}
+		history.BaseFee = append(history.BaseFee, (*hexutil.Big)(big.NewInt(nextBaseFee*gwei)))
+		return history, nil
+	})
+	node.handle("eth_maxPriorityFeePerGas", func([]json.RawMessage) (interface{}, error) {
+		return hexutil.EncodeBig(big.NewInt(3 * gwei)), nil
+	})
+	node.handle("eth_gasPrice", func([]json.RawMessage) (interface{}, error) {
+		return hexutil.EncodeBig(big.NewInt(40 * gwei)), nil
+	})
+}
+
+func TestEstimateFeesDynamic(t *testing.T) {
+	tests := []struct {
+		name        string
+		blocks      []feeHistoryBlock
+		nextBaseFee int64
+		wantTips    [3]int64
+		wantTrend   string
+	}{
+		{
+			name: "median of each percentile ignoring empty blocks",
+			blocks: []feeHistoryBlock{
+				{baseFee: 10, gasUsedRatio: 0.5, rewards: [3]int64{1, 5, 9}},
+				{baseFee: 10, gasUsedRatio: 0, rewards: [3]int64{0, 0, 0}},
+				{baseFee: 10, gasUsedRatio: 0.6, rewards: [3]int64{2, 6, 10}},
+				{baseFee: 10, gasUsedRatio: 0.4, rewards: [3]int64{3, 7, 11}},
+			},
+			nextBaseFee: 12,
+			wantTips:    [3]int64{2, 6, 10},
+			wantTrend:   types.BaseFeeRising,
+		},
+		{
+			name: "faster tiers never tip less than slower tiers",
+			blocks: []feeHistoryBlock{
+				{baseFee: 10, gasUsedRatio: 0.5, rewards: [3]int64{4, 2, 1}},
+				{baseFee: 10, gasUsedRatio: 0.5, rewards: [3]int64{4, 2, 1}},
+			},
+			nextBaseFee: 10,
+			wantTips:    [3]int64{4, 4, 4},
+			wantTrend:   types.BaseFeeStable,
+		},
+		{
+			name: "all empty blocks use the node tip suggestion",
+			blocks: []feeHistoryBlock{
+				{baseFee: 10, gasUsedRatio: 0, rewards: [3]int64{0, 0, 0}},
+				{baseFee: 10, gasUsedRatio: 0, rewards: [3]int64{0, 0, 0}},
+			},
+			nextBaseFee: 9,
+			wantTips:    [3]int64{3, 3, 3},
+			wantTrend:   types.BaseFeeFalling,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node, url := newFakeNode(t)
+			serveFeeHistory(node, 100, tt.blocks, tt.nextBaseFee)
+			c := newTestEVMClient(t, config.ChainConfig{EIP1559: true}, url)
+
+			fees, err := c.EstimateFees(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !fees.EIP1559 || fees.BlockNumber != 100 || fees.BlockTime != 12 {
+				t.Errorf("estimates = eip1559 %v block %d block time %v", fees.EIP1559, fees.BlockNumber, fees.BlockTime)
+			}
+			if fees.NextBaseFee.Int64() != tt.nextBaseFee*gwei || fees.BaseFeeTrend != tt.wantTrend {
+				t.Errorf("next base fee = %s trend %s, want %d gwei %s", fees.NextBaseFee, fees.BaseFeeTrend, tt.nextBaseFee, tt.wantTrend)
+			}
+
+			for i, tier := range []types.FeeTier{fees.Slow, fees.Standard, fees.Fast} {
+				tip := tt.wantTips[i] * gwei
+				nextBaseFee := tt.nextBaseFee * gwei
+				if tier.MaxPriorityFeePerGas.Int64() != tip {
+					t.Errorf("tier %d tip = %s, want %d", i, tier.MaxPriorityFeePerGas, tip)
+				}
+				if tier.MaxFeePerGas.Int64() != 2*nextBaseFee+tip || tier.GasPrice.Int64() != nextBaseFee+tip {
+					t.Errorf("tier %d max fee = %s gas price = %s", i, tier.MaxFeePerGas, tier.GasPrice)
+				}
+				if tier.EstimatedSeconds != feeTiers[i].blocks*12 {
+					t.Errorf("tier %d estimated seconds = %d, want %d", i, tier.EstimatedSeconds, feeTiers[i].blocks*12)
+				}
+			}
+		})
+	}
+}
+
+func TestEstimateFeesLegacy(t *testing.T) {
+	node, url := newFakeNode(t)
+	serveFeeHistory(node, 100, nil, 10)
+	c := newTestEVMClient(t, config.ChainConfig{EIP1559: false}, url)
+
+	fees, err := c.EstimateFees(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fees.EIP1559 {
+		t.Error("legacy chain returned EIP-1559 estimates")
+	}
+	// eth_gasPrice 为40 gwei，三档分别为 90%、100%、125%
+	for i, want := range []int64{36 * gwei, 40 * gwei, 50 * gwei} {
+		tier := []types.FeeTier{fees.Slow, fees.Standard, fees.Fast}[i]
+		if tier.GasPrice.Int64() != want || tier.MaxFeePerGas != nil {
+			t.Errorf("tier %d = gas price %s max fee %v, want %d", i, tier.GasPrice, tier.MaxFeePerGas, want)
+		}
+	}
+	if n := node.callCount("eth_feeHistory"); n != 0 {
+		t.Errorf("eth_feeHistory called %d times on a legacy chain", n)
+	}
+}
+
+func TestEstimateFeesCachedPerBlock(t *testing.T) {
+	node, url := newFakeNode(t)
+	blocks := []feeHistoryBlock{{baseFee: 10, gasUsedRatio: 0.5, rewards: [3]int64{1, 2, 3}}}
+	serveFeeHistory(node, 100, blocks, 10)
+	c := newTestEVMClient(t, config.ChainConfig{EIP1559: true}, url)
+
+	for i := 0; i < 3; i++ {
+		if _, err := c.EstimateFees(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := node.callCount("eth_feeHistory"); n != 1 {
+		t.Errorf("eth_feeHistory called %d times for the same block, want 1", n)
+	}
+
+	// 新区块重新估算
+	serveFeeHistory(node, 101, blocks, 10)
+	fees, err := c.EstimateFees(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fees.BlockNumber != 101 || node.callCount("eth_feeHistory") != 2 {
+		t.Errorf("estimates for block %d after %d fee history calls", fees.BlockNumber, node.callCount("eth_feeHistory"))
+	}
+}
+
+func TestWithDefaultFees(t *testing.T) {
+	node, url := newFakeNode(t)
+	serveFeeHistory(node, 100, []feeHistoryBlock{{baseFee: 10, gasUsedRatio: 0.5, rewards: [3]int64{1, 2, 3}}}, 10)
+	c := newTestEVMClient(t, config.ChainConfig{EIP1559: true}, url)
+
+	req := &types.TransactionRequest{From: testFrom, To: testTo}
+	got := c.withDefaultFees(context.Background(), req)
+	if got.MaxPriorityFeePerGas.Int64() != 2*gwei || got.MaxFeePerGas.Int64() != 22*gwei || got.GasPrice != nil {
+		t.Errorf("default fees = max fee %v tip %v gas price %v", got.MaxFeePerGas, got.MaxPriorityFeePerGas, got.GasPrice)
+	}
+	if req.MaxFeePerGas != nil {
+		t.Error("withDefaultFees() modified the request")
+	}
+
+	// 调用方指定了任一手续费字段时不覆盖
+	explicit := &types.TransactionRequest{From: testFrom, To: testTo, MaxPriorityFeePerGas: big.NewInt(1)}
+	if got := c.withDefaultFees(context.Background(), explicit); got != explicit {
+		t.Errorf("withDefaultFees() replaced explicit fees: %+v", got)
+	}
+
+	// 估算失败时保持原样，由节点建议值补全
+	node.setStatus(500)
+	if got := c.withDefaultFees(context.Background(), &types.TransactionRequest{From: testFrom, To: testTo}); got.MaxFeePerGas != nil || got.GasPrice != nil {
+		t.Errorf("withDefaultFees() after failed estimation = %+v", got)
+	}
+}
+
+func TestSuggestGasPrice(t *testing.T) {
+	node, url := newFakeNode(t)
+	serveFeeHistory(node, 100, nil, 10)
+	c := newTestEVMClient(t, config.ChainConfig{EIP1559: true}, url)
+
+	// 节点不支持 eth_feeHistory 时仍能取得Gas价格
+	node.handle("eth_feeHistory", func([]json.RawMessage) (interface{}, error) {
+		return nil, &fakeRPCError{Code: -32601, Message: "the method eth_feeHistory does not exist"}
+	})
+	if _, err := c.EstimateFees(context.Background()); err == nil {
+		t.Fatal("EstimateFees() succeeded without eth_feeHistory")
+	}
+	gasPrice, err := c.SuggestGasPrice(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gasPrice.Int64() != 40*gwei {
+		t.Errorf("SuggestGasPrice() = %s, want %d", gasPrice, 40*gwei)
+	}
+}
+
+func TestBaseFeeTrend(t *testing.T) {
+	fees := func(values ...int64) []*big.Int {
+		var out []*big.Int
+		for _, v := range values {
+			out = append(out, big.NewInt(v))
+		}
+		return out
+	}
+	tests := []struct {
+		name string
+		fees []*big.Int
+		next int64
+		want string
+	}{
+		{"no history", nil, 100, types.BaseFeeStable},
+		{"within threshold", fees(100, 100), 105, types.BaseFeeStable},
+		{"rising", fees(100, 100), 106, types.BaseFeeRising},
+		{"falling", fees(100, 100), 94, types.BaseFeeFalling},
+		{"compared with the average", fees(90, 110), 104, types.BaseFeeStable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := baseFeeTrend(tt.fees, big.NewInt(tt.next)); got != tt.want {
+				t.Errorf("baseFeeTrend() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
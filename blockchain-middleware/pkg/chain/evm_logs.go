@@ -0,0 +1,220 @@
+package chain
+
+import (
+	"blockchain-middleware/pkg/types"
+	"context"
+	"fmt"
+	"log"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// WatchLogs 按过滤条件把合约日志交给handler，直到ctx取消或处理到 filter.ToBlock
+// 配置了 WsURL 时使用WebSocket订阅，连接失败或订阅中断后改为通过 EventWatcher 轮询。
+// 切换时从最后投递日志所在的区块重新查询，同一区块的日志可能重复投递。
+// 链重组时被孤立区块的日志以 Removed=true 再次投递，随后投递新主链上的日志。
+func (c *EVMClient) WatchLogs(ctx context.Context, filter types.EventFilter, handler EventHandler) error {
+	query, err := buildFilterQuery(filter)
+	if err != nil {
+		return err
+	}
+
+	// next 为下一个需要查询的区块
+	next := filter.FromBlock
+	if next == 0 {
+		head, err := c.GetBlockNumber(ctx)
+		if err != nil {
+			return err
+		}
+		next = head + 1
+	}
+
+	if c.config.WsURL != "" {
+		err := c.watchLogsWS(ctx, query, filter.ToBlock, handler, &next)
+		if err == nil || ctx.Err() != nil {
+			return nil
+		}
+		log.Printf("WebSocket log subscription on %s failed, falling back to polling: %v", c.config.Name, err)
+	}
+
+	return c.pollLogs(ctx, query, next, filter.ToBlock, handler)
+}
+
+// watchLogsWS 通过WebSocket订阅日志，订阅建立后先补齐 next 之后的历史日志
+// 正常结束（ctx取消或到达 toBlock）时返回nil，next 更新为中断后需要重新查询的区块。
+func (c *EVMClient) watchLogsWS(ctx context.Context, query ethereum.FilterQuery, toBlock uint64, handler EventHandler, next *uint64) error {
+	wsClient, err := ethclient.DialContext(ctx, c.config.WsURL)
+	if err != nil {
+		return fmt.Errorf("failed to connect to websocket: %w", err)
+	}
+	defer wsClient.Close()
+
+	logs := make(chan ethtypes.Log, 256)
+	sub, err := wsClient.SubscribeFilterLogs(ctx, query, logs)
+	if err != nil {
+		return fmt.Errorf("failed to subscribe logs: %w", err)
+	}
+	defer sub.Unsubscribe()
+
+	head, err := wsClient.BlockNumber(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get block number: %w", err)
+	}
+
+	// 补齐历史日志，订阅建立后到达的同区块日志按区块号去重
+	// 断线较久时缺口可能很大，按自适应跨度分批查询，避免超出节点的结果数和区块范围限制
+	end := head
+	if toBlock > 0 && toBlock < end {
+		end = toBlock
+	}
+	if *next <= end {
+		var ranges logRange
+		history, err := scanLogs(ctx, poolLogFilterer{pool: c.pool}, query, *next, end, &ranges)
+		if err != nil {
+			return fmt.Errorf("failed to backfill logs: %w", err)
+		}
+		for _, l := range history {
+			if err := handler.HandleEvent(l); err != nil {
+				log.Printf("Failed to handle event: %v", err)
+			}
+		}
+		*next = end + 1
+	}
+	if toBlock > 0 && *next > toBlock {
+		return nil
+	}
+	backfilled := *next - 1
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-sub.Err():
+			return fmt.Errorf("log subscription dropped: %w", err)
+		case l := <-logs:
+			if l.Removed {
+				// 重组回撤了已补齐的区块，新主链上的同区块日志不能再按区块号去重
+				if l.BlockNumber <= backfilled {
+					backfilled = l.BlockNumber - 1
+				}
+			} else if l.BlockNumber <= backfilled {
+				continue
+			}
+			if toBlock > 0 && l.BlockNumber > toBlock {
+				return nil
+			}
+			if err := handler.HandleEvent(l); err != nil {
+				log.Printf("Failed to handle event: %v", err)
+			}
+			// 中断后从最早被回撤的区块重新查询
+			if l.Removed && l.BlockNumber < *next || !l.Removed && l.BlockNumber > *next {
+				*next = l.BlockNumber
+			}
+		}
+	}
+}
+
+// pollLogs 通过 EventWatcher 轮询日志
+func (c *EVMClient) pollLogs(ctx context.Context, query ethereum.FilterQuery, fromBlock, toBlock uint64, handler EventHandler) error {
+	watcher := newEventWatcher(c.config, poolLogFilterer{pool: c.pool})
+	// 订阅与WebSocket推送一致，处理到最新区块
+	watcher.SetConfirmations(0)
+	watcher.SetFilter(query.Addresses, query.Topics)
+	watcher.SetBlockRange(fromBlock, toBlock)
+	watcher.RegisterLogHandler(handler)
+
+	if err := watcher.Start(); err != nil {
+		return err
+	}
+	defer watcher.Stop()
+
+	select {
+	case <-ctx.Done():
+	case <-watcher.Done():
+	}
+	return nil
+}
+
+// ValidateEventFilter 校验事件过滤器的合约地址、主题和区块范围
+func ValidateEventFilter(filter types.EventFilter) error {
+	_, err := buildFilterQuery(filter)
+	return err
+}
+
+// buildFilterQuery 将事件过滤器转换为日志查询条件（不含区块范围）
+// Topics 按位置匹配，空字符串表示该位置不限。
+func buildFilterQuery(filter types.EventFilter) (ethereum.FilterQuery, error) {
+	var query ethereum.FilterQuery
+
+	if filter.ToBlock > 0 && filter.FromBlock > filter.ToBlock {
+		return query, fmt.Errorf("from_block %d is after to_block %d", filter.FromBlock, filter.ToBlock)
+	}
+
+	if filter.ContractAddress != "" {
+		if !common.IsHexAddress(filter.ContractAddress) {
+			return query, fmt.Errorf("invalid contract address: %s", filter.ContractAddress)
+		}
+		query.Addresses = []common.Address{common.HexToAddress(filter.ContractAddress)}
+	}
+
+	for i, topic := range filter.Topics {
+		if topic == "" {
+			query.Topics = append(query.Topics, nil)
+			continue
+		}
+		raw := common.FromHex(topic)
+		if len(raw) != common.HashLength {
+			return query, fmt.Errorf("invalid topic at position %d: %s", i, topic)
+		}
+		query.Topics = append(query.Topics, []common.Hash{common.BytesToHash(raw)})
+	}
+
+	return query, nil
+}
+
+// NewEventWatcher 创建通过节点池查询的事件监听器
+func (c *EVMClient) NewEventWatcher() *EventWatcher {
+	return newEventWatcher(c.config, poolLogFilterer{pool: c.pool})
+}
+
+// poolLogFilterer 通过节点池执行 EventWatcher 的节点查询
+type poolLogFilterer struct {
+	pool *ProviderPool
+}
+
+// BlockNumber 获取最新区块号
+func (f poolLogFilterer) BlockNumber(ctx context.Context) (uint64, error) {
+	var blockNumber uint64
+	err := f.pool.Call(ctx, "eth_blockNumber", func(ctx context.Context, client *ethclient.Client) error {
+		var err error
+		blockNumber, err = client.BlockNumber(ctx)
+		return err
+	})
+	return blockNumber, err
+}
+
+// FilterLogs 查询日志
+func (f poolLogFilterer) FilterLogs(ctx context.Context, q ethereum.FilterQuery) ([]ethtypes.Log, error) {
+	var logs []ethtypes.Log
+	err := f.pool.Call(ctx, "eth_getLogs", func(ctx context.Context, client *ethclient.Client) error {
+		var err error
+		logs, err = client.FilterLogs(ctx, q)
+		return err
+	})
+	return logs, err
+}
+
+// HeaderByNumber 获取区块头
+func (f poolLogFilterer) HeaderByNumber(ctx context.Context, number *big.Int) (*ethtypes.Header, error) {
+	var header *ethtypes.Header
+	err := f.pool.Call(ctx, "eth_getBlockByNumber", func(ctx context.Context, client *ethclient.Client) error {
+		var err error
+		header, err = client.HeaderByNumber(ctx, number)
+		return err
+	})
+	return header, err
+}
@@ -0,0 +1,499 @@
+package chain
+
+import (
+	"blockchain-middleware/pkg/types"
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// ERC-165 接口ID
+var (
+	interfaceERC721           = [4]byte{0x80, 0xac, 0x58, 0xcd}
+	interfaceERC721Metadata   = [4]byte{0x5b, 0x5e, 0x13, 0x9f}
+	interfaceERC721Enumerable = [4]byte{0x78, 0x0e, 0x9d, 0x63}
+	interfaceERC1155          = [4]byte{0xd9, 0xb6, 0x7a, 0x26}
+	interfaceERC1155Metadata  = [4]byte{0x0e, 0x89, 0x34, 0x1c}
+)
+
+const (
+	// maxNFTsPerQuery 单次持有查询从日志中得到的候选NFT上限
+	maxNFTsPerQuery = 1000
+	// maxNFTScanBlocks 不限合约时单次持有查询扫描的区块数上限
+	// 候选数量只有扫描完才知道，不限合约地扫描全部历史会拉取链上所有NFT转账日志。
+	maxNFTScanBlocks = 100000
+)
+
+var (
+	// transferTopic ERC-721 Transfer(address,address,uint256)，与ERC-20相同但 tokenId 也是索引参数
+	transferTopic = crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
+	// transferSingleTopic ERC-1155 TransferSingle(address,address,address,uint256,uint256)
+	transferSingleTopic = crypto.Keccak256Hash([]byte("TransferSingle(address,address,address,uint256,uint256)"))
+	// transferBatchTopic ERC-1155 TransferBatch(address,address,address,uint256[],uint256[])
+	transferBatchTopic = crypto.Keccak256Hash([]byte("TransferBatch(address,address,address,uint256[],uint256[])"))
+)
+
+var (
+	erc721ABI = mustParseABI(`[
+		{"type":"function","name":"supportsInterface","inputs":[{"name":"interfaceId","type":"bytes4"}],"outputs":[{"name":"","type":"bool"}]},
+		{"type":"function","name":"name","inputs":[],"outputs":[{"name":"","type":"string"}]},
+		{"type":"function","name":"symbol","inputs":[],"outputs":[{"name":"","type":"string"}]},
+		{"type":"function","name":"ownerOf","inputs":[{"name":"tokenId","type":"uint256"}],"outputs":[{"name":"","type":"address"}]},
+		{"type":"function","name":"tokenURI","inputs":[{"name":"tokenId","type":"uint256"}],"outputs":[{"name":"","type":"string"}]},
+		{"type":"function","name":"safeTransferFrom","inputs":[{"name":"from","type":"address"},{"name":"to","type":"address"},{"name":"tokenId","type":"uint256"},{"name":"data","type":"bytes"}],"outputs":[]}]`)
+
+	erc1155ABI = mustParseABI(`[
+		{"type":"function","name":"balanceOf","inputs":[{"name":"account","type":"address"},{"name":"id","type":"uint256"}],"outputs":[{"name":"","type":"uint256"}]},
+		{"type":"function","name":"uri","inputs":[{"name":"id","type":"uint256"}],"outputs":[{"name":"","type":"string"}]},
+		{"type":"function","name":"safeTransferFrom","inputs":[{"name":"from","type":"address"},{"name":"to","type":"address"},{"name":"id","type":"uint256"},{"name":"amount","type":"uint256"},{"name":"data","type":"bytes"}],"outputs":[]},
+		{"type":"event","name":"TransferSingle","inputs":[{"name":"operator","type":"address","indexed":true},{"name":"from","type":"address","indexed":true},{"name":"to","type":"address","indexed":true},{"name":"id","type":"uint256"},{"name":"value","type":"uint256"}]},
+		{"type":"event","name":"TransferBatch","inputs":[{"name":"operator","type":"address","indexed":true},{"name":"from","type":"address","indexed":true},{"name":"to","type":"address","indexed":true},{"name":"ids","type":"uint256[]"},{"name":"values","type":"uint256[]"}]}]`)
+)
+
+// nftKey 合约地址和代币ID
+type nftKey struct {
+	contract common.Address
+	id       string
+}
+
+// nftCandidate 日志中转入过持有地址的NFT
+type nftCandidate struct {
+	contract common.Address
+	id       *big.Int
+	standard string
+}
+
+// GetNFTContract 通过 supportsInterface 检测合约的NFT标准，并读取名称和符号
+func (c *EVMClient) GetNFTContract(ctx context.Context, contractAddress string) (*types.NFTContract, error) {
+	if !common.IsHexAddress(contractAddress) {
+		return nil, fmt.Errorf("invalid contract address: %s", contractAddress)
+	}
+	contract := common.HexToAddress(contractAddress)
+
+	interfaces := [][4]byte{interfaceERC721, interfaceERC1155, interfaceERC721Metadata, interfaceERC721Enumerable, interfaceERC1155Metadata}
+	var calls []multicall
+	for _, id := range interfaces {
+		data, err := erc721ABI.Pack("supportsInterface", id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode supportsInterface: %w", err)
+		}
+		calls = append(calls, multicall{Target: contract, AllowFailure: true, CallData: data})
+	}
+	name, _ := erc721ABI.Pack("name")
+	symbol, _ := erc721ABI.Pack("symbol")
+	calls = append(calls,
+		multicall{Target: contract, AllowFailure: true, CallData: name},
+		multicall{Target: contract, AllowFailure: true, CallData: symbol})
+
+	results, err := c.aggregate3(ctx, calls)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &types.NFTContract{ContractAddress: contract.Hex()}
+	switch {
+	case supportsInterface(results[0]):
+		info.Standard = types.NFTStandardERC721
+		info.SupportsMetadata = supportsInterface(results[2])
+		info.SupportsEnumerable = supportsInterface(results[3])
+	case supportsInterface(results[1]):
+		info.Standard = types.NFTStandardERC1155
+		info.SupportsMetadata = supportsInterface(results[4])
+	}
+	info.Name = unpackString(results[5])
+	info.Symbol = unpackString(results[6])
+
+	if info.Standard != "" {
+		c.tokenMu.Lock()
+		if c.nftStandards == nil {
+			c.nftStandards = make(map[common.Address]string)
+		}
+		c.nftStandards[contract] = info.Standard
+		c.tokenMu.Unlock()
+	}
+	return info, nil
+}
+
+// GetNFT 获取NFT的元数据地址和持有信息
+// ERC-721返回当前持有者；ERC-1155没有唯一持有者，holderAddress 非空时返回该地址的持有数量，为空时不查询余额。
+func (c *EVMClient) GetNFT(ctx context.Context, contractAddress, tokenID, holderAddress string) (*types.NFTToken, error) {
+	id, err := parseTokenID(tokenID)
+	if err != nil {
+		return nil, err
+	}
+	if holderAddress != "" && !common.IsHexAddress(holderAddress) {
+		return nil, fmt.Errorf("invalid address: %s", holderAddress)
+	}
+	standard, err := c.nftStandard(ctx, contractAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	var holder common.Address
+	if holderAddress != "" {
+		holder = common.HexToAddress(holderAddress)
+	}
+	candidate := nftCandidate{contract: common.HexToAddress(contractAddress), id: id, standard: standard}
+	tokens, err := c.readNFTs(ctx, holder, []nftCandidate{candidate})
+	if err != nil {
+		return nil, err
+	}
+
+	token := tokens[0]
+	if standard == types.NFTStandardERC721 {
+		if token.Owner == "" {
+			return nil, fmt.Errorf("token %s does not exist in contract %s", id, candidate.contract.Hex())
+		}
+		if holderAddress != "" && !strings.EqualFold(token.Owner, holder.Hex()) {
+			token.Balance = "0"
+		}
+	}
+	return &token, nil
+}
+
+// GetNFTs 获取地址当前持有的ERC-721/ERC-1155代币
+// 从 fromBlock 起扫描转入该地址的 Transfer、TransferSingle、TransferBatch 日志得到候选代币，
+// 再通过 ownerOf/balanceOf 确认当前仍持有，同时读取 tokenURI/uri。
+// contractAddresses 为空时扫描所有合约，此时 fromBlock 必须在最近 maxNFTScanBlocks 个区块内。
+func (c *EVMClient) GetNFTs(ctx context.Context, holderAddress string, contractAddresses []string, fromBlock uint64) ([]types.NFTToken, error) {
+	if !common.IsHexAddress(holderAddress) {
+		return nil, fmt.Errorf("invalid address: %s", holderAddress)
+	}
+	if len(contractAddresses) > maxTokensPerQuery {
+		return nil, fmt.Errorf("too many contracts: %d (max %d)", len(contractAddresses), maxTokensPerQuery)
+	}
+	holder := common.HexToAddress(holderAddress)
+
+	contracts := make([]common.Address, len(contractAddresses))
+	for i, addr := range contractAddresses {
+		if !common.IsHexAddress(addr) {
+			return nil, fmt.Errorf("invalid contract address: %s", addr)
+		}
+		contracts[i] = common.HexToAddress(addr)
+	}
+
+	client := poolLogFilterer{pool: c.pool}
+	head, err := client.BlockNumber(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if fromBlock > head {
+		return nil, fmt.Errorf("from block %d is after latest block %d", fromBlock, head)
+	}
+	if len(contracts) == 0 && head-fromBlock >= maxNFTScanBlocks {
+		return nil, fmt.Errorf("contract addresses are required to scan more than %d blocks, pass contracts or a from_block after %d", maxNFTScanBlocks, head-maxNFTScanBlocks)
+	}
+
+	// 转入持有地址的日志：ERC-721 的 to 是第2个索引参数，ERC-1155 的 to 是第3个
+	holderTopic := common.BytesToHash(holder.Bytes())
+	erc721Logs, err := scanLogs(ctx, client, ethereum.FilterQuery{
+		Addresses: contracts,
+		Topics:    [][]common.Hash{{transferTopic}, nil, {holderTopic}},
+	}, fromBlock, head, &c.nftRanges)
+	if err != nil {
+		return nil, err
+	}
+	// 每条日志至多一个ERC-721候选，超出上限时不再扫描ERC-1155日志
+	if len(erc721Logs) > maxNFTsPerQuery {
+		if candidates, err := nftCandidates(erc721Logs); err == nil && len(candidates) > maxNFTsPerQuery {
+			return nil, fmt.Errorf("too many NFTs: more than %d, narrow the query by contracts or from_block", maxNFTsPerQuery)
+		}
+	}
+	erc1155Logs, err := scanLogs(ctx, client, ethereum.FilterQuery{
+		Addresses: contracts,
+		Topics:    [][]common.Hash{{transferSingleTopic, transferBatchTopic}, nil, nil, {holderTopic}},
+	}, fromBlock, head, &c.nftRanges)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates, err := nftCandidates(append(erc721Logs, erc1155Logs...))
+	if err != nil {
+		return nil, err
+	}
+	if len(candidates) > maxNFTsPerQuery {
+		return nil, fmt.Errorf("too many NFTs: %d (max %d), narrow the query by contracts or from_block", len(candidates), maxNFTsPerQuery)
+	}
+
+	tokens, err := c.readNFTs(ctx, holder, candidates)
+	if err != nil {
+		return nil, err
+	}
+
+	held := make([]types.NFTToken, 0, len(tokens))
+	for _, token := range tokens {
+		if token.Balance != "" && token.Balance != "0" && strings.EqualFold(token.Owner, holder.Hex()) {
+			held = append(held, token)
+		}
+	}
+	return held, nil
+}
+
+// EncodeNFTTransfer 生成NFT转账的 safeTransferFrom 调用数据
+// 按合约标准选择ERC-721或ERC-1155的方法，并确认 from 当前持有足够的代币。
+func (c *EVMClient) EncodeNFTTransfer(ctx context.Context, req *types.NFTTransferRequest) (*types.ContractEncodeResponse, error) {
+	if !common.IsHexAddress(req.From) {
+		return nil, fmt.Errorf("invalid from address: %s", req.From)
+	}
+	if !common.IsHexAddress(req.To) {
+		return nil, fmt.Errorf("invalid to address: %s", req.To)
+	}
+	id, err := parseTokenID(req.TokenID)
+	if err != nil {
+		return nil, err
+	}
+	var data []byte
+	if req.Data != "" {
+		if data, err = hexutil.Decode(req.Data); err != nil {
+			return nil, fmt.Errorf("invalid data: %w", err)
+		}
+	}
+	standard, err := c.nftStandard(ctx, req.ContractAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	contract := common.HexToAddress(req.ContractAddress)
+	from := common.HexToAddress(req.From)
+	to := common.HexToAddress(req.To)
+
+	holding, err := c.readNFTs(ctx, from, []nftCandidate{{contract: contract, id: id, standard: standard}})
+	if err != nil {
+		return nil, err
+	}
+
+	var callData []byte
+	var method string
+	switch standard {
+	case types.NFTStandardERC721:
+		if req.Amount != "" && req.Amount != "1" {
+			return nil, fmt.Errorf("amount must be 1 for ERC-721 tokens")
+		}
+		if !strings.EqualFold(holding[0].Owner, from.Hex()) {
+			return nil, fmt.Errorf("token %s is not owned by %s", id, from.Hex())
+		}
+		method = erc721ABI.Methods["safeTransferFrom"].Sig
+		callData, err = erc721ABI.Pack("safeTransferFrom", from, to, id, data)
+
+	case types.NFTStandardERC1155:
+		amount := big.NewInt(1)
+		if req.Amount != "" {
+			if amount, err = parseTokenID(req.Amount); err != nil || amount.Sign() == 0 {
+				return nil, fmt.Errorf("invalid amount: %s", req.Amount)
+			}
+		}
+		balance, _ := new(big.Int).SetString(holding[0].Balance, 10)
+		if balance == nil || balance.Cmp(amount) < 0 {
+			return nil, fmt.Errorf("insufficient balance of token %s: %s holds %s, transfer needs %s", id, from.Hex(), holding[0].Balance, amount)
+		}
+		method = erc1155ABI.Methods["safeTransferFrom"].Sig
+		callData, err = erc1155ABI.Pack("safeTransferFrom", from, to, id, amount, data)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode safeTransferFrom: %w", err)
+	}
+
+	return &types.ContractEncodeResponse{
+		Data:      hexutil.Encode(callData),
+		Selector:  hexutil.Encode(callData[:4]),
+		Signature: method,
+		Transaction: &types.TransactionRequest{
+			From:     from.Hex(),
+			To:       contract.Hex(),
+			Value:    big.NewInt(0),
+			GasLimit: req.GasLimit,
+			Data:     callData,
+			ChainID:  c.config.ChainID,
+		},
+	}, nil
+}
+
+// nftStandard 获取合约的NFT标准，结果按合约缓存
+func (c *EVMClient) nftStandard(ctx context.Context, contractAddress string) (string, error) {
+	if !common.IsHexAddress(contractAddress) {
+		return "", fmt.Errorf("invalid contract address: %s", contractAddress)
+	}
+
+	c.tokenMu.Lock()
+	standard, ok := c.nftStandards[common.HexToAddress(contractAddress)]
+	c.tokenMu.Unlock()
+	if ok {
+		return standard, nil
+	}
+
+	info, err := c.GetNFTContract(ctx, contractAddress)
+	if err != nil {
+		return "", err
+	}
+	if info.Standard == "" {
+		return "", fmt.Errorf("contract %s does not support ERC-721 or ERC-1155", contractAddress)
+	}
+	return info.Standard, nil
+}
+
+// readNFTs 批量读取NFT的持有信息和元数据地址
+// ERC-721读取 ownerOf 和 tokenURI，持有者为 holder 时 Balance 为1；ERC-1155读取 holder 的 balanceOf 和 uri，
+// holder 为零地址时只读取 uri，Owner 和 Balance 为空。代币不存在（ownerOf 回滚）时 Owner 为空。
+func (c *EVMClient) readNFTs(ctx context.Context, holder common.Address, candidates []nftCandidate) ([]types.NFTToken, error) {
+	noHolder := holder == (common.Address{})
+
+	var calls []multicall
+	for _, nft := range candidates {
+		var owner, uri []byte
+		var err error
+		switch {
+		case nft.standard == types.NFTStandardERC721:
+			owner, err = erc721ABI.Pack("ownerOf", nft.id)
+		case !noHolder:
+			owner, err = erc1155ABI.Pack("balanceOf", holder, nft.id)
+		}
+		if err == nil {
+			if nft.standard == types.NFTStandardERC721 {
+				uri, err = erc721ABI.Pack("tokenURI", nft.id)
+			} else {
+				uri, err = erc1155ABI.Pack("uri", nft.id)
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode nft query: %w", err)
+		}
+		if owner != nil {
+			calls = append(calls, multicall{Target: nft.contract, AllowFailure: true, CallData: owner})
+		}
+		calls = append(calls, multicall{Target: nft.contract, AllowFailure: true, CallData: uri})
+	}
+
+	results, err := c.aggregate3(ctx, calls)
+	if err != nil {
+		return nil, err
+	}
+
+	tokens := make([]types.NFTToken, len(candidates))
+	next := 0
+	for i, nft := range candidates {
+		token := types.NFTToken{
+			ContractAddress: nft.contract.Hex(),
+			TokenID:         nft.id.String(),
+			Standard:        nft.standard,
+		}
+
+		if nft.standard == types.NFTStandardERC1155 && noHolder {
+			token.TokenURI = erc1155TokenURI(unpackString(results[next]), nft.id)
+			next++
+			tokens[i] = token
+			continue
+		}
+		owner, uri := results[next], results[next+1]
+		next += 2
+
+		if nft.standard == types.NFTStandardERC721 {
+			token.TokenURI = unpackString(uri)
+			if owner.Success && len(owner.ReturnData) == 32 {
+				token.Owner = common.BytesToAddress(owner.ReturnData).Hex()
+				token.Balance = "1"
+			}
+		} else {
+			token.TokenURI = erc1155TokenURI(unpackString(uri), nft.id)
+			token.Owner = holder.Hex()
+			token.Balance = "0"
+			if owner.Success && len(owner.ReturnData) == 32 {
+				token.Balance = new(big.Int).SetBytes(owner.ReturnData).String()
+			}
+		}
+		tokens[i] = token
+	}
+	return tokens, nil
+}
+
+// nftCandidates 从转入日志中提取去重的候选NFT，按合约地址和代币ID排序
+// 与ERC-721共用 Transfer 签名的ERC-20日志只有3个主题，在这里被排除。
+func nftCandidates(logs []ethtypes.Log) ([]nftCandidate, error) {
+	seen := make(map[nftKey]bool)
+	var candidates []nftCandidate
+	add := func(contract common.Address, id *big.Int, standard string) {
+		key := nftKey{contract: contract, id: id.String()}
+		if !seen[key] {
+			seen[key] = true
+			candidates = append(candidates, nftCandidate{contract: contract, id: id, standard: standard})
+		}
+	}
+
+	for _, eventLog := range logs {
+		if eventLog.Removed || len(eventLog.Topics) == 0 {
+			continue
+		}
+		switch eventLog.Topics[0] {
+		case transferTopic:
+			if len(eventLog.Topics) == 4 {
+				add(eventLog.Address, eventLog.Topics[3].Big(), types.NFTStandardERC721)
+			}
+
+		case transferSingleTopic:
+			values, err := erc1155ABI.Unpack("TransferSingle", eventLog.Data)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode TransferSingle in tx %s: %w", eventLog.TxHash.Hex(), err)
+			}
+			add(eventLog.Address, values[0].(*big.Int), types.NFTStandardERC1155)
+
+		case transferBatchTopic:
+			values, err := erc1155ABI.Unpack("TransferBatch", eventLog.Data)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode TransferBatch in tx %s: %w", eventLog.TxHash.Hex(), err)
+			}
+			for _, id := range values[0].([]*big.Int) {
+				add(eventLog.Address, id, types.NFTStandardERC1155)
+			}
+		}
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if cmp := bytes.Compare(candidates[i].contract.Bytes(), candidates[j].contract.Bytes()); cmp != 0 {
+			return cmp < 0
+		}
+		return candidates[i].id.Cmp(candidates[j].id) < 0
+	})
+	return candidates, nil
+}
+
+// supportsInterface supportsInterface 调用是否返回 true
+// 未实现 ERC-165 的合约调用失败或返回空数据，视为不支持。
+func supportsInterface(result multicallResult) bool {
+	return result.Success && len(result.ReturnData) == 32 && new(big.Int).SetBytes(result.ReturnData).Cmp(big.NewInt(1)) == 0
+}
+
+// unpackString 解码返回 string 的调用结果（name、symbol、tokenURI、uri），失败时返回空字符串
+func unpackString(result multicallResult) string {
+	if !result.Success {
+		return ""
+	}
+	out, err := erc721ABI.Methods["name"].Outputs.Unpack(result.ReturnData)
+	if err != nil {
+		return ""
+	}
+	s, _ := out[0].(string)
+	return s
+}
+
+// erc1155TokenURI 按ERC-1155规范把 uri 中的 {id} 替换为64位小写十六进制代币ID
+func erc1155TokenURI(uri string, id *big.Int) string {
+	return strings.ReplaceAll(uri, "{id}", fmt.Sprintf("%064x", id))
+}
+
+// parseTokenID 解析代币ID或数量，支持十进制和0x十六进制
+func parseTokenID(value string) (*big.Int, error) {
+	value = strings.TrimSpace(value)
+	id, ok := new(big.Int).SetString(value, 0)
+	if !ok || value == "" || id.Sign() < 0 || id.BitLen() > 256 {
+		return nil, fmt.Errorf("invalid token id: %s", value)
+	}
+	return id, nil
+}
@@ -0,0 +1,377 @@
+package chain
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"strings"
+	"sync"
+	"testing"
+
+	"blockchain-middleware/internal/config"
+	"blockchain-middleware/pkg/types"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+)
+
+var (
+	testERC721  = common.HexToAddress("0x00000000000000000000000000000000000000b1")
+	testERC1155 = common.HexToAddress("0x00000000000000000000000000000000000000b2")
+	testHolder  = common.HexToAddress(testFrom)
+	testOther   = common.HexToAddress(testTo)
+)
+
+// fakeNFT 模拟ERC-721或ERC-1155合约，记录被调用的方法
+type fakeNFT struct {
+	mu       sync.Mutex
+	standard string
+	owners   map[int64]common.Address // ERC-721 持有者
+	balances map[int64]int64          // ERC-1155 testHolder 的持有数量
+	called   []string
+}
+
+func (n *fakeNFT) call(data []byte) ([]byte, error) {
+	contractABI := erc721ABI
+	if n.standard == types.NFTStandardERC1155 {
+		contractABI = erc1155ABI
+	}
+	method, err := contractABI.MethodById(data)
+	if err != nil {
+		// supportsInterface、name、symbol 只在 erc721ABI 中定义
+		method, err = erc721ABI.MethodById(data)
+	}
+	if err != nil {
+		return nil, &fakeRPCError{Code: 3, Message: "execution reverted"}
+	}
+	args, err := method.Inputs.Unpack(data[4:])
+	if err != nil {
+		return nil, err
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.called = append(n.called, method.Name)
+
+	switch method.Name {
+	case "supportsInterface":
+		id := args[0].([4]byte)
+		supported := id == interfaceERC721 || id == interfaceERC721Metadata
+		if n.standard == types.NFTStandardERC1155 {
+			supported = id == interfaceERC1155 || id == interfaceERC1155Metadata
+		}
+		return method.Outputs.Pack(supported)
+	case "name", "symbol":
+		return method.Outputs.Pack("Test")
+	case "ownerOf":
+		owner, ok := n.owners[args[0].(*big.Int).Int64()]
+		if !ok {
+			return nil, &fakeRPCError{Code: 3, Message: "execution reverted: invalid token ID"}
+		}
+		return method.Outputs.Pack(owner)
+	case "tokenURI":
+		return method.Outputs.Pack("ipfs://token/" + args[0].(*big.Int).String())
+	case "balanceOf":
+		account := args[0].(common.Address)
+		if account == (common.Address{}) {
+			// OpenZeppelin ERC1155 对零地址回滚
+			return nil, &fakeRPCError{Code: 3, Message: "execution reverted: address zero is not a valid owner"}
+		}
+		var balance int64
+		if account == testHolder {
+			balance = n.balances[args[1].(*big.Int).Int64()]
+		}
+		return method.Outputs.Pack(big.NewInt(balance))
+	case "uri":
+		return method.Outputs.Pack("ipfs://items/{id}.json")
+	}
+	return nil, &fakeRPCError{Code: 3, Message: "execution reverted"}
+}
+
+// calls 返回被调用的方法
+func (n *fakeNFT) calls() []string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return append([]string(nil), n.called...)
+}
+
+// fakeLogs 在 fakeNode 上模拟 eth_getLogs，按事件签名过滤日志
+func fakeLogs(node *fakeNode, head uint64, logs []ethtypes.Log) {
+	node.handle("eth_blockNumber", func([]json.RawMessage) (interface{}, error) {
+		return hexutil.Uint64(head), nil
+	})
+	node.handle("eth_getLogs", func(params []json.RawMessage) (interface{}, error) {
+		var filter struct {
+			Topics [][]common.Hash `json:"topics"`
+		}
+		if err := json.Unmarshal(params[0], &filter); err != nil {
+			return nil, err
+		}
+		result := []ethtypes.Log{}
+		for _, l := range logs {
+			for _, topic := range filter.Topics[0] {
+				if l.Topics[0] == topic {
+					result = append(result, l)
+				}
+			}
+		}
+		return result, nil
+	})
+}
+
+// erc721Transfer ERC-721 Transfer 日志
+func erc721Transfer(contract, to common.Address, id int64) ethtypes.Log {
+	return ethtypes.Log{
+		Address: contract,
+		Topics:  []common.Hash{transferTopic, {}, common.BytesToHash(to.Bytes()), common.BigToHash(big.NewInt(id))},
+		Data:    []byte{},
+	}
+}
+
+// erc1155Transfer ERC-1155 TransferSingle 日志
+func erc1155Transfer(t *testing.T, contract, to common.Address, id, value int64) ethtypes.Log {
+	t.Helper()
+	data, err := erc1155ABI.Events["TransferSingle"].Inputs.NonIndexed().Pack(big.NewInt(id), big.NewInt(value))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ethtypes.Log{
+		Address: contract,
+		Topics:  []common.Hash{transferSingleTopic, {}, {}, common.BytesToHash(to.Bytes())},
+		Data:    data,
+	}
+}
+
+func newTestNFTs(t *testing.T, node *fakeNode) (*fakeNFT, *fakeNFT) {
+	contracts := newFakeContracts(node, true)
+	erc721 := &fakeNFT{
+		standard: types.NFTStandardERC721,
+		owners:   map[int64]common.Address{1: testHolder, 2: testOther},
+	}
+	erc1155 := &fakeNFT{
+		standard: types.NFTStandardERC1155,
+		balances: map[int64]int64{5: 3},
+	}
+	contracts.deploy(testERC721, erc721.call)
+	contracts.deploy(testERC1155, erc1155.call)
+	return erc721, erc1155
+}
+
+func TestGetNFTs(t *testing.T) {
+	node, url := newFakeNode(t)
+	newTestNFTs(t, node)
+	fakeLogs(node, 500, []ethtypes.Log{
+		erc721Transfer(testERC721, testHolder, 1),
+		erc721Transfer(testERC721, testHolder, 2), // 之后转给了其他地址
+		erc1155Transfer(t, testERC1155, testHolder, 5, 3),
+		erc1155Transfer(t, testERC1155, testHolder, 6, 1), // 已全部转出
+	})
+	c := newTestEVMClient(t, config.ChainConfig{}, url)
+
+	nfts, err := c.GetNFTs(context.Background(), testHolder.Hex(), nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []types.NFTToken{
+		{ContractAddress: testERC721.Hex(), TokenID: "1", Standard: types.NFTStandardERC721, Owner: testHolder.Hex(), Balance: "1", TokenURI: "ipfs://token/1"},
+		{ContractAddress: testERC1155.Hex(), TokenID: "5", Standard: types.NFTStandardERC1155, Owner: testHolder.Hex(), Balance: "3",
+			TokenURI: "ipfs://items/0000000000000000000000000000000000000000000000000000000000000005.json"},
+	}
+	if len(nfts) != len(want) {
+		t.Fatalf("got %d NFTs %+v, want %d", len(nfts), nfts, len(want))
+	}
+	for i := range want {
+		if nfts[i] != want[i] {
+			t.Errorf("nft %d = %+v, want %+v", i, nfts[i], want[i])
+		}
+	}
+}
+
+func TestGetNFTsBoundsUnfilteredScans(t *testing.T) {
+	const head = 2 * maxNFTScanBlocks
+
+	tests := []struct {
+		name      string
+		contracts []string
+		fromBlock uint64
+		wantErr   string
+	}{
+		{"all contracts from genesis", nil, 0, "contract addresses are required"},
+		{"all contracts just outside the window", nil, head - maxNFTScanBlocks, "contract addresses are required"},
+		{"all contracts within the window", nil, head - maxNFTScanBlocks + 1, ""},
+		{"given contracts from genesis", []string{testERC721.Hex()}, 0, ""},
+		{"from block after head", []string{testERC721.Hex()}, head + 1, "after latest block"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node, url := newFakeNode(t)
+			newTestNFTs(t, node)
+			fakeLogs(node, head, nil)
+			c := newTestEVMClient(t, config.ChainConfig{}, url)
+
+			_, err := c.GetNFTs(context.Background(), testHolder.Hex(), tt.contracts, tt.fromBlock)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("GetNFTs() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("GetNFTs() error = %v, want %q", err, tt.wantErr)
+			}
+			if got := node.callCount("eth_getLogs"); got != 0 {
+				t.Errorf("rejected query scanned logs %d times", got)
+			}
+		})
+	}
+}
+
+func TestGetNFTsStopsAfterTooManyERC721Candidates(t *testing.T) {
+	node, url := newFakeNode(t)
+	newTestNFTs(t, node)
+	logs := make([]ethtypes.Log, maxNFTsPerQuery+1)
+	for i := range logs {
+		logs[i] = erc721Transfer(testERC721, testHolder, int64(i))
+	}
+	fakeLogs(node, 500, logs)
+	c := newTestEVMClient(t, config.ChainConfig{}, url)
+
+	_, err := c.GetNFTs(context.Background(), testHolder.Hex(), []string{testERC721.Hex()}, 0)
+	if err == nil || !strings.Contains(err.Error(), "too many NFTs") {
+		t.Fatalf("GetNFTs() error = %v, want too many NFTs", err)
+	}
+	// 只扫描了ERC-721日志，也没有读取持有信息
+	if got := node.callCount("eth_getLogs"); got != 1 {
+		t.Errorf("eth_getLogs count = %d, want 1", got)
+	}
+	if got := node.callCount("eth_call"); got != 0 {
+		t.Errorf("eth_call count = %d, want 0", got)
+	}
+}
+
+func TestGetNFT(t *testing.T) {
+	erc1155URI := "ipfs://items/0000000000000000000000000000000000000000000000000000000000000005.json"
+
+	tests := []struct {
+		name      string
+		contract  common.Address
+		tokenID   string
+		holder    string
+		want      types.NFTToken
+		wantCalls []string // 检测标准之后调用的方法
+		wantErr   string
+	}{
+		{
+			name:      "erc721 owner",
+			contract:  testERC721,
+			tokenID:   "1",
+			want:      types.NFTToken{TokenID: "1", Owner: testHolder.Hex(), Balance: "1", TokenURI: "ipfs://token/1"},
+			wantCalls: []string{"ownerOf", "tokenURI"},
+		},
+		{
+			name:      "erc721 held by someone else",
+			contract:  testERC721,
+			tokenID:   "0x02",
+			holder:    testHolder.Hex(),
+			want:      types.NFTToken{TokenID: "2", Owner: testOther.Hex(), Balance: "0", TokenURI: "ipfs://token/2"},
+			wantCalls: []string{"ownerOf", "tokenURI"},
+		},
+		{
+			name:     "erc721 missing token",
+			contract: testERC721,
+			tokenID:  "9",
+			wantErr:  "does not exist",
+		},
+		{
+			name:      "erc1155 holder balance",
+			contract:  testERC1155,
+			tokenID:   "5",
+			holder:    testHolder.Hex(),
+			want:      types.NFTToken{TokenID: "5", Owner: testHolder.Hex(), Balance: "3", TokenURI: erc1155URI},
+			wantCalls: []string{"balanceOf", "uri"},
+		},
+		{
+			name:      "erc1155 without holder skips balanceOf",
+			contract:  testERC1155,
+			tokenID:   "5",
+			want:      types.NFTToken{TokenID: "5", TokenURI: erc1155URI},
+			wantCalls: []string{"uri"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node, url := newFakeNode(t)
+			erc721, erc1155 := newTestNFTs(t, node)
+			c := newTestEVMClient(t, config.ChainConfig{}, url)
+
+			token, err := c.GetNFT(context.Background(), tt.contract.Hex(), tt.tokenID, tt.holder)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("GetNFT() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			nft := erc721
+			if tt.contract == testERC1155 {
+				nft = erc1155
+			}
+			want := tt.want
+			want.ContractAddress = tt.contract.Hex()
+			want.Standard = nft.standard
+			if *token != want {
+				t.Errorf("GetNFT() = %+v, want %+v", *token, want)
+			}
+
+			// 跳过 supportsInterface、name、symbol
+			calls := nft.calls()[7:]
+			if strings.Join(calls, ",") != strings.Join(tt.wantCalls, ",") {
+				t.Errorf("calls = %v, want %v", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestNFTCandidates(t *testing.T) {
+	batch, err := erc1155ABI.Events["TransferBatch"].Inputs.NonIndexed().Pack(
+		[]*big.Int{big.NewInt(8), big.NewInt(7)}, []*big.Int{big.NewInt(1), big.NewInt(1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	erc20 := ethtypes.Log{Address: testUSDC, Topics: []common.Hash{transferTopic, {}, common.BytesToHash(testHolder.Bytes())}}
+	removed := erc721Transfer(testERC721, testHolder, 4)
+	removed.Removed = true
+
+	logs := []ethtypes.Log{
+		erc721Transfer(testERC721, testHolder, 3),
+		erc721Transfer(testERC721, testHolder, 3), // 重复转入
+		erc20,
+		removed,
+		{Address: testERC1155, Topics: []common.Hash{transferBatchTopic, {}, {}, common.BytesToHash(testHolder.Bytes())}, Data: batch},
+		erc1155Transfer(t, testERC1155, testHolder, 7, 1),
+	}
+	candidates, err := nftCandidates(logs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"b1/3/ERC721", "b2/7/ERC1155", "b2/8/ERC1155"}
+	var got []string
+	for _, c := range candidates {
+		got = append(got, hexutil.Encode(c.contract.Bytes()[19:])[2:]+"/"+c.id.String()+"/"+c.standard)
+	}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("candidates = %v, want %v", got, want)
+	}
+
+	bad := erc1155Transfer(t, testERC1155, testHolder, 1, 1)
+	bad.Data = bad.Data[:10]
+	if _, err := nftCandidates([]ethtypes.Log{bad}); err == nil {
+		t.Error("nftCandidates() accepted a truncated TransferSingle")
+	}
+}
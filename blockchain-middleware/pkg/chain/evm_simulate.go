@@ -0,0 +1,453 @@
+package chain
+
+import (
+	"blockchain-middleware/pkg/types"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// approvalTopic ERC-20 Approval(address,address,uint256)，ERC-721的 tokenId 也是索引参数，主题数不同
+var approvalTopic = crypto.Keccak256Hash([]byte("Approval(address,address,uint256)"))
+
+// callTracerConfig debug_traceCall 使用 callTracer 并记录各调用帧的日志
+var callTracerConfig = json.RawMessage(`{"withLog":true}`)
+
+// callFrame callTracer 输出的调用帧
+type callFrame struct {
+	Type    string         `json:"type"`
+	From    common.Address `json:"from"`
+	To      common.Address `json:"to"`
+	Value   *hexutil.Big   `json:"value"`
+	GasUsed hexutil.Uint64 `json:"gasUsed"`
+	Error   string         `json:"error"`
+	Calls   []callFrame    `json:"calls"`
+	Logs    []callFrameLog `json:"logs"`
+}
+
+// callFrameLog 调用帧中的日志，Position 为日志之前已发生的子调用数（旧版本节点没有该字段）
+type callFrameLog struct {
+	Address  common.Address `json:"address"`
+	Topics   []common.Hash  `json:"topics"`
+	Data     hexutil.Bytes  `json:"data"`
+	Position hexutil.Uint   `json:"position"`
+}
+
+// overrideAccount eth_call 和 debug_traceCall 的账户状态覆盖参数
+type overrideAccount struct {
+	Balance   *hexutil.Big                `json:"balance,omitempty"`
+	Nonce     *hexutil.Uint64             `json:"nonce,omitempty"`
+	Code      *hexutil.Bytes              `json:"code,omitempty"`
+	State     map[common.Hash]common.Hash `json:"state,omitempty"`
+	StateDiff map[common.Hash]common.Hash `json:"stateDiff,omitempty"`
+}
+
+// balanceKey 余额变化按地址和代币汇总，原生币的代币地址为零地址
+type balanceKey struct {
+	account common.Address
+	token   common.Address
+}
+
+// SimulateTransaction 在指定区块的状态上模拟执行交易
+// 先用 eth_call 得到执行结果或回滚数据，再用 debug_traceCall 的 callTracer 统计原生币转账、
+// ERC-20 Transfer 和 Approval；节点不支持追踪时只返回执行结果，Gas 改用 eth_estimateGas 估算。
+func (c *EVMClient) SimulateTransaction(ctx context.Context, req *types.SimulationRequest) (*types.SimulationResult, error) {
+	args, err := simulationArgs(&req.TransactionRequest)
+	if err != nil {
+		return nil, err
+	}
+	overrides, err := stateOverrides(req.StateOverrides)
+	if err != nil {
+		return nil, err
+	}
+
+	// 固定区块号，保证 eth_call 和追踪基于同一状态
+	blockNumber := req.BlockNumber
+	if blockNumber == 0 {
+		if blockNumber, err = c.GetBlockNumber(ctx); err != nil {
+			return nil, err
+		}
+	}
+	block := hexutil.EncodeUint64(blockNumber)
+	params := []interface{}{args, block}
+	if len(overrides) > 0 {
+		params = append(params, overrides)
+	}
+
+	result := &types.SimulationResult{BlockNumber: blockNumber}
+	var output hexutil.Bytes
+	err = c.pool.Call(ctx, "eth_call", func(ctx context.Context, client *ethclient.Client) error {
+		return client.Client().CallContext(ctx, &output, "eth_call", params...)
+	})
+	if err != nil {
+		var rpcErr rpc.Error
+		revertData, reverted := RevertData(err)
+		switch {
+		case reverted:
+			result.Error = "execution reverted"
+			result.Revert = &types.ContractRevert{}
+			if len(revertData) > 0 {
+				result.Revert.Data = hexutil.Encode(revertData)
+			}
+		case errors.As(err, &rpcErr):
+			// 余额不足、nonce错误等由节点拒绝执行的情况也是模拟结果
+			result.Error = rpcErr.Error()
+		default:
+			return nil, fmt.Errorf("failed to simulate transaction: %w", err)
+		}
+	} else {
+		result.Success = true
+		result.Result = output
+	}
+
+	// 节点拒绝执行的交易没有执行过程可以追踪
+	if !result.Success && result.Revert == nil {
+		return result, nil
+	}
+
+	frame, err := c.traceCall(ctx, args, block, overrides)
+	if err != nil {
+		result.TraceError = err.Error()
+	} else {
+		result.Traced = true
+		result.GasUsed = uint64(frame.GasUsed)
+		c.previewChanges(ctx, result, frame)
+	}
+
+	if !result.Traced && result.Success {
+		var gas hexutil.Uint64
+		err := c.pool.Call(ctx, "eth_estimateGas", func(ctx context.Context, client *ethclient.Client) error {
+			return client.Client().CallContext(ctx, &gas, "eth_estimateGas", params...)
+		})
+		if err != nil {
+			log.Printf("Failed to estimate gas for simulation on %s: %v", c.config.Name, err)
+		}
+		result.GasUsed = uint64(gas)
+	}
+
+	return result, nil
+}
+
+// traceCall 用 callTracer 追踪调用
+func (c *EVMClient) traceCall(ctx context.Context, args map[string]interface{}, block string, overrides map[common.Address]overrideAccount) (*callFrame, error) {
+	traceConfig := map[string]interface{}{
+		"tracer":       "callTracer",
+		"tracerConfig": callTracerConfig,
+	}
+	if len(overrides) > 0 {
+		traceConfig["stateOverrides"] = overrides
+	}
+
+	var frame callFrame
+	err := c.pool.Call(ctx, "debug_traceCall", func(ctx context.Context, client *ethclient.Client) error {
+		return client.Client().CallContext(ctx, &frame, "debug_traceCall", args, block, traceConfig)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to trace call: %w", err)
+	}
+	return &frame, nil
+}
+
+// previewChanges 从调用帧中汇总日志、余额变化和授权
+func (c *EVMClient) previewChanges(ctx context.Context, result *types.SimulationResult, frame *callFrame) {
+	result.Logs = collectLogs(frame, nil)
+	for i, eventLog := range result.Logs {
+		eventLog.BlockNumber = result.BlockNumber
+		eventLog.Index = uint(i)
+	}
+
+	deltas := make(map[balanceKey]*big.Int)
+	add := func(account, token common.Address, amount *big.Int, sign int) {
+		// 铸造和销毁的零地址不计入
+		if account == (common.Address{}) || amount.Sign() == 0 {
+			return
+		}
+		key := balanceKey{account: account, token: token}
+		if deltas[key] == nil {
+			deltas[key] = new(big.Int)
+		}
+		if sign < 0 {
+			deltas[key].Sub(deltas[key], amount)
+		} else {
+			deltas[key].Add(deltas[key], amount)
+		}
+	}
+	nativeTransfers(frame, func(from, to common.Address, value *big.Int) {
+		add(from, common.Address{}, value, -1)
+		add(to, common.Address{}, value, 1)
+	})
+
+	type approvalKey struct{ token, owner, spender common.Address }
+	approvals := make(map[approvalKey]*big.Int)
+	var approvalOrder []approvalKey
+	tokenSet := make(map[common.Address]bool)
+	for _, eventLog := range result.Logs {
+		// ERC-20 事件：三个主题（签名、from/owner、to/spender），数据为32字节金额
+		if len(eventLog.Topics) != 3 || len(eventLog.Data) != 32 {
+			continue
+		}
+		first := common.BytesToAddress(eventLog.Topics[1].Bytes())
+		second := common.BytesToAddress(eventLog.Topics[2].Bytes())
+		amount := new(big.Int).SetBytes(eventLog.Data)
+		switch eventLog.Topics[0] {
+		case transferTopic:
+			add(first, eventLog.Address, amount, -1)
+			add(second, eventLog.Address, amount, 1)
+			tokenSet[eventLog.Address] = true
+		case approvalTopic:
+			key := approvalKey{token: eventLog.Address, owner: first, spender: second}
+			if _, ok := approvals[key]; !ok {
+				approvalOrder = append(approvalOrder, key)
+			}
+			approvals[key] = amount
+			tokenSet[eventLog.Address] = true
+		}
+	}
+
+	tokens := make([]common.Address, 0, len(tokenSet))
+	for token := range tokenSet {
+		tokens = append(tokens, token)
+	}
+	metas := c.tokenMetadatas(ctx, tokens)
+
+	for key, delta := range deltas {
+		if delta.Sign() == 0 {
+			continue
+		}
+		change := types.BalanceChange{Address: key.account.Hex(), Delta: delta}
+		if key.token == (common.Address{}) {
+			change.Symbol = c.config.NativeSymbol
+			change.Decimals = c.config.NativeDecimals
+			change.Formatted = formatUnits(delta, change.Decimals, change.Symbol)
+		} else {
+			change.Token = key.token.Hex()
+			// 查询不到精度的代币只返回原始金额
+			if meta, ok := metas[key.token]; ok {
+				change.Symbol = meta.symbol
+				change.Decimals = meta.decimals
+				change.Formatted = formatUnits(delta, meta.decimals, meta.symbol)
+			}
+		}
+		result.BalanceChanges = append(result.BalanceChanges, change)
+	}
+	sort.Slice(result.BalanceChanges, func(i, j int) bool {
+		a, b := result.BalanceChanges[i], result.BalanceChanges[j]
+		if a.Address != b.Address {
+			return a.Address < b.Address
+		}
+		return a.Token < b.Token
+	})
+
+	for _, key := range approvalOrder {
+		meta, ok := metas[key.token]
+		approval := types.TokenApproval{
+			Token:     key.token.Hex(),
+			Symbol:    meta.symbol,
+			Decimals:  meta.decimals,
+			Owner:     key.owner.Hex(),
+			Spender:   key.spender.Hex(),
+			Amount:    approvals[key],
+			Unlimited: approvals[key].Cmp(abi.MaxUint256) == 0,
+		}
+		if approval.Unlimited {
+			approval.Formatted = strings.TrimSpace("unlimited " + meta.symbol)
+		} else if ok {
+			approval.Formatted = formatUnits(approval.Amount, meta.decimals, meta.symbol)
+		}
+		result.Approvals = append(result.Approvals, approval)
+	}
+}
+
+// collectLogs 按执行顺序收集成功调用帧中的日志，回滚的调用帧及其子调用的日志不会上链
+func collectLogs(frame *callFrame, logs []*ethtypes.Log) []*ethtypes.Log {
+	if frame.Error != "" {
+		return logs
+	}
+
+	next := 0
+	emit := func(before int) {
+		for ; next < len(frame.Logs) && int(frame.Logs[next].Position) <= before; next++ {
+			l := frame.Logs[next]
+			logs = append(logs, &ethtypes.Log{Address: l.Address, Topics: l.Topics, Data: l.Data})
+		}
+	}
+	for i := range frame.Calls {
+		emit(i)
+		logs = collectLogs(&frame.Calls[i], logs)
+	}
+	emit(len(frame.Logs) + len(frame.Calls))
+	return logs
+}
+
+// nativeTransfers 遍历成功调用帧中的原生币转账
+// DELEGATECALL 和 STATICCALL 的 value 只是上下文中的值，不发生转账。
+func nativeTransfers(frame *callFrame, transfer func(from, to common.Address, value *big.Int)) {
+	if frame.Error != "" {
+		return
+	}
+
+	switch frame.Type {
+	case "CALL", "CREATE", "CREATE2", "SELFDESTRUCT":
+		if frame.Value != nil && frame.Value.ToInt().Sign() > 0 {
+			transfer(frame.From, frame.To, frame.Value.ToInt())
+		}
+	}
+	for i := range frame.Calls {
+		nativeTransfers(&frame.Calls[i], transfer)
+	}
+}
+
+// simulationArgs 把交易请求转换为 eth_call 参数，To 为空时模拟合约部署
+func simulationArgs(req *types.TransactionRequest) (map[string]interface{}, error) {
+	args := map[string]interface{}{}
+	if req.From != "" {
+		if !common.IsHexAddress(req.From) {
+			return nil, fmt.Errorf("invalid from address: %s", req.From)
+		}
+		args["from"] = common.HexToAddress(req.From)
+	}
+	if req.To != "" {
+		if !common.IsHexAddress(req.To) {
+			return nil, fmt.Errorf("invalid to address: %s", req.To)
+		}
+		args["to"] = common.HexToAddress(req.To)
+	}
+	if req.GasLimit > 0 {
+		args["gas"] = hexutil.Uint64(req.GasLimit)
+	}
+	if req.MaxFeePerGas != nil || req.MaxPriorityFeePerGas != nil {
+		if req.MaxFeePerGas != nil {
+			args["maxFeePerGas"] = (*hexutil.Big)(req.MaxFeePerGas)
+		}
+		if req.MaxPriorityFeePerGas != nil {
+			args["maxPriorityFeePerGas"] = (*hexutil.Big)(req.MaxPriorityFeePerGas)
+		}
+	} else if req.GasPrice != nil {
+		args["gasPrice"] = (*hexutil.Big)(req.GasPrice)
+	}
+	if req.Value != nil {
+		args["value"] = (*hexutil.Big)(req.Value)
+	}
+	if req.Nonce != nil {
+		args["nonce"] = hexutil.Uint64(*req.Nonce)
+	}
+	if len(req.Data) > 0 {
+		args["input"] = hexutil.Bytes(req.Data)
+	}
+	return args, nil
+}
+
+// stateOverrides 校验并转换状态覆盖参数
+func stateOverrides(overrides map[string]types.StateOverride) (map[common.Address]overrideAccount, error) {
+	if len(overrides) == 0 {
+		return nil, nil
+	}
+
+	accounts := make(map[common.Address]overrideAccount, len(overrides))
+	for address, override := range overrides {
+		if !common.IsHexAddress(address) {
+			return nil, fmt.Errorf("invalid override address: %s", address)
+		}
+		if override.State != nil && override.StateDiff != nil {
+			return nil, fmt.Errorf("override for %s sets both state and state_diff", address)
+		}
+
+		var account overrideAccount
+		if override.Balance != nil {
+			if override.Balance.Sign() < 0 {
+				return nil, fmt.Errorf("invalid override balance for %s", address)
+			}
+			account.Balance = (*hexutil.Big)(override.Balance)
+		}
+		if override.Nonce != nil {
+			n := hexutil.Uint64(*override.Nonce)
+			account.Nonce = &n
+		}
+		if override.Code != "" {
+			code, err := hexutil.Decode(override.Code)
+			if err != nil {
+				return nil, fmt.Errorf("invalid override code for %s: %w", address, err)
+			}
+			account.Code = (*hexutil.Bytes)(&code)
+		}
+
+		var err error
+		if account.State, err = storageOverride(override.State); err != nil {
+			return nil, fmt.Errorf("invalid override state for %s: %w", address, err)
+		}
+		if account.StateDiff, err = storageOverride(override.StateDiff); err != nil {
+			return nil, fmt.Errorf("invalid override state_diff for %s: %w", address, err)
+		}
+		accounts[common.HexToAddress(address)] = account
+	}
+	return accounts, nil
+}
+
+// storageOverride 解析存储槽覆盖，槽和值都是不超过32字节的0x十六进制
+func storageOverride(slots map[string]string) (map[common.Hash]common.Hash, error) {
+	if slots == nil {
+		return nil, nil
+	}
+
+	storage := make(map[common.Hash]common.Hash, len(slots))
+	for slot, value := range slots {
+		key, err := parseWord(slot)
+		if err != nil {
+			return nil, err
+		}
+		if storage[key], err = parseWord(value); err != nil {
+			return nil, err
+		}
+	}
+	return storage, nil
+}
+
+// parseWord 解析32字节的存储字，较短的值左侧补零
+func parseWord(value string) (common.Hash, error) {
+	b, err := hexutil.Decode(value)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("invalid storage word %q: %w", value, err)
+	}
+	if len(b) > common.HashLength {
+		return common.Hash{}, fmt.Errorf("storage word %s exceeds 32 bytes", value)
+	}
+	return common.BytesToHash(b), nil
+}
+
+// formatUnits 按精度把最小单位的金额换算为十进制字符串，如 -500 USDC
+func formatUnits(amount *big.Int, decimals uint8, symbol string) string {
+	abs := new(big.Int).Abs(amount)
+	unit := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)
+	whole, frac := new(big.Int).QuoRem(abs, unit, new(big.Int))
+
+	var buf bytes.Buffer
+	if amount.Sign() < 0 {
+		buf.WriteByte('-')
+	}
+	buf.WriteString(whole.String())
+	if frac.Sign() > 0 {
+		digits := frac.String()
+		digits = strings.Repeat("0", int(decimals)-len(digits)) + digits
+		buf.WriteByte('.')
+		buf.WriteString(strings.TrimRight(digits, "0"))
+	}
+	if symbol != "" {
+		buf.WriteByte(' ')
+		buf.WriteString(symbol)
+	}
+	return buf.String()
+}
@@ -0,0 +1,321 @@
+package chain
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+
+	"blockchain-middleware/internal/config"
+	"blockchain-middleware/pkg/types"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+var (
+	testAlice  = common.HexToAddress(testFrom)
+	testRouter = common.HexToAddress(testTo)
+	testBob    = common.HexToAddress("0x3333333333333333333333333333333333333333")
+)
+
+// markedLog 以数据的第一个字节标记的日志
+func markedLog(mark byte, position uint) callFrameLog {
+	return callFrameLog{Address: testUSDC, Topics: []common.Hash{transferTopic}, Data: []byte{mark}, Position: hexutil.Uint(position)}
+}
+
+// tokenLog ERC-20 Transfer 或 Approval 日志
+func tokenLog(topic common.Hash, token, first, second common.Address, amount *big.Int) callFrameLog {
+	return callFrameLog{
+		Address: token,
+		Topics:  []common.Hash{topic, common.BytesToHash(first.Bytes()), common.BytesToHash(second.Bytes())},
+		Data:    common.BigToHash(amount).Bytes(),
+	}
+}
+
+func TestCollectLogs(t *testing.T) {
+	tests := []struct {
+		name  string
+		frame callFrame
+		want  []byte
+	}{
+		{
+			name: "ordered by position",
+			frame: callFrame{
+				Logs: []callFrameLog{markedLog(0, 0), markedLog(2, 1), markedLog(4, 2)},
+				Calls: []callFrame{
+					{Logs: []callFrameLog{markedLog(1, 0)}},
+					{Logs: []callFrameLog{markedLog(3, 0)}},
+				},
+			},
+			want: []byte{0, 1, 2, 3, 4},
+		},
+		{
+			// 旧版本节点没有 position，帧自身的日志排在子调用之前
+			name: "without position",
+			frame: callFrame{
+				Logs:  []callFrameLog{markedLog(0, 0), markedLog(1, 0)},
+				Calls: []callFrame{{Logs: []callFrameLog{markedLog(2, 0)}}},
+			},
+			want: []byte{0, 1, 2},
+		},
+		{
+			name: "nested calls",
+			frame: callFrame{
+				Logs: []callFrameLog{markedLog(3, 1)},
+				Calls: []callFrame{{
+					Logs:  []callFrameLog{markedLog(0, 0), markedLog(2, 1)},
+					Calls: []callFrame{{Logs: []callFrameLog{markedLog(1, 0)}}},
+				}},
+			},
+			want: []byte{0, 1, 2, 3},
+		},
+		{
+			name: "reverted sub frame",
+			frame: callFrame{
+				Logs: []callFrameLog{markedLog(0, 0), markedLog(2, 2)},
+				Calls: []callFrame{
+					{
+						Error: "execution reverted",
+						Logs:  []callFrameLog{markedLog(9, 0)},
+						Calls: []callFrame{{Logs: []callFrameLog{markedLog(9, 0)}}},
+					},
+					{Logs: []callFrameLog{markedLog(1, 0)}},
+				},
+			},
+			want: []byte{0, 1, 2},
+		},
+		{
+			name: "reverted root",
+			frame: callFrame{
+				Error: "execution reverted",
+				Logs:  []callFrameLog{markedLog(9, 0)},
+				Calls: []callFrame{{Logs: []callFrameLog{markedLog(9, 0)}}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []byte
+			for _, eventLog := range collectLogs(&tt.frame, nil) {
+				got = append(got, eventLog.Data[0])
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("collectLogs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNativeTransfers(t *testing.T) {
+	value := func(n int64) *hexutil.Big { return (*hexutil.Big)(big.NewInt(n)) }
+	tests := []struct {
+		name  string
+		frame callFrame
+		want  []string
+	}{
+		{
+			name: "nested calls",
+			frame: callFrame{Type: "CALL", From: testAlice, To: testRouter, Value: value(10), Calls: []callFrame{
+				{Type: "CALL", From: testRouter, To: testBob, Value: value(4)},
+				{Type: "CREATE2", From: testRouter, To: testUSDC, Value: value(1)},
+				{Type: "SELFDESTRUCT", From: testUSDC, To: testBob, Value: value(1)},
+			}},
+			want: []string{"alice->router 10", "router->bob 4", "router->usdc 1", "usdc->bob 1"},
+		},
+		{
+			// 只是调用上下文中的 value，不发生转账
+			name: "delegatecall and staticcall",
+			frame: callFrame{Type: "CALL", From: testAlice, To: testRouter, Value: value(10), Calls: []callFrame{
+				{Type: "DELEGATECALL", From: testRouter, To: testUSDC, Value: value(10)},
+				{Type: "STATICCALL", From: testRouter, To: testBob, Value: value(10)},
+			}},
+			want: []string{"alice->router 10"},
+		},
+		{
+			name: "reverted sub frame",
+			frame: callFrame{Type: "CALL", From: testAlice, To: testRouter, Value: value(10), Calls: []callFrame{
+				{Type: "CALL", From: testRouter, To: testBob, Value: value(4), Error: "execution reverted", Calls: []callFrame{
+					{Type: "CALL", From: testBob, To: testUSDC, Value: value(1)},
+				}},
+			}},
+			want: []string{"alice->router 10"},
+		},
+		{
+			name:  "reverted root",
+			frame: callFrame{Type: "CALL", From: testAlice, To: testRouter, Value: value(10), Error: "out of gas"},
+		},
+		{
+			name: "without value",
+			frame: callFrame{Type: "CALL", From: testAlice, To: testRouter, Calls: []callFrame{
+				{Type: "CALL", From: testRouter, To: testBob, Value: value(0)},
+			}},
+		},
+	}
+	names := map[common.Address]string{testAlice: "alice", testRouter: "router", testBob: "bob", testUSDC: "usdc"}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			nativeTransfers(&tt.frame, func(from, to common.Address, value *big.Int) {
+				got = append(got, fmt.Sprintf("%s->%s %s", names[from], names[to], value))
+			})
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("nativeTransfers() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPreviewChanges(t *testing.T) {
+	node, url := newFakeNode(t)
+	deployTestTokens(newFakeContracts(node, true))
+	c := newTestEVMClient(t, config.ChainConfig{NativeSymbol: "ETH", NativeDecimals: 18}, url)
+
+	erc721 := tokenLog(transferTopic, testMKR, testAlice, testBob, big.NewInt(0))
+	erc721.Topics = append(erc721.Topics, common.BigToHash(big.NewInt(7)))
+	erc721.Data = nil
+	frame := &callFrame{
+		Type:  "CALL",
+		From:  testAlice,
+		To:    testRouter,
+		Value: (*hexutil.Big)(big.NewInt(1e18)),
+		Logs: []callFrameLog{
+			tokenLog(transferTopic, testUSDC, testAlice, testBob, big.NewInt(1500000)),
+			// 铸造的零地址不计入
+			tokenLog(transferTopic, testUSDC, common.Address{}, testAlice, big.NewInt(250000)),
+			erc721,
+			tokenLog(approvalTopic, testUSDC, testAlice, testRouter, abi.MaxUint256),
+			tokenLog(approvalTopic, testMKR, testAlice, testRouter, big.NewInt(1e18)),
+			tokenLog(approvalTopic, testMKR, testAlice, testRouter, big.NewInt(2e18)),
+			tokenLog(approvalTopic, testEOA, testAlice, testRouter, big.NewInt(5)),
+		},
+		Calls: []callFrame{
+			{Type: "CALL", From: testRouter, To: testBob, Value: (*hexutil.Big)(big.NewInt(4e17))},
+			{Type: "CALL", From: testRouter, To: testUSDC, Value: (*hexutil.Big)(big.NewInt(1e18)), Error: "execution reverted", Logs: []callFrameLog{
+				tokenLog(transferTopic, testUSDC, testRouter, testBob, big.NewInt(1)),
+			}},
+		},
+	}
+
+	result := &types.SimulationResult{BlockNumber: 16}
+	c.previewChanges(context.Background(), result, frame)
+
+	if len(result.Logs) != 7 {
+		t.Fatalf("%d logs, want 7", len(result.Logs))
+	}
+	for i, eventLog := range result.Logs {
+		if eventLog.Index != uint(i) || eventLog.BlockNumber != 16 {
+			t.Errorf("log %d: index %d, block %d", i, eventLog.Index, eventLog.BlockNumber)
+		}
+	}
+
+	var changes []string
+	for _, change := range result.BalanceChanges {
+		changes = append(changes, fmt.Sprintf("%s %s %s %s", change.Address, change.Token, change.Delta, change.Formatted))
+	}
+	wantChanges := []string{
+		testAlice.Hex() + "  -1000000000000000000 -1 ETH",
+		testAlice.Hex() + " " + testUSDC.Hex() + " -1250000 -1.25 USDC",
+		testRouter.Hex() + "  600000000000000000 0.6 ETH",
+		testBob.Hex() + "  400000000000000000 0.4 ETH",
+		testBob.Hex() + " " + testUSDC.Hex() + " 1500000 1.5 USDC",
+	}
+	if !reflect.DeepEqual(changes, wantChanges) {
+		t.Errorf("balance changes:\n%s\nwant\n%s", strings.Join(changes, "\n"), strings.Join(wantChanges, "\n"))
+	}
+
+	// 同一授权多次设置时取最终值，查询不到精度的代币不换算
+	var approvals []string
+	for _, approval := range result.Approvals {
+		if approval.Owner != testAlice.Hex() || approval.Spender != testRouter.Hex() {
+			t.Errorf("approval = %+v", approval)
+		}
+		approvals = append(approvals, fmt.Sprintf("%s %v %q", approval.Token, approval.Unlimited, approval.Formatted))
+	}
+	wantApprovals := []string{
+		testUSDC.Hex() + ` true "unlimited USDC"`,
+		testMKR.Hex() + ` false "2 MKR"`,
+		testEOA.Hex() + ` false ""`,
+	}
+	if !reflect.DeepEqual(approvals, wantApprovals) {
+		t.Errorf("approvals:\n%s\nwant\n%s", strings.Join(approvals, "\n"), strings.Join(wantApprovals, "\n"))
+	}
+}
+
+func TestSimulateTransaction(t *testing.T) {
+	req := &types.SimulationRequest{TransactionRequest: types.TransactionRequest{From: testFrom, To: testTo, Value: big.NewInt(1)}}
+	// serveCall 设置 eth_call 的结果，并检查模拟固定在最新区块上
+	serveCall := func(t *testing.T, node *fakeNode, result interface{}, err error) {
+		node.handle("eth_call", func(params []json.RawMessage) (interface{}, error) {
+			if string(params[1]) != `"0x10"` {
+				t.Errorf("eth_call block = %s, want 0x10", params[1])
+			}
+			return result, err
+		})
+	}
+
+	t.Run("reverted", func(t *testing.T) {
+		node, url := newFakeNode(t)
+		serveCall(t, node, nil, &fakeRPCError{Code: 3, Message: "execution reverted", Data: "0xdeadbeef"})
+		node.handle("debug_traceCall", func([]json.RawMessage) (interface{}, error) {
+			return callFrame{Type: "CALL", From: testAlice, To: testRouter, GasUsed: 30000, Error: "execution reverted",
+				Logs: []callFrameLog{tokenLog(transferTopic, testUSDC, testAlice, testBob, big.NewInt(1))}}, nil
+		})
+		c := newTestEVMClient(t, config.ChainConfig{}, url)
+
+		result, err := c.SimulateTransaction(context.Background(), req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Success || result.Error != "execution reverted" || result.Revert == nil || result.Revert.Data != "0xdeadbeef" {
+			t.Errorf("result = %+v, revert %+v", result, result.Revert)
+		}
+		// 回滚的交易仍然追踪，但没有日志和余额变化
+		if !result.Traced || result.GasUsed != 30000 || len(result.Logs) != 0 || len(result.BalanceChanges) != 0 {
+			t.Errorf("trace = %v, gas %d, %d logs, %d balance changes", result.Traced, result.GasUsed, len(result.Logs), len(result.BalanceChanges))
+		}
+		if n := node.callCount("eth_estimateGas"); n != 0 {
+			t.Errorf("eth_estimateGas called %d times", n)
+		}
+	})
+
+	t.Run("rejected by the node", func(t *testing.T) {
+		node, url := newFakeNode(t)
+		serveCall(t, node, nil, &fakeRPCError{Code: -32000, Message: "insufficient funds for gas * price + value"})
+		c := newTestEVMClient(t, config.ChainConfig{}, url)
+
+		result, err := c.SimulateTransaction(context.Background(), req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Success || result.Revert != nil || result.Error != "insufficient funds for gas * price + value" {
+			t.Errorf("result = %+v", result)
+		}
+		if n := node.callCount("debug_traceCall"); n != 0 {
+			t.Errorf("debug_traceCall called %d times", n)
+		}
+	})
+
+	t.Run("without trace", func(t *testing.T) {
+		node, url := newFakeNode(t)
+		serveCall(t, node, hexutil.Bytes{1}, nil)
+		node.handle("eth_estimateGas", func([]json.RawMessage) (interface{}, error) { return hexutil.Uint64(21000), nil })
+		c := newTestEVMClient(t, config.ChainConfig{}, url)
+
+		result, err := c.SimulateTransaction(context.Background(), req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !result.Success || !reflect.DeepEqual(result.Result, []byte{1}) || result.BlockNumber != 16 {
+			t.Errorf("result = %+v", result)
+		}
+		// 节点不支持追踪时 Gas 改用估算值
+		if result.Traced || !strings.Contains(result.TraceError, "failed to trace call") || result.GasUsed != 21000 {
+			t.Errorf("traced %v (%s), gas %d", result.Traced, result.TraceError, result.GasUsed)
+		}
+	})
+}
@@ -0,0 +1,204 @@
+package chain
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"blockchain-middleware/internal/config"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+const gwei int64 = 1e9
+
+// minedTx 节点上的一笔交易及其收据
+type minedTx struct {
+	tx      *ethtypes.Transaction
+	pending bool
+	receipt *ethtypes.Receipt
+	header  *ethtypes.Header
+}
+
+// serveTransaction 让节点返回交易、收据和所在区块头
+func serveTransaction(t *testing.T, node *fakeNode, m minedTx) {
+	t.Helper()
+	node.handle("eth_getTransactionByHash", func([]json.RawMessage) (interface{}, error) {
+		raw, err := m.tx.MarshalJSON()
+		if err != nil {
+			return nil, err
+		}
+		var fields map[string]interface{}
+		if err := json.Unmarshal(raw, &fields); err != nil {
+			return nil, err
+		}
+		// 不返回 from，发送方必须从签名中恢复
+		if !m.pending {
+			fields["blockNumber"] = hexutil.EncodeBig(m.header.Number)
+			fields["blockHash"] = m.header.Hash()
+		}
+		return fields, nil
+	})
+	node.handle("eth_getTransactionReceipt", func([]json.RawMessage) (interface{}, error) {
+		return m.receipt, nil
+	})
+	node.handle("eth_getBlockByHash", func([]json.RawMessage) (interface{}, error) {
+		return m.header, nil
+	})
+}
+
+func TestGetTransaction(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sender := crypto.PubkeyToAddress(key.PublicKey)
+	to := common.HexToAddress(testTo)
+	chainID := big.NewInt(1337)
+	header := &ethtypes.Header{Number: big.NewInt(100), Time: 1700000000, Difficulty: big.NewInt(0), BaseFee: big.NewInt(10 * gwei)}
+
+	sign := func(tx *ethtypes.Transaction, signer ethtypes.Signer) *ethtypes.Transaction {
+		signed, err := ethtypes.SignTx(tx, signer, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signed
+	}
+	dynamic := func(tip, feeCap int64) *ethtypes.Transaction {
+		return sign(ethtypes.NewTx(&ethtypes.DynamicFeeTx{
+			ChainID: chainID, Nonce: 3, GasTipCap: big.NewInt(tip), GasFeeCap: big.NewInt(feeCap), Gas: 21000, To: &to, Value: big.NewInt(1),
+		}), ethtypes.NewLondonSigner(chainID))
+	}
+	creation := sign(ethtypes.NewTx(&ethtypes.LegacyTx{Nonce: 0, GasPrice: big.NewInt(5 * gwei), Gas: 100000, Data: []byte{0x60, 0x80}}), ethtypes.NewEIP155Signer(chainID))
+	// 未启用EIP-155重放保护的传统交易
+	homestead := sign(ethtypes.NewTx(&ethtypes.LegacyTx{Nonce: 1, GasPrice: big.NewInt(7 * gwei), Gas: 21000, To: &to}), ethtypes.HomesteadSigner{})
+
+	receiptFor := func(tx *ethtypes.Transaction, effectiveGasPrice *big.Int) *ethtypes.Receipt {
+		r := &ethtypes.Receipt{
+			Type: tx.Type(), Status: ethtypes.ReceiptStatusSuccessful, CumulativeGasUsed: 50000, GasUsed: 21000,
+			TxHash: tx.Hash(), BlockHash: header.Hash(), BlockNumber: header.Number, TransactionIndex: 2,
+			EffectiveGasPrice: effectiveGasPrice, Logs: []*ethtypes.Log{},
+		}
+		if tx.To() == nil {
+			r.ContractAddress = crypto.CreateAddress(sender, tx.Nonce())
+		}
+		return r
+	}
+
+	tests := []struct {
+		name         string
+		mined        minedTx
+		wantTo       string
+		wantContract string
+		wantPrice    int64 // 实际单位Gas价格，待打包交易不检查
+		wantDynamic  bool
+	}{
+		{
+			name:        "dynamic fee below cap without receipt price",
+			mined:       minedTx{tx: dynamic(2*gwei, 20*gwei)},
+			wantTo:      to.Hex(),
+			wantPrice:   12 * gwei,
+			wantDynamic: true,
+		},
+		{
+			name:        "dynamic fee capped without receipt price",
+			mined:       minedTx{tx: dynamic(2*gwei, 11*gwei)},
+			wantTo:      to.Hex(),
+			wantPrice:   11 * gwei,
+			wantDynamic: true,
+		},
+		{
+			name:      "receipt price is used when present",
+			mined:     minedTx{tx: homestead, receipt: receiptFor(homestead, big.NewInt(7*gwei))},
+			wantTo:    to.Hex(),
+			wantPrice: 7 * gwei,
+		},
+		{
+			name:         "contract creation",
+			mined:        minedTx{tx: creation},
+			wantContract: crypto.CreateAddress(sender, 0).Hex(),
+			wantPrice:    5 * gwei,
+		},
+		{
+			name:        "pending",
+			mined:       minedTx{tx: dynamic(2*gwei, 20*gwei), pending: true},
+			wantTo:      to.Hex(),
+			wantDynamic: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := tt.mined
+			m.header = header
+			if m.receipt == nil {
+				m.receipt = receiptFor(m.tx, nil)
+			}
+			node, url := newFakeNode(t)
+			serveTransaction(t, node, m)
+			c := newTestEVMClient(t, config.ChainConfig{ChainID: chainID.Int64()}, url)
+
+			got, err := c.GetTransaction(context.Background(), m.tx.Hash().Hex())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got.From != sender.Hex() {
+				t.Errorf("from = %s, want %s", got.From, sender.Hex())
+			}
+			if got.To != tt.wantTo || got.ContractAddress != tt.wantContract {
+				t.Errorf("to = %q contract = %q, want %q %q", got.To, got.ContractAddress, tt.wantTo, tt.wantContract)
+			}
+			if (got.MaxFeePerGas != nil) != tt.wantDynamic {
+				t.Errorf("max fee per gas = %v, want dynamic %v", got.MaxFeePerGas, tt.wantDynamic)
+			}
+
+			if tt.mined.pending {
+				if !got.Pending || got.Timestamp != 0 || got.EffectiveGasPrice != nil || got.Fee != nil {
+					t.Errorf("pending transaction = %+v", got)
+				}
+				if n := node.callCount("eth_getTransactionReceipt"); n != 0 {
+					t.Errorf("receipt requested %d times for a pending transaction", n)
+				}
+				return
+			}
+			if got.Pending || got.Timestamp != header.Time || got.BlockHash != header.Hash().Hex() || got.BlockNumber != 100 {
+				t.Errorf("block info = pending %v time %d hash %s number %d", got.Pending, got.Timestamp, got.BlockHash, got.BlockNumber)
+			}
+			if got.EffectiveGasPrice == nil || got.EffectiveGasPrice.Int64() != tt.wantPrice {
+				t.Fatalf("effective gas price = %v, want %d", got.EffectiveGasPrice, tt.wantPrice)
+			}
+			if want := tt.wantPrice * 21000; got.Fee.Int64() != want {
+				t.Errorf("fee = %s, want %d", got.Fee, want)
+			}
+		})
+	}
+}
+
+func TestEffectiveGasPrice(t *testing.T) {
+	to := common.HexToAddress(testTo)
+	legacy := ethtypes.NewTx(&ethtypes.LegacyTx{GasPrice: big.NewInt(30), To: &to})
+	dynamic := ethtypes.NewTx(&ethtypes.DynamicFeeTx{GasTipCap: big.NewInt(2), GasFeeCap: big.NewInt(20), To: &to})
+
+	tests := []struct {
+		name    string
+		tx      *ethtypes.Transaction
+		baseFee *big.Int
+		want    int64
+	}{
+		{"legacy pays gas price", legacy, big.NewInt(10), 30},
+		{"dynamic pays base fee plus tip", dynamic, big.NewInt(10), 12},
+		{"dynamic capped at fee cap", dynamic, big.NewInt(19), 20},
+		{"pre-london block", dynamic, nil, 20},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := effectiveGasPrice(tt.tx, tt.baseFee); got.Int64() != tt.want {
+				t.Errorf("effectiveGasPrice() = %s, want %d", got, tt.want)
+			}
+		})
+	}
+}
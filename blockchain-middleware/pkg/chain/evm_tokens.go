@@ -0,0 +1,320 @@
+package chain
+
+import (
+	"blockchain-middleware/pkg/types"
+	"context"
+	"fmt"
+	"log"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// multicall3Address Multicall3 在各EVM链上的统一部署地址
+var multicall3Address = common.HexToAddress("0xcA11bde05977b3631167028862bE2a173976CA11")
+
+const (
+	// maxTokensPerQuery 单次批量查询的代币数上限
+	maxTokensPerQuery = 100
+	// maxCallsPerMulticall 单次 aggregate3 的子调用上限，超过时分批调用，避免超出节点 eth_call 的gas上限
+	maxCallsPerMulticall = 500
+)
+
+var (
+	multicall3ABI = mustParseABI(`[{"type":"function","name":"aggregate3","stateMutability":"payable",
+		"inputs":[{"name":"calls","type":"tuple[]","components":[{"name":"target","type":"address"},{"name":"allowFailure","type":"bool"},{"name":"callData","type":"bytes"}]}],
+		"outputs":[{"name":"returnData","type":"tuple[]","components":[{"name":"success","type":"bool"},{"name":"returnData","type":"bytes"}]}]}]`)
+
+	erc20ABI = mustParseABI(`[
+		{"type":"function","name":"balanceOf","inputs":[{"name":"owner","type":"address"}],"outputs":[{"name":"","type":"uint256"}]},
+		{"type":"function","name":"decimals","inputs":[],"outputs":[{"name":"","type":"uint8"}]},
+		{"type":"function","name":"symbol","inputs":[],"outputs":[{"name":"","type":"string"}]}]`)
+)
+
+// multicall 单个 aggregate3 子调用
+type multicall struct {
+	Target       common.Address
+	AllowFailure bool
+	CallData     []byte
+}
+
+// multicallResult aggregate3 子调用结果
+type multicallResult struct {
+	Success    bool
+	ReturnData []byte
+}
+
+// tokenMetadata 代币符号和精度
+type tokenMetadata struct {
+	symbol   string
+	decimals uint8
+}
+
+// GetTokenBalance 获取ERC-20代币余额及符号、精度
+func (c *EVMClient) GetTokenBalance(ctx context.Context, tokenAddress, holderAddress string) (*types.TokenBalance, error) {
+	balances, err := c.GetTokenBalances(ctx, holderAddress, []string{tokenAddress})
+	if err != nil {
+		return nil, err
+	}
+	if balances[0].Error != "" {
+		return nil, fmt.Errorf("failed to query token %s: %s", tokenAddress, balances[0].Error)
+	}
+	return &balances[0], nil
+}
+
+// GetTokenBalances 批量获取地址持有的ERC-20代币余额
+// 所有余额和未缓存的代币信息通过一次 Multicall3 aggregate3 调用查询，
+// 单个代币查询失败时只在该代币的 Error 中说明；链上未部署 Multicall3 时逐个查询。
+func (c *EVMClient) GetTokenBalances(ctx context.Context, holderAddress string, tokenAddresses []string) ([]types.TokenBalance, error) {
+	if !common.IsHexAddress(holderAddress) {
+		return nil, fmt.Errorf("invalid address: %s", holderAddress)
+	}
+	if len(tokenAddresses) > maxTokensPerQuery {
+		return nil, fmt.Errorf("too many tokens: %d (max %d)", len(tokenAddresses), maxTokensPerQuery)
+	}
+	holder := common.HexToAddress(holderAddress)
+
+	tokens := make([]common.Address, len(tokenAddresses))
+	for i, addr := range tokenAddresses {
+		if !common.IsHexAddress(addr) {
+			return nil, fmt.Errorf("invalid token address: %s", addr)
+		}
+		tokens[i] = common.HexToAddress(addr)
+	}
+
+	balanceOf, err := erc20ABI.Pack("balanceOf", holder)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode balanceOf: %w", err)
+	}
+	decimals, _ := erc20ABI.Pack("decimals")
+	symbol, _ := erc20ABI.Pack("symbol")
+
+	// 组装子调用：每个代币查询余额，未缓存信息的代币额外查询精度和符号
+	metas := c.cachedTokenMetadata(tokens)
+	var calls []multicall
+	for _, token := range tokens {
+		calls = append(calls, multicall{Target: token, AllowFailure: true, CallData: balanceOf})
+		if _, ok := metas[token]; !ok {
+			calls = append(calls,
+				multicall{Target: token, AllowFailure: true, CallData: decimals},
+				multicall{Target: token, AllowFailure: true, CallData: symbol})
+		}
+	}
+
+	results, err := c.aggregate3(ctx, calls)
+	if err != nil {
+		return nil, err
+	}
+
+	balances := make([]types.TokenBalance, len(tokens))
+	next := 0
+	for i, token := range tokens {
+		balance := types.TokenBalance{ContractAddress: token.Hex()}
+
+		balanceResult := results[next]
+		next++
+
+		meta, cached := metas[token]
+		if !cached {
+			decimalsResult, symbolResult := results[next], results[next+1]
+			next += 2
+
+			var err error
+			meta, err = decodeTokenMetadata(decimalsResult, symbolResult)
+			if err != nil {
+				balance.Error = err.Error()
+				balances[i] = balance
+				continue
+			}
+			c.cacheTokenMetadata(token, meta)
+		}
+		balance.Symbol = meta.symbol
+		balance.Decimals = meta.decimals
+
+		if !balanceResult.Success || len(balanceResult.ReturnData) != 32 {
+			balance.Error = "balanceOf call failed, not an ERC-20 token"
+		} else {
+			balance.Balance = new(big.Int).SetBytes(balanceResult.ReturnData)
+		}
+		balances[i] = balance
+	}
+
+	return balances, nil
+}
+
+// aggregate3 通过 Multicall3 在一次 eth_call 中执行多个调用
+// 链上未部署 Multicall3（返回空数据）时改为逐个调用，之后不再尝试。
+func (c *EVMClient) aggregate3(ctx context.Context, calls []multicall) ([]multicallResult, error) {
+	if len(calls) == 0 {
+		return nil, nil
+	}
+	if len(calls) > maxCallsPerMulticall {
+		var results []multicallResult
+		for start := 0; start < len(calls); start += maxCallsPerMulticall {
+			end := start + maxCallsPerMulticall
+			if end > len(calls) {
+				end = len(calls)
+			}
+			batch, err := c.aggregate3(ctx, calls[start:end])
+			if err != nil {
+				return nil, err
+			}
+			results = append(results, batch...)
+		}
+		return results, nil
+	}
+
+	c.tokenMu.Lock()
+	noMulti := c.noMulti
+	c.tokenMu.Unlock()
+	if noMulti {
+		return c.callEach(ctx, calls), nil
+	}
+
+	data, err := multicall3ABI.Pack("aggregate3", calls)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode multicall: %w", err)
+	}
+
+	var output []byte
+	err = c.pool.Call(ctx, "eth_call", func(ctx context.Context, client *ethclient.Client) error {
+		var err error
+		output, err = client.CallContract(ctx, ethereum.CallMsg{To: &multicall3Address, Data: data}, nil)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to call multicall3: %w", err)
+	}
+	if len(output) == 0 {
+		log.Printf("Multicall3 not deployed on %s, falling back to individual calls", c.config.Name)
+		c.tokenMu.Lock()
+		c.noMulti = true
+		c.tokenMu.Unlock()
+		return c.callEach(ctx, calls), nil
+	}
+
+	unpacked, err := multicall3ABI.Unpack("aggregate3", output)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode multicall result: %w", err)
+	}
+	results := *abi.ConvertType(unpacked[0], new([]multicallResult)).(*[]multicallResult)
+	if len(results) != len(calls) {
+		return nil, fmt.Errorf("multicall returned %d results for %d calls", len(results), len(calls))
+	}
+	return results, nil
+}
+
+// callEach 逐个执行调用，失败的调用记为不成功
+func (c *EVMClient) callEach(ctx context.Context, calls []multicall) []multicallResult {
+	results := make([]multicallResult, len(calls))
+	for i, call := range calls {
+		call := call
+		err := c.pool.Call(ctx, "eth_call", func(ctx context.Context, client *ethclient.Client) error {
+			var err error
+			results[i].ReturnData, err = client.CallContract(ctx, ethereum.CallMsg{To: &call.Target, Data: call.CallData}, nil)
+			return err
+		})
+		results[i].Success = err == nil
+	}
+	return results
+}
+
+// cachedTokenMetadata 获取已缓存的代币信息
+func (c *EVMClient) cachedTokenMetadata(tokens []common.Address) map[common.Address]tokenMetadata {
+	c.tokenMu.Lock()
+	defer c.tokenMu.Unlock()
+
+	metas := make(map[common.Address]tokenMetadata)
+	for _, token := range tokens {
+		if meta, ok := c.tokenMetas[token]; ok {
+			metas[token] = meta
+		}
+	}
+	return metas
+}
+
+// tokenMetadatas 获取代币符号和精度，未缓存的代币通过一次 aggregate3 查询
+// 查询失败的代币（如不是ERC-20合约）不在结果中。
+func (c *EVMClient) tokenMetadatas(ctx context.Context, tokens []common.Address) map[common.Address]tokenMetadata {
+	metas := c.cachedTokenMetadata(tokens)
+
+	decimals, _ := erc20ABI.Pack("decimals")
+	symbol, _ := erc20ABI.Pack("symbol")
+	var missing []common.Address
+	var calls []multicall
+	for _, token := range tokens {
+		if _, ok := metas[token]; !ok {
+			missing = append(missing, token)
+			calls = append(calls,
+				multicall{Target: token, AllowFailure: true, CallData: decimals},
+				multicall{Target: token, AllowFailure: true, CallData: symbol})
+		}
+	}
+	if len(calls) == 0 {
+		return metas
+	}
+
+	results, err := c.aggregate3(ctx, calls)
+	if err != nil {
+		log.Printf("Failed to query token metadata on %s: %v", c.config.Name, err)
+		return metas
+	}
+	for i, token := range missing {
+		meta, err := decodeTokenMetadata(results[2*i], results[2*i+1])
+		if err != nil {
+			continue
+		}
+		c.cacheTokenMetadata(token, meta)
+		metas[token] = meta
+	}
+	return metas
+}
+
+// cacheTokenMetadata 缓存代币信息
+func (c *EVMClient) cacheTokenMetadata(token common.Address, meta tokenMetadata) {
+	c.tokenMu.Lock()
+	defer c.tokenMu.Unlock()
+
+	if c.tokenMetas == nil {
+		c.tokenMetas = make(map[common.Address]tokenMetadata)
+	}
+	c.tokenMetas[token] = meta
+}
+
+// decodeTokenMetadata 解码 decimals() 和 symbol() 的返回值
+// 部分早期代币（如MKR）的 symbol 返回 bytes32 而不是 string。
+func decodeTokenMetadata(decimals, symbol multicallResult) (tokenMetadata, error) {
+	var meta tokenMetadata
+
+	if !decimals.Success || len(decimals.ReturnData) != 32 {
+		return meta, fmt.Errorf("decimals call failed, not an ERC-20 token")
+	}
+	d := new(big.Int).SetBytes(decimals.ReturnData)
+	if !d.IsUint64() || d.Uint64() > 255 {
+		return meta, fmt.Errorf("invalid token decimals %s", d)
+	}
+	meta.decimals = uint8(d.Uint64())
+
+	// symbol 是可选的，查询失败时留空
+	if symbol.Success {
+		if out, err := erc20ABI.Unpack("symbol", symbol.ReturnData); err == nil {
+			meta.symbol = out[0].(string)
+		} else if len(symbol.ReturnData) == 32 {
+			meta.symbol = strings.TrimRight(string(symbol.ReturnData), "\x00")
+		}
+	}
+	return meta, nil
+}
+
+// mustParseABI 解析内置ABI
+func mustParseABI(raw string) abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(raw))
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}
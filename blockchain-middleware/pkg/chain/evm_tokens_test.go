@@ -0,0 +1,283 @@
+package chain
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"strings"
+	"sync"
+	"testing"
+
+	"blockchain-middleware/internal/config"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// contractFunc 模拟合约，返回调用结果；返回错误表示调用回滚
+type contractFunc func(data []byte) ([]byte, error)
+
+// fakeContracts 在 fakeNode 上模拟 eth_call，未注册的地址按没有代码的外部账户处理
+type fakeContracts struct {
+	mu         sync.Mutex
+	contracts  map[common.Address]contractFunc
+	multicall  bool  // 是否部署了 Multicall3
+	aggregated []int // 每次 aggregate3 调用的子调用数
+}
+
+// newFakeContracts 在节点上注册 eth_call 处理函数
+func newFakeContracts(node *fakeNode, multicall bool) *fakeContracts {
+	f := &fakeContracts{contracts: make(map[common.Address]contractFunc), multicall: multicall}
+	node.handle("eth_call", func(params []json.RawMessage) (interface{}, error) {
+		var arg struct {
+			To    common.Address `json:"to"`
+			Input hexutil.Bytes  `json:"input"`
+		}
+		if err := json.Unmarshal(params[0], &arg); err != nil {
+			return nil, err
+		}
+		out, err := f.call(arg.To, arg.Input)
+		if err != nil {
+			return nil, err
+		}
+		return hexutil.Bytes(out), nil
+	})
+	return f
+}
+
+// deploy 在地址上部署模拟合约
+func (f *fakeContracts) deploy(addr common.Address, fn contractFunc) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.contracts[addr] = fn
+}
+
+func (f *fakeContracts) call(to common.Address, data []byte) ([]byte, error) {
+	f.mu.Lock()
+	fn, ok := f.contracts[to]
+	multicall := f.multicall
+	f.mu.Unlock()
+
+	if to == multicall3Address && multicall {
+		return f.aggregate3(data)
+	}
+	if !ok {
+		return nil, nil
+	}
+	return fn(data)
+}
+
+func (f *fakeContracts) aggregate3(data []byte) ([]byte, error) {
+	method := multicall3ABI.Methods["aggregate3"]
+	unpacked, err := method.Inputs.Unpack(data[4:])
+	if err != nil {
+		return nil, err
+	}
+	calls := *abi.ConvertType(unpacked[0], new([]multicall)).(*[]multicall)
+
+	f.mu.Lock()
+	f.aggregated = append(f.aggregated, len(calls))
+	f.mu.Unlock()
+
+	results := make([]multicallResult, len(calls))
+	for i, call := range calls {
+		out, err := f.call(call.Target, call.CallData)
+		results[i] = multicallResult{Success: err == nil, ReturnData: out}
+	}
+	return method.Outputs.Pack(results)
+}
+
+// batches 返回每次 aggregate3 调用的子调用数
+func (f *fakeContracts) batches() []int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]int(nil), f.aggregated...)
+}
+
+var (
+	testUSDC  = common.HexToAddress("0x00000000000000000000000000000000000000a1")
+	testMKR   = common.HexToAddress("0x00000000000000000000000000000000000000a2")
+	testEOA   = common.HexToAddress("0x00000000000000000000000000000000000000a3")
+	testBroke = common.HexToAddress("0x00000000000000000000000000000000000000a4")
+)
+
+// fakeERC20 模拟ERC-20代币，symbolBytes32 模拟 symbol 返回 bytes32 的早期代币
+func fakeERC20(balance int64, decimals uint8, symbol string, symbolBytes32 bool) contractFunc {
+	return func(data []byte) ([]byte, error) {
+		method, err := erc20ABI.MethodById(data)
+		if err != nil {
+			return nil, &fakeRPCError{Code: 3, Message: "execution reverted"}
+		}
+		switch method.Name {
+		case "balanceOf":
+			return common.BigToHash(big.NewInt(balance)).Bytes(), nil
+		case "decimals":
+			return common.BigToHash(big.NewInt(int64(decimals))).Bytes(), nil
+		default:
+			if symbolBytes32 {
+				var b common.Hash
+				copy(b[:], symbol)
+				return b.Bytes(), nil
+			}
+			return method.Outputs.Pack(symbol)
+		}
+	}
+}
+
+// revertAll 模拟所有调用都回滚的合约
+func revertAll([]byte) ([]byte, error) {
+	return nil, &fakeRPCError{Code: 3, Message: "execution reverted"}
+}
+
+func deployTestTokens(f *fakeContracts) {
+	f.deploy(testUSDC, fakeERC20(1500000, 6, "USDC", false))
+	f.deploy(testMKR, fakeERC20(7, 18, "MKR", true))
+	f.deploy(testBroke, revertAll)
+}
+
+func TestGetTokenBalances(t *testing.T) {
+	for _, multicall := range []bool{true, false} {
+		name := "multicall"
+		if !multicall {
+			name = "fallback to individual calls"
+		}
+		t.Run(name, func(t *testing.T) {
+			node, url := newFakeNode(t)
+			contracts := newFakeContracts(node, multicall)
+			deployTestTokens(contracts)
+			c := newTestEVMClient(t, config.ChainConfig{}, url)
+
+			tokens := []string{testUSDC.Hex(), testMKR.Hex(), testEOA.Hex(), testBroke.Hex()}
+			balances, err := c.GetTokenBalances(context.Background(), testFrom, tokens)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(balances) != len(tokens) {
+				t.Fatalf("got %d balances, want %d", len(balances), len(tokens))
+			}
+
+			usdc, mkr := balances[0], balances[1]
+			if usdc.Error != "" || usdc.Balance.Int64() != 1500000 || usdc.Decimals != 6 || usdc.Symbol != "USDC" {
+				t.Errorf("usdc = %+v", usdc)
+			}
+			if mkr.Error != "" || mkr.Balance.Int64() != 7 || mkr.Decimals != 18 || mkr.Symbol != "MKR" {
+				t.Errorf("bytes32 symbol token = %+v", mkr)
+			}
+			for _, b := range balances[2:] {
+				if !strings.Contains(b.Error, "not an ERC-20 token") || b.Balance != nil {
+					t.Errorf("non token %s = %+v, want error", b.ContractAddress, b)
+				}
+			}
+
+			// 每个代币查询余额、精度和符号
+			wantCalls := 1
+			if !multicall {
+				wantCalls = 1 + 3*len(tokens)
+			}
+			if got := node.callCount("eth_call"); got != wantCalls {
+				t.Errorf("eth_call count = %d, want %d", got, wantCalls)
+			}
+
+			// 第二次查询使用缓存的代币信息，只查询余额；没有 Multicall3 时不再尝试
+			if _, err := c.GetTokenBalances(context.Background(), testFrom, tokens[:2]); err != nil {
+				t.Fatal(err)
+			}
+			if multicall {
+				if got := contracts.batches(); len(got) != 2 || got[1] != 2 {
+					t.Errorf("aggregate3 sub calls = %v, want second query with 2", got)
+				}
+			} else if got := node.callCount("eth_call"); got != wantCalls+2 {
+				t.Errorf("eth_call count after cached query = %d, want %d", got, wantCalls+2)
+			}
+			// 合约回滚不是节点故障
+			assertNoProviderFailures(t, c.pool)
+		})
+	}
+}
+
+func TestGetTokenBalancesValidatesInput(t *testing.T) {
+	node, url := newFakeNode(t)
+	newFakeContracts(node, true)
+	c := newTestEVMClient(t, config.ChainConfig{}, url)
+
+	tooMany := make([]string, maxTokensPerQuery+1)
+	for i := range tooMany {
+		tooMany[i] = testUSDC.Hex()
+	}
+	tests := []struct {
+		name   string
+		holder string
+		tokens []string
+	}{
+		{"invalid holder", "0x1234", []string{testUSDC.Hex()}},
+		{"invalid token", testFrom, []string{"usdc"}},
+		{"too many tokens", testFrom, tooMany},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := c.GetTokenBalances(context.Background(), tt.holder, tt.tokens); err == nil {
+				t.Fatal("GetTokenBalances() succeeded")
+			}
+		})
+	}
+	if got := node.callCount("eth_call"); got != 0 {
+		t.Errorf("invalid requests made %d eth_call requests", got)
+	}
+}
+
+func TestAggregate3SplitsLargeBatches(t *testing.T) {
+	node, url := newFakeNode(t)
+	contracts := newFakeContracts(node, true)
+	deployTestTokens(contracts)
+	c := newTestEVMClient(t, config.ChainConfig{}, url)
+
+	decimals, _ := erc20ABI.Pack("decimals")
+	calls := make([]multicall, maxCallsPerMulticall+1)
+	for i := range calls {
+		calls[i] = multicall{Target: testUSDC, AllowFailure: true, CallData: decimals}
+	}
+	results, err := c.aggregate3(context.Background(), calls)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != len(calls) {
+		t.Fatalf("got %d results, want %d", len(results), len(calls))
+	}
+	if got := contracts.batches(); len(got) != 2 || got[0] != maxCallsPerMulticall || got[1] != 1 {
+		t.Errorf("aggregate3 batches = %v, want [%d 1]", got, maxCallsPerMulticall)
+	}
+}
+
+func TestDecodeTokenMetadata(t *testing.T) {
+	word := func(n int64) []byte { return common.BigToHash(big.NewInt(n)).Bytes() }
+	symbol, _ := erc20ABI.Methods["symbol"].Outputs.Pack("DAI")
+	var bytes32Symbol common.Hash
+	copy(bytes32Symbol[:], "MKR")
+
+	tests := []struct {
+		name     string
+		decimals multicallResult
+		symbol   multicallResult
+		want     tokenMetadata
+		wantErr  bool
+	}{
+		{"string symbol", multicallResult{true, word(18)}, multicallResult{true, symbol}, tokenMetadata{"DAI", 18}, false},
+		{"bytes32 symbol", multicallResult{true, word(18)}, multicallResult{true, bytes32Symbol.Bytes()}, tokenMetadata{"MKR", 18}, false},
+		{"symbol reverts", multicallResult{true, word(0)}, multicallResult{false, nil}, tokenMetadata{"", 0}, false},
+		{"decimals reverts", multicallResult{false, nil}, multicallResult{true, symbol}, tokenMetadata{}, true},
+		{"no code", multicallResult{true, nil}, multicallResult{true, nil}, tokenMetadata{}, true},
+		{"decimals out of range", multicallResult{true, word(256)}, multicallResult{true, symbol}, tokenMetadata{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeTokenMetadata(tt.decimals, tt.symbol)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("decodeTokenMetadata() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("decodeTokenMetadata() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
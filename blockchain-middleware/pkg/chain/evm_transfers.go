@@ -0,0 +1,262 @@
+package chain
+
+import (
+	"blockchain-middleware/pkg/types"
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+const (
+	// maxTopicAddresses 单次日志查询中地址主题的个数上限，超过时分批查询
+	maxTopicAddresses = 100
+	// transferBlockConcurrency 提取原生币转账时并发读取的区块数
+	transferBlockConcurrency = 8
+)
+
+// rpcBlock eth_getBlockByNumber 返回的区块中转账索引需要的字段
+// 直接读取节点返回的 from，不依赖本地解码交易，L2链的存款等特有交易类型也能处理。
+type rpcBlock struct {
+	Number       hexutil.Uint64   `json:"number"`
+	Timestamp    hexutil.Uint64   `json:"timestamp"`
+	Transactions []rpcTransaction `json:"transactions"`
+}
+
+// rpcTransaction 区块中交易的转账字段
+type rpcTransaction struct {
+	Hash  common.Hash     `json:"hash"`
+	From  common.Address  `json:"from"`
+	To    *common.Address `json:"to"`
+	Value *hexutil.Big    `json:"value"`
+}
+
+// rpcReceipt 收据中的执行状态
+type rpcReceipt struct {
+	Status hexutil.Uint64 `json:"status"`
+}
+
+// GetTransfers 提取 [fromBlock, toBlock] 内 addresses 作为发送方或接收方的转账，按区块和日志顺序返回
+// 原生币转账逐块读取交易的 value，代币转账按 Transfer 日志的 from、to 主题查询，
+// 3个主题的为ERC-20，4个主题的为ERC-721。
+func (c *EVMClient) GetTransfers(ctx context.Context, fromBlock, toBlock uint64, addresses []string) ([]types.Transfer, error) {
+	if fromBlock > toBlock || len(addresses) == 0 {
+		return nil, nil
+	}
+	watched := make(map[common.Address]bool, len(addresses))
+	for _, addr := range addresses {
+		if !common.IsHexAddress(addr) {
+			return nil, fmt.Errorf("invalid address: %s", addr)
+		}
+		watched[common.HexToAddress(addr)] = true
+	}
+
+	times, transfers, err := c.nativeBlockTransfers(ctx, fromBlock, toBlock, watched)
+	if err != nil {
+		return nil, err
+	}
+	tokenTransfers, err := c.tokenLogTransfers(ctx, fromBlock, toBlock, watched, times)
+	if err != nil {
+		return nil, err
+	}
+	transfers = append(transfers, tokenTransfers...)
+
+	sort.SliceStable(transfers, func(i, j int) bool {
+		if transfers[i].BlockNumber != transfers[j].BlockNumber {
+			return transfers[i].BlockNumber < transfers[j].BlockNumber
+		}
+		return transfers[i].LogIndex < transfers[j].LogIndex
+	})
+	for i := range transfers {
+		transfers[i].ChainName = c.config.Name
+	}
+	return transfers, nil
+}
+
+// nativeBlockTransfers 并发读取区块，提取 value 大于0且发送方或接收方是监听地址的交易
+// 返回各区块的时间戳供代币转账使用。
+func (c *EVMClient) nativeBlockTransfers(ctx context.Context, fromBlock, toBlock uint64, watched map[common.Address]bool) (map[uint64]uint64, []types.Transfer, error) {
+	count := toBlock - fromBlock + 1
+	blocks := make([]*rpcBlock, count)
+	errs := make([]error, count)
+
+	sem := make(chan struct{}, transferBlockConcurrency)
+	var wg sync.WaitGroup
+	for i := uint64(0); i < count; i++ {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i uint64) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			blocks[i], errs[i] = c.rpcBlock(ctx, fromBlock+i)
+		}(i)
+	}
+	wg.Wait()
+
+	times := make(map[uint64]uint64, count)
+	var transfers []types.Transfer
+	for i, block := range blocks {
+		if errs[i] != nil {
+			return nil, nil, errs[i]
+		}
+		number := fromBlock + uint64(i)
+		times[number] = uint64(block.Timestamp)
+
+		for _, tx := range block.Transactions {
+			if tx.Value == nil || tx.Value.ToInt().Sign() == 0 {
+				continue
+			}
+			if !watched[tx.From] && (tx.To == nil || !watched[*tx.To]) {
+				continue
+			}
+
+			transfer := types.Transfer{
+				TxHash:      tx.Hash.Hex(),
+				LogIndex:    -1,
+				BlockNumber: number,
+				Timestamp:   uint64(block.Timestamp),
+				Type:        types.TransferNative,
+				From:        tx.From.Hex(),
+				Value:       tx.Value.ToInt(),
+				Symbol:      c.config.NativeSymbol,
+				Decimals:    c.config.NativeDecimals,
+			}
+			if tx.To != nil {
+				transfer.To = tx.To.Hex()
+			}
+
+			var receipt *rpcReceipt
+			err := c.pool.Call(ctx, "eth_getTransactionReceipt", func(ctx context.Context, client *ethclient.Client) error {
+				return client.Client().CallContext(ctx, &receipt, "eth_getTransactionReceipt", tx.Hash)
+			})
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to get receipt of %s: %w", tx.Hash.Hex(), err)
+			}
+			if receipt == nil {
+				return nil, nil, fmt.Errorf("receipt of %s not found", tx.Hash.Hex())
+			}
+			transfer.Success = receipt.Status == hexutil.Uint64(ethtypes.ReceiptStatusSuccessful)
+			transfers = append(transfers, transfer)
+		}
+	}
+	return times, transfers, nil
+}
+
+// rpcBlock 读取包含完整交易的区块
+func (c *EVMClient) rpcBlock(ctx context.Context, number uint64) (*rpcBlock, error) {
+	var block *rpcBlock
+	err := c.pool.Call(ctx, "eth_getBlockByNumber", func(ctx context.Context, client *ethclient.Client) error {
+		return client.Client().CallContext(ctx, &block, "eth_getBlockByNumber", hexutil.EncodeUint64(number), true)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get block %d: %w", number, err)
+	}
+	if block == nil {
+		return nil, fmt.Errorf("block %d not found", number)
+	}
+	return block, nil
+}
+
+// tokenLogTransfers 查询监听地址转出和转入的 Transfer 日志
+// 同一日志可能同时匹配转出和转入查询，按交易哈希和日志序号去重。
+func (c *EVMClient) tokenLogTransfers(ctx context.Context, fromBlock, toBlock uint64, watched map[common.Address]bool, times map[uint64]uint64) ([]types.Transfer, error) {
+	topics := make([]common.Hash, 0, len(watched))
+	for addr := range watched {
+		topics = append(topics, common.BytesToHash(addr.Bytes()))
+	}
+
+	client := poolLogFilterer{pool: c.pool}
+	seen := make(map[logKey]bool)
+	var logs []ethtypes.Log
+	for start := 0; start < len(topics); start += maxTopicAddresses {
+		end := start + maxTopicAddresses
+		if end > len(topics) {
+			end = len(topics)
+		}
+		chunk := topics[start:end]
+
+		for _, query := range []ethereum.FilterQuery{
+			{Topics: [][]common.Hash{{transferTopic}, chunk}},
+			{Topics: [][]common.Hash{{transferTopic}, nil, chunk}},
+		} {
+			batch, err := scanLogs(ctx, client, query, fromBlock, toBlock, &c.transferRanges)
+			if err != nil {
+				return nil, err
+			}
+			for _, eventLog := range batch {
+				key := logKey{txHash: eventLog.TxHash, index: eventLog.Index}
+				if eventLog.Removed || seen[key] {
+					continue
+				}
+				seen[key] = true
+				logs = append(logs, eventLog)
+			}
+		}
+	}
+
+	var transfers []types.Transfer
+	var tokens []common.Address
+	for _, eventLog := range logs {
+		transfer := types.Transfer{
+			TxHash:      eventLog.TxHash.Hex(),
+			LogIndex:    int64(eventLog.Index),
+			BlockNumber: eventLog.BlockNumber,
+			Timestamp:   times[eventLog.BlockNumber],
+			From:        common.BytesToAddress(eventLog.Topics[1].Bytes()).Hex(),
+			To:          common.BytesToAddress(eventLog.Topics[2].Bytes()).Hex(),
+			Token:       eventLog.Address.Hex(),
+			Success:     true,
+		}
+		switch {
+		case len(eventLog.Topics) == 3 && len(eventLog.Data) == 32:
+			transfer.Type = types.TransferERC20
+			transfer.Value = new(big.Int).SetBytes(eventLog.Data)
+			tokens = append(tokens, eventLog.Address)
+		case len(eventLog.Topics) == 4 && len(eventLog.Data) == 0:
+			transfer.Type = types.TransferERC721
+			transfer.TokenID = eventLog.Topics[3].Big().String()
+		default:
+			// 不符合标准的 Transfer 事件
+			continue
+		}
+		transfers = append(transfers, transfer)
+	}
+
+	metas := c.tokenMetadatas(ctx, uniqueAddresses(tokens))
+	for i := range transfers {
+		if transfers[i].Type != types.TransferERC20 {
+			continue
+		}
+		if meta, ok := metas[common.HexToAddress(transfers[i].Token)]; ok {
+			transfers[i].Symbol = meta.symbol
+			transfers[i].Decimals = meta.decimals
+		}
+	}
+	return transfers, nil
+}
+
+// logKey 交易哈希和日志序号，唯一确定一条日志
+type logKey struct {
+	txHash common.Hash
+	index  uint
+}
+
+// uniqueAddresses 去重并保持首次出现的顺序
+func uniqueAddresses(addrs []common.Address) []common.Address {
+	seen := make(map[common.Address]bool, len(addrs))
+	out := make([]common.Address, 0, len(addrs))
+	for _, addr := range addrs {
+		if !seen[addr] {
+			seen[addr] = true
+			out = append(out, addr)
+		}
+	}
+	return out
+}
@@ -0,0 +1,189 @@
+package chain
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"blockchain-middleware/internal/config"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+)
+
+// fakeTransferChain 在 fakeNode 上模拟区块、收据和按主题过滤的 eth_getLogs
+type fakeTransferChain struct {
+	blocks  map[uint64]rpcBlock
+	failed  map[common.Hash]bool // 执行失败的交易
+	logs    []ethtypes.Log
+	queried [][]common.Hash // 每次 eth_getLogs 查询的地址主题
+}
+
+func newFakeTransferChain(node *fakeNode) *fakeTransferChain {
+	f := &fakeTransferChain{blocks: make(map[uint64]rpcBlock), failed: make(map[common.Hash]bool)}
+	node.handle("eth_getBlockByNumber", func(params []json.RawMessage) (interface{}, error) {
+		var number hexutil.Uint64
+		if err := json.Unmarshal(params[0], &number); err != nil {
+			return nil, err
+		}
+		block, ok := f.blocks[uint64(number)]
+		if !ok {
+			return nil, nil
+		}
+		return block, nil
+	})
+	node.handle("eth_getTransactionReceipt", func(params []json.RawMessage) (interface{}, error) {
+		var hash common.Hash
+		if err := json.Unmarshal(params[0], &hash); err != nil {
+			return nil, err
+		}
+		if f.failed[hash] {
+			return rpcReceipt{Status: 0}, nil
+		}
+		return rpcReceipt{Status: 1}, nil
+	})
+	node.handle("eth_getLogs", func(params []json.RawMessage) (interface{}, error) {
+		var filter struct {
+			FromBlock hexutil.Uint64  `json:"fromBlock"`
+			ToBlock   hexutil.Uint64  `json:"toBlock"`
+			Topics    [][]common.Hash `json:"topics"`
+		}
+		if err := json.Unmarshal(params[0], &filter); err != nil {
+			return nil, err
+		}
+		f.queried = append(f.queried, filter.Topics[len(filter.Topics)-1])
+
+		result := []ethtypes.Log{}
+		for _, l := range f.logs {
+			if l.BlockNumber >= uint64(filter.FromBlock) && l.BlockNumber <= uint64(filter.ToBlock) && matchTopics(l.Topics, filter.Topics) {
+				result = append(result, l)
+			}
+		}
+		return result, nil
+	})
+	return f
+}
+
+// matchTopics 每个位置的主题为空时不限，否则日志主题必须是其中之一
+func matchTopics(topics []common.Hash, filter [][]common.Hash) bool {
+	for i, set := range filter {
+		if len(set) == 0 {
+			continue
+		}
+		if i >= len(topics) {
+			return false
+		}
+		found := false
+		for _, topic := range set {
+			found = found || topics[i] == topic
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// nativeTx 区块中的原生币转账，to 为nil时为合约部署
+func nativeTx(n int64, from common.Address, to *common.Address, value int64) rpcTransaction {
+	return rpcTransaction{Hash: common.BigToHash(big.NewInt(n)), From: from, To: to, Value: (*hexutil.Big)(big.NewInt(value))}
+}
+
+// transferLog 位于指定区块和序号的 Transfer 日志
+func transferLog(l callFrameLog, block uint64, index uint) ethtypes.Log {
+	return ethtypes.Log{
+		Address:     l.Address,
+		Topics:      l.Topics,
+		Data:        l.Data,
+		BlockNumber: block,
+		TxHash:      common.BigToHash(big.NewInt(int64(100 + index))),
+		Index:       index,
+	}
+}
+
+func TestGetTransfers(t *testing.T) {
+	node, url := newFakeNode(t)
+	deployTestTokens(newFakeContracts(node, true))
+	fake := newFakeTransferChain(node)
+	c := newTestEVMClient(t, config.ChainConfig{NativeSymbol: "ETH", NativeDecimals: 18}, url)
+
+	other := common.HexToAddress("0x4444444444444444444444444444444444444444")
+	fake.blocks[10] = rpcBlock{Number: 10, Timestamp: 1000, Transactions: []rpcTransaction{
+		nativeTx(1, testAlice, &testRouter, 5),
+		nativeTx(2, other, &testRouter, 3), // 与监听地址无关
+		nativeTx(3, testAlice, nil, 0),     // 没有转账金额的合约部署
+	}}
+	fake.blocks[11] = rpcBlock{Number: 11, Timestamp: 1012, Transactions: []rpcTransaction{
+		nativeTx(4, other, &testBob, 7),
+	}}
+	fake.failed[common.BigToHash(big.NewInt(4))] = true
+
+	erc721 := tokenLog(transferTopic, testMKR, other, testAlice, big.NewInt(0))
+	erc721.Topics = append(erc721.Topics, common.BigToHash(big.NewInt(7)))
+	erc721.Data = nil
+	nonStandard := tokenLog(transferTopic, testUSDC, testAlice, other, big.NewInt(1))
+	nonStandard.Data = append(nonStandard.Data, nonStandard.Data...)
+	removed := transferLog(tokenLog(transferTopic, testUSDC, testAlice, other, big.NewInt(1)), 11, 2)
+	removed.Removed = true
+	fake.logs = []ethtypes.Log{
+		// 同时匹配转出和转入查询
+		transferLog(tokenLog(transferTopic, testUSDC, testAlice, testBob, big.NewInt(1500000)), 10, 0),
+		transferLog(nonStandard, 11, 1),
+		removed,
+		transferLog(erc721, 11, 3),
+	}
+
+	transfers, err := c.GetTransfers(context.Background(), 10, 11, []string{testAlice.Hex(), testBob.Hex()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, transfer := range transfers {
+		if transfer.ChainName != "test" {
+			t.Errorf("chain = %s", transfer.ChainName)
+		}
+		got = append(got, fmt.Sprintf("%d/%d %d %s %s->%s %s %s %s %d %v", transfer.BlockNumber, transfer.LogIndex, transfer.Timestamp,
+			transfer.Type, transfer.From, transfer.To, transfer.Value, transfer.TokenID, transfer.Symbol, transfer.Decimals, transfer.Success))
+	}
+	want := []string{
+		fmt.Sprintf("10/-1 1000 native %s->%s 5  ETH 18 true", testAlice.Hex(), testRouter.Hex()),
+		fmt.Sprintf("10/0 1000 erc20 %s->%s 1500000  USDC 6 true", testAlice.Hex(), testBob.Hex()),
+		fmt.Sprintf("11/-1 1012 native %s->%s 7  ETH 18 false", other.Hex(), testBob.Hex()),
+		fmt.Sprintf("11/3 1012 erc721 %s->%s <nil> 7  0 true", other.Hex(), testAlice.Hex()),
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d transfers:\n%v", len(got), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("transfer %d = %s\nwant %s", i, got[i], want[i])
+		}
+	}
+	if len(fake.queried) != 2 {
+		t.Errorf("eth_getLogs called %d times, want from and to queries", len(fake.queried))
+	}
+
+	// 地址主题超过上限时分批查询
+	fake.queried = nil
+	addresses := make([]string, maxTopicAddresses+1)
+	for i := range addresses {
+		addresses[i] = common.BigToAddress(big.NewInt(int64(i + 1))).Hex()
+	}
+	if _, err := c.GetTransfers(context.Background(), 11, 11, addresses); err != nil {
+		t.Fatal(err)
+	}
+	if len(fake.queried) != 4 || len(fake.queried[0]) != maxTopicAddresses || len(fake.queried[2]) != 1 {
+		t.Errorf("eth_getLogs queried %d address batches", len(fake.queried))
+	}
+
+	if _, err := c.GetTransfers(context.Background(), 10, 11, []string{"alice"}); err == nil {
+		t.Error("GetTransfers() accepted an invalid address")
+	}
+	if transfers, err := c.GetTransfers(context.Background(), 11, 10, addresses); err != nil || transfers != nil {
+		t.Errorf("GetTransfers() of an empty range = %v, %v", transfers, err)
+	}
+}
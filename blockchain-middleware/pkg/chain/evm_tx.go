@@ -0,0 +1,184 @@
+package chain
+
+import (
+	"blockchain-middleware/internal/config"
+	"blockchain-middleware/pkg/types"
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// maxPendingNonceGap 原始交易nonce允许超出待处理nonce的最大值，超出的交易会长期滞留在交易池
+const maxPendingNonceGap = 16
+
+// validateTxRequest 校验交易请求中不依赖链上状态的字段
+// 在发起任何RPC调用之前执行：这类错误是确定性的，不能计入节点故障，也不应重试。
+func validateTxRequest(cfg config.ChainConfig, req *types.TransactionRequest) error {
+	if !common.IsHexAddress(req.From) {
+		return fmt.Errorf("invalid from address: %s", req.From)
+	}
+	to, err := parseRecipient(req.To)
+	if err != nil {
+		return err
+	}
+	if to == nil && len(req.Data) == 0 {
+		return fmt.Errorf("to address is required unless data contains contract creation code")
+	}
+
+	if req.MaxFeePerGas != nil || req.MaxPriorityFeePerGas != nil {
+		if !cfg.EIP1559 {
+			return fmt.Errorf("chain %d does not support EIP-1559 fees, use gas_price", cfg.ChainID)
+		}
+		if req.MaxFeePerGas != nil && req.MaxPriorityFeePerGas != nil && req.MaxFeePerGas.Cmp(req.MaxPriorityFeePerGas) < 0 {
+			return fmt.Errorf("max fee per gas (%s) is lower than max priority fee per gas (%s)", req.MaxFeePerGas, req.MaxPriorityFeePerGas)
+		}
+	}
+	return nil
+}
+
+// parseRecipient 解析交易接收地址，为空表示创建合约
+func parseRecipient(to string) (*common.Address, error) {
+	if to == "" {
+		return nil, nil
+	}
+	if !common.IsHexAddress(to) {
+		return nil, fmt.Errorf("invalid to address: %s", to)
+	}
+	addr := common.HexToAddress(to)
+	return &addr, nil
+}
+
+// buildUnsignedTx 根据请求构建未签名交易及对应的签名器，请求须已通过 validateTxRequest 且指定了nonce
+// 配置启用EIP-1559且最新区块带有 baseFee 时构建动态手续费交易，否则回退到传统交易。
+func buildUnsignedTx(ctx context.Context, client *ethclient.Client, cfg config.ChainConfig, req *types.TransactionRequest) (*ethtypes.Transaction, ethtypes.Signer, error) {
+	chainID := cfg.ChainID
+	nonce := *req.Nonce
+	to, err := parseRecipient(req.To)
+	if err != nil {
+		return nil, nil, err
+	}
+	value := req.Value
+	if value == nil {
+		value = new(big.Int)
+	}
+
+	var baseFee *big.Int
+	if cfg.EIP1559 {
+		head, err := client.HeaderByNumber(ctx, nil)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get latest header: %w", err)
+		}
+		baseFee = head.BaseFee
+	}
+
+	if baseFee == nil {
+		if req.MaxFeePerGas != nil || req.MaxPriorityFeePerGas != nil {
+			return nil, nil, fmt.Errorf("chain %d does not support EIP-1559 fees, use gas_price", chainID)
+		}
+
+		gasPrice := req.GasPrice
+		if gasPrice == nil {
+			gasPrice, err = client.SuggestGasPrice(ctx)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to suggest gas price: %w", err)
+			}
+		}
+
+		tx := ethtypes.NewTx(&ethtypes.LegacyTx{
+			Nonce:    nonce,
+			GasPrice: gasPrice,
+			Gas:      req.GasLimit,
+			To:       to,
+			Value:    value,
+			Data:     req.Data,
+		})
+		return tx, ethtypes.NewEIP155Signer(big.NewInt(chainID)), nil
+	}
+
+	tipCap, feeCap, err := resolveDynamicFees(ctx, client, baseFee, req)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tx := ethtypes.NewTx(&ethtypes.DynamicFeeTx{
+		ChainID:   big.NewInt(chainID),
+		Nonce:     nonce,
+		GasTipCap: tipCap,
+		GasFeeCap: feeCap,
+		Gas:       req.GasLimit,
+		To:        to,
+		Value:     value,
+		Data:      req.Data,
+	})
+	return tx, ethtypes.NewLondonSigner(big.NewInt(chainID)), nil
+}
+
+// resolveDynamicFees 确定 EIP-1559 的小费上限和手续费上限
+// 未指定时小费取节点建议值，手续费上限取 2*baseFee+小费，可承受连续几个满块的 baseFee 上涨。
+func resolveDynamicFees(ctx context.Context, client *ethclient.Client, baseFee *big.Int, req *types.TransactionRequest) (*big.Int, *big.Int, error) {
+	tipCap := req.MaxPriorityFeePerGas
+	feeCap := req.MaxFeePerGas
+
+	// 只指定 gas_price 时按传统语义处理：小费和上限都等于 gas_price
+	if tipCap == nil && feeCap == nil && req.GasPrice != nil {
+		return req.GasPrice, req.GasPrice, nil
+	}
+
+	if tipCap == nil {
+		var err error
+		tipCap, err = client.SuggestGasTipCap(ctx)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to suggest gas tip cap: %w", err)
+		}
+		// 用户给出的上限低于建议小费时，以上限为准
+		if feeCap != nil && tipCap.Cmp(feeCap) > 0 {
+			tipCap = new(big.Int).Set(feeCap)
+		}
+	}
+
+	if feeCap == nil {
+		feeCap = new(big.Int).Add(new(big.Int).Mul(baseFee, big.NewInt(2)), tipCap)
+	}
+
+	return tipCap, feeCap, nil
+}
+
+// attachSignature 将65字节 [R || S || V] 签名附加到交易，并校验恢复出的签名者与 from 一致
+// V 兼容 0/1、27/28 以及 EIP-155 的 chainId*2+35/36 三种写法。
+func attachSignature(tx *ethtypes.Transaction, signer ethtypes.Signer, from string, signature []byte) (*ethtypes.Transaction, error) {
+	if len(signature) != crypto.SignatureLength {
+		return nil, fmt.Errorf("signature must be %d bytes, got %d", crypto.SignatureLength, len(signature))
+	}
+
+	sig := make([]byte, crypto.SignatureLength)
+	copy(sig, signature)
+	switch v := sig[64]; {
+	case v >= 35:
+		sig[64] = (v - 35) % 2
+	case v >= 27:
+		sig[64] = v - 27
+	}
+	if sig[64] > 1 {
+		return nil, fmt.Errorf("invalid signature recovery id %d", signature[64])
+	}
+
+	signedTx, err := tx.WithSignature(signer, sig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to attach signature: %w", err)
+	}
+
+	sender, err := ethtypes.Sender(signer, signedTx)
+	if err != nil {
+		return nil, fmt.Errorf("invalid transaction signature: %w", err)
+	}
+	if from != "" && sender != common.HexToAddress(from) {
+		return nil, fmt.Errorf("signature was produced by %s, not %s", sender.Hex(), from)
+	}
+
+	return signedTx, nil
+}
@@ -0,0 +1,119 @@
+package chain
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"blockchain-middleware/internal/config"
+	"blockchain-middleware/pkg/types"
+)
+
+const (
+	testFrom = "0x1111111111111111111111111111111111111111"
+	testTo   = "0x2222222222222222222222222222222222222222"
+)
+
+// newTestEVMClient 创建连接到测试节点的EVM客户端
+func newTestEVMClient(t *testing.T, cfg config.ChainConfig, urls ...string) *EVMClient {
+	t.Helper()
+	if cfg.Name == "" {
+		cfg.Name = "test"
+	}
+	if cfg.ChainID == 0 {
+		cfg.ChainID = 1337
+	}
+	pool, err := NewProviderPool(cfg.Name, urls, 0, time.Second, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(pool.Close)
+	return &EVMClient{config: cfg, pool: pool}
+}
+
+func TestValidateTxRequest(t *testing.T) {
+	legacy := config.ChainConfig{ChainID: 56}
+	london := config.ChainConfig{ChainID: 1, EIP1559: true}
+
+	tests := []struct {
+		name    string
+		cfg     config.ChainConfig
+		req     types.TransactionRequest
+		wantErr string
+	}{
+		{"transfer", legacy, types.TransactionRequest{From: testFrom, To: testTo}, ""},
+		{"contract creation", legacy, types.TransactionRequest{From: testFrom, Data: []byte{0x60, 0x80}}, ""},
+		{"invalid from", legacy, types.TransactionRequest{From: "0x1234", To: testTo}, "invalid from address"},
+		{"malformed to", legacy, types.TransactionRequest{From: testFrom, To: "0xnot-an-address"}, "invalid to address"},
+		{"short to", legacy, types.TransactionRequest{From: testFrom, To: "0x2222"}, "invalid to address"},
+		{"empty to without code", legacy, types.TransactionRequest{From: testFrom}, "to address is required"},
+		{"dynamic fees on legacy chain", legacy, types.TransactionRequest{From: testFrom, To: testTo, MaxFeePerGas: big.NewInt(10)}, "does not support EIP-1559"},
+		{"dynamic fees", london, types.TransactionRequest{From: testFrom, To: testTo, MaxFeePerGas: big.NewInt(10), MaxPriorityFeePerGas: big.NewInt(2)}, ""},
+		{"fee cap below tip", london, types.TransactionRequest{From: testFrom, To: testTo, MaxFeePerGas: big.NewInt(1), MaxPriorityFeePerGas: big.NewInt(2)}, "lower than max priority fee"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateTxRequest(tt.cfg, &tt.req)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("validateTxRequest() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("validateTxRequest() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestBuildTransactionRejectsInvalidRequestBeforeRPC(t *testing.T) {
+	node, url := newFakeNode(t)
+	c := newTestEVMClient(t, config.ChainConfig{EIP1559: true}, url)
+
+	nonce := uint64(0)
+	req := &types.TransactionRequest{From: testFrom, To: "0xdead", Nonce: &nonce, GasLimit: 21000}
+	if _, _, err := c.buildTransaction(context.Background(), req); err == nil {
+		t.Fatal("buildTransaction() accepted a malformed to address")
+	}
+	if n := node.callCount("eth_getBlockByNumber"); n != 0 {
+		t.Errorf("eth_getBlockByNumber called %d times for an invalid request", n)
+	}
+	assertNoProviderFailures(t, c.pool)
+}
+
+func TestBuildTransactionRecipient(t *testing.T) {
+	_, url := newFakeNode(t)
+	c := newTestEVMClient(t, config.ChainConfig{}, url)
+
+	nonce := uint64(7)
+	tests := []struct {
+		name   string
+		to     string
+		data   []byte
+		wantTo string
+	}{
+		{"transfer", testTo, nil, testTo},
+		{"contract creation", "", []byte{0x60, 0x80, 0x60, 0x40}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &types.TransactionRequest{From: testFrom, To: tt.to, Data: tt.data, Nonce: &nonce, GasLimit: 100000, GasPrice: big.NewInt(1e9)}
+			tx, _, err := c.buildTransaction(context.Background(), req)
+			if err != nil {
+				t.Fatalf("buildTransaction() error = %v", err)
+			}
+			if tt.wantTo == "" {
+				if tx.To() != nil {
+					t.Fatalf("contract creation built with to = %s", tx.To().Hex())
+				}
+				return
+			}
+			if tx.To() == nil || !strings.EqualFold(tx.To().Hex(), tt.wantTo) {
+				t.Fatalf("to = %v, want %s", tx.To(), tt.wantTo)
+			}
+		})
+	}
+}
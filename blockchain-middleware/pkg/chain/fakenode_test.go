@@ -0,0 +1,138 @@
+package chain
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// fakeNode 测试用的JSON-RPC节点，按方法名返回预设结果
+type fakeNode struct {
+	mu       sync.Mutex
+	handlers map[string]func(params []json.RawMessage) (interface{}, error)
+	status   int // 非0时所有请求直接返回该HTTP状态码
+	calls    map[string]int
+}
+
+// fakeRPCError 节点返回的JSON-RPC错误
+type fakeRPCError struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"` // 回滚数据等附加信息
+}
+
+func (e *fakeRPCError) Error() string { return e.Message }
+
+// ErrorCode 实现 rpc.Error
+func (e *fakeRPCError) ErrorCode() int { return e.Code }
+
+type fakeRequest struct {
+	ID     json.RawMessage   `json:"id"`
+	Method string            `json:"method"`
+	Params []json.RawMessage `json:"params"`
+}
+
+type fakeResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result"`
+	Error   *fakeRPCError   `json:"error,omitempty"`
+}
+
+// newFakeNode 启动节点，默认只支持 eth_blockNumber（供健康检查使用）
+func newFakeNode(t *testing.T) (*fakeNode, string) {
+	t.Helper()
+	n := &fakeNode{
+		handlers: make(map[string]func([]json.RawMessage) (interface{}, error)),
+		calls:    make(map[string]int),
+	}
+	n.handle("eth_blockNumber", func([]json.RawMessage) (interface{}, error) { return "0x10", nil })
+
+	srv := httptest.NewServer(http.HandlerFunc(n.serve))
+	t.Cleanup(srv.Close)
+	return n, srv.URL
+}
+
+// handle 设置方法的处理函数
+func (n *fakeNode) handle(method string, fn func(params []json.RawMessage) (interface{}, error)) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.handlers[method] = fn
+}
+
+// setStatus 让节点对之后的请求返回指定HTTP状态码，0表示恢复正常
+func (n *fakeNode) setStatus(status int) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.status = status
+}
+
+// callCount 返回方法被调用的次数
+func (n *fakeNode) callCount(method string) int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.calls[method]
+}
+
+func (n *fakeNode) serve(w http.ResponseWriter, r *http.Request) {
+	var body bytes.Buffer
+	body.ReadFrom(r.Body)
+
+	n.mu.Lock()
+	status := n.status
+	n.mu.Unlock()
+	if status != 0 {
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+
+	batch := bytes.HasPrefix(bytes.TrimSpace(body.Bytes()), []byte("["))
+	var reqs []fakeRequest
+	if batch {
+		json.Unmarshal(body.Bytes(), &reqs)
+	} else {
+		var req fakeRequest
+		json.Unmarshal(body.Bytes(), &req)
+		reqs = []fakeRequest{req}
+	}
+
+	resps := make([]fakeResponse, len(reqs))
+	for i, req := range reqs {
+		resps[i] = n.call(req)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if batch {
+		json.NewEncoder(w).Encode(resps)
+		return
+	}
+	json.NewEncoder(w).Encode(resps[0])
+}
+
+func (n *fakeNode) call(req fakeRequest) fakeResponse {
+	n.mu.Lock()
+	n.calls[req.Method]++
+	fn, ok := n.handlers[req.Method]
+	n.mu.Unlock()
+
+	resp := fakeResponse{JSONRPC: "2.0", ID: req.ID}
+	if !ok {
+		resp.Error = &fakeRPCError{Code: -32601, Message: fmt.Sprintf("the method %s does not exist", req.Method)}
+		return resp
+	}
+	result, err := fn(req.Params)
+	if err != nil {
+		rpcErr, ok := err.(*fakeRPCError)
+		if !ok {
+			rpcErr = &fakeRPCError{Code: -32000, Message: err.Error()}
+		}
+		resp.Error = rpcErr
+		return resp
+	}
+	resp.Result = result
+	return resp
+}
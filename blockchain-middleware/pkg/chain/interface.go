@@ -2,9 +2,15 @@ package chain
 
 import (
 	"blockchain-middleware/internal/config"
+	"blockchain-middleware/pkg/nonce"
+	"blockchain-middleware/pkg/signer"
 	"blockchain-middleware/pkg/types"
+	"context"
 	"fmt"
 	"math/big"
+	"time"
+
+	"github.com/btcsuite/btcd/wire"
 )
 
 // ChainClient 区块链客户端接口
@@ -14,60 +20,177 @@ type ChainClient interface {
 	GetNetworkName() string
 
 	// 账户相关
-	GetBalance(address string) (*big.Int, error)
-	GetNonce(address string) (uint64, error)
+	GetBalance(ctx context.Context, address string) (*big.Int, error)
+	GetNonce(ctx context.Context, address string) (uint64, error)
 
 	// 交易相关
-	SendTransaction(req *types.TransactionRequest) (string, error)
-	GetTransaction(txHash string) (*types.Transaction, error)
-	EstimateGas(req *types.TransactionRequest) (uint64, error)
+	SendTransaction(ctx context.Context, req *types.TransactionRequest) (string, error)
+	SendRawTransaction(ctx context.Context, rawTx []byte) (string, error)
+	GetTransaction(ctx context.Context, txHash string) (*types.Transaction, error)
+	EstimateGas(ctx context.Context, req *types.TransactionRequest) (uint64, error)
 
 	// 区块相关
-	GetBlockNumber() (uint64, error)
-	GetBlockByNumber(blockNumber uint64) (*types.Block, error)
+	GetBlockNumber(ctx context.Context) (uint64, error)
+	GetBlockByNumber(ctx context.Context, blockNumber uint64) (*types.Block, error)
 
 	// 合约相关
-	CallContract(req *types.ContractCallRequest) ([]byte, error)
+	CallContract(ctx context.Context, req *types.ContractCallRequest) ([]byte, error)
 
 	// 关闭连接
 	Close() error
 }
 
+// Unwrapper 由包装其他客户端的装饰器（如缓存层）实现
+type Unwrapper interface {
+	Unwrap() ChainClient
+}
+
+// As 沿装饰器逐层查找实现了 T 的客户端，可选能力接口都应通过它断言
+func As[T any](client ChainClient) (T, bool) {
+	for client != nil {
+		if t, ok := client.(T); ok {
+			return t, true
+		}
+		wrapper, ok := client.(Unwrapper)
+		if !ok {
+			break
+		}
+		client = wrapper.Unwrap()
+	}
+
+	var zero T
+	return zero, false
+}
+
+// Reconfigurable 由支持配置热加载的客户端实现
+type Reconfigurable interface {
+	// Reconfigure 应用新配置中的RPC节点、认证信息、请求超时和限流，其余字段需要重启后生效
+	Reconfigure(cfg config.ChainConfig) error
+}
+
+// ProviderStatusReporter 由使用RPC节点池的客户端实现
+type ProviderStatusReporter interface {
+	ProviderStatus() []types.ProviderStatus
+}
+
+// SignedTransactionBroadcaster 由支持外部签名（如MPC）交易广播的客户端实现
+type SignedTransactionBroadcaster interface {
+	// BroadcastSignedTransaction 按请求重建交易，附加外部签名，校验签名者后广播
+	BroadcastSignedTransaction(ctx context.Context, req *types.TransactionRequest, signature []byte) (string, error)
+}
+
+// SignerSender 由支持使用调用方签名器（如MPC会话）签名交易的客户端实现
+type SignerSender interface {
+	// SendTransactionWithSigner 构建未签名交易，由 txSigner 签名交易哈希，校验签名者与 From 一致后广播
+	SendTransactionWithSigner(ctx context.Context, req *types.TransactionRequest, txSigner signer.Signer) (string, error)
+}
+
+// NonceReserver 由使用账户nonce的链客户端实现
+type NonceReserver interface {
+	ReserveNonce(ctx context.Context, address string) (uint64, error)
+	ReleaseNonce(ctx context.Context, address string, nonce uint64) error
+	NonceStatus(ctx context.Context, address string) (*types.NonceStatus, error)
+}
+
+// TransactionStatusProvider 由支持交易生命周期跟踪的客户端实现
+type TransactionStatusProvider interface {
+	// TransactionStatus 查询交易在节点中的状态，from 非空时一并查询发送方已上链的nonce
+	TransactionStatus(ctx context.Context, txHash, from string) (*types.TxChainStatus, error)
+}
+
+// LogWatcher 由支持合约日志订阅的客户端实现
+type LogWatcher interface {
+	// WatchLogs 按过滤条件把日志交给handler，阻塞直到ctx取消或处理到 filter.ToBlock
+	WatchLogs(ctx context.Context, filter types.EventFilter, handler EventHandler) error
+}
+
+// EventWatcherProvider 由支持按区块轮询合约事件的客户端实现
+type EventWatcherProvider interface {
+	// NewEventWatcher 创建复用客户端节点连接的事件监听器
+	NewEventWatcher() *EventWatcher
+}
+
+// TokenBalanceProvider 由支持ERC-20代币的客户端实现
+type TokenBalanceProvider interface {
+	GetTokenBalance(ctx context.Context, tokenAddress, holderAddress string) (*types.TokenBalance, error)
+	// GetTokenBalances 批量查询，单个代币失败时只在该代币的 Error 中说明
+	GetTokenBalances(ctx context.Context, holderAddress string, tokenAddresses []string) ([]types.TokenBalance, error)
+}
+
+// NFTProvider 由支持ERC-721/ERC-1155的客户端实现
+type NFTProvider interface {
+	GetNFTContract(ctx context.Context, contractAddress string) (*types.NFTContract, error)
+	GetNFT(ctx context.Context, contractAddress, tokenID, holderAddress string) (*types.NFTToken, error)
+	// GetNFTs 从 fromBlock 起的转账日志推导地址当前持有的NFT，contractAddresses 为空时不限合约但只能扫描最近的区块
+	GetNFTs(ctx context.Context, holderAddress string, contractAddresses []string, fromBlock uint64) ([]types.NFTToken, error)
+	EncodeNFTTransfer(ctx context.Context, req *types.NFTTransferRequest) (*types.ContractEncodeResponse, error)
+}
+
+// FeeOracle 由支持手续费估算的客户端实现
+type FeeOracle interface {
+	EstimateFees(ctx context.Context) (*types.FeeEstimates, error)
+	// SuggestGasPrice 节点建议的Gas价格（eth_gasPrice），不依赖 eth_feeHistory
+	SuggestGasPrice(ctx context.Context) (*big.Int, error)
+}
+
+// TransactionSimulator 由支持交易模拟的客户端实现
+type TransactionSimulator interface {
+	SimulateTransaction(ctx context.Context, req *types.SimulationRequest) (*types.SimulationResult, error)
+}
+
+// TransferSource 由支持地址转账索引的客户端实现
+type TransferSource interface {
+	GetTransfers(ctx context.Context, fromBlock, toBlock uint64, addresses []string) ([]types.Transfer, error)
+}
+
 // ChainFactory 区块链客户端工厂
 type ChainFactory struct{}
 
 // NewClient 创建区块链客户端
-func (f *ChainFactory) NewClient(chainType string, config config.ChainConfig) (ChainClient, error) {
+// chainType 为 "evm" 时由通用EVM客户端驱动，链参数全部来自配置。
+// txSigner 为nil时客户端不能发送未签名交易，只能广播外部签名的交易；nonces 为EVM链的nonce管理器。
+func (f *ChainFactory) NewClient(chainType string, config config.ChainConfig, txSigner signer.Signer, nonces *nonce.Manager) (ChainClient, error) {
 	switch chainType {
-	case "ethereum":
-		return NewEthereumClient(config)
-	case "polygon":
-		return NewPolygonClient(config)
-	case "bsc":
-		return NewBSCClient(config)
+	case "evm":
+		return NewEVMClient(config, txSigner, nonces)
 	case "bitcoin":
-		return nil, fmt.Errorf("bitcoin support not implemented yet")
+		return NewBitcoinClient(config, txSigner)
 	default:
 		return nil, fmt.Errorf("unsupported chain type: %s", chainType)
 	}
 }
 
-// NewEthereumClient 创建以太坊客户端
-func NewEthereumClient(config config.ChainConfig) (ChainClient, error) {
-	return &EthereumClient{config: config}, nil
-}
+// NewEVMClient 创建EVM链客户端
+func NewEVMClient(config config.ChainConfig, txSigner signer.Signer, nonces *nonce.Manager) (ChainClient, error) {
+	if config.Name == "" {
+		return nil, fmt.Errorf("evm chain name is required")
+	}
+	if config.ChainID <= 0 {
+		return nil, fmt.Errorf("invalid chain id %d for %s", config.ChainID, config.Name)
+	}
 
-// NewPolygonClient 创建Polygon客户端
-func NewPolygonClient(config config.ChainConfig) (ChainClient, error) {
-	return &PolygonClient{config: config}, nil
-}
+	pool, err := NewProviderPool(config.Name, append([]string{config.RPCURL}, config.RPCURLs...), config.MaxBlockLag, config.RequestTimeout, config.RPCRateLimit)
+	if err != nil {
+		return nil, err
+	}
 
-// NewBSCClient 创建BSC客户端
-func NewBSCClient(config config.ChainConfig) (ChainClient, error) {
-	return &BSCClient{config: config}, nil
+	return &EVMClient{config: config, pool: pool, signer: txSigner, nonces: nonces}, nil
 }
 
 // NewBitcoinClient 创建比特币客户端
-func NewBitcoinClient(config config.ChainConfig) (ChainClient, error) {
-	return nil, fmt.Errorf("bitcoin support not implemented yet")
-}
\ No newline at end of file
+func NewBitcoinClient(config config.ChainConfig, txSigner signer.Signer) (ChainClient, error) {
+	params, err := bitcoinNetParams(config.NetworkName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &BitcoinClient{
+		config: config,
+		signer: txSigner,
+		rpc:    newBitcoinRPC(config.Name, config.RPCURL, config.RPCUser, config.RPCPassword, config.RequestTimeout, config.RPCRateLimit),
+		params: params,
+		utxos:  make(map[string][]types.UTXO),
+		locked: make(map[wire.OutPoint]time.Time),
+		spent:  make(map[wire.OutPoint]string),
+	}, nil
+}
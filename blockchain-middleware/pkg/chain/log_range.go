@@ -0,0 +1,232 @@
+package chain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const (
+	// logRangeInitial 按区块范围查询日志的初始跨度
+	logRangeInitial = 1000
+	// logRangeMax 单次查询的最大跨度
+	logRangeMax = 10000
+	// logRangeGrowBelow 满跨度查询返回的日志少于该值时扩大跨度
+	logRangeGrowBelow = 1000
+)
+
+// logRange 自适应的日志查询跨度，节点返回结果过多时缩小，结果较少时扩大
+type logRange struct {
+	mu   sync.Mutex
+	size uint64
+}
+
+// current 当前跨度
+func (r *logRange) current() uint64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.size == 0 {
+		r.size = logRangeInitial
+	}
+	return r.size
+}
+
+// shrink 跨度为 span 的查询结果过多，跨度减半
+func (r *logRange) shrink(span uint64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if half := span / 2; half < r.size || r.size == 0 {
+		r.size = half
+	}
+	if r.size == 0 {
+		r.size = 1
+	}
+}
+
+// observe 记录一次成功查询，满跨度查询结果较少时跨度加倍
+func (r *logRange) observe(span uint64, logs int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if span >= r.size && logs < logRangeGrowBelow && r.size < logRangeMax {
+		r.size *= 2
+		if r.size > logRangeMax {
+			r.size = logRangeMax
+		}
+	}
+}
+
+// SetBackfillConcurrency 设置补齐历史区块时并发查询的批次数
+// 落后最新区块超过重组窗口的区块按范围批量查询，多个批次并发查询后按区块顺序交给处理器。
+func (w *EventWatcher) SetBackfillConcurrency(n int) {
+	if n < 1 {
+		n = 1
+	}
+	w.backfillConcurrency = n
+}
+
+// logQuery 日志查询条件（不含区块范围）
+// 未设置主题过滤且没有默认处理器时，只查询已注册处理器的事件签名。
+func (w *EventWatcher) logQuery() ethereum.FilterQuery {
+	query := ethereum.FilterQuery{
+		Addresses: w.addresses,
+		Topics:    w.topics,
+	}
+	if len(query.Topics) == 0 && w.logHandler == nil && len(w.handlers) > 0 {
+		sigs := make([]common.Hash, 0, len(w.handlers))
+		for sig := range w.handlers {
+			sigs = append(sigs, sig)
+		}
+		query.Topics = [][]common.Hash{sigs}
+	}
+	return query
+}
+
+// processRanges 按区块范围补齐 [from, to] 中的一轮批次，成功处理的部分推进 lastBlock
+// 这些区块已超出重组窗口，不再记录区块哈希。
+func (w *EventWatcher) processRanges(ctx context.Context, from, to uint64) error {
+	// 切分本轮批次
+	size := w.ranges.current()
+	var batches [][2]uint64
+	for start := from; start <= to && len(batches) < w.backfillConcurrency; {
+		end := start + size - 1
+		if end > to || end < start {
+			end = to
+		}
+		batches = append(batches, [2]uint64{start, end})
+		start = end + 1
+	}
+
+	// 并发查询，按顺序处理
+	results := make([][]ethtypes.Log, len(batches))
+	errs := make([]error, len(batches))
+	var wg sync.WaitGroup
+	for i, batch := range batches {
+		wg.Add(1)
+		go func(i int, from, to uint64) {
+			defer wg.Done()
+			results[i], errs[i] = w.fetchRange(ctx, from, to)
+		}(i, batch[0], batch[1])
+	}
+	wg.Wait()
+
+	w.recent.reset()
+	for i, batch := range batches {
+		if errs[i] != nil {
+			return errs[i]
+		}
+		if err := w.dispatchRange(batch[0], batch[1], results[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fetchRange 查询区块范围内的日志
+func (w *EventWatcher) fetchRange(ctx context.Context, from, to uint64) ([]ethtypes.Log, error) {
+	return fetchLogs(ctx, w.client, w.logQuery(), from, to, &w.ranges)
+}
+
+// fetchLogs 查询区块范围内的日志，节点提示结果过多时对半拆分
+func fetchLogs(ctx context.Context, client logFilterer, query ethereum.FilterQuery, from, to uint64, ranges *logRange) ([]ethtypes.Log, error) {
+	query.FromBlock = new(big.Int).SetUint64(from)
+	query.ToBlock = new(big.Int).SetUint64(to)
+
+	span := to - from + 1
+	logs, err := client.FilterLogs(ctx, query)
+	if err == nil {
+		ranges.observe(span, len(logs))
+		return logs, nil
+	}
+	if !isTooManyResults(err) || from == to {
+		return nil, fmt.Errorf("failed to filter logs in blocks %d-%d: %w", from, to, err)
+	}
+
+	ranges.shrink(span)
+	mid := from + (to-from)/2
+	left, err := fetchLogs(ctx, client, query, from, mid, ranges)
+	if err != nil {
+		return nil, err
+	}
+	right, err := fetchLogs(ctx, client, query, mid+1, to, ranges)
+	if err != nil {
+		return nil, err
+	}
+	return append(left, right...), nil
+}
+
+// scanLogs 按自适应跨度依次查询 [from, to] 的全部日志
+func scanLogs(ctx context.Context, client logFilterer, query ethereum.FilterQuery, from, to uint64, ranges *logRange) ([]ethtypes.Log, error) {
+	var logs []ethtypes.Log
+	for start := from; start <= to; {
+		end := start + ranges.current() - 1
+		if end > to || end < start {
+			end = to
+		}
+
+		batch, err := fetchLogs(ctx, client, query, start, end, ranges)
+		if err != nil {
+			return nil, err
+		}
+		logs = append(logs, batch...)
+
+		if end == to {
+			break
+		}
+		start = end + 1
+	}
+	return logs, nil
+}
+
+// dispatchRange 按顺序处理区块范围内的日志
+// 处理器失败时 lastBlock 停在失败日志所在区块之前，从该区块重试。
+func (w *EventWatcher) dispatchRange(from, to uint64, logs []ethtypes.Log) error {
+	for _, eventLog := range logs {
+		if err := w.dispatch(eventLog); err != nil {
+			if eventLog.BlockNumber > from {
+				w.lastBlock = eventLog.BlockNumber - 1
+				w.saveCheckpoint(w.lastBlock)
+			}
+			return err
+		}
+	}
+
+	w.lastBlock = to
+	w.saveCheckpoint(to)
+	return nil
+}
+
+// isTooManyResults 节点是否因结果过多或范围过大拒绝 eth_getLogs
+func isTooManyResults(err error) bool {
+	var rpcErr rpc.Error
+	if !errors.As(err, &rpcErr) {
+		return false
+	}
+
+	msg := strings.ToLower(rpcErr.Error())
+	for _, hint := range []string{
+		"query returned more than",
+		"too many results",
+		"response size exceeded",
+		"response size should not",
+		"exceed maximum block range",
+		"block range is too",
+		"range is too large",
+		"limited to a",
+	} {
+		if strings.Contains(msg, hint) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,126 @@
+package chain
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+)
+
+// rangeLimitedNode 每个区块一条日志，跨度超过 maxSpan 的查询返回结果过多
+type rangeLimitedNode struct {
+	mu      sync.Mutex
+	maxSpan uint64
+	err     error // 非nil时所有查询返回该错误
+	queries [][2]uint64
+}
+
+func (n *rangeLimitedNode) BlockNumber(ctx context.Context) (uint64, error) { return 0, nil }
+
+func (n *rangeLimitedNode) HeaderByNumber(ctx context.Context, number *big.Int) (*ethtypes.Header, error) {
+	return nil, ethereum.NotFound
+}
+
+func (n *rangeLimitedNode) FilterLogs(ctx context.Context, q ethereum.FilterQuery) ([]ethtypes.Log, error) {
+	from, to := q.FromBlock.Uint64(), q.ToBlock.Uint64()
+	n.mu.Lock()
+	n.queries = append(n.queries, [2]uint64{from, to})
+	n.mu.Unlock()
+
+	if n.err != nil {
+		return nil, n.err
+	}
+	if to-from+1 > n.maxSpan {
+		return nil, &fakeRPCError{Code: -32005, Message: "query returned more than 10000 results"}
+	}
+	logs := make([]ethtypes.Log, 0, to-from+1)
+	for b := from; b <= to; b++ {
+		logs = append(logs, ethtypes.Log{BlockNumber: b})
+	}
+	return logs, nil
+}
+
+func TestScanLogs(t *testing.T) {
+	tests := []struct {
+		name        string
+		from, to    uint64
+		maxSpan     uint64
+		initial     uint64
+		wantQueries int
+		wantSize    uint64 // 扫描结束后的跨度
+	}{
+		{"single batch", 1, 500, logRangeMax, 0, 1, logRangeInitial},
+		{"splits into batches", 1, 2500, logRangeMax, 0, 3, logRangeInitial},
+		// 1-1000、1-500 被拒绝后按250查询，成功后跨度回升到500，501-1000 再次被拒绝
+		{"shrinks when the node refuses", 1, 1000, 300, 0, 7, 500},
+		{"grows after sparse results", 42, 42, 1, 1, 1, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node := &rangeLimitedNode{maxSpan: tt.maxSpan}
+			ranges := logRange{size: tt.initial}
+
+			logs, err := scanLogs(context.Background(), node, ethereum.FilterQuery{}, tt.from, tt.to, &ranges)
+			if err != nil {
+				t.Fatalf("scanLogs() error = %v", err)
+			}
+
+			// 每个区块恰好一条日志，按区块顺序
+			if uint64(len(logs)) != tt.to-tt.from+1 {
+				t.Fatalf("got %d logs, want %d", len(logs), tt.to-tt.from+1)
+			}
+			for i, l := range logs {
+				if l.BlockNumber != tt.from+uint64(i) {
+					t.Fatalf("log %d in block %d, want %d", i, l.BlockNumber, tt.from+uint64(i))
+				}
+			}
+
+			if len(node.queries) != tt.wantQueries {
+				t.Errorf("queries = %v, want %d queries", node.queries, tt.wantQueries)
+			}
+			if got := ranges.current(); got != tt.wantSize {
+				t.Errorf("range size = %d, want %d", got, tt.wantSize)
+			}
+		})
+	}
+}
+
+func TestScanLogsReturnsOtherErrors(t *testing.T) {
+	nodeErr := errors.New("connection reset by peer")
+	node := &rangeLimitedNode{maxSpan: logRangeMax, err: nodeErr}
+	var ranges logRange
+
+	_, err := scanLogs(context.Background(), node, ethereum.FilterQuery{}, 1, 5000, &ranges)
+	if !errors.Is(err, nodeErr) {
+		t.Fatalf("scanLogs() error = %v, want %v", err, nodeErr)
+	}
+	if len(node.queries) != 1 {
+		t.Errorf("non range errors were retried with smaller ranges: %v", node.queries)
+	}
+	if !strings.Contains(err.Error(), "blocks 1-1000") {
+		t.Errorf("error %q does not name the failed range", err)
+	}
+}
+
+func TestIsTooManyResults(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{&fakeRPCError{Code: -32005, Message: "query returned more than 10000 results"}, true},
+		{&fakeRPCError{Code: -32000, Message: "exceed maximum block range: 2000"}, true},
+		{&fakeRPCError{Code: -32602, Message: "Log response size exceeded."}, true},
+		{&fakeRPCError{Code: -32000, Message: "header not found"}, false},
+		{errors.New("query returned more than 10000 results"), false}, // 不是节点返回的错误
+	}
+	for _, tt := range tests {
+		if got := isTooManyResults(tt.err); got != tt.want {
+			t.Errorf("isTooManyResults(%q) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package chain
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// rateLimiter 令牌桶限流器，桶容量为一秒的请求数（至少为1）
+type rateLimiter struct {
+	mu     sync.Mutex
+	rate   float64 // 每秒令牌数，0表示不限流
+	tokens float64
+	last   time.Time
+}
+
+// newRateLimiter 创建限流器，rate 为每秒请求数，0表示不限流
+func newRateLimiter(rate float64) *rateLimiter {
+	l := &rateLimiter{}
+	l.setRate(rate)
+	return l
+}
+
+// setRate 修改速率，桶中已有的令牌不超过新的容量
+func (l *rateLimiter) setRate(rate float64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if rate < 0 {
+		rate = 0
+	}
+	if l.rate == 0 {
+		l.tokens = bucketSize(rate)
+		l.last = time.Now()
+	}
+	l.rate = rate
+	l.tokens = math.Min(l.tokens, bucketSize(rate))
+}
+
+// wait 取得一个令牌，令牌不足时等待，ctx 结束时返回其错误
+func (l *rateLimiter) wait(ctx context.Context) error {
+	for {
+		l.mu.Lock()
+		if l.rate == 0 {
+			l.mu.Unlock()
+			return nil
+		}
+
+		now := time.Now()
+		l.tokens = math.Min(bucketSize(l.rate), l.tokens+now.Sub(l.last).Seconds()*l.rate)
+		l.last = now
+		if l.tokens >= 1 {
+			l.tokens--
+			l.mu.Unlock()
+			return nil
+		}
+		delay := time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+		l.mu.Unlock()
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// bucketSize 桶容量
+func bucketSize(rate float64) float64 {
+	return math.Max(1, rate)
+}
@@ -0,0 +1,104 @@
+package chain
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+)
+
+// reorgWindowSize 保留的最近已处理区块数，超过该深度的重组无法回撤已投递的事件
+const reorgWindowSize = 128
+
+// blockRecord 已处理区块的哈希和已投递的日志
+type blockRecord struct {
+	number uint64
+	hash   common.Hash
+	logs   []ethtypes.Log
+}
+
+// blockWindow 最近已处理区块的滚动窗口，区块号连续递增
+type blockWindow struct {
+	blocks []blockRecord
+}
+
+// get 获取窗口内的区块
+func (bw *blockWindow) get(number uint64) (blockRecord, bool) {
+	if len(bw.blocks) == 0 {
+		return blockRecord{}, false
+	}
+	first := bw.blocks[0].number
+	if number < first || number >= first+uint64(len(bw.blocks)) {
+		return blockRecord{}, false
+	}
+	return bw.blocks[number-first], true
+}
+
+// last 获取窗口内最新的区块
+func (bw *blockWindow) last() (blockRecord, bool) {
+	if len(bw.blocks) == 0 {
+		return blockRecord{}, false
+	}
+	return bw.blocks[len(bw.blocks)-1], true
+}
+
+// push 追加区块，与窗口不连续时清空窗口重新开始
+func (bw *blockWindow) push(rec blockRecord) {
+	if last, ok := bw.last(); ok && last.number+1 != rec.number {
+		bw.blocks = nil
+	}
+	bw.blocks = append(bw.blocks, rec)
+	if len(bw.blocks) > reorgWindowSize {
+		bw.blocks = append(bw.blocks[:0], bw.blocks[len(bw.blocks)-reorgWindowSize:]...)
+	}
+}
+
+// pop 移除窗口内最新的区块
+func (bw *blockWindow) pop() {
+	if len(bw.blocks) > 0 {
+		bw.blocks = bw.blocks[:len(bw.blocks)-1]
+	}
+}
+
+// reset 清空窗口
+func (bw *blockWindow) reset() {
+	bw.blocks = nil
+}
+
+// rollback 从最新的已处理区块向前回撤，直到与当前主链一致的区块
+// 被孤立区块的日志以 Removed=true 倒序重新交给处理器，每回撤一个区块保存一次检查点。
+// 重组深度超过窗口时只能回撤窗口内的区块。
+func (w *EventWatcher) rollback(ctx context.Context) error {
+	for {
+		rec, ok := w.recent.last()
+		if !ok {
+			log.Printf("Chain reorg on %s deeper than %d blocks, events before block %d cannot be retracted",
+				w.config.Name, reorgWindowSize, w.lastBlock+1)
+			return nil
+		}
+
+		header, err := w.client.HeaderByNumber(ctx, new(big.Int).SetUint64(rec.number))
+		if err != nil {
+			return fmt.Errorf("failed to get block header: %w", err)
+		}
+		if header.Hash() == rec.hash {
+			return nil
+		}
+
+		log.Printf("Chain reorg on %s: block %d %s orphaned", w.config.Name, rec.number, rec.hash.Hex())
+		for i := len(rec.logs) - 1; i >= 0; i-- {
+			removed := rec.logs[i]
+			removed.Removed = true
+			if err := w.dispatch(removed); err != nil {
+				return err
+			}
+		}
+
+		w.recent.pop()
+		w.lastBlock = rec.number - 1
+		w.saveCheckpoint(w.lastBlock)
+	}
+}
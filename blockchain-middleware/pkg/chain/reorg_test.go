@@ -0,0 +1,119 @@
+package chain
+
+import (
+	"blockchain-middleware/internal/config"
+	"context"
+	"sync"
+	"testing"
+
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+)
+
+// recordingCheckpoints 记录每次保存的检查点
+type recordingCheckpoints struct {
+	mu    sync.Mutex
+	saved []uint64
+}
+
+func (s *recordingCheckpoints) Load(ctx context.Context, chainName, watcherID string) (uint64, bool, error) {
+	return 0, false, nil
+}
+
+func (s *recordingCheckpoints) Save(ctx context.Context, chainName, watcherID string, block uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.saved = append(s.saved, block)
+	return nil
+}
+
+func TestEventWatcherReorgRetractsLogs(t *testing.T) {
+	ctx := context.Background()
+	chain := newFakeChain(10)
+	orphan9a, orphan9b, orphan10 := chain.addLog(9, 0), chain.addLog(9, 1), chain.addLog(10, 0)
+
+	checkpoints := &recordingCheckpoints{}
+	recorder := &logRecorder{}
+	w := newEventWatcher(config.ChainConfig{Name: "test"}, chain)
+	w.SetBlockRange(1, 0)
+	w.SetCheckpointStore(checkpoints, "reorg")
+	w.RegisterLogHandler(recorder)
+
+	lastBlock, err := w.startBlock(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.lastBlock = lastBlock
+	w.checkNewEvents(ctx)
+	if w.GetLastBlock() != 10 || len(recorder.logs) != 3 {
+		t.Fatalf("before reorg: last block %d, %d logs; want 10 and 3", w.GetLastBlock(), len(recorder.logs))
+	}
+
+	// 区块9、10被替换，新的主链延伸到11，新区块10中有一条不同的日志
+	chain.setBlock(9, 1)
+	chain.setBlock(10, 1)
+	chain.setBlock(11, 1)
+	replacement := chain.addLog(10, 0)
+	recorder.logs, checkpoints.saved = nil, nil
+
+	w.checkNewEvents(ctx)
+
+	// 先按倒序回撤被孤立区块的日志，再投递新主链的日志
+	want := []struct {
+		log     ethtypes.Log
+		removed bool
+	}{
+		{orphan10, true},
+		{orphan9b, true},
+		{orphan9a, true},
+		{replacement, false},
+	}
+	if len(recorder.logs) != len(want) {
+		t.Fatalf("after reorg got %d logs, want %d: %+v", len(recorder.logs), len(want), recorder.logs)
+	}
+	for i, exp := range want {
+		got := recorder.logs[i]
+		if got.TxHash != exp.log.TxHash || got.BlockHash != exp.log.BlockHash || got.Removed != exp.removed {
+			t.Errorf("log %d = {tx %s block %s removed %v}, want {tx %s block %s removed %v}",
+				i, got.TxHash, got.BlockHash, got.Removed, exp.log.TxHash, exp.log.BlockHash, exp.removed)
+		}
+	}
+
+	// 检查点回退到分叉点8，再随新主链推进
+	wantSaved := []uint64{9, 8, 9, 10, 11}
+	if len(checkpoints.saved) != len(wantSaved) {
+		t.Fatalf("checkpoints = %v, want %v", checkpoints.saved, wantSaved)
+	}
+	for i := range wantSaved {
+		if checkpoints.saved[i] != wantSaved[i] {
+			t.Fatalf("checkpoints = %v, want %v", checkpoints.saved, wantSaved)
+		}
+	}
+	if w.GetLastBlock() != 11 {
+		t.Errorf("last block = %d, want 11", w.GetLastBlock())
+	}
+}
+
+func TestEventWatcherReorgWithoutRecordedBlocks(t *testing.T) {
+	ctx := context.Background()
+	chain := newFakeChain(5)
+	w := newEventWatcher(config.ChainConfig{Name: "test"}, chain)
+	w.SetBlockRange(1, 0)
+	recorder := &logRecorder{}
+	w.RegisterLogHandler(recorder)
+	w.checkNewEvents(ctx)
+
+	// 窗口被清空后（如重启后从检查点继续）无法校验父哈希，不应回撤任何日志
+	w.SetLastBlock(5)
+	chain.setBlock(5, 1)
+	chain.setBlock(6, 1)
+	w.checkNewEvents(ctx)
+
+	for _, l := range recorder.logs {
+		if l.Removed {
+			t.Fatalf("retracted log %s without a recorded block", l.TxHash)
+		}
+	}
+	if w.GetLastBlock() != 6 {
+		t.Errorf("last block = %d, want 6", w.GetLastBlock())
+	}
+}
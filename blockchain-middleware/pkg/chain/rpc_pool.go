@@ -0,0 +1,584 @@
+package chain
+
+import (
+	"blockchain-middleware/pkg/telemetry"
+	"blockchain-middleware/pkg/types"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// healthCheckInterval 节点健康检查间隔
+	healthCheckInterval = 10 * time.Second
+	// healthCheckTimeout 单次健康检查超时
+	healthCheckTimeout = 5 * time.Second
+	// defaultMaxBlockLag 未配置时允许落后最高区块的块数
+	defaultMaxBlockLag = 5
+	// maxConsecutiveFailures 连续失败达到该次数的节点被剔除
+	maxConsecutiveFailures = 3
+	// readmitAfterChecks 被剔除节点需要连续通过的健康检查次数
+	readmitAfterChecks = 2
+	// defaultRequestTimeout 未配置时单次RPC请求的超时
+	defaultRequestTimeout = 15 * time.Second
+
+	latencyAlpha   = 0.2 // 延迟EWMA平滑系数
+	errorRateAlpha = 0.1 // 错误率EWMA平滑系数
+)
+
+// provider 池中的单个RPC节点
+type provider struct {
+	url    string
+	client *ethclient.Client
+
+	latency     float64 // 毫秒，EWMA
+	errorRate   float64 // 0~1，EWMA
+	headBlock   uint64
+	blockLag    uint64
+	failures    int // 连续失败次数
+	healthyRuns int // 剔除后连续通过健康检查的次数
+	ejected     bool
+	ejectReason string
+	lastError   string
+	removed     bool // 已从池中移除（配置热加载或池已关闭），不再保留新建的连接
+	inflight    int  // 正在使用连接的调用数，移除的节点在归零后断开连接
+}
+
+// ProviderPool 单条链的多节点RPC池
+// 根据延迟、错误率和区块落后程度为节点打分，每次调用路由到得分最好的节点，
+// 节点故障时自动切换到下一个节点；持续异常的节点被剔除，恢复后重新加入。
+type ProviderPool struct {
+	chainName   string
+	maxBlockLag uint64
+	timeout     time.Duration
+	providers   []*provider
+	limiter     *rateLimiter
+	mu          sync.Mutex
+	stopChan    chan struct{}
+	stopOnce    sync.Once
+}
+
+// NewProviderPool 创建RPC节点池并启动健康检查
+// timeout 为单个节点单次请求的超时，0表示默认值；rateLimit 为整个池每秒的请求上限，0表示不限流。
+func NewProviderPool(chainName string, urls []string, maxBlockLag uint64, timeout time.Duration, rateLimit float64) (*ProviderPool, error) {
+	urls = dedupeURLs(urls)
+	if len(urls) == 0 {
+		return nil, fmt.Errorf("no rpc endpoints configured for %s", chainName)
+	}
+	if maxBlockLag == 0 {
+		maxBlockLag = defaultMaxBlockLag
+	}
+	if timeout <= 0 {
+		timeout = defaultRequestTimeout
+	}
+
+	pool := &ProviderPool{
+		chainName:   chainName,
+		maxBlockLag: maxBlockLag,
+		timeout:     timeout,
+		limiter:     newRateLimiter(rateLimit),
+		stopChan:    make(chan struct{}),
+	}
+	for _, u := range urls {
+		pool.providers = append(pool.providers, &provider{url: u})
+	}
+
+	go pool.healthLoop()
+	return pool, nil
+}
+
+// Reconfigure 替换节点列表和请求参数，用于配置热加载
+// 仍在列表中的节点保留连接和统计数据；被移除的节点不再接收新的调用，正在进行的调用结束后断开连接。
+func (p *ProviderPool) Reconfigure(urls []string, maxBlockLag uint64, timeout time.Duration, rateLimit float64) error {
+	urls = dedupeURLs(urls)
+	if len(urls) == 0 {
+		return fmt.Errorf("no rpc endpoints configured for %s", p.chainName)
+	}
+	if maxBlockLag == 0 {
+		maxBlockLag = defaultMaxBlockLag
+	}
+	if timeout <= 0 {
+		timeout = defaultRequestTimeout
+	}
+
+	p.mu.Lock()
+	existing := make(map[string]*provider, len(p.providers))
+	for _, pr := range p.providers {
+		existing[pr.url] = pr
+	}
+	providers := make([]*provider, 0, len(urls))
+	for _, u := range urls {
+		if pr, ok := existing[u]; ok {
+			providers = append(providers, pr)
+			delete(existing, u)
+			continue
+		}
+		providers = append(providers, &provider{url: u})
+	}
+	p.providers = providers
+	for _, pr := range existing {
+		pr.removed = true
+		if pr.inflight == 0 && pr.client != nil {
+			pr.client.Close()
+			pr.client = nil
+		}
+	}
+	p.maxBlockLag = maxBlockLag
+	p.timeout = timeout
+	p.mu.Unlock()
+
+	p.limiter.setRate(rateLimit)
+	return nil
+}
+
+// Call 在得分最好的节点上执行调用，节点故障或超时时依次切换到其他节点
+// 每次尝试的上下文在 ctx 的基础上附加单节点超时；ctx 被取消后立即返回，不再切换。
+// method 用于日志、指标和span名称。应用层错误（如交易回滚、nonce过低）直接返回，不触发切换。
+func (p *ProviderPool) Call(ctx context.Context, method string, fn func(ctx context.Context, client *ethclient.Client) error) (err error) {
+	ctx, span := telemetry.StartRPCSpan(ctx, p.chainName, method)
+	defer func() { telemetry.EndSpan(span, err) }()
+
+	p.mu.Lock()
+	attempts, timeout := len(p.providers), p.timeout
+	p.mu.Unlock()
+	tried := make(map[*provider]bool, attempts)
+
+	var lastErr error
+	for i := 0; i < attempts; i++ {
+		if err := p.limiter.wait(ctx); err != nil {
+			return err
+		}
+
+		pr := p.pick(tried)
+		if pr == nil {
+			break
+		}
+		tried[pr] = true
+
+		start := time.Now()
+		client, err := p.dial(ctx, pr)
+		if errors.Is(err, errProviderRemoved) {
+			continue
+		}
+		if err != nil {
+			p.recordFailure(pr, 0, err)
+			telemetry.ObserveRPC(p.chainName, method, telemetry.RPCFailure, time.Since(start))
+			span.AddEvent("provider failed", trace.WithAttributes(semconv.ServerAddress(redactURL(pr.url))))
+			lastErr = err
+			continue
+		}
+
+		attemptCtx, cancel := context.WithTimeout(ctx, timeout)
+		err = fn(attemptCtx, client)
+		elapsed := time.Since(start)
+		cancel()
+		p.release(pr)
+
+		// 调用方已放弃（如HTTP客户端断开），不计入节点故障
+		if ctx.Err() != nil {
+			telemetry.ObserveRPC(p.chainName, method, rpcResult(err), elapsed)
+			return err
+		}
+		if !isProviderError(err) {
+			// 只有节点给出了响应才更新节点统计；调用方自己的校验错误与节点无关
+			if err == nil || isNodeResponse(err) {
+				p.recordSuccess(pr, elapsed)
+			}
+			telemetry.ObserveRPC(p.chainName, method, rpcResult(err), elapsed)
+			span.SetAttributes(semconv.ServerAddress(redactURL(pr.url)))
+			return err
+		}
+
+		p.recordFailure(pr, elapsed, err)
+		telemetry.ObserveRPC(p.chainName, method, telemetry.RPCFailure, elapsed)
+		span.AddEvent("provider failed", trace.WithAttributes(
+			semconv.ServerAddress(redactURL(pr.url)),
+			attribute.String("error", err.Error()),
+		))
+		lastErr = err
+		log.Printf("RPC %s on %s provider %s failed, trying next provider: %v", method, p.chainName, redactURL(pr.url), err)
+	}
+
+	if lastErr == nil {
+		lastErr = fmt.Errorf("no rpc provider available for %s", p.chainName)
+	}
+	return lastErr
+}
+
+// Status 返回池中所有节点的状态
+func (p *ProviderPool) Status() []types.ProviderStatus {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	status := make([]types.ProviderStatus, len(p.providers))
+	for i, pr := range p.providers {
+		status[i] = types.ProviderStatus{
+			URL:         redactURL(pr.url),
+			Healthy:     !pr.ejected,
+			LatencyMs:   math.Round(pr.latency*100) / 100,
+			ErrorRate:   math.Round(pr.errorRate*1000) / 1000,
+			HeadBlock:   pr.headBlock,
+			BlockLag:    pr.blockLag,
+			Score:       math.Round(p.score(pr)*100) / 100,
+			EjectReason: pr.ejectReason,
+			LastError:   pr.lastError,
+		}
+	}
+	return status
+}
+
+// Close 停止健康检查并关闭所有连接
+func (p *ProviderPool) Close() {
+	p.stopOnce.Do(func() {
+		close(p.stopChan)
+	})
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, pr := range p.providers {
+		pr.removed = true
+		if pr.client != nil {
+			pr.client.Close()
+			pr.client = nil
+		}
+	}
+}
+
+// pick 选择未尝试过的得分最好的节点，健康节点都不可用时退而选择被剔除的节点
+func (p *ProviderPool) pick(tried map[*provider]bool) *provider {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var best, fallback *provider
+	for _, pr := range p.providers {
+		if tried[pr] {
+			continue
+		}
+		if pr.ejected {
+			if fallback == nil || p.score(pr) < p.score(fallback) {
+				fallback = pr
+			}
+			continue
+		}
+		if best == nil || p.score(pr) < p.score(best) {
+			best = pr
+		}
+	}
+
+	if best != nil {
+		return best
+	}
+	return fallback
+}
+
+// score 节点得分，越低越好：延迟按错误率放大，再加上区块落后惩罚
+func (p *ProviderPool) score(pr *provider) float64 {
+	latency := pr.latency
+	if latency == 0 {
+		latency = 100 // 尚无样本的节点按中等延迟对待
+	}
+	return latency*(1+10*pr.errorRate) + float64(pr.blockLag)*50
+}
+
+// errProviderRemoved 建立连接期间节点已被移出池
+var errProviderRemoved = errors.New("rpc provider was removed from the pool")
+
+// dial 按需建立节点连接并登记一次在途调用，调用结束后须调用 release
+// 建立连接（尤其是WebSocket）可能很慢，不持有池的锁，避免一个无响应的节点阻塞所有调用。
+func (p *ProviderPool) dial(ctx context.Context, pr *provider) (*ethclient.Client, error) {
+	p.mu.Lock()
+	if pr.removed {
+		p.mu.Unlock()
+		return nil, errProviderRemoved
+	}
+	client, rawURL := pr.client, pr.url
+	if client != nil {
+		pr.inflight++
+		p.mu.Unlock()
+		return client, nil
+	}
+	p.mu.Unlock()
+
+	dialCtx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+	client, err := ethclient.DialContext(dialCtx, rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s node: %w", p.chainName, err)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	switch {
+	case pr.removed:
+		client.Close()
+		return nil, errProviderRemoved
+	case pr.client != nil:
+		// 并发的调用已经建立了连接，使用先建立的那个
+		client.Close()
+		client = pr.client
+	default:
+		pr.client = client
+	}
+	pr.inflight++
+	return client, nil
+}
+
+// release 结束一次在途调用，已移出池的节点在最后一个调用结束后断开连接
+func (p *ProviderPool) release(pr *provider) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	pr.inflight--
+	if pr.removed && pr.inflight == 0 && pr.client != nil {
+		pr.client.Close()
+		pr.client = nil
+	}
+}
+
+// recordSuccess 记录一次成功调用
+func (p *ProviderPool) recordSuccess(pr *provider, elapsed time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	pr.observeLatency(elapsed)
+	pr.errorRate = ewma(pr.errorRate, 0, errorRateAlpha)
+	pr.failures = 0
+}
+
+// recordFailure 记录一次节点故障，连续失败过多时剔除节点
+func (p *ProviderPool) recordFailure(pr *provider, elapsed time.Duration, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if elapsed > 0 {
+		pr.observeLatency(elapsed)
+	}
+	pr.errorRate = ewma(pr.errorRate, 1, errorRateAlpha)
+	pr.failures++
+	pr.lastError = err.Error()
+
+	if !pr.ejected && pr.failures >= maxConsecutiveFailures {
+		p.eject(pr, fmt.Sprintf("%d consecutive failures", pr.failures))
+	}
+}
+
+// eject 剔除节点（调用方持有锁）
+func (p *ProviderPool) eject(pr *provider, reason string) {
+	pr.ejected = true
+	pr.ejectReason = reason
+	pr.healthyRuns = 0
+	log.Printf("Ejected %s rpc provider %s: %s", p.chainName, redactURL(pr.url), reason)
+}
+
+// healthLoop 定期检查所有节点
+func (p *ProviderPool) healthLoop() {
+	p.checkHealth()
+
+	ticker := time.NewTicker(healthCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.stopChan:
+			return
+		case <-ticker.C:
+			p.checkHealth()
+		}
+	}
+}
+
+// checkHealth 并发查询每个节点的最新区块，更新落后程度并处理剔除和恢复
+func (p *ProviderPool) checkHealth() {
+	type result struct {
+		head    uint64
+		elapsed time.Duration
+		err     error
+	}
+
+	p.mu.Lock()
+	providers := append([]*provider(nil), p.providers...)
+	p.mu.Unlock()
+
+	results := make([]result, len(providers))
+	var wg sync.WaitGroup
+	for i, pr := range providers {
+		wg.Add(1)
+		go func(i int, pr *provider) {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(context.Background(), healthCheckTimeout)
+			defer cancel()
+
+			client, err := p.dial(ctx, pr)
+			if err != nil {
+				results[i] = result{err: err}
+				return
+			}
+
+			start := time.Now()
+			head, err := client.BlockNumber(ctx)
+			results[i] = result{head: head, elapsed: time.Since(start), err: err}
+			p.release(pr)
+		}(i, pr)
+	}
+	wg.Wait()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var maxHead uint64
+	for i, r := range results {
+		if r.err == nil {
+			providers[i].headBlock = r.head
+		}
+		if providers[i].headBlock > maxHead {
+			maxHead = providers[i].headBlock
+		}
+	}
+
+	for i, pr := range providers {
+		r := results[i]
+		pr.blockLag = maxHead - pr.headBlock
+
+		if r.err != nil {
+			pr.errorRate = ewma(pr.errorRate, 1, errorRateAlpha)
+			pr.failures++
+			pr.healthyRuns = 0
+			pr.lastError = r.err.Error()
+			if !pr.ejected && pr.failures >= maxConsecutiveFailures {
+				p.eject(pr, fmt.Sprintf("%d consecutive failures", pr.failures))
+			}
+			continue
+		}
+
+		pr.observeLatency(r.elapsed)
+
+		// 区块停滞或落后过多的节点会返回过期数据
+		if pr.blockLag > p.maxBlockLag {
+			pr.healthyRuns = 0
+			if !pr.ejected {
+				p.eject(pr, fmt.Sprintf("head block lags by %d blocks", pr.blockLag))
+			}
+			continue
+		}
+
+		if pr.ejected {
+			pr.healthyRuns++
+			if pr.healthyRuns >= readmitAfterChecks {
+				pr.ejected = false
+				pr.ejectReason = ""
+				pr.failures = 0
+				pr.errorRate /= 2
+				log.Printf("Re-admitted %s rpc provider %s", p.chainName, redactURL(pr.url))
+			}
+		}
+	}
+}
+
+// isProviderError 判断错误是否由节点本身引起，这类错误需要切换节点并计入节点故障：
+// 网络错误、超时、HTTP 429/5xx，以及节点返回的限流和内部错误。
+// 其余错误（JSON-RPC 业务错误、调用方自己的校验错误等）原样返回给调用方，不影响节点状态。
+func isProviderError(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, rpc.ErrClientQuit) ||
+		errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+
+	var httpErr rpc.HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.StatusCode == http.StatusTooManyRequests || httpErr.StatusCode >= http.StatusInternalServerError
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+
+	var rpcErr rpc.Error
+	if errors.As(err, &rpcErr) {
+		// 日志查询结果过多换节点也一样，由调用方缩小查询范围
+		if isTooManyResults(err) {
+			return false
+		}
+		switch rpcErr.ErrorCode() {
+		case -32005, -32603: // 超出限额、节点内部错误
+			return true
+		}
+		msg := strings.ToLower(rpcErr.Error())
+		return strings.Contains(msg, "header not found") || strings.Contains(msg, "rate limit")
+	}
+
+	return false
+}
+
+// isNodeResponse 错误是否来自节点的正常响应（如交易回滚、查询对象不存在）
+func isNodeResponse(err error) bool {
+	var rpcErr rpc.Error
+	return errors.Is(err, ethereum.NotFound) || errors.As(err, &rpcErr)
+}
+
+// dedupeURLs 去掉空地址和重复地址，保持原有顺序
+func dedupeURLs(urls []string) []string {
+	seen := make(map[string]bool, len(urls))
+	var result []string
+	for _, u := range urls {
+		u = strings.TrimSpace(u)
+		if u == "" || seen[u] {
+			continue
+		}
+		seen[u] = true
+		result = append(result, u)
+	}
+	return result
+}
+
+// observeLatency 记录一次延迟样本（毫秒）
+func (pr *provider) observeLatency(elapsed time.Duration) {
+	ms := float64(elapsed.Microseconds()) / 1000
+	if pr.latency == 0 {
+		pr.latency = ms
+		return
+	}
+	pr.latency = ewma(pr.latency, ms, latencyAlpha)
+}
+
+// ewma 指数加权移动平均
+func ewma(current, sample, alpha float64) float64 {
+	return current*(1-alpha) + sample*alpha
+}
+
+// rpcResult 按错误类型给出指标中的调用结果，查询对象不存在视为成功
+func rpcResult(err error) string {
+	if err == nil || errors.Is(err, ethereum.NotFound) {
+		return telemetry.RPCSuccess
+	}
+	return telemetry.RPCError
+}
+
+// redactURL 隐藏RPC地址中可能包含的API密钥，只保留协议和主机
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return "invalid-url"
+	}
+	return u.Scheme + "://" + u.Host
+}
@@ -0,0 +1,192 @@
+package chain
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+func TestIsProviderError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"caller cancelled", context.Canceled, false},
+		{"timeout", context.DeadlineExceeded, true},
+		{"wrapped timeout", fmt.Errorf("failed to get balance: %w", context.DeadlineExceeded), true},
+		{"connection refused", &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}, true},
+		{"http 429", rpc.HTTPError{StatusCode: http.StatusTooManyRequests, Status: "429 Too Many Requests"}, true},
+		{"http 502", rpc.HTTPError{StatusCode: http.StatusBadGateway, Status: "502 Bad Gateway"}, true},
+		{"http 401", rpc.HTTPError{StatusCode: http.StatusUnauthorized, Status: "401 Unauthorized"}, false},
+		{"limit exceeded", &fakeRPCError{Code: -32005, Message: "limit exceeded"}, true},
+		{"internal error", &fakeRPCError{Code: -32603, Message: "internal error"}, true},
+		{"header not found", &fakeRPCError{Code: -32000, Message: "header not found"}, true},
+		{"nonce too low", &fakeRPCError{Code: -32000, Message: "nonce too low"}, false},
+		{"execution reverted", &fakeRPCError{Code: 3, Message: "execution reverted"}, false},
+		{"too many logs", &fakeRPCError{Code: -32005, Message: "query returned more than 10000 results"}, false},
+		{"not found", ethereum.NotFound, false},
+		{"request validation", errors.New("nonce is required"), false},
+		{"fee validation", fmt.Errorf("chain %d does not support EIP-1559 fees, use gas_price", 56), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isProviderError(tt.err); got != tt.want {
+				t.Errorf("isProviderError(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+// providerFailures 返回每个节点的连续失败次数和剔除状态
+func providerFailures(p *ProviderPool) map[string]int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	failures := make(map[string]int, len(p.providers))
+	for _, pr := range p.providers {
+		failures[pr.url] = pr.failures
+		if pr.ejected {
+			failures[pr.url] = -1
+		}
+	}
+	return failures
+}
+
+// assertNoProviderFailures 断言没有节点被记录失败
+func assertNoProviderFailures(t *testing.T, pool *ProviderPool) {
+	t.Helper()
+	for url, failures := range providerFailures(pool) {
+		if failures != 0 {
+			t.Errorf("provider %s failures = %d, want 0", url, failures)
+		}
+	}
+}
+
+func TestProviderPoolCallerErrorsDoNotEject(t *testing.T) {
+	_, urlA := newFakeNode(t)
+	_, urlB := newFakeNode(t)
+	pool, err := NewProviderPool("test", []string{urlA, urlB}, 0, time.Second, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+
+	validationErr := errors.New("nonce is required")
+	for i := 0; i < maxConsecutiveFailures+2; i++ {
+		calls := 0
+		err := pool.Call(context.Background(), "buildTransaction", func(ctx context.Context, client *ethclient.Client) error {
+			calls++
+			return validationErr
+		})
+		if !errors.Is(err, validationErr) {
+			t.Fatalf("Call() error = %v, want %v", err, validationErr)
+		}
+		if calls != 1 {
+			t.Fatalf("validation error was retried on %d providers", calls)
+		}
+	}
+
+	assertNoProviderFailures(t, pool)
+}
+
+func TestProviderPoolFailsOverOnServerError(t *testing.T) {
+	nodeA, urlA := newFakeNode(t)
+	_, urlB := newFakeNode(t)
+	pool, err := NewProviderPool("test", []string{urlA, urlB}, 0, time.Second, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+	nodeA.setStatus(http.StatusServiceUnavailable)
+
+	for i := 0; i < maxConsecutiveFailures; i++ {
+		var head uint64
+		err := pool.Call(context.Background(), "eth_blockNumber", func(ctx context.Context, client *ethclient.Client) error {
+			var err error
+			head, err = client.BlockNumber(ctx)
+			return err
+		})
+		if err != nil {
+			t.Fatalf("Call() error = %v, want failover to healthy provider", err)
+		}
+		if head != 0x10 {
+			t.Fatalf("head = %d, want 16", head)
+		}
+	}
+
+	failures := providerFailures(pool)
+	if failures[urlA] == 0 {
+		t.Errorf("provider returning 503 has no recorded failures")
+	}
+	if failures[urlB] != 0 {
+		t.Errorf("healthy provider failures = %d, want 0", failures[urlB])
+	}
+}
+
+func TestProviderPoolReconfigureDrainsRemovedProvider(t *testing.T) {
+	nodeA, urlA := newFakeNode(t)
+	_, urlB := newFakeNode(t)
+	entered, unblock := make(chan struct{}), make(chan struct{})
+	nodeA.handle("eth_chainId", func([]json.RawMessage) (interface{}, error) {
+		close(entered)
+		<-unblock
+		return "0x1", nil
+	})
+
+	pool, err := NewProviderPool("test", []string{urlA}, 0, 5*time.Second, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+	removed := pool.providers[0]
+
+	done := make(chan error, 1)
+	go func() {
+		done <- pool.Call(context.Background(), "eth_chainId", func(ctx context.Context, client *ethclient.Client) error {
+			_, err := client.ChainID(ctx)
+			return err
+		})
+	}()
+	<-entered
+
+	if err := pool.Reconfigure([]string{urlB}, 0, 5*time.Second, 0); err != nil {
+		t.Fatal(err)
+	}
+	pool.mu.Lock()
+	draining := removed.client != nil
+	pool.mu.Unlock()
+	if !draining {
+		t.Error("removed provider was disconnected while a call was in flight")
+	}
+
+	close(unblock)
+	if err := <-done; err != nil {
+		t.Fatalf("in-flight call failed after reconfigure: %v", err)
+	}
+	// 启动时的健康检查可能仍在使用该节点，等它结束
+	deadline := time.Now().Add(2 * time.Second)
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	for (removed.client != nil || removed.inflight != 0) && time.Now().Before(deadline) {
+		pool.mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+		pool.mu.Lock()
+	}
+	if removed.client != nil || removed.inflight != 0 {
+		t.Errorf("removed provider still connected after its calls finished (inflight %d)", removed.inflight)
+	}
+	if len(pool.providers) != 1 || pool.providers[0].url != urlB {
+		t.Errorf("providers = %v", pool.providers)
+	}
+}
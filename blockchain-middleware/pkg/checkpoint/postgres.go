@@ -0,0 +1,58 @@
+package checkpoint
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// PostgresStore 基于PostgreSQL的检查点存储，服务重启后从上次处理的区块继续
+type PostgresStore struct {
+	db *sql.DB
+}
+
+// NewPostgresStore 创建PostgreSQL存储并确保表结构存在
+func NewPostgresStore(ctx context.Context, db *sql.DB) (*PostgresStore, error) {
+	_, err := db.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS middleware_event_checkpoints (
+			chain_name VARCHAR(64) NOT NULL,
+			watcher_id VARCHAR(128) NOT NULL,
+			block_number BIGINT NOT NULL,
+			updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (chain_name, watcher_id)
+		)`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create checkpoint table: %w", err)
+	}
+
+	return &PostgresStore{db: db}, nil
+}
+
+// Load 加载检查点
+func (s *PostgresStore) Load(ctx context.Context, chainName, watcherID string) (uint64, bool, error) {
+	var block int64
+	err := s.db.QueryRowContext(ctx,
+		`SELECT block_number FROM middleware_event_checkpoints WHERE chain_name = $1 AND watcher_id = $2`,
+		chainName, watcherID).Scan(&block)
+	if err == sql.ErrNoRows {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to load checkpoint: %w", err)
+	}
+	return uint64(block), true, nil
+}
+
+// Save 保存检查点
+func (s *PostgresStore) Save(ctx context.Context, chainName, watcherID string, block uint64) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO middleware_event_checkpoints (chain_name, watcher_id, block_number, updated_at)
+		VALUES ($1, $2, $3, CURRENT_TIMESTAMP)
+		ON CONFLICT (chain_name, watcher_id)
+		DO UPDATE SET block_number = EXCLUDED.block_number, updated_at = EXCLUDED.updated_at`,
+		chainName, watcherID, int64(block))
+	if err != nil {
+		return fmt.Errorf("failed to save checkpoint: %w", err)
+	}
+	return nil
+}
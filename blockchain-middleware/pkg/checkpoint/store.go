@@ -0,0 +1,48 @@
+package checkpoint
+
+import (
+	"context"
+	"sync"
+)
+
+// Store 事件监听检查点持久化接口，按(链, 监听器)记录已处理完成的最后一个区块
+type Store interface {
+	// Load 加载检查点，不存在时 found 为false
+	Load(ctx context.Context, chainName, watcherID string) (block uint64, found bool, err error)
+	// Save 保存检查点
+	Save(ctx context.Context, chainName, watcherID string, block uint64) error
+}
+
+// MemoryStore 内存存储，仅用于未启用数据库的开发环境
+type MemoryStore struct {
+	mu     sync.Mutex
+	blocks map[string]uint64
+}
+
+// NewMemoryStore 创建内存存储
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{blocks: make(map[string]uint64)}
+}
+
+// Load 加载检查点
+func (s *MemoryStore) Load(ctx context.Context, chainName, watcherID string) (uint64, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	block, ok := s.blocks[checkpointKey(chainName, watcherID)]
+	return block, ok, nil
+}
+
+// Save 保存检查点
+func (s *MemoryStore) Save(ctx context.Context, chainName, watcherID string, block uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.blocks[checkpointKey(chainName, watcherID)] = block
+	return nil
+}
+
+// checkpointKey 检查点在内存中的键
+func checkpointKey(chainName, watcherID string) string {
+	return chainName + ":" + watcherID
+}
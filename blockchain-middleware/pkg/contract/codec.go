@@ -0,0 +1,354 @@
+package contract
+
+import (
+	"blockchain-middleware/pkg/types"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+var bigIntType = reflect.TypeOf(&big.Int{})
+
+// maxJSONIntegerBits JSON数字能精确表示的最大整数位数（IEEE 754 双精度）
+const maxJSONIntegerBits = 53
+
+// FindMethod 按方法名或完整签名（如 transfer(address,uint256)）查找方法
+// 重载方法必须使用签名。
+func FindMethod(contractABI *abi.ABI, name string) (*abi.Method, error) {
+	if name == "" {
+		return nil, fmt.Errorf("method is required")
+	}
+
+	if strings.Contains(name, "(") {
+		sig := strings.ReplaceAll(name, " ", "")
+		for _, m := range contractABI.Methods {
+			if m.Sig == sig {
+				m := m
+				return &m, nil
+			}
+		}
+		return nil, fmt.Errorf("method %s not found in abi", name)
+	}
+
+	var found *abi.Method
+	for _, m := range contractABI.Methods {
+		if m.RawName != name {
+			continue
+		}
+		if found != nil {
+			return nil, fmt.Errorf("method %s is overloaded, use its full signature", name)
+		}
+		m := m
+		found = &m
+	}
+	if found == nil {
+		return nil, fmt.Errorf("method %s not found in abi", name)
+	}
+	return found, nil
+}
+
+// EncodeCall 按方法定义把JSON参数编码为调用数据
+func EncodeCall(method *abi.Method, args []json.RawMessage) ([]byte, error) {
+	values, err := decodeArgs(method.Inputs, args)
+	if err != nil {
+		return nil, fmt.Errorf("invalid arguments for %s: %w", method.Sig, err)
+	}
+
+	packed, err := method.Inputs.Pack(values...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode arguments for %s: %w", method.Sig, err)
+	}
+	return append(append([]byte{}, method.ID...), packed...), nil
+}
+
+// DecodeOutputs 按方法定义解码返回数据
+func DecodeOutputs(method *abi.Method, data []byte) ([]types.ABIValue, error) {
+	values, err := method.Outputs.Unpack(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode outputs of %s: %w", method.Sig, err)
+	}
+	return encodeValues(method.Outputs, values), nil
+}
+
+// decodeArgs 把JSON参数转换为ABI编码需要的Go值
+func decodeArgs(inputs abi.Arguments, args []json.RawMessage) ([]interface{}, error) {
+	if len(args) != len(inputs) {
+		return nil, fmt.Errorf("expected %d arguments, got %d", len(inputs), len(args))
+	}
+
+	values := make([]interface{}, len(inputs))
+	for i, input := range inputs {
+		v, err := fromJSON(input.Type, args[i])
+		if err != nil {
+			return nil, fmt.Errorf("argument %d (%s %s): %w", i, input.Type.String(), input.Name, err)
+		}
+		values[i] = v.Interface()
+	}
+	return values, nil
+}
+
+// fromJSON 把JSON值转换为ABI类型对应的Go值
+func fromJSON(t abi.Type, raw json.RawMessage) (reflect.Value, error) {
+	goType := t.GetType()
+
+	switch t.T {
+	case abi.IntTy, abi.UintTy:
+		n, err := parseInteger(raw)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		return integerValue(t, goType, n)
+
+	case abi.BoolTy:
+		var b bool
+		if err := json.Unmarshal(raw, &b); err != nil {
+			return reflect.Value{}, fmt.Errorf("expected boolean")
+		}
+		return reflect.ValueOf(b), nil
+
+	case abi.StringTy:
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return reflect.Value{}, fmt.Errorf("expected string")
+		}
+		return reflect.ValueOf(s), nil
+
+	case abi.AddressTy:
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil || !common.IsHexAddress(s) {
+			return reflect.Value{}, fmt.Errorf("expected hex address")
+		}
+		return reflect.ValueOf(common.HexToAddress(s)), nil
+
+	case abi.BytesTy:
+		b, err := parseHexBytes(raw)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		return reflect.ValueOf(b), nil
+
+	case abi.FixedBytesTy, abi.FunctionTy:
+		b, err := parseHexBytes(raw)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		if len(b) != goType.Len() {
+			return reflect.Value{}, fmt.Errorf("expected %d bytes, got %d", goType.Len(), len(b))
+		}
+		v := reflect.New(goType).Elem()
+		reflect.Copy(v, reflect.ValueOf(b))
+		return v, nil
+
+	case abi.SliceTy, abi.ArrayTy:
+		var items []json.RawMessage
+		if err := json.Unmarshal(raw, &items); err != nil {
+			return reflect.Value{}, fmt.Errorf("expected array")
+		}
+
+		var v reflect.Value
+		if t.T == abi.SliceTy {
+			v = reflect.MakeSlice(goType, len(items), len(items))
+		} else {
+			if len(items) != t.Size {
+				return reflect.Value{}, fmt.Errorf("expected %d elements, got %d", t.Size, len(items))
+			}
+			v = reflect.New(goType).Elem()
+		}
+		for i, item := range items {
+			elem, err := fromJSON(*t.Elem, item)
+			if err != nil {
+				return reflect.Value{}, fmt.Errorf("element %d: %w", i, err)
+			}
+			v.Index(i).Set(elem)
+		}
+		return v, nil
+
+	case abi.TupleTy:
+		items, err := tupleItems(t, raw)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+
+		v := reflect.New(goType).Elem()
+		for i, elemType := range t.TupleElems {
+			field, err := fromJSON(*elemType, items[i])
+			if err != nil {
+				return reflect.Value{}, fmt.Errorf("field %s: %w", tupleFieldName(t, i), err)
+			}
+			v.Field(i).Set(field)
+		}
+		return v, nil
+	}
+
+	return reflect.Value{}, fmt.Errorf("unsupported abi type %s", t.String())
+}
+
+// tupleItems 按字段顺序取出tuple的JSON值，支持按字段名的对象或按位置的数组
+func tupleItems(t abi.Type, raw json.RawMessage) ([]json.RawMessage, error) {
+	var items []json.RawMessage
+	if err := json.Unmarshal(raw, &items); err == nil {
+		if len(items) != len(t.TupleElems) {
+			return nil, fmt.Errorf("expected %d tuple fields, got %d", len(t.TupleElems), len(items))
+		}
+		return items, nil
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil, fmt.Errorf("expected object or array for tuple")
+	}
+	items = make([]json.RawMessage, len(t.TupleElems))
+	for i := range t.TupleElems {
+		name := tupleFieldName(t, i)
+		item, ok := fields[name]
+		if !ok {
+			return nil, fmt.Errorf("missing tuple field %s", name)
+		}
+		items[i] = item
+	}
+	return items, nil
+}
+
+// tupleFieldName tuple字段名，匿名字段使用位置序号
+func tupleFieldName(t abi.Type, i int) string {
+	if i < len(t.TupleRawNames) && t.TupleRawNames[i] != "" {
+		return t.TupleRawNames[i]
+	}
+	return strconv.Itoa(i)
+}
+
+// parseInteger 解析整数，支持JSON数字、十进制字符串和0x十六进制字符串
+// 不带0x前缀的一律按十进制解析：前导零不表示八进制，也不接受0b、0o前缀和下划线。
+func parseInteger(raw json.RawMessage) (*big.Int, error) {
+	text := string(bytes.TrimSpace(raw))
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		text = strings.TrimSpace(s)
+	}
+
+	negative := strings.HasPrefix(text, "-")
+	digits := strings.TrimPrefix(text, "-")
+	base := 10
+	if strings.HasPrefix(digits, "0x") || strings.HasPrefix(digits, "0X") {
+		digits, base = digits[2:], 16
+	}
+	// 符号已经处理过，SetString 不应再看到正负号
+	if digits == "" || strings.ContainsAny(digits[:1], "+-") {
+		return nil, fmt.Errorf("expected integer, got %s", text)
+	}
+	n, ok := new(big.Int).SetString(digits, base)
+	if !ok {
+		return nil, fmt.Errorf("expected integer, got %s", text)
+	}
+	if negative {
+		n.Neg(n)
+	}
+	return n, nil
+}
+
+// integerValue 校验整数范围并转换为ABI类型对应的Go值
+func integerValue(t abi.Type, goType reflect.Type, n *big.Int) (reflect.Value, error) {
+	if t.T == abi.UintTy {
+		if n.Sign() < 0 || n.BitLen() > t.Size {
+			return reflect.Value{}, fmt.Errorf("%s out of range for %s", n, t.String())
+		}
+	} else {
+		limit := new(big.Int).Lsh(big.NewInt(1), uint(t.Size-1))
+		if n.Cmp(limit) >= 0 || n.Cmp(new(big.Int).Neg(limit)) < 0 {
+			return reflect.Value{}, fmt.Errorf("%s out of range for %s", n, t.String())
+		}
+	}
+
+	if goType == bigIntType {
+		return reflect.ValueOf(n), nil
+	}
+	v := reflect.New(goType).Elem()
+	if t.T == abi.UintTy {
+		v.SetUint(n.Uint64())
+	} else {
+		v.SetInt(n.Int64())
+	}
+	return v, nil
+}
+
+// parseHexBytes 解析0x十六进制字符串
+func parseHexBytes(raw json.RawMessage) ([]byte, error) {
+	var s string
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return nil, fmt.Errorf("expected 0x-prefixed hex string")
+	}
+	b, err := hexutil.Decode(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid hex %q: %w", s, err)
+	}
+	return b, nil
+}
+
+// encodeValues 把解码出的Go值转换为带名称和类型的JSON值
+func encodeValues(args abi.Arguments, values []interface{}) []types.ABIValue {
+	out := make([]types.ABIValue, len(values))
+	for i, v := range values {
+		out[i] = types.ABIValue{
+			Name:  args[i].Name,
+			Type:  args[i].Type.String(),
+			Value: toJSON(args[i].Type, reflect.ValueOf(v)),
+		}
+	}
+	return out
+}
+
+// toJSON 把ABI解码出的Go值转换为便于JSON序列化的值
+// 超过53位的整数类型一律返回十进制字符串，避免JavaScript等客户端丢失精度。
+func toJSON(t abi.Type, v reflect.Value) interface{} {
+	switch t.T {
+	case abi.IntTy, abi.UintTy:
+		var n *big.Int
+		switch {
+		case v.Type() == bigIntType:
+			n = v.Interface().(*big.Int)
+		case t.T == abi.UintTy:
+			n = new(big.Int).SetUint64(v.Uint())
+		default:
+			n = big.NewInt(v.Int())
+		}
+		if t.Size <= maxJSONIntegerBits {
+			return n.Int64()
+		}
+		return n.String()
+
+	case abi.AddressTy:
+		return v.Interface().(common.Address).Hex()
+
+	case abi.BytesTy:
+		return hexutil.Encode(v.Bytes())
+
+	case abi.FixedBytesTy, abi.FunctionTy:
+		b := make([]byte, v.Len())
+		reflect.Copy(reflect.ValueOf(b), v)
+		return hexutil.Encode(b)
+
+	case abi.SliceTy, abi.ArrayTy:
+		items := make([]interface{}, v.Len())
+		for i := range items {
+			items[i] = toJSON(*t.Elem, v.Index(i))
+		}
+		return items
+
+	case abi.TupleTy:
+		fields := make(map[string]interface{}, len(t.TupleElems))
+		for i, elemType := range t.TupleElems {
+			fields[tupleFieldName(t, i)] = toJSON(*elemType, v.Field(i))
+		}
+		return fields
+	}
+
+	return v.Interface()
+}
@@ -0,0 +1,154 @@
+package contract
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+)
+
+func TestParseInteger(t *testing.T) {
+	maxUint256, _ := new(big.Int).SetString("115792089237316195423570985008687907853269984665640564039457584007913129639935", 10)
+
+	tests := []struct {
+		raw     string
+		want    *big.Int
+		wantErr bool
+	}{
+		{raw: `42`, want: big.NewInt(42)},
+		{raw: `"42"`, want: big.NewInt(42)},
+		{raw: `" 42 "`, want: big.NewInt(42)},
+		{raw: `"-42"`, want: big.NewInt(-42)},
+		{raw: `"0x2a"`, want: big.NewInt(42)},
+		{raw: `"0X2A"`, want: big.NewInt(42)},
+		{raw: `"-0x2a"`, want: big.NewInt(-42)},
+		{raw: `"0010"`, want: big.NewInt(10)}, // 前导零不是八进制
+		{raw: `"0"`, want: big.NewInt(0)},
+		{raw: `"` + maxUint256.String() + `"`, want: maxUint256},
+		{raw: `"0b101"`, wantErr: true},
+		{raw: `"0o17"`, wantErr: true},
+		{raw: `"1_000"`, wantErr: true},
+		{raw: `"0x_ff"`, wantErr: true},
+		{raw: `"--5"`, wantErr: true},
+		{raw: `"-+5"`, wantErr: true},
+		{raw: `"+5"`, wantErr: true},
+		{raw: `"0x-5"`, wantErr: true},
+		{raw: `"-"`, wantErr: true},
+		{raw: `"0x"`, wantErr: true},
+		{raw: `""`, wantErr: true},
+		{raw: `1.5`, wantErr: true},
+		{raw: `1e18`, wantErr: true},
+		{raw: `"ff"`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			got, err := parseInteger(json.RawMessage(tt.raw))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseInteger(%s) = %s, want error", tt.raw, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseInteger(%s) error = %v", tt.raw, err)
+			}
+			if got.Cmp(tt.want) != 0 {
+				t.Fatalf("parseInteger(%s) = %s, want %s", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIntegerRoundTrip(t *testing.T) {
+	tests := []struct {
+		typ     string
+		in      string
+		want    interface{} // JSON数字为int64，超过53位为十进制字符串
+		wantErr bool
+	}{
+		{typ: "uint8", in: `"0xff"`, want: int64(255)},
+		{typ: "uint8", in: `256`, wantErr: true},
+		{typ: "int8", in: `-128`, want: int64(-128)},
+		{typ: "int8", in: `128`, wantErr: true},
+		{typ: "uint32", in: `"4294967295"`, want: int64(4294967295)},
+		{typ: "uint40", in: `"1099511627775"`, want: int64(1099511627775)},
+		{typ: "int48", in: `"-140737488355328"`, want: int64(-140737488355328)},
+		{typ: "uint48", in: `"281474976710655"`, want: int64(281474976710655)},
+		{typ: "uint56", in: `"72057594037927935"`, want: "72057594037927935"},
+		{typ: "int56", in: `"-1"`, want: "-1"},
+		{typ: "uint64", in: `"18446744073709551615"`, want: "18446744073709551615"},
+		{typ: "int64", in: `"-9223372036854775808"`, want: "-9223372036854775808"},
+		{typ: "uint256", in: `"0x0100"`, want: "256"},
+		{typ: "uint256", in: `"-1"`, wantErr: true},
+		{typ: "int256", in: `"-0010"`, want: "-10"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.typ+" "+tt.in, func(t *testing.T) {
+			typ, err := abi.NewType(tt.typ, "", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			args := abi.Arguments{{Name: "value", Type: typ}}
+
+			values, err := decodeArgs(args, []json.RawMessage{json.RawMessage(tt.in)})
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("decodeArgs(%s) succeeded, want error", tt.in)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("decodeArgs(%s) error = %v", tt.in, err)
+			}
+			packed, err := args.Pack(values...)
+			if err != nil {
+				t.Fatalf("Pack() error = %v", err)
+			}
+			unpacked, err := args.Unpack(packed)
+			if err != nil {
+				t.Fatalf("Unpack() error = %v", err)
+			}
+
+			out := encodeValues(args, unpacked)
+			if out[0].Value != tt.want {
+				t.Fatalf("value = %#v, want %#v", out[0].Value, tt.want)
+			}
+		})
+	}
+}
+
+func TestToJSONNestedIntegers(t *testing.T) {
+	typ, err := abi.NewType("tuple[]", "", []abi.ArgumentMarshaling{
+		{Name: "id", Type: "uint256"},
+		{Name: "deadline", Type: "uint32"},
+		{Name: "amounts", Type: "uint64[2]"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	args := abi.Arguments{{Name: "orders", Type: typ}}
+
+	in := `[{"id": "0x01", "deadline": 1700000000, "amounts": ["9007199254740993", 7]}]`
+	values, err := decodeArgs(args, []json.RawMessage{json.RawMessage(in)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	packed, err := args.Pack(values...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unpacked, err := args.Unpack(packed)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := json.Marshal(encodeValues(args, unpacked)[0].Value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `[{"amounts":["9007199254740993","7"],"deadline":1700000000,"id":"1"}]`
+	if string(got) != want {
+		t.Fatalf("value = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,249 @@
+package contract
+
+import (
+	"blockchain-middleware/pkg/types"
+	"bytes"
+	"math/bits"
+	"reflect"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+)
+
+// maxInferredParams 按主题数推断索引参数时尝试所有组合的参数个数上限
+const maxInferredParams = 12
+
+// Decoder 按已注册ABI和签名库解码交易输入和事件日志
+// 已注册ABI带参数名和索引信息，优先使用；签名库只有参数类型，事件的索引参数按主题数推断。
+// 候选签名解码后必须能重新编码为原始数据，避免选择器碰撞时给出错误结果。
+type Decoder struct {
+	abis *Registry
+	sigs *Signatures
+}
+
+// NewDecoder 创建解码器
+func NewDecoder(abis *Registry, sigs *Signatures) *Decoder {
+	return &Decoder{abis: abis, sigs: sigs}
+}
+
+// DecodeTransaction 解码交易的输入数据和日志，合约部署交易不解码输入
+func (d *Decoder) DecodeTransaction(tx *types.Transaction) {
+	if tx.To != "" && len(tx.Data) >= 4 {
+		tx.DecodedInput = d.DecodeInput(tx.Data)
+	}
+	for _, eventLog := range tx.Logs {
+		tx.DecodedLogs = append(tx.DecodedLogs, d.DecodeLog(eventLog))
+	}
+}
+
+// DecodeSimulation 解码模拟交易的输入数据、日志和回滚原因
+func (d *Decoder) DecodeSimulation(req *types.TransactionRequest, result *types.SimulationResult) {
+	if req.To != "" && len(req.Data) >= 4 {
+		result.DecodedInput = d.DecodeInput(req.Data)
+	}
+	for _, eventLog := range result.Logs {
+		result.DecodedLogs = append(result.DecodedLogs, d.DecodeLog(eventLog))
+	}
+	if result.Revert != nil {
+		data, _ := hexutil.Decode(result.Revert.Data)
+		result.Revert = d.DecodeRevert(data)
+	}
+}
+
+// DecodeRevert 解码回滚数据，标准错误以外的自定义错误按已注册ABI依次尝试
+func (d *Decoder) DecodeRevert(data []byte) *types.ContractRevert {
+	revert := DecodeRevert(nil, data)
+	if revert.Reason != "" || len(data) < 4 {
+		return revert
+	}
+	for _, contractABI := range d.abis.all() {
+		if custom := DecodeRevert(contractABI, data); custom.Error != "" {
+			return custom
+		}
+	}
+	return revert
+}
+
+// DecodeInput 解码方法调用数据，无法识别时只返回选择器
+func (d *Decoder) DecodeInput(data []byte) *types.DecodedCall {
+	if len(data) < 4 {
+		return nil
+	}
+
+	call := &types.DecodedCall{Selector: hexutil.Encode(data[:4])}
+	for _, method := range d.methodCandidates(data[:4]) {
+		values, ok := unpackExact(method.Inputs, data[4:])
+		if !ok {
+			continue
+		}
+		call.Signature = method.Sig
+		call.Name = method.RawName
+		call.Args = encodeValues(method.Inputs, values)
+		break
+	}
+	return call
+}
+
+// DecodeLog 解码事件日志，无法识别时只返回主题
+func (d *Decoder) DecodeLog(eventLog *ethtypes.Log) types.DecodedLog {
+	decoded := types.DecodedLog{
+		Address:  eventLog.Address.Hex(),
+		LogIndex: eventLog.Index,
+	}
+	if len(eventLog.Topics) == 0 {
+		return decoded
+	}
+
+	topic := eventLog.Topics[0]
+	decoded.Topic = topic.Hex()
+	for _, event := range d.eventCandidates(topic, len(eventLog.Topics)-1) {
+		args, ok := decodeEvent(event.Inputs, eventLog.Topics[1:], eventLog.Data)
+		if !ok {
+			continue
+		}
+		decoded.Signature = event.Sig
+		decoded.Name = event.RawName
+		decoded.Args = args
+		break
+	}
+	return decoded
+}
+
+// methodCandidates 选择器对应的候选方法，已注册ABI在前
+func (d *Decoder) methodCandidates(selector []byte) []abi.Method {
+	var methods []abi.Method
+	for _, contractABI := range d.abis.all() {
+		if method, err := contractABI.MethodById(selector); err == nil {
+			methods = append(methods, *method)
+		}
+	}
+
+	var id [4]byte
+	copy(id[:], selector)
+	for _, sig := range d.sigs.Methods(id) {
+		name, args, err := parseSignature(sig)
+		if err != nil {
+			continue
+		}
+		methods = append(methods, abi.NewMethod(name, name, abi.Function, "", false, false, args, nil))
+	}
+	return methods
+}
+
+// eventCandidates 事件主题对应的候选事件，已注册ABI在前
+// 签名库中的事件按 indexed 个索引参数的各种组合生成候选，优先前 indexed 个参数为索引参数。
+func (d *Decoder) eventCandidates(topic common.Hash, indexed int) []abi.Event {
+	var events []abi.Event
+	for _, contractABI := range d.abis.all() {
+		if event, err := contractABI.EventByID(topic); err == nil {
+			events = append(events, *event)
+		}
+	}
+
+	for _, sig := range d.sigs.Events(topic) {
+		name, args, err := parseSignature(sig)
+		if err != nil || indexed > len(args) {
+			continue
+		}
+		for _, mask := range indexedMasks(len(args), indexed) {
+			inputs := make(abi.Arguments, len(args))
+			for i, arg := range args {
+				arg.Indexed = mask&(1<<i) != 0
+				inputs[i] = arg
+			}
+			// NewEvent 会把无名参数改名为 arg0 等，传入副本并保留原始的无名参数
+			event := abi.NewEvent(name, name, false, append(abi.Arguments(nil), inputs...))
+			event.Inputs = inputs
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+// indexedMasks n 个参数中 k 个为索引参数的组合，前 k 个参数为索引参数的组合在前
+func indexedMasks(n, k int) []uint {
+	first := uint(1)<<k - 1
+	masks := []uint{first}
+	if n > maxInferredParams {
+		return masks
+	}
+	for mask := uint(0); mask < 1<<n; mask++ {
+		if mask != first && bits.OnesCount(mask) == k {
+			masks = append(masks, mask)
+		}
+	}
+	return masks
+}
+
+// decodeEvent 按事件参数解码主题和数据，参数按声明顺序返回
+func decodeEvent(inputs abi.Arguments, topics []common.Hash, data []byte) ([]types.ABIValue, bool) {
+	indexed := 0
+	for _, input := range inputs {
+		if input.Indexed {
+			indexed++
+		}
+	}
+	if indexed != len(topics) {
+		return nil, false
+	}
+
+	nonIndexed := inputs.NonIndexed()
+	values, ok := unpackExact(nonIndexed, data)
+	if !ok {
+		return nil, false
+	}
+
+	out := make([]types.ABIValue, 0, len(inputs))
+	nextTopic, nextValue := 0, 0
+	for _, input := range inputs {
+		arg := types.ABIValue{Name: input.Name, Type: input.Type.String()}
+		if input.Indexed {
+			value, ok := topicValue(input, topics[nextTopic])
+			if !ok {
+				return nil, false
+			}
+			arg.Value = value
+			nextTopic++
+		} else {
+			arg.Value = toJSON(input.Type, reflect.ValueOf(values[nextValue]))
+			nextValue++
+		}
+		out = append(out, arg)
+	}
+	return out, true
+}
+
+// topicValue 解码索引参数，动态类型的主题是值的哈希，原样返回
+func topicValue(input abi.Argument, topic common.Hash) (interface{}, bool) {
+	switch input.Type.T {
+	case abi.StringTy, abi.BytesTy, abi.SliceTy, abi.ArrayTy, abi.TupleTy:
+		return topic.Hex(), true
+	case abi.AddressTy:
+		if common.BytesToHash(topic[12:]) != topic {
+			return nil, false
+		}
+	}
+
+	arg := input
+	arg.Name = "value"
+	out := make(map[string]interface{})
+	if err := abi.ParseTopicsIntoMap(out, abi.Arguments{arg}, []common.Hash{topic}); err != nil {
+		return nil, false
+	}
+	return toJSON(input.Type, reflect.ValueOf(out["value"])), true
+}
+
+// unpackExact 解码数据，并要求重新编码后与原始数据一致
+func unpackExact(args abi.Arguments, data []byte) ([]interface{}, bool) {
+	values, err := args.Unpack(data)
+	if err != nil {
+		return nil, false
+	}
+	packed, err := args.Pack(values...)
+	if err != nil || !bytes.Equal(packed, data) {
+		return nil, false
+	}
+	return values, true
+}
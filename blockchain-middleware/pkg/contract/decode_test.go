@@ -0,0 +1,309 @@
+package contract
+
+import (
+	"blockchain-middleware/pkg/types"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+const escrowABI = `[
+	{"type":"function","name":"release","inputs":[{"name":"orderId","type":"uint256"},{"name":"to","type":"address"}],"outputs":[]},
+	{"type":"event","name":"Released","inputs":[{"name":"orderId","type":"uint256","indexed":true},{"name":"to","type":"address","indexed":false},{"name":"amount","type":"uint256","indexed":false}]},
+	{"type":"error","name":"InsufficientBalance","inputs":[{"name":"available","type":"uint256"},{"name":"required","type":"uint256"}]}]`
+
+var (
+	alice = common.HexToAddress("0x1111111111111111111111111111111111111111")
+	bob   = common.HexToAddress("0x2222222222222222222222222222222222222222")
+)
+
+// newTestDecoder 注册了 escrow ABI 的解码器
+func newTestDecoder(t *testing.T) (*Decoder, *Signatures) {
+	t.Helper()
+	abis := NewRegistry()
+	if err := abis.Register("escrow", json.RawMessage(escrowABI)); err != nil {
+		t.Fatal(err)
+	}
+	sigs := NewSignatures()
+	return NewDecoder(abis, sigs), sigs
+}
+
+// word 32字节ABI编码
+func word(b []byte) []byte {
+	return common.LeftPadBytes(b, 32)
+}
+
+func selector(sig string) []byte {
+	return crypto.Keccak256([]byte(sig))[:4]
+}
+
+func concat(parts ...[]byte) []byte {
+	var out []byte
+	for _, p := range parts {
+		out = append(out, p...)
+	}
+	return out
+}
+
+// argsJSON 把解码参数序列化为JSON，便于比较
+func argsJSON(t *testing.T, args []types.ABIValue) string {
+	t.Helper()
+	b, err := json.Marshal(args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestDecodeInput(t *testing.T) {
+	decoder, sigs := newTestDecoder(t)
+	// burn(uint256) 与之选择器相同
+	if _, err := sigs.Add("collate_propagate_storage(bytes16)"); err != nil {
+		t.Fatal(err)
+	}
+
+	dirtyAddress := word(alice.Bytes())
+	dirtyAddress[0] = 0xff
+
+	tests := []struct {
+		name     string
+		data     []byte
+		wantSig  string
+		wantName string
+		wantArgs string
+	}{
+		{
+			name:     "registered abi with parameter names",
+			data:     concat(selector("release(uint256,address)"), word([]byte{7}), word(bob.Bytes())),
+			wantSig:  "release(uint256,address)",
+			wantName: "release",
+			wantArgs: `[{"name":"orderId","type":"uint256","value":"7"},{"name":"to","type":"address","value":"` + bob.Hex() + `"}]`,
+		},
+		{
+			name:     "signature registry",
+			data:     concat(selector("transfer(address,uint256)"), word(alice.Bytes()), word(big.NewInt(1e18).Bytes())),
+			wantSig:  "transfer(address,uint256)",
+			wantName: "transfer",
+			wantArgs: `[{"type":"address","value":"` + alice.Hex() + `"},{"type":"uint256","value":"1000000000000000000"}]`,
+		},
+		{
+			name:     "colliding selectors decode as the first valid candidate",
+			data:     concat(selector("burn(uint256)"), word([]byte{1})),
+			wantSig:  "burn(uint256)",
+			wantName: "burn",
+			wantArgs: `[{"type":"uint256","value":"1"}]`,
+		},
+		{
+			name: "address with dirty padding is not decoded",
+			data: concat(selector("transfer(address,uint256)"), dirtyAddress, word([]byte{1})),
+		},
+		{
+			name: "truncated arguments",
+			data: concat(selector("transfer(address,uint256)"), word(alice.Bytes())),
+		},
+		{
+			name: "unknown selector",
+			data: []byte{0xde, 0xad, 0xbe, 0xef},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			call := decoder.DecodeInput(tt.data)
+			if call.Selector != "0x"+common.Bytes2Hex(tt.data[:4]) {
+				t.Errorf("selector = %s", call.Selector)
+			}
+			if call.Signature != tt.wantSig || call.Name != tt.wantName {
+				t.Fatalf("decoded %s (%s), want %s (%s)", call.Signature, call.Name, tt.wantSig, tt.wantName)
+			}
+			if tt.wantArgs != "" {
+				if got := argsJSON(t, call.Args); got != tt.wantArgs {
+					t.Errorf("args = %s, want %s", got, tt.wantArgs)
+				}
+			}
+		})
+	}
+
+	if call := decoder.DecodeInput([]byte{0x01, 0x02}); call != nil {
+		t.Errorf("DecodeInput() of 2 bytes = %+v, want nil", call)
+	}
+}
+
+func TestDecodeLog(t *testing.T) {
+	decoder, _ := newTestDecoder(t)
+
+	transfer := crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
+	released := crypto.Keccak256Hash([]byte("Released(uint256,address,uint256)"))
+	from, to := common.BytesToHash(alice.Bytes()), common.BytesToHash(bob.Bytes())
+
+	tests := []struct {
+		name     string
+		log      ethtypes.Log
+		wantSig  string
+		wantArgs string
+	}{
+		{
+			name:     "erc20 transfer with two indexed parameters",
+			log:      ethtypes.Log{Topics: []common.Hash{transfer, from, to}, Data: word([]byte{5})},
+			wantSig:  "Transfer(address,address,uint256)",
+			wantArgs: `[{"type":"address","value":"` + alice.Hex() + `"},{"type":"address","value":"` + bob.Hex() + `"},{"type":"uint256","value":"5"}]`,
+		},
+		{
+			name:     "erc721 transfer with three indexed parameters",
+			log:      ethtypes.Log{Topics: []common.Hash{transfer, from, to, common.BigToHash(big.NewInt(42))}},
+			wantSig:  "Transfer(address,address,uint256)",
+			wantArgs: `[{"type":"address","value":"` + alice.Hex() + `"},{"type":"address","value":"` + bob.Hex() + `"},{"type":"uint256","value":"42"}]`,
+		},
+		{
+			name:     "registered abi with parameter names",
+			log:      ethtypes.Log{Topics: []common.Hash{released, common.BigToHash(big.NewInt(7))}, Data: concat(word(bob.Bytes()), word([]byte{9}))},
+			wantSig:  "Released(uint256,address,uint256)",
+			wantArgs: `[{"name":"orderId","type":"uint256","value":"7"},{"name":"to","type":"address","value":"` + bob.Hex() + `"},{"name":"amount","type":"uint256","value":"9"}]`,
+		},
+		{
+			name: "more topics than parameters",
+			log:  ethtypes.Log{Topics: []common.Hash{transfer, from, to, {}, {}}},
+		},
+		{
+			name: "indexed address with dirty topic",
+			log:  ethtypes.Log{Topics: []common.Hash{transfer, common.HexToHash("0xff" + alice.Hex()[2:]), to}, Data: word([]byte{5})},
+		},
+		{
+			name: "unknown event",
+			log:  ethtypes.Log{Topics: []common.Hash{{0x01}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.log.Address = alice
+			tt.log.Index = 3
+			decoded := decoder.DecodeLog(&tt.log)
+
+			if decoded.Address != alice.Hex() || decoded.LogIndex != 3 || decoded.Topic != tt.log.Topics[0].Hex() {
+				t.Errorf("decoded log header = %+v", decoded)
+			}
+			if decoded.Signature != tt.wantSig {
+				t.Fatalf("signature = %q, want %q", decoded.Signature, tt.wantSig)
+			}
+			if tt.wantArgs != "" {
+				if got := argsJSON(t, decoded.Args); got != tt.wantArgs {
+					t.Errorf("args = %s, want %s", got, tt.wantArgs)
+				}
+			}
+		})
+	}
+
+	anonymous := decoder.DecodeLog(&ethtypes.Log{Address: alice})
+	if anonymous.Topic != "" || anonymous.Signature != "" {
+		t.Errorf("log without topics = %+v", anonymous)
+	}
+}
+
+func TestDecoderDecodeRevert(t *testing.T) {
+	decoder, _ := newTestDecoder(t)
+
+	reasonData, err := (abi.Arguments{{Type: mustType(t, "string")}}).Pack("not the buyer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	custom := concat(selector("InsufficientBalance(uint256,uint256)"), word([]byte{1}), word([]byte{2}))
+
+	tests := []struct {
+		name       string
+		data       []byte
+		wantReason string
+		wantError  string
+		wantPanic  uint64
+	}{
+		{"reason string", concat(selector("Error(string)"), reasonData), "not the buyer", "", 0},
+		{"panic code", concat(selector("Panic(uint256)"), word([]byte{0x11})), "arithmetic underflow or overflow", "", 0x11},
+		{"custom error from registered abi", custom, "InsufficientBalance", "InsufficientBalance(uint256,uint256)", 0},
+		{"unknown custom error", []byte{0xde, 0xad, 0xbe, 0xef}, "", "", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			revert := decoder.DecodeRevert(tt.data)
+			if revert.Reason != tt.wantReason || revert.Error != tt.wantError {
+				t.Fatalf("DecodeRevert() = %+v, want reason %q error %q", revert, tt.wantReason, tt.wantError)
+			}
+			if tt.wantPanic != 0 && (revert.PanicCode == nil || *revert.PanicCode != tt.wantPanic) {
+				t.Errorf("panic code = %v, want %#x", revert.PanicCode, tt.wantPanic)
+			}
+			if revert.Data != "0x"+common.Bytes2Hex(tt.data) {
+				t.Errorf("data = %s", revert.Data)
+			}
+		})
+	}
+
+	revert := decoder.DecodeRevert(custom)
+	if got := argsJSON(t, revert.Args); got != `[{"name":"available","type":"uint256","value":"1"},{"name":"required","type":"uint256","value":"2"}]` {
+		t.Errorf("custom error args = %s", got)
+	}
+}
+
+func TestIndexedMasks(t *testing.T) {
+	tests := []struct {
+		n, k int
+		want []uint
+	}{
+		{3, 2, []uint{0b011, 0b101, 0b110}},
+		{3, 3, []uint{0b111}},
+		{3, 0, []uint{0}},
+		{4, 1, []uint{0b0001, 0b0010, 0b0100, 0b1000}},
+		{maxInferredParams + 1, 2, []uint{0b11}}, // 参数过多时只尝试前 k 个
+	}
+	for _, tt := range tests {
+		got := indexedMasks(tt.n, tt.k)
+		if len(got) != len(tt.want) {
+			t.Errorf("indexedMasks(%d, %d) = %b, want %b", tt.n, tt.k, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("indexedMasks(%d, %d) = %b, want %b", tt.n, tt.k, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestSignaturesAdd(t *testing.T) {
+	sigs := NewSignatures()
+
+	sig, err := sigs.Add("deposit(uint amount, address to)")
+	if err == nil {
+		t.Fatalf("Add() accepted parameter names: %s", sig)
+	}
+	sig, err = sigs.Add("stake( uint , (address,uint8)[] )")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sig != "stake(uint256,(address,uint8)[])" {
+		t.Fatalf("normalized signature = %s", sig)
+	}
+
+	var id [4]byte
+	copy(id[:], selector(sig))
+	if got := sigs.Methods(id); len(got) != 1 || got[0] != sig {
+		t.Errorf("Methods() = %v", got)
+	}
+	if got := sigs.Events(crypto.Keccak256Hash([]byte(sig))); len(got) != 1 || got[0] != sig {
+		t.Errorf("Events() = %v", got)
+	}
+	if _, err := sigs.Add("broken(uint256"); err == nil {
+		t.Error("Add() accepted an unterminated signature")
+	}
+}
+
+func mustType(t *testing.T, name string) abi.Type {
+	t.Helper()
+	typ, err := abi.NewType(name, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return typ
+}
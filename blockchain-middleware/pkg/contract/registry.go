@@ -0,0 +1,156 @@
+package contract
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+)
+
+// Registry 按名称注册的合约ABI
+type Registry struct {
+	mu   sync.RWMutex
+	abis map[string]*entry
+}
+
+// entry 已注册的ABI及其原始JSON
+type entry struct {
+	abi *abi.ABI
+	raw json.RawMessage
+}
+
+// NewRegistry 创建ABI注册表
+func NewRegistry() *Registry {
+	return &Registry{abis: make(map[string]*entry)}
+}
+
+// Register 注册ABI，同名ABI被替换
+func (r *Registry) Register(name string, raw json.RawMessage) error {
+	if name == "" {
+		return fmt.Errorf("abi name is required")
+	}
+
+	parsed, normalized, err := parseABI(raw)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.abis[name] = &entry{abi: parsed, raw: normalized}
+	return nil
+}
+
+// Get 获取已注册的ABI
+func (r *Registry) Get(name string) (*abi.ABI, json.RawMessage, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	e, ok := r.abis[name]
+	if !ok {
+		return nil, nil, false
+	}
+	return e.abi, e.raw, true
+}
+
+// Names 获取所有已注册的ABI名称
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.abis))
+	for name := range r.abis {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// all 获取所有已注册的ABI，按名称排序
+func (r *Registry) all() []*abi.ABI {
+	names := r.Names()
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	abis := make([]*abi.ABI, 0, len(names))
+	for _, name := range names {
+		if e, ok := r.abis[name]; ok {
+			abis = append(abis, e.abi)
+		}
+	}
+	return abis
+}
+
+// Resolve 按请求中的ABI片段或注册名称获取ABI，片段优先
+func (r *Registry) Resolve(fragment json.RawMessage, name string) (*abi.ABI, error) {
+	if len(bytes.TrimSpace(fragment)) > 0 {
+		parsed, _, err := parseABI(fragment)
+		return parsed, err
+	}
+	if name == "" {
+		return nil, fmt.Errorf("abi or abi_name is required")
+	}
+
+	parsed, _, ok := r.Get(name)
+	if !ok {
+		return nil, fmt.Errorf("abi %s not registered", name)
+	}
+	return parsed, nil
+}
+
+// LoadDir 注册目录下所有 .json 文件，文件名（不含扩展名）作为名称
+func (r *Registry) LoadDir(dir string) error {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return fmt.Errorf("failed to list abi dir: %w", err)
+	}
+
+	for _, file := range files {
+		raw, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("failed to read abi file %s: %w", file, err)
+		}
+		name := strings.TrimSuffix(filepath.Base(file), ".json")
+		if err := r.Register(name, raw); err != nil {
+			return fmt.Errorf("failed to load abi %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// parseABI 解析ABI，支持JSON数组、单个定义和带 abi 字段的编译产物（如Hardhat artifact）
+// 同时返回统一为JSON数组的ABI。
+func parseABI(raw json.RawMessage) (*abi.ABI, json.RawMessage, error) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 {
+		return nil, nil, fmt.Errorf("abi is empty")
+	}
+
+	if raw[0] == '{' {
+		var artifact struct {
+			ABI json.RawMessage `json:"abi"`
+		}
+		if err := json.Unmarshal(raw, &artifact); err != nil {
+			return nil, nil, fmt.Errorf("invalid abi: %w", err)
+		}
+		if len(artifact.ABI) > 0 {
+			raw = artifact.ABI
+		} else {
+			raw = append(append([]byte{'['}, raw...), ']')
+		}
+	}
+
+	parsed, err := abi.JSON(bytes.NewReader(raw))
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid abi: %w", err)
+	}
+	return &parsed, raw, nil
+}
@@ -0,0 +1,66 @@
+package contract
+
+import (
+	"blockchain-middleware/pkg/types"
+	"bytes"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+var (
+	// errorSelector require/revert 原因字符串 Error(string)
+	errorSelector = crypto.Keccak256([]byte("Error(string)"))[:4]
+	// panicSelector assert、溢出等触发的 Panic(uint256)
+	panicSelector = crypto.Keccak256([]byte("Panic(uint256)"))[:4]
+)
+
+// DecodeRevert 解码回滚数据：标准原因字符串、Panic错误码，以及ABI中定义的自定义错误
+// contractABI 可以为nil，此时只解码标准错误。
+func DecodeRevert(contractABI *abi.ABI, data []byte) *types.ContractRevert {
+	revert := &types.ContractRevert{}
+	if len(data) == 0 {
+		return revert
+	}
+	revert.Data = hexutil.Encode(data)
+	if len(data) < 4 {
+		return revert
+	}
+
+	selector := data[:4]
+	switch {
+	case bytes.Equal(selector, errorSelector):
+		if reason, err := abi.UnpackRevert(data); err == nil {
+			revert.Reason = reason
+		}
+
+	case bytes.Equal(selector, panicSelector):
+		if reason, err := abi.UnpackRevert(data); err == nil {
+			revert.Reason = reason
+		}
+		if len(data) >= 36 {
+			code := new(big.Int).SetBytes(data[4:36])
+			if code.IsUint64() {
+				c := code.Uint64()
+				revert.PanicCode = &c
+			}
+		}
+
+	case contractABI != nil:
+		var id [4]byte
+		copy(id[:], selector)
+		customErr, err := contractABI.ErrorByID(id)
+		if err != nil {
+			return revert
+		}
+		revert.Error = customErr.Sig
+		revert.Reason = customErr.Name
+		if values, err := customErr.Inputs.Unpack(data[4:]); err == nil {
+			revert.Args = encodeValues(customErr.Inputs, values)
+		}
+	}
+
+	return revert
+}
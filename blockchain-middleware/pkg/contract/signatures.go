@@ -0,0 +1,176 @@
+package contract
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// intAlias Solidity 中 uint/int 是 uint256/int256 的别名
+var intAlias = regexp.MustCompile(`\bu?int\b`)
+
+// builtinSignatures 内置的常用方法和事件签名
+var builtinSignatures = []string{
+	// ERC-20 / WETH
+	"transfer(address,uint256)",
+	"transferFrom(address,address,uint256)",
+	"approve(address,uint256)",
+	"balanceOf(address)",
+	"allowance(address,address)",
+	"totalSupply()",
+	"name()",
+	"symbol()",
+	"decimals()",
+	"deposit()",
+	"withdraw(uint256)",
+	"mint(address,uint256)",
+	"burn(uint256)",
+	"Transfer(address,address,uint256)",
+	"Approval(address,address,uint256)",
+	"Deposit(address,uint256)",
+	"Withdrawal(address,uint256)",
+
+	// ERC-721 / ERC-1155
+	"safeTransferFrom(address,address,uint256)",
+	"safeTransferFrom(address,address,uint256,bytes)",
+	"safeTransferFrom(address,address,uint256,uint256,bytes)",
+	"safeBatchTransferFrom(address,address,uint256[],uint256[],bytes)",
+	"setApprovalForAll(address,bool)",
+	"ownerOf(uint256)",
+	"tokenURI(uint256)",
+	"uri(uint256)",
+	"safeMint(address,uint256)",
+	"safeMint(address,string)",
+	"ApprovalForAll(address,address,bool)",
+	"TransferSingle(address,address,address,uint256,uint256)",
+	"TransferBatch(address,address,address,uint256[],uint256[])",
+	"URI(string,uint256)",
+
+	// Ownable / Multicall3
+	"transferOwnership(address)",
+	"renounceOwnership()",
+	"OwnershipTransferred(address,address)",
+	"aggregate3((address,bool,bytes)[])",
+}
+
+// Signatures 4字节方法选择器和事件主题到文本签名的索引
+// 同一选择器可能对应多个签名（哈希碰撞），解码时依次尝试。
+type Signatures struct {
+	mu      sync.RWMutex
+	methods map[[4]byte][]string
+	events  map[common.Hash][]string
+}
+
+// NewSignatures 创建签名索引，包含内置的常用签名
+func NewSignatures() *Signatures {
+	s := &Signatures{
+		methods: make(map[[4]byte][]string),
+		events:  make(map[common.Hash][]string),
+	}
+	for _, sig := range builtinSignatures {
+		if _, err := s.Add(sig); err != nil {
+			panic(err)
+		}
+	}
+	return s
+}
+
+// Add 添加文本签名（如 transfer(address,uint256)），同时作为方法选择器和事件主题索引
+// 返回规范化后的签名。
+func (s *Signatures) Add(signature string) (string, error) {
+	name, args, err := parseSignature(signature)
+	if err != nil {
+		return "", err
+	}
+	sig := abi.NewMethod(name, name, abi.Function, "", false, false, args, nil).Sig
+	hash := crypto.Keccak256Hash([]byte(sig))
+
+	var selector [4]byte
+	copy(selector[:], hash[:4])
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.methods[selector] = appendUnique(s.methods[selector], sig)
+	s.events[hash] = appendUnique(s.events[hash], sig)
+	return sig, nil
+}
+
+// LoadFile 加载签名文件，每行一个签名，# 开头的行为注释
+func (s *Signatures) LoadFile(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open signature file: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		if _, err := s.Add(text); err != nil {
+			return fmt.Errorf("invalid signature at %s:%d: %w", path, line, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read signature file: %w", err)
+	}
+	return nil
+}
+
+// Methods 获取方法选择器对应的签名
+func (s *Signatures) Methods(selector [4]byte) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return append([]string(nil), s.methods[selector]...)
+}
+
+// Events 获取事件主题对应的签名
+func (s *Signatures) Events(topic common.Hash) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return append([]string(nil), s.events[topic]...)
+}
+
+// parseSignature 解析文本签名，参数没有名称
+func parseSignature(signature string) (string, abi.Arguments, error) {
+	normalized := intAlias.ReplaceAllString(strings.ReplaceAll(signature, " ", ""), "${0}256")
+	selector, err := abi.ParseSelector(normalized)
+	if err != nil {
+		return "", nil, err
+	}
+
+	args := make(abi.Arguments, len(selector.Inputs))
+	for i, input := range selector.Inputs {
+		t, err := abi.NewType(input.Type, input.InternalType, input.Components)
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid type in %s: %w", signature, err)
+		}
+		args[i] = abi.Argument{Type: t}
+	}
+	return selector.Name, args, nil
+}
+
+// appendUnique 追加不重复的签名并保持有序
+func appendUnique(list []string, sig string) []string {
+	for _, existing := range list {
+		if existing == sig {
+			return list
+		}
+	}
+	list = append(list, sig)
+	sort.Strings(list)
+	return list
+}
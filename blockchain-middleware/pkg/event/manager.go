@@ -1,16 +1,26 @@
 package event
 
 import (
+	"blockchain-middleware/pkg/chain"
+	"blockchain-middleware/pkg/telemetry"
 	"blockchain-middleware/pkg/types"
 	"context"
 	"fmt"
 	"log"
+	"strings"
 	"sync"
 	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
 )
 
+// ClientLookup 按链名称获取链客户端
+type ClientLookup func(chainName string) (chain.ChainClient, error)
+
 // EventManager 事件管理器
 type EventManager struct {
+	clients       ClientLookup
 	subscriptions map[string]*Subscription
 	mu            sync.RWMutex
 	ctx           context.Context
@@ -19,20 +29,26 @@ type EventManager struct {
 
 // Subscription 事件订阅
 type Subscription struct {
-	ID            string
-	ChainName     string
-	Filter        types.EventFilter
-	EventChan     chan types.BlockchainEvent
-	ctx           context.Context
-	cancel        context.CancelFunc
+	ID        string
+	ChainName string
+	Filter    types.EventFilter
+	EventChan chan types.BlockchainEvent
+	ctx       context.Context
+	cancel    context.CancelFunc
+
+	mu           sync.Mutex
+	nextID       uint64
+	history      []types.BlockchainEvent // 最近的事件，用于断线续传
+	streamSeq    uint64                  // 流式连接序号，用于识别连接是否已被接管
+	streamCancel context.CancelFunc      // 当前流式连接，nil表示没有连接
+	detachTimer  *time.Timer             // 流式连接断开后到期取消订阅
 }
 
-
-
-// NewEventManager 创建新的事件管理器
-func NewEventManager() *EventManager {
+// NewEventManager 创建新的事件管理器，clients 用于查找订阅所在链的客户端
+func NewEventManager(clients ClientLookup) *EventManager {
 	ctx, cancel := context.WithCancel(context.Background())
 	return &EventManager{
+		clients:       clients,
 		subscriptions: make(map[string]*Subscription),
 		ctx:           ctx,
 		cancel:        cancel,
@@ -41,9 +57,8 @@ func NewEventManager() *EventManager {
 
 // Start 启动事件管理器
 func (em *EventManager) Start() error {
+	// 每个订阅在 Subscribe 时各自启动日志监听
 	log.Println("Event manager started")
-	// 这里可以启动事件监听循环
-	// 实际实现需要连接到各个区块链的WebSocket端点
 	return nil
 }
 
@@ -69,12 +84,30 @@ func (em *EventManager) Stop() error {
 }
 
 // Subscribe 订阅事件
+// 交易状态订阅（EventType 为 TransactionStatus）只接收 Publish 推送，不监听链上日志。
 func (em *EventManager) Subscribe(chainName string, filter types.EventFilter) (string, error) {
+	client, err := em.clients(chainName)
+	if err != nil {
+		return "", err
+	}
+
+	var watcher chain.LogWatcher
+	if filter.EventType != types.EventTypeTransactionStatus {
+		var ok bool
+		watcher, ok = chain.As[chain.LogWatcher](client)
+		if !ok {
+			return "", fmt.Errorf("chain %s does not support log subscriptions", chainName)
+		}
+		if err := chain.ValidateEventFilter(filter); err != nil {
+			return "", err
+		}
+	}
+
 	em.mu.Lock()
 	defer em.mu.Unlock()
 
 	subscriptionID := generateSubscriptionID()
-	
+
 	ctx, cancel := context.WithCancel(em.ctx)
 	sub := &Subscription{
 		ID:        subscriptionID,
@@ -88,7 +121,9 @@ func (em *EventManager) Subscribe(chainName string, filter types.EventFilter) (s
 	em.subscriptions[subscriptionID] = sub
 
 	// 启动事件监听协程
-	go em.startSubscriptionListener(sub)
+	if watcher != nil {
+		go em.startSubscriptionListener(sub, watcher)
+	}
 
 	log.Printf("New subscription created: %s for chain %s", subscriptionID, chainName)
 	return subscriptionID, nil
@@ -99,6 +134,11 @@ func (em *EventManager) Unsubscribe(subscriptionID string) error {
 	em.mu.Lock()
 	defer em.mu.Unlock()
 
+	return em.unsubscribeLocked(subscriptionID)
+}
+
+// unsubscribeLocked 取消订阅（调用方持有 em.mu）
+func (em *EventManager) unsubscribeLocked(subscriptionID string) error {
 	sub, exists := em.subscriptions[subscriptionID]
 	if !exists {
 		return fmt.Errorf("subscription not found: %s", subscriptionID)
@@ -112,6 +152,32 @@ func (em *EventManager) Unsubscribe(subscriptionID string) error {
 	return nil
 }
 
+// UnsubscribeChain 取消指定链上的所有订阅（链被停用时），返回取消的数量
+func (em *EventManager) UnsubscribeChain(chainName string) int {
+	em.mu.Lock()
+	defer em.mu.Unlock()
+
+	removed := 0
+	for id, sub := range em.subscriptions {
+		if sub.ChainName == chainName && em.unsubscribeLocked(id) == nil {
+			removed++
+		}
+	}
+	return removed
+}
+
+// SubscriptionCounts 返回每条链上的订阅数量
+func (em *EventManager) SubscriptionCounts() map[string]int {
+	em.mu.RLock()
+	defer em.mu.RUnlock()
+
+	counts := make(map[string]int)
+	for _, sub := range em.subscriptions {
+		counts[sub.ChainName]++
+	}
+	return counts
+}
+
 // GetSubscription 获取订阅
 func (em *EventManager) GetSubscription(subscriptionID string) (*Subscription, error) {
 	em.mu.RLock()
@@ -125,48 +191,89 @@ func (em *EventManager) GetSubscription(subscriptionID string) (*Subscription, e
 	return sub, nil
 }
 
-// startSubscriptionListener 启动订阅监听器
-func (em *EventManager) startSubscriptionListener(sub *Subscription) {
+// startSubscriptionListener 启动订阅监听器，把链上日志转换为事件投递给订阅
+func (em *EventManager) startSubscriptionListener(sub *Subscription, watcher chain.LogWatcher) {
 	log.Printf("Starting listener for subscription: %s", sub.ID)
 
-	// 模拟事件生成（实际实现需要连接到区块链节点）
-	ticker := time.NewTicker(10 * time.Second) // 每10秒生成一个模拟事件
-	defer ticker.Stop()
-
-	blockNumber := uint64(10000000) // 起始区块号
-
-	for {
-		select {
-		case <-sub.ctx.Done():
-			log.Printf("Listener stopped for subscription: %s", sub.ID)
-			return
-		case <-ticker.C:
-			// 生成模拟事件
-			event := types.BlockchainEvent{
-				ChainName:   sub.ChainName,
-				Type:        "NewBlock",
-				BlockNumber: blockNumber,
-				BlockHash:   fmt.Sprintf("0x%x", time.Now().UnixNano()),
-				Data: map[string]interface{}{
-					"transactions": 10,
-					"gas_used":     1000000,
-				},
-				Timestamp: time.Now(),
-			}
+	handler := chain.EventHandlerFunc(func(l ethtypes.Log) error {
+		event := logEvent(sub.ChainName, l)
+		if em.filterMatches(sub.Filter, event) {
+			em.deliver(sub, event)
+		}
+		return nil
+	})
 
-			// 检查事件是否符合过滤器
-			if em.filterMatches(sub.Filter, event) {
-				select {
-				case sub.EventChan <- event:
-					log.Printf("Event sent to subscription: %s", sub.ID)
-				default:
-					log.Printf("Event channel full for subscription: %s", sub.ID)
-				}
-			}
+	if err := watcher.WatchLogs(sub.ctx, sub.Filter, handler); err != nil {
+		log.Printf("Listener failed for subscription %s: %v", sub.ID, err)
+		return
+	}
+	log.Printf("Listener stopped for subscription: %s", sub.ID)
+}
+
+// deliver 为事件分配订阅内序号并投递，通道已满时丢弃（仍保留在历史中供续传补发）
+// 持有读锁并检查订阅是否已取消，避免向 Unsubscribe 关闭的通道发送。
+func (em *EventManager) deliver(sub *Subscription, event types.BlockchainEvent) {
+	em.mu.RLock()
+	defer em.mu.RUnlock()
+
+	if sub.ctx.Err() != nil {
+		return
+	}
+
+	// 序号分配与入队在同一把锁内完成，保证通道中的事件按序号递增
+	sub.mu.Lock()
+	defer sub.mu.Unlock()
+
+	sub.nextID++
+	event.ID = sub.nextID
+	sub.appendHistory(event)
+
+	select {
+	case sub.EventChan <- event:
+	default:
+		telemetry.EventDropped(sub.ChainName)
+		log.Printf("Event channel full for subscription: %s", sub.ID)
+	}
+}
+
+// logEvent 把合约日志转换为区块链事件
+func logEvent(chainName string, l ethtypes.Log) types.BlockchainEvent {
+	topics := make([]string, len(l.Topics))
+	for i, topic := range l.Topics {
+		topics[i] = topic.Hex()
+	}
+
+	return types.BlockchainEvent{
+		ChainName:   chainName,
+		Type:        types.EventTypeLog,
+		BlockNumber: l.BlockNumber,
+		BlockHash:   l.BlockHash.Hex(),
+		TxHash:      l.TxHash.Hex(),
+		LogIndex:    l.Index,
+		Removed:     l.Removed,
+		Data: map[string]interface{}{
+			"contract_address": l.Address.Hex(),
+			"topics":           topics,
+			"data":             hexutil.Encode(l.Data),
+		},
+		Timestamp: time.Now(),
+	}
+}
 
-			blockNumber++
+// Publish 把外部产生的事件（如交易状态变更）分发给同一条链上过滤条件匹配的订阅
+func (em *EventManager) Publish(event types.BlockchainEvent) {
+	em.mu.RLock()
+	var targets []*Subscription
+	for _, sub := range em.subscriptions {
+		if sub.ChainName == event.ChainName && em.filterMatches(sub.Filter, event) {
+			targets = append(targets, sub)
 		}
 	}
+	em.mu.RUnlock()
+
+	for _, sub := range targets {
+		em.deliver(sub, event)
+	}
 }
 
 // filterMatches 检查事件是否符合过滤器
@@ -177,14 +284,34 @@ func (em *EventManager) filterMatches(filter types.EventFilter, event types.Bloc
 	}
 
 	// 检查合约地址
-	if filter.ContractAddress != "" && event.Data["contract_address"] != filter.ContractAddress {
-		return false
+	if filter.ContractAddress != "" {
+		address, _ := event.Data["contract_address"].(string)
+		if !strings.EqualFold(address, filter.ContractAddress) {
+			return false
+		}
 	}
 
-	// 检查事件主题
+	// 检查事件主题，按位置匹配，空字符串表示该位置不限
 	if len(filter.Topics) > 0 {
-		// 简化处理，实际需要更复杂的匹配逻辑
-		return true
+		topics, _ := event.Data["topics"].([]string)
+		for i, want := range filter.Topics {
+			if want == "" {
+				continue
+			}
+			if i >= len(topics) || !strings.EqualFold(topics[i], want) {
+				return false
+			}
+		}
+	}
+
+	// 检查区块范围，尚未打包的事件（区块号为0）不受限制
+	if event.BlockNumber > 0 {
+		if event.BlockNumber < filter.FromBlock {
+			return false
+		}
+		if filter.ToBlock > 0 && event.BlockNumber > filter.ToBlock {
+			return false
+		}
 	}
 
 	return true
@@ -204,4 +331,4 @@ func (s *Subscription) GetEventChan() <-chan types.BlockchainEvent {
 func (s *Subscription) Close() {
 	s.cancel()
 	close(s.EventChan)
-}
\ No newline at end of file
+}
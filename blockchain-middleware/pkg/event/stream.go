@@ -0,0 +1,99 @@
+package event
+
+import (
+	"blockchain-middleware/pkg/types"
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+const (
+	// subscriptionHistorySize 每个订阅保留的最近事件数，决定断线续传能回溯的范围
+	subscriptionHistorySize = 1000
+	// streamResumeWindow 流式连接断开后保留订阅的时间，期间可凭游标重连续传
+	streamResumeWindow = 2 * time.Minute
+)
+
+// Attach 为流式连接占用订阅，同一订阅同一时间只有一个连接
+// 已有连接时由新连接接管（旧连接可能尚未察觉客户端断开），旧连接的ctx被取消。
+// 返回的 release 在连接结束时调用，超过 streamResumeWindow 未重连时取消订阅。
+func (em *EventManager) Attach(ctx context.Context, chainName, subscriptionID string) (*Subscription, context.Context, func(), error) {
+	em.mu.Lock()
+	defer em.mu.Unlock()
+
+	sub, exists := em.subscriptions[subscriptionID]
+	if !exists || sub.ChainName != chainName {
+		return nil, nil, nil, fmt.Errorf("subscription not found: %s", subscriptionID)
+	}
+
+	sub.mu.Lock()
+	defer sub.mu.Unlock()
+
+	if sub.streamCancel != nil {
+		sub.streamCancel()
+	}
+	if sub.detachTimer != nil {
+		sub.detachTimer.Stop()
+		sub.detachTimer = nil
+	}
+
+	streamCtx, cancel := context.WithCancel(ctx)
+	sub.streamSeq++
+	seq := sub.streamSeq
+	sub.streamCancel = cancel
+
+	release := func() {
+		cancel()
+		em.detach(sub, seq)
+	}
+	return sub, streamCtx, release, nil
+}
+
+// detach 释放流式连接，已被新连接接管时不做处理
+func (em *EventManager) detach(sub *Subscription, seq uint64) {
+	sub.mu.Lock()
+	defer sub.mu.Unlock()
+
+	if sub.streamSeq != seq {
+		return
+	}
+	sub.streamCancel = nil
+	sub.detachTimer = time.AfterFunc(streamResumeWindow, func() {
+		em.mu.Lock()
+		defer em.mu.Unlock()
+
+		sub.mu.Lock()
+		reattached := sub.streamSeq != seq
+		sub.mu.Unlock()
+		if reattached || sub.ctx.Err() != nil {
+			return
+		}
+
+		if err := em.unsubscribeLocked(sub.ID); err == nil {
+			log.Printf("Subscription %s expired after stream disconnect", sub.ID)
+		}
+	})
+}
+
+// EventsAfter 获取序号大于 id 的历史事件
+func (s *Subscription) EventsAfter(id uint64) []types.BlockchainEvent {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, event := range s.history {
+		if event.ID > id {
+			return append([]types.BlockchainEvent(nil), s.history[i:]...)
+		}
+	}
+	return nil
+}
+
+// appendHistory 记录事件，超出容量时丢弃最早的事件（调用方持有 s.mu）
+func (s *Subscription) appendHistory(event types.BlockchainEvent) {
+	if len(s.history) >= subscriptionHistorySize {
+		copy(s.history, s.history[1:])
+		s.history = s.history[:len(s.history)-1]
+	}
+	s.history = append(s.history, event)
+}
@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"blockchain-middleware/pkg/types"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// EncodeContractCall 按ABI编码合约调用数据，返回可直接发送的交易请求
+func (h *Handler) EncodeContractCall(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	chainName := vars["chain"]
+
+	var req types.ContractCallRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	resp, err := h.services.EncodeContractCall(chainName, &req)
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, resp)
+}
+
+// RegisterABI 注册合约ABI
+func (h *Handler) RegisterABI(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Name string          `json:"name"`
+		ABI  json.RawMessage `json:"abi"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := h.services.RegisterABI(req.Name, req.ABI); err != nil {
+		h.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	h.writeJSON(w, http.StatusCreated, map[string]interface{}{
+		"name": req.Name,
+	})
+}
+
+// ListABIs 获取已注册的ABI名称
+func (h *Handler) ListABIs(w http.ResponseWriter, r *http.Request) {
+	h.writeJSON(w, http.StatusOK, map[string]interface{}{
+		"abis": h.services.ListABIs(),
+	})
+}
+
+// GetABI 获取已注册的ABI
+func (h *Handler) GetABI(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+
+	raw, err := h.services.GetABI(name)
+	if err != nil {
+		h.writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, map[string]interface{}{
+		"name": name,
+		"abi":  raw,
+	})
+}
+
+// AddSignatures 向交易解码使用的签名库添加方法/事件文本签名
+func (h *Handler) AddSignatures(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Signatures []string `json:"signatures"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	added, err := h.services.AddSignatures(req.Signatures)
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	h.writeJSON(w, http.StatusCreated, map[string]interface{}{
+		"signatures": added,
+	})
+}
+
+// LookupSignatures 按4字节方法选择器或32字节事件主题查询签名
+func (h *Handler) LookupSignatures(w http.ResponseWriter, r *http.Request) {
+	hash := mux.Vars(r)["hash"]
+
+	signatures, err := h.services.LookupSignatures(hash)
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, map[string]interface{}{
+		"hash":       hash,
+		"signatures": signatures,
+	})
+}
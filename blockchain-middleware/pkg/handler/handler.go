@@ -1,12 +1,16 @@
 package handler
 
 import (
+	"blockchain-middleware/pkg/bridge"
+	"blockchain-middleware/pkg/chain"
 	"blockchain-middleware/pkg/service"
 	"blockchain-middleware/pkg/types"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"math/big"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gorilla/mux"
@@ -47,7 +51,7 @@ func (h *Handler) GetChainInfo(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	chainName := vars["chain"]
 
-	info, err := h.services.GetChainInfo(chainName)
+	info, err := h.services.GetChainInfo(r.Context(), chainName)
 	if err != nil {
 		h.writeError(w, http.StatusBadRequest, err.Error())
 		return
@@ -56,13 +60,27 @@ func (h *Handler) GetChainInfo(w http.ResponseWriter, r *http.Request) {
 	h.writeJSON(w, http.StatusOK, info)
 }
 
+// GetFees 获取慢速、标准、快速三档手续费估算
+func (h *Handler) GetFees(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	chainName := vars["chain"]
+
+	fees, err := h.services.GetFeeEstimates(r.Context(), chainName)
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, fees)
+}
+
 // GetBalance 获取账户余额
 func (h *Handler) GetBalance(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	chainName := vars["chain"]
 	address := vars["address"]
 
-	balance, err := h.services.GetBalance(chainName, address)
+	balance, err := h.services.GetBalance(r.Context(), chainName, address)
 	if err != nil {
 		h.writeError(w, http.StatusBadRequest, err.Error())
 		return
@@ -88,24 +106,33 @@ func (h *Handler) GetAccountInfo(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// 获取ETH余额
-	ethBalance, err := client.GetBalance(address)
+	ethBalance, err := client.GetBalance(r.Context(), address)
 	if err != nil {
 		h.writeError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	// 获取nonce
-	nonce, err := client.GetNonce(address)
+	nonce, err := client.GetNonce(r.Context(), address)
 	if err != nil {
 		h.writeError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	accountInfo := &types.AccountInfo{
-		Address:           address,
-		ETHBalance:        ethBalance,
-		Nonce:             nonce,
-		TransactionCount:  0, // 简化处理
+		Address:          address,
+		ETHBalance:       ethBalance,
+		Nonce:            nonce,
+		TransactionCount: 0, // 简化处理
+	}
+
+	// 支持代币的链附带代币余额，查询参数 tokens 为空时使用链配置的默认代币
+	if _, ok := chain.As[chain.TokenBalanceProvider](client); ok {
+		accountInfo.TokenBalances, err = h.services.GetTokenBalances(r.Context(), chainName, address, parseList(r.URL.Query().Get("tokens")))
+		if err != nil {
+			h.writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
 	}
 
 	h.writeJSON(w, http.StatusOK, accountInfo)
@@ -123,7 +150,41 @@ func (h *Handler) GetNonce(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	nonce, err := client.GetNonce(address)
+	nonce, err := client.GetNonce(r.Context(), address)
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, map[string]interface{}{
+		"address": address,
+		"nonce":   nonce,
+		"chain":   chainName,
+	})
+}
+
+// GetNonceStatus 获取nonce分配状态（在途nonce和待重新分配的缺口）
+func (h *Handler) GetNonceStatus(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	chainName := vars["chain"]
+	address := vars["address"]
+
+	status, err := h.services.GetNonceStatus(r.Context(), chainName, address)
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, status)
+}
+
+// ReserveNonce 预留nonce，供MPC签名的交易使用
+func (h *Handler) ReserveNonce(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	chainName := vars["chain"]
+	address := vars["address"]
+
+	nonce, err := h.services.ReserveNonce(r.Context(), chainName, address)
 	if err != nil {
 		h.writeError(w, http.StatusBadRequest, err.Error())
 		return
@@ -136,6 +197,29 @@ func (h *Handler) GetNonce(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// ReleaseNonce 释放预留后未广播的nonce
+func (h *Handler) ReleaseNonce(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	chainName := vars["chain"]
+	address := vars["address"]
+
+	var req types.NonceReleaseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := h.services.ReleaseNonce(r.Context(), chainName, address, req.Nonce); err != nil {
+		h.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, map[string]interface{}{
+		"message": "Nonce released",
+		"nonce":   req.Nonce,
+	})
+}
+
 // SendTransaction 发送交易
 func (h *Handler) SendTransaction(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
@@ -147,7 +231,36 @@ func (h *Handler) SendTransaction(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	txHash, err := h.services.SendTransaction(chainName, &req)
+	txHash, err := h.services.SendTransaction(r.Context(), chainName, &req)
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, map[string]interface{}{
+		"tx_hash": txHash,
+		"chain":   chainName,
+	})
+}
+
+// SendRawTransaction 广播已签名的原始交易
+func (h *Handler) SendRawTransaction(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	chainName := vars["chain"]
+
+	var req types.RawTransactionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	rawTx, err := hex.DecodeString(strings.TrimPrefix(req.RawTransaction, "0x"))
+	if err != nil || len(rawTx) == 0 {
+		h.writeError(w, http.StatusBadRequest, "Invalid raw transaction hex")
+		return
+	}
+
+	txHash, err := h.services.SendRawTransaction(r.Context(), chainName, rawTx)
 	if err != nil {
 		h.writeError(w, http.StatusBadRequest, err.Error())
 		return
@@ -165,7 +278,7 @@ func (h *Handler) GetTransaction(w http.ResponseWriter, r *http.Request) {
 	chainName := vars["chain"]
 	txHash := vars["txHash"]
 
-	tx, err := h.services.GetTransaction(chainName, txHash)
+	tx, err := h.services.GetTransaction(r.Context(), chainName, txHash)
 	if err != nil {
 		h.writeError(w, http.StatusBadRequest, err.Error())
 		return
@@ -174,6 +287,36 @@ func (h *Handler) GetTransaction(w http.ResponseWriter, r *http.Request) {
 	h.writeJSON(w, http.StatusOK, tx)
 }
 
+// GetTransactionStatus 获取交易的生命周期状态
+func (h *Handler) GetTransactionStatus(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	chainName := vars["chain"]
+	txHash := vars["txHash"]
+
+	status, err := h.services.GetTransactionStatus(r.Context(), chainName, txHash)
+	if err != nil {
+		h.writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, status)
+}
+
+// TrackTransaction 开始跟踪交易的生命周期
+func (h *Handler) TrackTransaction(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	chainName := vars["chain"]
+	txHash := vars["txHash"]
+
+	status, err := h.services.TrackTransaction(r.Context(), chainName, txHash)
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, status)
+}
+
 // EstimateGas 预估Gas
 func (h *Handler) EstimateGas(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
@@ -185,7 +328,7 @@ func (h *Handler) EstimateGas(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	gas, err := h.services.EstimateGas(chainName, &req)
+	gas, err := h.services.EstimateGas(r.Context(), chainName, &req)
 	if err != nil {
 		h.writeError(w, http.StatusBadRequest, err.Error())
 		return
@@ -193,10 +336,30 @@ func (h *Handler) EstimateGas(w http.ResponseWriter, r *http.Request) {
 
 	h.writeJSON(w, http.StatusOK, map[string]interface{}{
 		"estimated_gas": gas,
-		"chain":        chainName,
+		"chain":         chainName,
 	})
 }
 
+// SimulateTransaction 模拟执行交易，返回执行结果、Gas消耗以及余额变化和授权预览
+func (h *Handler) SimulateTransaction(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	chainName := vars["chain"]
+
+	var req types.SimulationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	result, err := h.services.SimulateTransaction(r.Context(), chainName, &req)
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, result)
+}
+
 // CallContract 调用合约
 func (h *Handler) CallContract(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
@@ -208,7 +371,18 @@ func (h *Handler) CallContract(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	result, err := h.services.CallContract(chainName, &req)
+	// 指定方法时按ABI编码参数并解码返回值
+	if req.Method != "" {
+		resp, err := h.services.CallContractMethod(r.Context(), chainName, &req)
+		if err != nil {
+			h.writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		h.writeJSON(w, http.StatusOK, resp)
+		return
+	}
+
+	result, err := h.services.CallContract(r.Context(), chainName, &req)
 	if err != nil {
 		h.writeError(w, http.StatusBadRequest, err.Error())
 		return
@@ -226,25 +400,42 @@ func (h *Handler) GetTokenBalance(w http.ResponseWriter, r *http.Request) {
 	contract := vars["contract"]
 	address := vars["address"]
 
-	// 检查链服务是否可用
-	_, err := h.services.GetChainClient(chainName)
+	balance, err := h.services.GetTokenBalance(r.Context(), chainName, contract, address)
 	if err != nil {
 		h.writeError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	// 这里需要实现代币余额查询逻辑
-	// 简化处理，返回示例数据
-	balance := big.NewInt(1000000000000000000) // 1 token
-
 	h.writeJSON(w, http.StatusOK, map[string]interface{}{
-		"contract": contract,
+		"contract": balance.ContractAddress,
 		"address":  address,
-		"balance":  balance.String(),
+		"symbol":   balance.Symbol,
+		"decimals": balance.Decimals,
+		"balance":  balance.Balance.String(),
 		"chain":    chainName,
 	})
 }
 
+// GetTokenPortfolio 批量获取地址持有的代币余额
+// 查询参数 tokens 为逗号分隔的代币地址，为空时使用链配置的默认代币。
+func (h *Handler) GetTokenPortfolio(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	chainName := vars["chain"]
+	address := vars["address"]
+
+	balances, err := h.services.GetTokenBalances(r.Context(), chainName, address, parseList(r.URL.Query().Get("tokens")))
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, map[string]interface{}{
+		"address": address,
+		"tokens":  balances,
+		"chain":   chainName,
+	})
+}
+
 // GetLatestBlock 获取最新区块
 func (h *Handler) GetLatestBlock(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
@@ -256,13 +447,13 @@ func (h *Handler) GetLatestBlock(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	blockNumber, err := client.GetBlockNumber()
+	blockNumber, err := client.GetBlockNumber(r.Context())
 	if err != nil {
 		h.writeError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	block, err := client.GetBlockByNumber(blockNumber)
+	block, err := client.GetBlockByNumber(r.Context(), blockNumber)
 	if err != nil {
 		h.writeError(w, http.StatusBadRequest, err.Error())
 		return
@@ -286,7 +477,7 @@ func (h *Handler) GetBlockByNumber(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	block, err := client.GetBlockByNumber(blockNumber)
+	block, err := client.GetBlockByNumber(r.Context(), blockNumber)
 	if err != nil {
 		h.writeError(w, http.StatusBadRequest, err.Error())
 		return
@@ -314,7 +505,7 @@ func (h *Handler) SubscribeEvents(w http.ResponseWriter, r *http.Request) {
 
 	h.writeJSON(w, http.StatusOK, map[string]interface{}{
 		"subscription_id": subscriptionID,
-		"chain":          chainName,
+		"chain":           chainName,
 	})
 }
 
@@ -342,7 +533,7 @@ func (h *Handler) SignMPCTransaction(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	response, err := h.services.SignMPCTransaction(&req)
+	response, err := h.services.SignMPCTransaction(r.Context(), &req)
 	if err != nil {
 		h.writeError(w, http.StatusBadRequest, err.Error())
 		return
@@ -359,19 +550,19 @@ func (h *Handler) BroadcastMPCTransaction(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	txHash, err := h.services.BroadcastMPCTransaction(&req)
+	txHash, err := h.services.BroadcastMPCTransaction(r.Context(), &req)
 	if err != nil {
 		h.writeError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	h.writeJSON(w, http.StatusOK, map[string]interface{}{
-		"tx_hash": txHash,
+		"tx_hash":    txHash,
 		"session_id": req.SessionID,
 	})
 }
 
-// CrossChainTransfer 跨链转账
+// CrossChainTransfer 跨链转账，返回已提交源链交易的转账状态
 func (h *Handler) CrossChainTransfer(w http.ResponseWriter, r *http.Request) {
 	var req types.CrossChainRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -379,16 +570,13 @@ func (h *Handler) CrossChainTransfer(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	transferID, err := h.services.CrossChainTransfer(&req)
+	status, err := h.services.CrossChainTransfer(r.Context(), &req)
 	if err != nil {
 		h.writeError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	h.writeJSON(w, http.StatusOK, map[string]interface{}{
-		"transfer_id": transferID,
-		"status":      "pending",
-	})
+	h.writeJSON(w, http.StatusOK, status)
 }
 
 // GetCrossChainStatus 获取跨链状态
@@ -396,7 +584,11 @@ func (h *Handler) GetCrossChainStatus(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	transferID := vars["transferId"]
 
-	status, err := h.services.GetCrossChainStatus(transferID)
+	status, err := h.services.GetCrossChainStatus(r.Context(), transferID)
+	if errors.Is(err, bridge.ErrTransferNotFound) {
+		h.writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
 	if err != nil {
 		h.writeError(w, http.StatusBadRequest, err.Error())
 		return
@@ -405,11 +597,87 @@ func (h *Handler) GetCrossChainStatus(w http.ResponseWriter, r *http.Request) {
 	h.writeJSON(w, http.StatusOK, status)
 }
 
+// ListBridgeRoutes 列出跨链路由
+func (h *Handler) ListBridgeRoutes(w http.ResponseWriter, r *http.Request) {
+	h.writeJSON(w, http.StatusOK, map[string]interface{}{
+		"routes": h.services.ListBridgeRoutes(),
+	})
+}
+
+// GetCacheStats 获取链上读取缓存的命中统计
+func (h *Handler) GetCacheStats(w http.ResponseWriter, r *http.Request) {
+	h.writeJSON(w, http.StatusOK, h.services.GetCacheStats())
+}
+
+// ListUTXOs 获取地址的UTXO列表
+func (h *Handler) ListUTXOs(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	chainName := vars["chain"]
+	address := vars["address"]
+
+	utxos, err := h.services.ListUTXOs(r.Context(), chainName, address)
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// 比特币UTXO来自已确认的UTXO集合，未确认的输出（包括找零）要等确认后才会出现
+	h.writeJSON(w, http.StatusOK, map[string]interface{}{
+		"address":        address,
+		"utxos":          utxos,
+		"chain":          chainName,
+		"confirmed_only": true,
+	})
+}
+
+// BuildPSBT 构建待签名的PSBT
+func (h *Handler) BuildPSBT(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	chainName := vars["chain"]
+
+	var req types.PSBTRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	response, err := h.services.BuildPSBT(r.Context(), chainName, &req)
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, response)
+}
+
+// FinalizePSBT 写入签名并广播PSBT交易
+func (h *Handler) FinalizePSBT(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	chainName := vars["chain"]
+
+	var req types.PSBTFinalizeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	txHash, err := h.services.FinalizePSBT(r.Context(), chainName, &req)
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, map[string]interface{}{
+		"tx_hash": txHash,
+		"chain":   chainName,
+	})
+}
+
 // writeJSON 写入JSON响应
 func (h *Handler) writeJSON(w http.ResponseWriter, status int, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
-	
+
 	if err := json.NewEncoder(w).Encode(data); err != nil {
 		h.writeError(w, http.StatusInternalServerError, "Failed to encode response")
 	}
@@ -419,12 +687,23 @@ func (h *Handler) writeJSON(w http.ResponseWriter, status int, data interface{})
 func (h *Handler) writeError(w http.ResponseWriter, status int, message string) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
-	
+
 	errorResponse := map[string]interface{}{
 		"error":   http.StatusText(status),
 		"message": message,
 		"code":    status,
 	}
-	
+
 	json.NewEncoder(w).Encode(errorResponse)
-}
\ No newline at end of file
+}
+
+// parseList 解析逗号分隔的查询参数
+func parseList(value string) []string {
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"blockchain-middleware/pkg/types"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// GetNFTs 获取地址持有的ERC-721/ERC-1155代币
+// 可选参数 contracts（逗号分隔的合约地址）和 from_block（扫描转账日志的起始区块，默认0）。
+// 没有指定合约且链未配置默认NFT合约时，from_block 必须在最近10万个区块内。
+func (h *Handler) GetNFTs(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	chainName := vars["chain"]
+	address := vars["address"]
+
+	var fromBlock uint64
+	if value := r.URL.Query().Get("from_block"); value != "" {
+		var err error
+		if fromBlock, err = strconv.ParseUint(value, 10, 64); err != nil {
+			h.writeError(w, http.StatusBadRequest, "Invalid from_block")
+			return
+		}
+	}
+
+	nfts, err := h.services.GetNFTs(r.Context(), chainName, address, parseList(r.URL.Query().Get("contracts")), fromBlock)
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, map[string]interface{}{
+		"address": address,
+		"nfts":    nfts,
+		"chain":   chainName,
+	})
+}
+
+// GetNFTContract 获取NFT合约的标准、名称和符号
+func (h *Handler) GetNFTContract(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	chainName := vars["chain"]
+	contractAddress := vars["contract"]
+
+	info, err := h.services.GetNFTContract(r.Context(), chainName, contractAddress)
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, info)
+}
+
+// GetNFT 获取NFT的持有者和元数据地址，可选参数 owner 查询该地址的持有数量
+func (h *Handler) GetNFT(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	chainName := vars["chain"]
+	contractAddress := vars["contract"]
+	tokenID := vars["tokenId"]
+
+	nft, err := h.services.GetNFT(r.Context(), chainName, contractAddress, tokenID, r.URL.Query().Get("owner"))
+	if err != nil {
+		h.writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, nft)
+}
+
+// EncodeNFTTransfer 生成NFT的 safeTransferFrom 调用数据，返回可直接发送的交易请求
+func (h *Handler) EncodeNFTTransfer(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	chainName := vars["chain"]
+
+	var req types.NFTTransferRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	resp, err := h.services.EncodeNFTTransfer(r.Context(), chainName, &req)
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, resp)
+}
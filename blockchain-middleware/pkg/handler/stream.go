@@ -0,0 +1,215 @@
+package handler
+
+import (
+	"blockchain-middleware/pkg/event"
+	"blockchain-middleware/pkg/types"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// streamHeartbeatInterval 心跳间隔，SSE发送注释行，WebSocket发送ping帧
+	streamHeartbeatInterval = 15 * time.Second
+	// streamWriteTimeout 单次写入超时，客户端长时间不读取时断开连接
+	streamWriteTimeout = 10 * time.Second
+)
+
+// upgrader WebSocket升级器，来源限制与CORS配置一致
+var upgrader = websocket.Upgrader{
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// StreamEvents 通过Server-Sent Events或WebSocket推送订阅事件
+// 续传游标为事件 id：SSE 使用 Last-Event-ID 请求头，WebSocket 使用 cursor 查询参数。
+// 同一订阅的新连接会接管旧连接；连接断开后订阅保留一段时间供重连，超时未重连则自动取消。
+func (h *Handler) StreamEvents(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	chainName := vars["chain"]
+	subscriptionID := vars["subscriptionId"]
+
+	cursor, err := streamCursor(r)
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, "Invalid cursor")
+		return
+	}
+
+	sub, ctx, release, err := h.services.AttachEventStream(r.Context(), chainName, subscriptionID)
+	if err != nil {
+		h.writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	defer release()
+
+	if websocket.IsWebSocketUpgrade(r) {
+		h.streamWebSocket(ctx, w, r, sub, cursor)
+	} else {
+		h.streamSSE(ctx, w, sub, cursor)
+	}
+}
+
+// streamSSE 以Server-Sent Events格式推送事件
+func (h *Handler) streamSSE(ctx context.Context, w http.ResponseWriter, sub *event.Subscription, cursor uint64) {
+	rc := http.NewResponseController(w)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no") // 关闭反向代理缓冲
+	w.WriteHeader(http.StatusOK)
+
+	write := func(format string, args ...interface{}) error {
+		// 覆盖服务器的 WriteTimeout，每次写入单独计时
+		rc.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+		if _, err := fmt.Fprintf(w, format, args...); err != nil {
+			return err
+		}
+		return rc.Flush()
+	}
+
+	send := func(ev types.BlockchainEvent) error {
+		data, err := json.Marshal(ev)
+		if err != nil {
+			return fmt.Errorf("failed to encode event: %w", err)
+		}
+		return write("id: %d\ndata: %s\n\n", ev.ID, data)
+	}
+	heartbeat := func() error {
+		return write(": heartbeat\n\n")
+	}
+
+	if err := write(": connected to %s\n\n", sub.ID); err != nil {
+		return
+	}
+	if err := streamLoop(ctx, sub, cursor, send, heartbeat); err != nil && !errors.Is(err, context.Canceled) {
+		log.Printf("Event stream for %s ended: %v", sub.ID, err)
+	}
+}
+
+// streamWebSocket 以WebSocket文本帧推送事件，每帧一个JSON事件
+func (h *Handler) streamWebSocket(ctx context.Context, w http.ResponseWriter, r *http.Request, sub *event.Subscription, cursor uint64) {
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// Upgrade 已写入错误响应
+		return
+	}
+	defer conn.Close()
+
+	// 接管连接后请求上下文不再感知断开，由读协程处理pong和关闭帧
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	conn.SetReadDeadline(time.Now().Add(2 * streamHeartbeatInterval))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(2 * streamHeartbeatInterval))
+	})
+	go func() {
+		defer cancel()
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	send := func(ev types.BlockchainEvent) error {
+		conn.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+		return conn.WriteJSON(ev)
+	}
+	heartbeat := func() error {
+		return conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(streamWriteTimeout))
+	}
+
+	err = streamLoop(ctx, sub, cursor, send, heartbeat)
+	switch {
+	case err == nil:
+		// 订阅已取消
+		conn.WriteControl(websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.CloseNormalClosure, "subscription closed"),
+			time.Now().Add(streamWriteTimeout))
+	case errors.Is(err, context.Canceled):
+		// 客户端断开或被新连接接管
+		conn.WriteControl(websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.CloseGoingAway, "stream closed"),
+			time.Now().Add(streamWriteTimeout))
+	default:
+		log.Printf("Event stream for %s ended: %v", sub.ID, err)
+	}
+}
+
+// streamLoop 先补发游标之后的历史事件，再持续推送新事件并定时发送心跳
+// 订阅被取消时返回nil，连接断开或写入失败时返回错误。
+func streamLoop(ctx context.Context, sub *event.Subscription, cursor uint64, send func(types.BlockchainEvent) error, heartbeat func() error) error {
+	last := cursor
+
+	// sendHistory 补发 last 之后、序号不超过 upTo 的历史事件（upTo 为0表示全部）
+	sendHistory := func(upTo uint64) error {
+		for _, ev := range sub.EventsAfter(last) {
+			if upTo > 0 && ev.ID > upTo {
+				break
+			}
+			if err := send(ev); err != nil {
+				return err
+			}
+			last = ev.ID
+		}
+		return nil
+	}
+
+	if err := sendHistory(0); err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(streamHeartbeatInterval)
+	defer ticker.Stop()
+
+	events := sub.GetEventChan()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case ev, ok := <-events:
+			if !ok {
+				return nil
+			}
+			if ev.ID <= last {
+				// 已在补发历史时发送过
+				continue
+			}
+			if ev.ID > last+1 {
+				// 通道满时丢弃的事件从历史中补发
+				if err := sendHistory(ev.ID - 1); err != nil {
+					return err
+				}
+			}
+			if err := send(ev); err != nil {
+				return err
+			}
+			last = ev.ID
+		case <-ticker.C:
+			if err := heartbeat(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// streamCursor 解析续传游标，未提供时为0（从订阅保留的最早事件开始）
+func streamCursor(r *http.Request) (uint64, error) {
+	value := r.Header.Get("Last-Event-ID")
+	if value == "" {
+		value = r.URL.Query().Get("cursor")
+	}
+	if value == "" {
+		return 0, nil
+	}
+	return strconv.ParseUint(value, 10, 64)
+}
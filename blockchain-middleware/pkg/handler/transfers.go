@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"blockchain-middleware/pkg/indexer"
+	"blockchain-middleware/pkg/types"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// GetTransfers 分页获取监听地址的转账记录，按区块倒序
+// 可选参数 type（native、erc20、erc721）、token（代币合约地址）、limit（默认50，最大200）和 offset。
+func (h *Handler) GetTransfers(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	chainName := vars["chain"]
+	address := vars["address"]
+
+	query := types.TransferQuery{
+		Type:  r.URL.Query().Get("type"),
+		Token: r.URL.Query().Get("token"),
+	}
+	for name, target := range map[string]*int{"limit": &query.Limit, "offset": &query.Offset} {
+		if value := r.URL.Query().Get(name); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				h.writeError(w, http.StatusBadRequest, "Invalid "+name)
+				return
+			}
+			*target = n
+		}
+	}
+
+	page, err := h.services.ListTransfers(r.Context(), chainName, address, query)
+	if errors.Is(err, indexer.ErrNotWatched) {
+		h.writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, page)
+}
+
+// WatchAddress 把地址加入转账索引，请求体可选，from_block 指定补齐历史的起始区块
+func (h *Handler) WatchAddress(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	chainName := vars["chain"]
+	address := vars["address"]
+
+	var req types.WatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		h.writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	watched, err := h.services.WatchAddress(r.Context(), chainName, address, req.FromBlock)
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, watched)
+}
@@ -0,0 +1,521 @@
+package indexer
+
+import (
+	"blockchain-middleware/internal/config"
+	"blockchain-middleware/pkg/chain"
+	"blockchain-middleware/pkg/checkpoint"
+	"blockchain-middleware/pkg/types"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const (
+	defaultPollInterval = 15 * time.Second
+	defaultBatchBlocks  = 50
+	// checkpointID 转账索引在检查点存储中的监听器ID
+	checkpointID = "transfer-indexer"
+
+	defaultPageSize = 50
+	maxPageSize     = 200
+)
+
+// ErrNotWatched 地址不在转账索引中
+var ErrNotWatched = errors.New("address is not watched")
+
+// ClientLookup 按链名称获取链客户端
+type ClientLookup func(chainName string) (chain.ChainClient, error)
+
+// Indexer 地址转账索引器
+// 每条链一个循环，把监听地址的转账依次索引到 最新区块-确认数，这些区块不会再被重组，不需要回滚；
+// 新监听的地址从指定区块到当时已索引区块的历史由同一循环按地址分批补齐，进度持久化，重启后继续。
+type Indexer struct {
+	pollInterval time.Duration
+	batchBlocks  uint64
+	store        Store
+	checkpoints  checkpoint.Store
+	clients      ClientLookup
+
+	mu     sync.Mutex
+	chains map[string]*chainIndex
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// chainIndex 单条链的索引状态
+type chainIndex struct {
+	name          string
+	client        chain.ChainClient
+	source        chain.TransferSource
+	confirmations uint64
+	seeds         []string // 配置的监听地址，初始化后加入
+
+	mu      sync.Mutex // 保护 watched、indexed 和 ready
+	watched map[string]*types.WatchedAddress
+	indexed uint64 // 已索引完成的最后一个区块
+	ready   bool   // indexed 已从检查点或最新区块初始化
+
+	wake chan struct{}
+	stop chan struct{} // StopChain 时关闭
+}
+
+// NewIndexer 创建转账索引器，checkpoints 保存各链已索引的区块
+func NewIndexer(cfg config.IndexerConfig, store Store, checkpoints checkpoint.Store, clients ClientLookup) *Indexer {
+	pollInterval := cfg.PollInterval
+	if pollInterval <= 0 {
+		pollInterval = defaultPollInterval
+	}
+	batchBlocks := cfg.BatchBlocks
+	if batchBlocks == 0 {
+		batchBlocks = defaultBatchBlocks
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Indexer{
+		pollInterval: pollInterval,
+		batchBlocks:  batchBlocks,
+		store:        store,
+		checkpoints:  checkpoints,
+		clients:      clients,
+		chains:       make(map[string]*chainIndex),
+		ctx:          ctx,
+		cancel:       cancel,
+	}
+}
+
+// Start 从存储恢复监听地址并为支持转账索引的链启动索引循环
+func (i *Indexer) Start(ctx context.Context, chains []config.ChainConfig) error {
+	for _, cfg := range chains {
+		if !cfg.Enabled {
+			continue
+		}
+		if err := i.StartChain(ctx, cfg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// StartChain 为单条链启动索引循环，链客户端不支持转账索引或已在索引时直接返回
+func (i *Indexer) StartChain(ctx context.Context, cfg config.ChainConfig) error {
+	client, err := i.clients(cfg.Name)
+	if err != nil {
+		return nil
+	}
+	source, ok := chain.As[chain.TransferSource](client)
+	if !ok {
+		return nil
+	}
+
+	stored, err := i.store.ListWatched(ctx, cfg.Name)
+	if err != nil {
+		return fmt.Errorf("failed to restore watched addresses on %s: %w", cfg.Name, err)
+	}
+	ci := &chainIndex{
+		name:          cfg.Name,
+		client:        client,
+		source:        source,
+		confirmations: cfg.Confirmations,
+		seeds:         cfg.WatchAddresses,
+		watched:       make(map[string]*types.WatchedAddress, len(stored)),
+		wake:          make(chan struct{}, 1),
+		stop:          make(chan struct{}),
+	}
+	for _, w := range stored {
+		ci.watched[w.Address] = w
+	}
+
+	i.mu.Lock()
+	if _, exists := i.chains[cfg.Name]; exists {
+		i.mu.Unlock()
+		return nil
+	}
+	i.chains[cfg.Name] = ci
+	i.mu.Unlock()
+
+	i.wg.Add(1)
+	go i.run(ci)
+	log.Printf("Transfer indexer started on %s, %d addresses watched", cfg.Name, len(stored))
+	return nil
+}
+
+// StopChain 停止单条链的索引循环，进度保留在检查点中，重新启动后继续
+func (i *Indexer) StopChain(chainName string) {
+	i.mu.Lock()
+	ci, ok := i.chains[chainName]
+	delete(i.chains, chainName)
+	i.mu.Unlock()
+
+	if ok {
+		close(ci.stop)
+		log.Printf("Transfer indexer stopped on %s", chainName)
+	}
+}
+
+// Stop 停止所有索引循环
+func (i *Indexer) Stop() {
+	i.cancel()
+	i.wg.Wait()
+}
+
+// Watch 把地址加入转账索引，已监听的地址直接返回当前状态
+// fromBlock 为0时从下一个待索引区块开始记录；早于已索引区块时，之间的历史在后台补齐。
+func (i *Indexer) Watch(ctx context.Context, chainName, address string, fromBlock uint64) (*types.WatchedAddress, error) {
+	ci, err := i.chain(chainName)
+	if err != nil {
+		return nil, err
+	}
+	if !common.IsHexAddress(address) {
+		return nil, fmt.Errorf("invalid address: %s", address)
+	}
+	addr := common.HexToAddress(address).Hex()
+
+	ci.mu.Lock()
+	defer ci.mu.Unlock()
+
+	if !ci.ready {
+		return nil, fmt.Errorf("transfer indexer on %s is not ready yet", chainName)
+	}
+	if w, ok := ci.watched[addr]; ok {
+		return snapshot(w), nil
+	}
+
+	w := ci.newWatch(addr, fromBlock)
+	if err := i.store.SaveWatched(ctx, w); err != nil {
+		return nil, err
+	}
+	ci.watched[addr] = w
+
+	select {
+	case ci.wake <- struct{}{}:
+	default:
+	}
+	return snapshot(w), nil
+}
+
+// ListTransfers 分页列出监听地址从监听起始区块开始的转账，按区块倒序
+func (i *Indexer) ListTransfers(ctx context.Context, chainName, address string, query types.TransferQuery) (*types.TransferPage, error) {
+	ci, err := i.chain(chainName)
+	if err != nil {
+		return nil, err
+	}
+	if !common.IsHexAddress(address) {
+		return nil, fmt.Errorf("invalid address: %s", address)
+	}
+	addr := common.HexToAddress(address).Hex()
+
+	switch query.Type {
+	case "", types.TransferNative, types.TransferERC20, types.TransferERC721:
+	default:
+		return nil, fmt.Errorf("invalid transfer type: %s", query.Type)
+	}
+	if query.Token != "" {
+		if !common.IsHexAddress(query.Token) {
+			return nil, fmt.Errorf("invalid token address: %s", query.Token)
+		}
+		query.Token = common.HexToAddress(query.Token).Hex()
+	}
+	if query.Limit <= 0 {
+		query.Limit = defaultPageSize
+	}
+	if query.Limit > maxPageSize {
+		query.Limit = maxPageSize
+	}
+	if query.Offset < 0 {
+		query.Offset = 0
+	}
+
+	ci.mu.Lock()
+	w, ok := ci.watched[addr]
+	var watch *types.WatchedAddress
+	if ok {
+		watch = snapshot(w)
+	}
+	indexed := ci.indexed
+	ci.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %s on %s", ErrNotWatched, addr, chainName)
+	}
+
+	// 其他监听地址记录的更早的转账不完整，只列出监听起始区块之后的
+	query.FromBlock = watch.FromBlock
+	transfers, total, err := i.store.ListTransfers(ctx, chainName, addr, query)
+	if err != nil {
+		return nil, err
+	}
+	for j := range transfers {
+		transfers[j].Direction = direction(&transfers[j], addr)
+	}
+
+	return &types.TransferPage{
+		ChainName:    chainName,
+		Address:      addr,
+		Transfers:    transfers,
+		Total:        total,
+		Limit:        query.Limit,
+		Offset:       query.Offset,
+		IndexedBlock: indexed,
+		Watch:        watch,
+	}, nil
+}
+
+// chain 获取链的索引状态
+func (i *Indexer) chain(chainName string) (*chainIndex, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	ci, ok := i.chains[chainName]
+	if !ok {
+		return nil, fmt.Errorf("chain %s does not support transfer indexing", chainName)
+	}
+	return ci, nil
+}
+
+// run 单条链的索引循环，有待处理的批次时连续处理，否则等待下一个轮询周期
+func (i *Indexer) run(ci *chainIndex) {
+	defer i.wg.Done()
+
+	for {
+		worked, err := i.step(ci)
+		if err != nil {
+			log.Printf("Transfer indexer on %s: %v", ci.name, err)
+		}
+		if err == nil && worked {
+			select {
+			case <-i.ctx.Done():
+				return
+			case <-ci.stop:
+				return
+			default:
+			}
+			continue
+		}
+
+		select {
+		case <-i.ctx.Done():
+			return
+		case <-ci.stop:
+			return
+		case <-time.After(i.pollInterval):
+		case <-ci.wake:
+		}
+	}
+}
+
+// step 处理一个批次：先初始化，再索引新区块，追上后补齐历史；没有待处理的批次时返回false
+func (i *Indexer) step(ci *chainIndex) (bool, error) {
+	ci.mu.Lock()
+	ready := ci.ready
+	ci.mu.Unlock()
+	if !ready {
+		return true, i.initialize(ci)
+	}
+
+	head, err := ci.client.GetBlockNumber(i.ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to get latest block: %w", err)
+	}
+	if safe := safeBlock(head, ci.confirmations); ci.indexedBlock() < safe {
+		return true, i.indexNext(ci, safe)
+	}
+	return i.backfillNext(ci)
+}
+
+// initialize 从检查点恢复已索引区块，首次运行时从当前的安全区块开始，并加入配置的监听地址
+func (i *Indexer) initialize(ci *chainIndex) error {
+	indexed, found, err := i.checkpoints.Load(i.ctx, ci.name, checkpointID)
+	if err != nil {
+		return err
+	}
+	if !found {
+		head, err := ci.client.GetBlockNumber(i.ctx)
+		if err != nil {
+			return fmt.Errorf("failed to get latest block: %w", err)
+		}
+		indexed = safeBlock(head, ci.confirmations)
+		if err := i.checkpoints.Save(i.ctx, ci.name, checkpointID, indexed); err != nil {
+			return err
+		}
+	}
+
+	ci.mu.Lock()
+	defer ci.mu.Unlock()
+
+	ci.indexed = indexed
+	for _, address := range ci.seeds {
+		if !common.IsHexAddress(address) {
+			log.Printf("Ignoring invalid watch address %s on %s", address, ci.name)
+			continue
+		}
+		addr := common.HexToAddress(address).Hex()
+		if _, ok := ci.watched[addr]; ok {
+			continue
+		}
+		w := ci.newWatch(addr, 0)
+		if err := i.store.SaveWatched(i.ctx, w); err != nil {
+			return err
+		}
+		ci.watched[addr] = w
+	}
+	ci.ready = true
+	return nil
+}
+
+// indexNext 索引已索引区块之后的一批区块
+// 批次处理期间新监听的地址没有包含在本批查询中，本批区块加入这些地址的补齐范围。
+func (i *Indexer) indexNext(ci *chainIndex, safe uint64) error {
+	ci.mu.Lock()
+	from := ci.indexed + 1
+	addresses := make([]string, 0, len(ci.watched))
+	included := make(map[string]bool, len(ci.watched))
+	for addr := range ci.watched {
+		addresses = append(addresses, addr)
+		included[addr] = true
+	}
+	ci.mu.Unlock()
+
+	to := from + i.batchBlocks - 1
+	if to > safe {
+		to = safe
+	}
+
+	if len(addresses) > 0 {
+		transfers, err := ci.source.GetTransfers(i.ctx, from, to, addresses)
+		if err != nil {
+			return err
+		}
+		if err := i.store.SaveTransfers(i.ctx, transfers); err != nil {
+			return err
+		}
+	}
+	if err := i.checkpoints.Save(i.ctx, ci.name, checkpointID, to); err != nil {
+		return err
+	}
+
+	ci.mu.Lock()
+	defer ci.mu.Unlock()
+
+	ci.indexed = to
+	for addr, w := range ci.watched {
+		if included[addr] || w.FromBlock > to {
+			continue
+		}
+		if !backfilling(w) {
+			w.BackfillNext = from
+			if w.FromBlock > from {
+				w.BackfillNext = w.FromBlock
+			}
+		}
+		w.BackfillEnd = to
+		if err := i.store.SaveWatched(i.ctx, w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// backfillNext 为每个需要补齐历史的地址处理一批区块
+func (i *Indexer) backfillNext(ci *chainIndex) (bool, error) {
+	ci.mu.Lock()
+	var pending []types.WatchedAddress
+	for _, w := range ci.watched {
+		if backfilling(w) {
+			pending = append(pending, *w)
+		}
+	}
+	ci.mu.Unlock()
+
+	for _, w := range pending {
+		to := w.BackfillNext + i.batchBlocks - 1
+		if to > w.BackfillEnd {
+			to = w.BackfillEnd
+		}
+
+		transfers, err := ci.source.GetTransfers(i.ctx, w.BackfillNext, to, []string{w.Address})
+		if err != nil {
+			return false, fmt.Errorf("failed to backfill %s: %w", w.Address, err)
+		}
+		if err := i.store.SaveTransfers(i.ctx, transfers); err != nil {
+			return false, err
+		}
+
+		ci.mu.Lock()
+		current := ci.watched[w.Address]
+		current.BackfillNext = to + 1
+		err = i.store.SaveWatched(i.ctx, current)
+		done := !backfilling(current)
+		ci.mu.Unlock()
+		if err != nil {
+			return false, err
+		}
+		if done {
+			log.Printf("Transfer backfill of %s on %s completed", w.Address, ci.name)
+		}
+	}
+	return len(pending) > 0, nil
+}
+
+// newWatch 创建监听记录，调用方持有 ci.mu
+func (ci *chainIndex) newWatch(addr string, fromBlock uint64) *types.WatchedAddress {
+	w := &types.WatchedAddress{
+		ChainName: ci.name,
+		Address:   addr,
+		FromBlock: fromBlock,
+		CreatedAt: time.Now(),
+	}
+	if fromBlock == 0 || fromBlock > ci.indexed {
+		if w.FromBlock == 0 {
+			w.FromBlock = ci.indexed + 1
+		}
+	} else {
+		w.BackfillNext = fromBlock
+		w.BackfillEnd = ci.indexed
+	}
+	return w
+}
+
+// indexedBlock 已索引完成的最后一个区块
+func (ci *chainIndex) indexedBlock() uint64 {
+	ci.mu.Lock()
+	defer ci.mu.Unlock()
+	return ci.indexed
+}
+
+// safeBlock 最新区块减去确认数，之前的区块不会再被重组
+func safeBlock(head, confirmations uint64) uint64 {
+	if head < confirmations {
+		return 0
+	}
+	return head - confirmations
+}
+
+// backfilling 地址是否还有待补齐的历史区块
+func backfilling(w *types.WatchedAddress) bool {
+	return w.BackfillEnd > 0 && w.BackfillNext <= w.BackfillEnd
+}
+
+// snapshot 复制监听记录并填充补齐状态，调用方持有 ci.mu
+func snapshot(w *types.WatchedAddress) *types.WatchedAddress {
+	c := *w
+	c.Backfilling = backfilling(w)
+	return &c
+}
+
+// direction 转账相对地址的方向
+func direction(transfer *types.Transfer, addr string) string {
+	switch {
+	case transfer.From == addr && transfer.To == addr:
+		return types.TransferSelf
+	case transfer.To == addr:
+		return types.TransferIn
+	default:
+		return types.TransferOut
+	}
+}
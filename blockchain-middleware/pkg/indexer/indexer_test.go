@@ -0,0 +1,276 @@
+package indexer
+
+import (
+	"blockchain-middleware/internal/config"
+	"blockchain-middleware/pkg/chain"
+	"blockchain-middleware/pkg/checkpoint"
+	"blockchain-middleware/pkg/types"
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var (
+	alice = common.HexToAddress("0x1111111111111111111111111111111111111111").Hex()
+	bob   = common.HexToAddress("0x2222222222222222222222222222222222222222").Hex()
+	carol = common.HexToAddress("0x3333333333333333333333333333333333333333").Hex()
+)
+
+// fakeSource 链客户端，记录每次转账查询的区块范围和地址
+type fakeSource struct {
+	chain.ChainClient
+
+	mu      sync.Mutex
+	head    uint64
+	queries []string
+	during  func() // 查询期间执行，模拟批次处理中新监听的地址
+}
+
+func (s *fakeSource) GetBlockNumber(ctx context.Context) (uint64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.head, nil
+}
+
+func (s *fakeSource) GetTransfers(ctx context.Context, fromBlock, toBlock uint64, addresses []string) ([]types.Transfer, error) {
+	s.mu.Lock()
+	sorted := append([]string(nil), addresses...)
+	sort.Strings(sorted)
+	s.queries = append(s.queries, fmt.Sprintf("%d-%d %s", fromBlock, toBlock, strings.Join(sorted, ",")))
+	during := s.during
+	s.mu.Unlock()
+
+	if during != nil {
+		during()
+	}
+	return nil, nil
+}
+
+// takeQueries 返回并清空记录的查询
+func (s *fakeSource) takeQueries() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	queries := s.queries
+	s.queries = nil
+	return queries
+}
+
+// newTestIndexer 不启动索引循环的索引器，由测试调用 step 推进
+func newTestIndexer(t *testing.T, source *fakeSource, confirmations uint64, seeds ...string) (*Indexer, *chainIndex) {
+	t.Helper()
+	i := NewIndexer(config.IndexerConfig{BatchBlocks: 10}, NewMemoryStore(), checkpoint.NewMemoryStore(), func(string) (chain.ChainClient, error) {
+		return source, nil
+	})
+	t.Cleanup(i.Stop)
+	ci := &chainIndex{
+		name:          "ethereum",
+		client:        source,
+		source:        source,
+		confirmations: confirmations,
+		seeds:         seeds,
+		watched:       make(map[string]*types.WatchedAddress),
+		wake:          make(chan struct{}, 1),
+		stop:          make(chan struct{}),
+	}
+	i.chains[ci.name] = ci
+	return i, ci
+}
+
+// steps 推进 n 步，每步都应有待处理的批次
+func steps(t *testing.T, i *Indexer, ci *chainIndex, n int) {
+	t.Helper()
+	for k := 0; k < n; k++ {
+		worked, err := i.step(ci)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !worked {
+			t.Fatalf("step %d had nothing to do", k)
+		}
+	}
+}
+
+func TestSafeBlock(t *testing.T) {
+	tests := []struct {
+		head, confirmations, want uint64
+	}{
+		{100, 12, 88},
+		{100, 0, 100},
+		{12, 12, 0},
+		{5, 12, 0},
+	}
+	for _, tt := range tests {
+		if got := safeBlock(tt.head, tt.confirmations); got != tt.want {
+			t.Errorf("safeBlock(%d, %d) = %d, want %d", tt.head, tt.confirmations, got, tt.want)
+		}
+	}
+}
+
+func TestIndexerInitialize(t *testing.T) {
+	source := &fakeSource{head: 100}
+	i, ci := newTestIndexer(t, source, 12, strings.ToLower(alice), "not an address")
+
+	// 首次运行从当前的安全区块开始，配置的地址从下一个区块开始记录
+	steps(t, i, ci, 1)
+	if ci.indexed != 88 {
+		t.Errorf("indexed = %d, want 88", ci.indexed)
+	}
+	if saved, _, _ := i.checkpoints.Load(context.Background(), "ethereum", checkpointID); saved != 88 {
+		t.Errorf("checkpoint = %d", saved)
+	}
+	if w, ok := ci.watched[alice]; !ok || w.FromBlock != 89 || backfilling(w) || len(ci.watched) != 1 {
+		t.Errorf("watched = %v", ci.watched)
+	}
+
+	// 重启后从检查点继续
+	i2, ci2 := newTestIndexer(t, source, 12)
+	i2.checkpoints = i.checkpoints
+	source.head = 200
+	steps(t, i2, ci2, 1)
+	if ci2.indexed != 88 {
+		t.Errorf("indexed after restart = %d, want 88", ci2.indexed)
+	}
+}
+
+func TestIndexerLiveAndBackfill(t *testing.T) {
+	source := &fakeSource{head: 100}
+	i, ci := newTestIndexer(t, source, 10)
+	ctx := context.Background()
+	steps(t, i, ci, 1)
+
+	// alice 只记录之后的转账，bob 补齐 50 到已索引区块 90
+	if _, err := i.Watch(ctx, "ethereum", alice, 0); err != nil {
+		t.Fatal(err)
+	}
+	w, err := i.Watch(ctx, "ethereum", bob, 50)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.FromBlock != 50 || !w.Backfilling || w.BackfillNext != 50 || w.BackfillEnd != 90 {
+		t.Errorf("bob = %+v", w)
+	}
+
+	// carol 在第一批处理期间加入，没有包含在该批查询中，由补齐处理
+	source.head = 115
+	source.during = func() {
+		source.during = nil
+		if _, err := i.Watch(ctx, "ethereum", carol, 0); err != nil {
+			t.Error(err)
+		}
+	}
+	steps(t, i, ci, 2)
+	if got, want := source.takeQueries(), []string{
+		"91-100 " + alice + "," + bob,
+		"101-105 " + alice + "," + bob + "," + carol,
+	}; !reflect.DeepEqual(got, want) {
+		t.Errorf("live queries = %v, want %v", got, want)
+	}
+	if c := ci.watched[carol]; c.FromBlock != 91 || c.BackfillNext != 91 || c.BackfillEnd != 100 {
+		t.Errorf("carol = %+v", c)
+	}
+
+	// 追上安全区块后补齐历史，每个地址每步一批
+	steps(t, i, ci, 5)
+	got := source.takeQueries()
+	sort.Strings(got)
+	want := []string{
+		"50-59 " + bob, "60-69 " + bob, "70-79 " + bob, "80-89 " + bob, "90-90 " + bob,
+		"91-100 " + carol,
+	}
+	sort.Strings(want)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("backfill queries = %v, want %v", got, want)
+	}
+
+	if worked, err := i.step(ci); err != nil || worked {
+		t.Errorf("step() after backfill = %v, %v", worked, err)
+	}
+	stored, _ := i.store.ListWatched(ctx, "ethereum")
+	for _, w := range stored {
+		if backfilling(w) {
+			t.Errorf("%s is still backfilling: %+v", w.Address, w)
+		}
+	}
+}
+
+func TestListTransfers(t *testing.T) {
+	source := &fakeSource{head: 100}
+	i, ci := newTestIndexer(t, source, 10)
+	ctx := context.Background()
+	steps(t, i, ci, 1)
+
+	if _, err := i.ListTransfers(ctx, "ethereum", alice, types.TransferQuery{}); !errors.Is(err, ErrNotWatched) {
+		t.Errorf("ListTransfers() of an unwatched address = %v", err)
+	}
+	if _, err := i.ListTransfers(ctx, "polygon", alice, types.TransferQuery{}); err == nil || errors.Is(err, ErrNotWatched) {
+		t.Errorf("ListTransfers() on an unindexed chain = %v", err)
+	}
+	if _, err := i.Watch(ctx, "ethereum", alice, 80); err != nil {
+		t.Fatal(err)
+	}
+
+	var transfers []types.Transfer
+	for n := 0; n < 5; n++ {
+		transfers = append(transfers, types.Transfer{
+			ChainName:   "ethereum",
+			TxHash:      fmt.Sprintf("0x%064x", n),
+			LogIndex:    -1,
+			BlockNumber: uint64(78 + n),
+			Type:        types.TransferNative,
+			From:        alice,
+			To:          bob,
+			Value:       big.NewInt(1),
+		})
+	}
+	transfers[3].From, transfers[3].To = bob, alice
+	transfers[4].To = alice
+	if err := i.store.SaveTransfers(ctx, transfers); err != nil {
+		t.Fatal(err)
+	}
+
+	// 监听起始区块之前的两笔不列出
+	page, err := i.ListTransfers(ctx, "ethereum", strings.ToLower(alice), types.TransferQuery{Limit: 2, Offset: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.Total != 3 || page.Limit != 2 || page.Offset != 1 || page.IndexedBlock != 90 || page.Watch.FromBlock != 80 {
+		t.Errorf("page = %+v", page)
+	}
+	var got []string
+	for _, transfer := range page.Transfers {
+		got = append(got, fmt.Sprintf("%d %s", transfer.BlockNumber, transfer.Direction))
+	}
+	if want := []string{"81 in", "80 out"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("transfers = %v, want %v", got, want)
+	}
+
+	for _, tt := range []struct {
+		query     types.TransferQuery
+		wantLimit int
+	}{
+		{types.TransferQuery{}, defaultPageSize},
+		{types.TransferQuery{Limit: 1000}, maxPageSize},
+	} {
+		page, err := i.ListTransfers(ctx, "ethereum", alice, tt.query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if page.Limit != tt.wantLimit || len(page.Transfers) != 3 {
+			t.Errorf("ListTransfers(%+v) limit = %d, %d transfers", tt.query, page.Limit, len(page.Transfers))
+		}
+	}
+	if page, err := i.ListTransfers(ctx, "ethereum", alice, types.TransferQuery{Offset: 5}); err != nil || len(page.Transfers) != 0 || page.Total != 3 {
+		t.Errorf("ListTransfers() past the end = %+v, %v", page, err)
+	}
+	if _, err := i.ListTransfers(ctx, "ethereum", alice, types.TransferQuery{Type: "erc1155"}); err == nil {
+		t.Error("ListTransfers() accepted an invalid type")
+	}
+}
@@ -0,0 +1,194 @@
+package indexer
+
+import (
+	"blockchain-middleware/pkg/types"
+	"context"
+	"database/sql"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// PostgresStore 基于PostgreSQL的转账记录存储
+type PostgresStore struct {
+	db *sql.DB
+}
+
+// NewPostgresStore 创建PostgreSQL存储并确保表结构存在
+func NewPostgresStore(ctx context.Context, db *sql.DB) (*PostgresStore, error) {
+	_, err := db.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS middleware_transfers (
+			chain_name VARCHAR(64) NOT NULL,
+			tx_hash VARCHAR(128) NOT NULL,
+			log_index BIGINT NOT NULL,
+			block_number BIGINT NOT NULL,
+			block_time BIGINT NOT NULL,
+			transfer_type VARCHAR(16) NOT NULL,
+			from_address VARCHAR(128) NOT NULL,
+			to_address VARCHAR(128) NOT NULL,
+			value NUMERIC(78, 0),
+			token VARCHAR(128) NOT NULL DEFAULT '',
+			token_id TEXT NOT NULL DEFAULT '',
+			symbol TEXT NOT NULL DEFAULT '',
+			decimals SMALLINT NOT NULL DEFAULT 0,
+			success BOOLEAN NOT NULL,
+			PRIMARY KEY (chain_name, tx_hash, log_index)
+		)`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create transfer table: %w", err)
+	}
+
+	for _, column := range []string{"from_address", "to_address"} {
+		_, err = db.ExecContext(ctx, fmt.Sprintf(`
+			CREATE INDEX IF NOT EXISTS idx_middleware_transfers_%s
+			ON middleware_transfers (chain_name, %s, block_number DESC)`, column, column))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create transfer index: %w", err)
+		}
+	}
+
+	_, err = db.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS middleware_watched_addresses (
+			chain_name VARCHAR(64) NOT NULL,
+			address VARCHAR(128) NOT NULL,
+			from_block BIGINT NOT NULL,
+			backfill_next BIGINT NOT NULL DEFAULT 0,
+			backfill_end BIGINT NOT NULL DEFAULT 0,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (chain_name, address)
+		)`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create watched address table: %w", err)
+	}
+
+	return &PostgresStore{db: db}, nil
+}
+
+// SaveTransfers 在一个事务中保存一批转账记录
+func (s *PostgresStore) SaveTransfers(ctx context.Context, transfers []types.Transfer) error {
+	if len(transfers) == 0 {
+		return nil
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO middleware_transfers (chain_name, tx_hash, log_index, block_number, block_time, transfer_type,
+			from_address, to_address, value, token, token_id, symbol, decimals, success)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		ON CONFLICT (chain_name, tx_hash, log_index) DO NOTHING`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare transfer insert: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, t := range transfers {
+		var value interface{}
+		if t.Value != nil {
+			value = t.Value.String()
+		}
+		_, err := stmt.ExecContext(ctx, t.ChainName, strings.ToLower(t.TxHash), t.LogIndex, int64(t.BlockNumber), int64(t.Timestamp),
+			t.Type, t.From, t.To, value, t.Token, t.TokenID, t.Symbol, int(t.Decimals), t.Success)
+		if err != nil {
+			return fmt.Errorf("failed to save transfer %s: %w", t.TxHash, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transfers: %w", err)
+	}
+	return nil
+}
+
+// ListTransfers 按区块倒序列出地址的转账
+func (s *PostgresStore) ListTransfers(ctx context.Context, chainName, address string, query types.TransferQuery) ([]types.Transfer, int, error) {
+	where := `chain_name = $1 AND (from_address = $2 OR to_address = $2) AND block_number >= $3`
+	args := []interface{}{chainName, address, int64(query.FromBlock)}
+	if query.Type != "" {
+		args = append(args, query.Type)
+		where += fmt.Sprintf(" AND transfer_type = $%d", len(args))
+	}
+	if query.Token != "" {
+		args = append(args, query.Token)
+		where += fmt.Sprintf(" AND token = $%d", len(args))
+	}
+
+	var total int
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM middleware_transfers WHERE `+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count transfers: %w", err)
+	}
+
+	args = append(args, query.Limit, query.Offset)
+	rows, err := s.db.QueryContext(ctx, fmt.Sprintf(`
+		SELECT tx_hash, log_index, block_number, block_time, transfer_type, from_address, to_address,
+			value::TEXT, token, token_id, symbol, decimals, success
+		FROM middleware_transfers WHERE %s
+		ORDER BY block_number DESC, tx_hash DESC, log_index DESC
+		LIMIT $%d OFFSET $%d`, where, len(args)-1, len(args)), args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list transfers: %w", err)
+	}
+	defer rows.Close()
+
+	transfers := []types.Transfer{}
+	for rows.Next() {
+		t := types.Transfer{ChainName: chainName}
+		var value sql.NullString
+		var decimals int
+		if err := rows.Scan(&t.TxHash, &t.LogIndex, &t.BlockNumber, &t.Timestamp, &t.Type, &t.From, &t.To,
+			&value, &t.Token, &t.TokenID, &t.Symbol, &decimals, &t.Success); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan transfer: %w", err)
+		}
+		if value.Valid {
+			t.Value, _ = new(big.Int).SetString(value.String, 10)
+		}
+		t.Decimals = uint8(decimals)
+		transfers = append(transfers, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to list transfers: %w", err)
+	}
+	return transfers, total, nil
+}
+
+// SaveWatched 保存监听地址
+func (s *PostgresStore) SaveWatched(ctx context.Context, watched *types.WatchedAddress) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO middleware_watched_addresses (chain_name, address, from_block, backfill_next, backfill_end, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (chain_name, address)
+		DO UPDATE SET from_block = EXCLUDED.from_block, backfill_next = EXCLUDED.backfill_next, backfill_end = EXCLUDED.backfill_end`,
+		watched.ChainName, watched.Address, int64(watched.FromBlock), int64(watched.BackfillNext), int64(watched.BackfillEnd), watched.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save watched address: %w", err)
+	}
+	return nil
+}
+
+// ListWatched 列出链上的监听地址
+func (s *PostgresStore) ListWatched(ctx context.Context, chainName string) ([]*types.WatchedAddress, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT address, from_block, backfill_next, backfill_end, created_at
+		FROM middleware_watched_addresses WHERE chain_name = $1`, chainName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list watched addresses: %w", err)
+	}
+	defer rows.Close()
+
+	var list []*types.WatchedAddress
+	for rows.Next() {
+		watched := &types.WatchedAddress{ChainName: chainName}
+		if err := rows.Scan(&watched.Address, &watched.FromBlock, &watched.BackfillNext, &watched.BackfillEnd, &watched.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan watched address: %w", err)
+		}
+		list = append(list, watched)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list watched addresses: %w", err)
+	}
+	return list, nil
+}
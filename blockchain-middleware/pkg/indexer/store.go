@@ -0,0 +1,128 @@
+package indexer
+
+import (
+	"blockchain-middleware/pkg/types"
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Store 转账记录和监听地址持久化接口
+// 地址和代币地址由索引器统一为校验和格式后传入。
+type Store interface {
+	// SaveTransfers 保存转账记录，已存在的记录（同一交易和日志序号）忽略
+	SaveTransfers(ctx context.Context, transfers []types.Transfer) error
+	// ListTransfers 按区块倒序列出地址作为发送方或接收方的转账，同时返回符合条件的总数
+	ListTransfers(ctx context.Context, chainName, address string, query types.TransferQuery) ([]types.Transfer, int, error)
+	// SaveWatched 保存监听地址
+	SaveWatched(ctx context.Context, watched *types.WatchedAddress) error
+	// ListWatched 列出链上的监听地址
+	ListWatched(ctx context.Context, chainName string) ([]*types.WatchedAddress, error)
+}
+
+// MemoryStore 内存存储，仅用于未启用数据库的开发环境
+type MemoryStore struct {
+	mu        sync.Mutex
+	transfers map[string]types.Transfer
+	watched   map[string]*types.WatchedAddress
+}
+
+// NewMemoryStore 创建内存存储
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		transfers: make(map[string]types.Transfer),
+		watched:   make(map[string]*types.WatchedAddress),
+	}
+}
+
+// SaveTransfers 保存转账记录
+func (s *MemoryStore) SaveTransfers(ctx context.Context, transfers []types.Transfer) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, transfer := range transfers {
+		key := fmt.Sprintf("%s:%s:%d", transfer.ChainName, strings.ToLower(transfer.TxHash), transfer.LogIndex)
+		if _, ok := s.transfers[key]; ok {
+			continue
+		}
+		if transfer.Value != nil {
+			transfer.Value = new(big.Int).Set(transfer.Value)
+		}
+		s.transfers[key] = transfer
+	}
+	return nil
+}
+
+// ListTransfers 列出地址的转账
+func (s *MemoryStore) ListTransfers(ctx context.Context, chainName, address string, query types.TransferQuery) ([]types.Transfer, int, error) {
+	s.mu.Lock()
+	var matched []types.Transfer
+	for _, transfer := range s.transfers {
+		if transfer.ChainName != chainName || (transfer.From != address && transfer.To != address) {
+			continue
+		}
+		if query.Type != "" && transfer.Type != query.Type {
+			continue
+		}
+		if query.Token != "" && transfer.Token != query.Token {
+			continue
+		}
+		if transfer.BlockNumber < query.FromBlock {
+			continue
+		}
+		matched = append(matched, transfer)
+	}
+	s.mu.Unlock()
+
+	sortTransfers(matched)
+	total := len(matched)
+	if query.Offset >= total {
+		return []types.Transfer{}, total, nil
+	}
+	end := query.Offset + query.Limit
+	if end > total {
+		end = total
+	}
+	return matched[query.Offset:end], total, nil
+}
+
+// SaveWatched 保存监听地址
+func (s *MemoryStore) SaveWatched(ctx context.Context, watched *types.WatchedAddress) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c := *watched
+	s.watched[watched.ChainName+":"+watched.Address] = &c
+	return nil
+}
+
+// ListWatched 列出链上的监听地址
+func (s *MemoryStore) ListWatched(ctx context.Context, chainName string) ([]*types.WatchedAddress, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var list []*types.WatchedAddress
+	for _, watched := range s.watched {
+		if watched.ChainName == chainName {
+			c := *watched
+			list = append(list, &c)
+		}
+	}
+	return list, nil
+}
+
+// sortTransfers 按区块和日志序号倒序排列，与 PostgresStore 的查询顺序一致
+func sortTransfers(transfers []types.Transfer) {
+	sort.Slice(transfers, func(i, j int) bool {
+		if transfers[i].BlockNumber != transfers[j].BlockNumber {
+			return transfers[i].BlockNumber > transfers[j].BlockNumber
+		}
+		if transfers[i].TxHash != transfers[j].TxHash {
+			return transfers[i].TxHash > transfers[j].TxHash
+		}
+		return transfers[i].LogIndex > transfers[j].LogIndex
+	})
+}
@@ -0,0 +1,319 @@
+package nonce
+
+import (
+	"blockchain-middleware/pkg/types"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// reservationTimeout 预留后一直未广播的nonce超过该时间视为放弃，可重新分配
+	reservationTimeout = 10 * time.Minute
+	// dropGracePeriod 已广播交易在节点交易池中消失超过该时间视为被丢弃
+	dropGracePeriod = 2 * time.Minute
+	// accountIdleTimeout 超过该时间未使用的地址从内存中移除，再次使用时从存储重新加载
+	accountIdleTimeout = 30 * time.Minute
+)
+
+// ChainNonces 查询链上nonce：confirmed 为已上链交易数，pending 为包含交易池连续交易的nonce
+type ChainNonces func(ctx context.Context) (confirmed, pending uint64, err error)
+
+// Manager 按(链, 地址)分配nonce并跟踪在途交易
+// 同一地址的并发请求依次获得连续的nonce；每次分配前与节点同步，
+// 清理已上链的nonce，并把被丢弃交易或放弃的预留留下的缺口重新分配出去。
+type Manager struct {
+	store     Store
+	mu        sync.Mutex
+	accounts  map[string]*account
+	lastSweep time.Time // 上次清理空闲地址的时间
+}
+
+// account 单个地址的分配状态，mu 保证同一地址的分配串行执行
+type account struct {
+	mu    sync.Mutex
+	state *AccountState
+
+	refs     int       // 正在使用该地址的调用数，由 Manager.mu 保护
+	lastUsed time.Time // 由 Manager.mu 保护
+}
+
+// NewManager 创建nonce管理器
+func NewManager(store Store) *Manager {
+	return &Manager{
+		store:     store,
+		accounts:  make(map[string]*account),
+		lastSweep: time.Now(),
+	}
+}
+
+// Reserve 为地址分配下一个nonce
+// 优先重新使用缺口nonce，否则分配最大nonce之后的下一个值。
+func (m *Manager) Reserve(ctx context.Context, chainName, address string, chainNonces ChainNonces) (uint64, error) {
+	acc, release := m.acquire(chainName, address)
+	defer release()
+
+	if err := m.load(ctx, acc, chainName, address); err != nil {
+		return 0, err
+	}
+
+	confirmed, pending, err := chainNonces(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to sync nonce with node: %w", err)
+	}
+	acc.state.resync(confirmed, pending, time.Now())
+
+	var nonce uint64
+	if len(acc.state.Released) > 0 {
+		nonce = acc.state.Released[0]
+		acc.state.Released = acc.state.Released[1:]
+	} else {
+		nonce = acc.state.Next
+		acc.state.Next++
+	}
+	acc.state.InFlight[nonce] = &InFlight{ReservedAt: time.Now()}
+
+	// 状态未持久化时本次分配作废，避免重启后重复分配
+	if err := m.save(ctx, acc, chainName, address); err != nil {
+		return 0, err
+	}
+	return nonce, nil
+}
+
+// MarkSent 记录nonce对应的交易已广播
+// 对于未经 Reserve 分配的nonce（如外部签名的原始交易）同样跟踪，以推进下一个nonce。
+func (m *Manager) MarkSent(ctx context.Context, chainName, address string, nonce uint64, txHash string) error {
+	acc, release := m.acquire(chainName, address)
+	defer release()
+
+	if err := m.load(ctx, acc, chainName, address); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	tx, ok := acc.state.InFlight[nonce]
+	if !ok {
+		tx = &InFlight{ReservedAt: now}
+		acc.state.InFlight[nonce] = tx
+	}
+	tx.TxHash = txHash
+	tx.SentAt = now
+
+	acc.state.removeReleased(nonce)
+	if nonce >= acc.state.Next {
+		// 跳过的nonce是缺口，需要重新分配
+		for n := acc.state.Next; n < nonce; n++ {
+			if _, ok := acc.state.InFlight[n]; !ok {
+				acc.state.addReleased(n)
+			}
+		}
+		acc.state.Next = nonce + 1
+	}
+
+	return m.save(ctx, acc, chainName, address)
+}
+
+// Release 释放确定没有广播出去的nonce
+// 广播结果不确定（如超时）时不能释放：节点可能已经收到交易，重新分配会产生两笔相同nonce的交易；
+// 这种情况保留预留，由下次分配前的同步按节点状态清理或回收。
+func (m *Manager) Release(ctx context.Context, chainName, address string, nonce uint64) error {
+	acc, release := m.acquire(chainName, address)
+	defer release()
+
+	if err := m.load(ctx, acc, chainName, address); err != nil {
+		return err
+	}
+
+	tx, ok := acc.state.InFlight[nonce]
+	if !ok {
+		return nil
+	}
+	if tx.TxHash != "" {
+		return fmt.Errorf("nonce %d already used by transaction %s", nonce, tx.TxHash)
+	}
+	delete(acc.state.InFlight, nonce)
+
+	// 释放的是最后分配的nonce时直接回退，否则留作缺口供下次分配
+	if nonce+1 == acc.state.Next {
+		acc.state.Next = nonce
+		for len(acc.state.Released) > 0 && acc.state.Released[len(acc.state.Released)-1]+1 == acc.state.Next {
+			acc.state.Next--
+			acc.state.Released = acc.state.Released[:len(acc.state.Released)-1]
+		}
+	} else {
+		acc.state.addReleased(nonce)
+	}
+
+	return m.save(ctx, acc, chainName, address)
+}
+
+// Status 获取地址的nonce分配状态
+func (m *Manager) Status(ctx context.Context, chainName, address string) (*types.NonceStatus, error) {
+	acc, release := m.acquire(chainName, address)
+	defer release()
+
+	if err := m.load(ctx, acc, chainName, address); err != nil {
+		return nil, err
+	}
+
+	status := &types.NonceStatus{
+		ChainName: chainName,
+		Address:   normalizeAddress(address),
+		Next:      acc.state.Next,
+		InFlight:  make([]uint64, 0, len(acc.state.InFlight)),
+		Gaps:      append([]uint64{}, acc.state.Released...),
+	}
+	for n := range acc.state.InFlight {
+		status.InFlight = append(status.InFlight, n)
+	}
+	sort.Slice(status.InFlight, func(i, j int) bool { return status.InFlight[i] < status.InFlight[j] })
+	return status, nil
+}
+
+// acquire 获取地址的分配状态并加锁，调用方用完后调用返回的函数解锁
+// 顺便移除空闲的地址，避免长期运行时内存随地址数增长。
+func (m *Manager) acquire(chainName, address string) (*account, func()) {
+	key := accountKey(chainName, address)
+	now := time.Now()
+
+	m.mu.Lock()
+	if now.Sub(m.lastSweep) > accountIdleTimeout {
+		m.sweep(now)
+	}
+	acc, ok := m.accounts[key]
+	if !ok {
+		acc = &account{}
+		m.accounts[key] = acc
+	}
+	acc.refs++
+	m.mu.Unlock()
+
+	acc.mu.Lock()
+	return acc, func() {
+		acc.mu.Unlock()
+
+		m.mu.Lock()
+		acc.refs--
+		acc.lastUsed = time.Now()
+		m.mu.Unlock()
+	}
+}
+
+// sweep 移除没有调用在使用且空闲超时的地址（调用方持有 m.mu）
+// 状态每次变更都已持久化，移除后再次使用时从存储加载，不会丢失在途交易。
+func (m *Manager) sweep(now time.Time) {
+	for key, acc := range m.accounts {
+		if acc.refs == 0 && now.Sub(acc.lastUsed) > accountIdleTimeout {
+			delete(m.accounts, key)
+		}
+	}
+	m.lastSweep = now
+}
+
+// load 首次使用时从存储加载状态（调用方持有 acc.mu）
+func (m *Manager) load(ctx context.Context, acc *account, chainName, address string) error {
+	if acc.state != nil {
+		return nil
+	}
+
+	state, err := m.store.Load(ctx, chainName, normalizeAddress(address))
+	if err != nil {
+		return err
+	}
+	if state == nil {
+		state = &AccountState{}
+	}
+	if state.InFlight == nil {
+		state.InFlight = make(map[uint64]*InFlight)
+	}
+	acc.state = state
+	return nil
+}
+
+// save 持久化状态，失败时丢弃内存状态，下次使用时从存储重新加载（调用方持有 acc.mu）
+func (m *Manager) save(ctx context.Context, acc *account, chainName, address string) error {
+	if err := m.store.Save(ctx, chainName, normalizeAddress(address), acc.state); err != nil {
+		acc.state = nil
+		return err
+	}
+	return nil
+}
+
+// resync 按节点返回的nonce校正状态
+func (st *AccountState) resync(confirmed, pending uint64, now time.Time) {
+	// 已上链的nonce不再跟踪
+	for n := range st.InFlight {
+		if n < confirmed {
+			delete(st.InFlight, n)
+		}
+	}
+	// 节点交易池中已有的nonce不能作为缺口重新分配
+	released := st.Released[:0]
+	for _, n := range st.Released {
+		if n >= pending {
+			released = append(released, n)
+		}
+	}
+	st.Released = released
+
+	// 其他渠道发出的交易推进了链上nonce
+	if st.Next < pending {
+		for n := range st.InFlight {
+			if n < pending {
+				delete(st.InFlight, n)
+			}
+		}
+		st.Next = pending
+		return
+	}
+
+	// 缺口检测：pending 是节点交易池中第一个缺失的nonce，
+	// 该nonce的交易被丢弃或预留被放弃时需要重新分配，否则后续交易会一直卡在交易池中
+	for n := pending; n < st.Next; n++ {
+		tx, ok := st.InFlight[n]
+		switch {
+		case !ok:
+			st.addReleased(n)
+		case tx.TxHash == "" && now.Sub(tx.ReservedAt) > reservationTimeout:
+			delete(st.InFlight, n)
+			st.addReleased(n)
+		case tx.TxHash != "" && n == pending && now.Sub(tx.SentAt) > dropGracePeriod:
+			delete(st.InFlight, n)
+			st.addReleased(n)
+		}
+	}
+}
+
+// addReleased 按升序加入缺口nonce
+func (st *AccountState) addReleased(nonce uint64) {
+	i := sort.Search(len(st.Released), func(i int) bool { return st.Released[i] >= nonce })
+	if i < len(st.Released) && st.Released[i] == nonce {
+		return
+	}
+	st.Released = append(st.Released, 0)
+	copy(st.Released[i+1:], st.Released[i:])
+	st.Released[i] = nonce
+}
+
+// removeReleased 移除缺口nonce
+func (st *AccountState) removeReleased(nonce uint64) {
+	for i, n := range st.Released {
+		if n == nonce {
+			st.Released = append(st.Released[:i], st.Released[i+1:]...)
+			return
+		}
+	}
+}
+
+// accountKey 账户在内存中的键
+func accountKey(chainName, address string) string {
+	return chainName + ":" + normalizeAddress(address)
+}
+
+// normalizeAddress EVM地址统一小写
+func normalizeAddress(address string) string {
+	return strings.ToLower(address)
+}
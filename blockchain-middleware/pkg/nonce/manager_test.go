@@ -0,0 +1,315 @@
+package nonce
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+const (
+	testChain   = "ethereum"
+	testAddress = "0xAbC0000000000000000000000000000000000001"
+)
+
+// chainAt 返回固定的链上nonce
+func chainAt(confirmed, pending uint64) ChainNonces {
+	return func(context.Context) (uint64, uint64, error) {
+		return confirmed, pending, nil
+	}
+}
+
+// step 对管理器的一次操作
+type step struct {
+	op        string // reserve、sent、release
+	nonce     uint64 // reserve 时为期望分配的nonce
+	confirmed uint64
+	pending   uint64
+	wantErr   bool
+}
+
+func TestManagerReserveRelease(t *testing.T) {
+	tests := []struct {
+		name     string
+		steps    []step
+		wantNext uint64
+		wantGaps []uint64
+		wantIn   []uint64
+	}{
+		{
+			name:     "sequential reservations",
+			steps:    []step{{op: "reserve", nonce: 0}, {op: "reserve", nonce: 1}, {op: "reserve", nonce: 2}},
+			wantNext: 3,
+			wantIn:   []uint64{0, 1, 2},
+		},
+		{
+			name:     "starts at pending nonce",
+			steps:    []step{{op: "reserve", nonce: 7, confirmed: 5, pending: 7}},
+			wantNext: 8,
+			wantIn:   []uint64{7},
+		},
+		{
+			name:     "releasing the last nonce rolls back",
+			steps:    []step{{op: "reserve", nonce: 0}, {op: "reserve", nonce: 1}, {op: "release", nonce: 1}},
+			wantNext: 1,
+			wantIn:   []uint64{0},
+		},
+		{
+			name: "releasing trailing nonces rolls back over gaps",
+			steps: []step{
+				{op: "reserve", nonce: 0}, {op: "reserve", nonce: 1}, {op: "reserve", nonce: 2},
+				{op: "release", nonce: 1}, {op: "release", nonce: 2},
+			},
+			wantNext: 1,
+			wantIn:   []uint64{0},
+		},
+		{
+			name: "released nonce in the middle is reused first",
+			steps: []step{
+				{op: "reserve", nonce: 0}, {op: "reserve", nonce: 1}, {op: "reserve", nonce: 2},
+				{op: "release", nonce: 1}, {op: "reserve", nonce: 1}, {op: "reserve", nonce: 3},
+			},
+			wantNext: 4,
+			wantIn:   []uint64{0, 1, 2, 3},
+		},
+		{
+			name:     "sent nonce cannot be released",
+			steps:    []step{{op: "reserve", nonce: 0}, {op: "sent", nonce: 0}, {op: "release", nonce: 0, wantErr: true}},
+			wantNext: 1,
+			wantIn:   []uint64{0},
+		},
+		{
+			name:     "external transaction ahead leaves gaps",
+			steps:    []step{{op: "sent", nonce: 3}},
+			wantNext: 4,
+			wantGaps: []uint64{0, 1, 2},
+			wantIn:   []uint64{3},
+		},
+		{
+			name:     "gap is reserved before the next nonce",
+			steps:    []step{{op: "sent", nonce: 2}, {op: "reserve", nonce: 0}, {op: "reserve", nonce: 1}, {op: "reserve", nonce: 3}},
+			wantNext: 4,
+			wantIn:   []uint64{0, 1, 2, 3},
+		},
+		{
+			name:     "releasing an unknown nonce is a no-op",
+			steps:    []step{{op: "reserve", nonce: 0}, {op: "release", nonce: 5}},
+			wantNext: 1,
+			wantIn:   []uint64{0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			m := NewManager(NewMemoryStore())
+
+			for i, s := range tt.steps {
+				var err error
+				switch s.op {
+				case "reserve":
+					var n uint64
+					n, err = m.Reserve(ctx, testChain, testAddress, chainAt(s.confirmed, s.pending))
+					if err == nil && n != s.nonce {
+						t.Fatalf("step %d: Reserve() = %d, want %d", i, n, s.nonce)
+					}
+				case "sent":
+					err = m.MarkSent(ctx, testChain, testAddress, s.nonce, fmt.Sprintf("0x%064x", s.nonce))
+				case "release":
+					err = m.Release(ctx, testChain, testAddress, s.nonce)
+				}
+				if (err != nil) != s.wantErr {
+					t.Fatalf("step %d: %s(%d) error = %v, wantErr %v", i, s.op, s.nonce, err, s.wantErr)
+				}
+			}
+
+			status, err := m.Status(ctx, testChain, testAddress)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if status.Next != tt.wantNext {
+				t.Errorf("next = %d, want %d", status.Next, tt.wantNext)
+			}
+			if !equalNonces(status.Gaps, tt.wantGaps) {
+				t.Errorf("gaps = %v, want %v", status.Gaps, tt.wantGaps)
+			}
+			if !equalNonces(status.InFlight, tt.wantIn) {
+				t.Errorf("inflight = %v, want %v", status.InFlight, tt.wantIn)
+			}
+		})
+	}
+}
+
+func TestAccountStateResync(t *testing.T) {
+	now := time.Now()
+	fresh := now.Add(-time.Second)
+	reservedLongAgo := now.Add(-reservationTimeout - time.Minute)
+	sentLongAgo := now.Add(-dropGracePeriod - time.Minute)
+
+	reserved := func(at time.Time) *InFlight { return &InFlight{ReservedAt: at} }
+	sent := func(at time.Time) *InFlight { return &InFlight{TxHash: "0x01", ReservedAt: at, SentAt: at} }
+
+	tests := []struct {
+		name               string
+		state              AccountState
+		confirmed, pending uint64
+		wantNext           uint64
+		wantIn             []uint64
+		wantGaps           []uint64
+	}{
+		{
+			name:      "confirmed nonces are pruned",
+			state:     AccountState{Next: 3, InFlight: map[uint64]*InFlight{0: sent(fresh), 1: sent(fresh), 2: sent(fresh)}},
+			confirmed: 2, pending: 3,
+			wantNext: 3,
+			wantIn:   []uint64{2},
+		},
+		{
+			name:      "other sender advanced the account",
+			state:     AccountState{Next: 2, InFlight: map[uint64]*InFlight{1: reserved(fresh)}, Released: []uint64{0}},
+			confirmed: 4, pending: 5,
+			wantNext: 5,
+		},
+		{
+			name:      "gaps below pending are dropped",
+			state:     AccountState{Next: 5, InFlight: map[uint64]*InFlight{4: sent(fresh)}, Released: []uint64{1, 3}},
+			confirmed: 0, pending: 3,
+			wantNext: 5,
+			wantIn:   []uint64{4},
+			wantGaps: []uint64{3},
+		},
+		{
+			name:      "missing nonce in the pending range is a gap",
+			state:     AccountState{Next: 4, InFlight: map[uint64]*InFlight{3: sent(fresh)}},
+			confirmed: 1, pending: 1,
+			wantNext: 4,
+			wantIn:   []uint64{3},
+			wantGaps: []uint64{1, 2},
+		},
+		{
+			name:      "abandoned reservation is reclaimed",
+			state:     AccountState{Next: 2, InFlight: map[uint64]*InFlight{0: reserved(reservedLongAgo), 1: reserved(fresh)}},
+			confirmed: 0, pending: 0,
+			wantNext: 2,
+			wantIn:   []uint64{1},
+			wantGaps: []uint64{0},
+		},
+		{
+			name:      "dropped transaction is reclaimed after the grace period",
+			state:     AccountState{Next: 2, InFlight: map[uint64]*InFlight{0: sent(sentLongAgo), 1: sent(sentLongAgo)}},
+			confirmed: 0, pending: 0,
+			wantNext: 2,
+			wantIn:   []uint64{1},
+			wantGaps: []uint64{0},
+		},
+		{
+			name:      "recently sent transaction is kept",
+			state:     AccountState{Next: 1, InFlight: map[uint64]*InFlight{0: sent(fresh)}},
+			confirmed: 0, pending: 0,
+			wantNext: 1,
+			wantIn:   []uint64{0},
+		},
+		{
+			// 广播结果不确定而保留的预留：节点已收到时 pending 越过它，随确认清理
+			name:      "uncertain broadcast accepted by the node",
+			state:     AccountState{Next: 1, InFlight: map[uint64]*InFlight{0: reserved(fresh)}},
+			confirmed: 0, pending: 1,
+			wantNext: 1,
+			wantIn:   []uint64{0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := tt.state
+			st.resync(tt.confirmed, tt.pending, now)
+
+			if st.Next != tt.wantNext {
+				t.Errorf("next = %d, want %d", st.Next, tt.wantNext)
+			}
+			var inFlight []uint64
+			for n := range st.InFlight {
+				inFlight = append(inFlight, n)
+			}
+			if !equalNonces(inFlight, tt.wantIn) {
+				t.Errorf("inflight = %v, want %v", inFlight, tt.wantIn)
+			}
+			if !equalNonces(st.Released, tt.wantGaps) {
+				t.Errorf("gaps = %v, want %v", st.Released, tt.wantGaps)
+			}
+		})
+	}
+}
+
+func TestManagerEvictsIdleAccounts(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	m := NewManager(store)
+
+	if _, err := m.Reserve(ctx, testChain, testAddress, chainAt(0, 0)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Reserve(ctx, "polygon", testAddress, chainAt(0, 0)); err != nil {
+		t.Fatal(err)
+	}
+
+	// 让以太坊上的地址空闲超时，下一次访问时触发清理
+	m.mu.Lock()
+	m.accounts[accountKey(testChain, testAddress)].lastUsed = time.Now().Add(-accountIdleTimeout - time.Minute)
+	m.lastSweep = time.Now().Add(-accountIdleTimeout - time.Minute)
+	m.mu.Unlock()
+
+	if _, err := m.Status(ctx, "polygon", testAddress); err != nil {
+		t.Fatal(err)
+	}
+	m.mu.Lock()
+	_, kept := m.accounts[accountKey("polygon", testAddress)]
+	_, evicted := m.accounts[accountKey(testChain, testAddress)]
+	m.mu.Unlock()
+	if !kept || evicted {
+		t.Fatalf("after sweep: polygon kept = %v, ethereum evicted = %v", kept, !evicted)
+	}
+
+	// 被移除的地址从存储重新加载，继续分配下一个nonce
+	n, err := m.Reserve(ctx, testChain, testAddress, chainAt(0, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Fatalf("Reserve() after eviction = %d, want 1", n)
+	}
+}
+
+func TestManagerKeepsAccountsInUse(t *testing.T) {
+	m := NewManager(NewMemoryStore())
+
+	acc, release := m.acquire(testChain, testAddress)
+	m.mu.Lock()
+	acc.lastUsed = time.Now().Add(-accountIdleTimeout - time.Minute)
+	m.sweep(time.Now())
+	_, ok := m.accounts[accountKey(testChain, testAddress)]
+	m.mu.Unlock()
+	release()
+
+	if !ok {
+		t.Fatal("account in use was evicted")
+	}
+}
+
+// equalNonces 忽略顺序比较nonce集合，nil与空切片相等
+func equalNonces(got, want []uint64) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	count := make(map[uint64]int)
+	for _, n := range got {
+		count[n]++
+	}
+	for _, n := range want {
+		count[n]--
+	}
+	for _, c := range count {
+		if c != 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,72 @@
+package nonce
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+)
+
+// PostgresStore 基于PostgreSQL的nonce状态存储，服务重启后恢复在途交易
+type PostgresStore struct {
+	db *sql.DB
+}
+
+// NewPostgresStore 创建PostgreSQL存储并确保表结构存在
+func NewPostgresStore(ctx context.Context, db *sql.DB) (*PostgresStore, error) {
+	_, err := db.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS middleware_nonces (
+			chain_name VARCHAR(64) NOT NULL,
+			address VARCHAR(128) NOT NULL,
+			state JSONB NOT NULL,
+			updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (chain_name, address)
+		)`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create nonce table: %w", err)
+	}
+
+	return &PostgresStore{db: db}, nil
+}
+
+// Load 加载账户状态
+func (s *PostgresStore) Load(ctx context.Context, chainName, address string) (*AccountState, error) {
+	var raw []byte
+	err := s.db.QueryRowContext(ctx,
+		`SELECT state FROM middleware_nonces WHERE chain_name = $1 AND address = $2`,
+		chainName, normalizeAddress(address)).Scan(&raw)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load nonce state: %w", err)
+	}
+
+	var state AccountState
+	if err := json.Unmarshal(raw, &state); err != nil {
+		return nil, fmt.Errorf("failed to decode nonce state: %w", err)
+	}
+	if state.InFlight == nil {
+		state.InFlight = make(map[uint64]*InFlight)
+	}
+	return &state, nil
+}
+
+// Save 保存账户状态
+func (s *PostgresStore) Save(ctx context.Context, chainName, address string, state *AccountState) error {
+	raw, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("failed to encode nonce state: %w", err)
+	}
+
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO middleware_nonces (chain_name, address, state, updated_at)
+		VALUES ($1, $2, $3, CURRENT_TIMESTAMP)
+		ON CONFLICT (chain_name, address)
+		DO UPDATE SET state = EXCLUDED.state, updated_at = EXCLUDED.updated_at`,
+		chainName, normalizeAddress(address), string(raw))
+	if err != nil {
+		return fmt.Errorf("failed to save nonce state: %w", err)
+	}
+	return nil
+}
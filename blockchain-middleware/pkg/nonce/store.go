@@ -0,0 +1,75 @@
+package nonce
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// AccountState 单个(链, 地址)的nonce分配状态
+type AccountState struct {
+	Next     uint64               `json:"next"`     // 下一个待分配的nonce
+	InFlight map[uint64]*InFlight `json:"inflight"` // 已分配但尚未上链的nonce
+	Released []uint64             `json:"released"` // 需要重新使用的缺口nonce，升序
+}
+
+// InFlight 已分配的nonce
+type InFlight struct {
+	TxHash     string    `json:"tx_hash,omitempty"` // 为空表示已预留但尚未广播
+	ReservedAt time.Time `json:"reserved_at"`
+	SentAt     time.Time `json:"sent_at,omitempty"`
+}
+
+// Store nonce状态持久化接口
+type Store interface {
+	// Load 加载账户状态，不存在时返回nil
+	Load(ctx context.Context, chainName, address string) (*AccountState, error)
+	// Save 保存账户状态
+	Save(ctx context.Context, chainName, address string, state *AccountState) error
+}
+
+// MemoryStore 内存存储，仅用于未启用数据库的开发环境
+type MemoryStore struct {
+	mu     sync.Mutex
+	states map[string]*AccountState
+}
+
+// NewMemoryStore 创建内存存储
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{states: make(map[string]*AccountState)}
+}
+
+// Load 加载账户状态
+func (s *MemoryStore) Load(ctx context.Context, chainName, address string) (*AccountState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state, ok := s.states[accountKey(chainName, address)]
+	if !ok {
+		return nil, nil
+	}
+	return state.clone(), nil
+}
+
+// Save 保存账户状态
+func (s *MemoryStore) Save(ctx context.Context, chainName, address string, state *AccountState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.states[accountKey(chainName, address)] = state.clone()
+	return nil
+}
+
+// clone 深拷贝状态
+func (st *AccountState) clone() *AccountState {
+	c := &AccountState{
+		Next:     st.Next,
+		InFlight: make(map[uint64]*InFlight, len(st.InFlight)),
+		Released: append([]uint64(nil), st.Released...),
+	}
+	for n, tx := range st.InFlight {
+		copied := *tx
+		c.InFlight[n] = &copied
+	}
+	return c
+}
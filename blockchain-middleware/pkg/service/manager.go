@@ -2,30 +2,92 @@ package service
 
 import (
 	"blockchain-middleware/internal/config"
+	"blockchain-middleware/pkg/bridge"
+	"blockchain-middleware/pkg/cache"
 	"blockchain-middleware/pkg/chain"
+	"blockchain-middleware/pkg/checkpoint"
+	"blockchain-middleware/pkg/contract"
 	"blockchain-middleware/pkg/event"
+	"blockchain-middleware/pkg/indexer"
+	"blockchain-middleware/pkg/nonce"
+	"blockchain-middleware/pkg/signer"
+	"blockchain-middleware/pkg/storage"
+	"blockchain-middleware/pkg/telemetry"
+	"blockchain-middleware/pkg/tracker"
 	"blockchain-middleware/pkg/types"
+	"context"
+	"database/sql"
+	"encoding/json"
 	"fmt"
+	"io"
 	"log"
 	"math/big"
 	"sync"
 	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 )
 
 // ServiceManager 服务管理器
 type ServiceManager struct {
-	config    *config.Config
-	clients   map[string]chain.ChainClient
-	eventMgr  *event.EventManager
-	mu        sync.RWMutex
+	config       *config.Config
+	clients      map[string]chain.ChainClient
+	chainConfigs map[string]config.ChainConfig
+	signer       signer.Signer
+	mpc          *signer.MPCClient // 未配置 MPC_CORE_URL 时为nil
+	db           *sql.DB
+	cache        cache.Store
+	cacheStats   *cache.Stats
+	nonces       *nonce.Manager
+	tracker      *tracker.Tracker
+	indexer      *indexer.Indexer // 未启用转账索引时为nil
+	bridgeRoutes []*bridge.Route
+	bridge       *bridge.Engine
+	checkpoints  checkpoint.Store
+	eventMgr     *event.EventManager
+	abis         *contract.Registry
+	signatures   *contract.Signatures
+	decoder      *contract.Decoder
+	mu           sync.RWMutex
 }
 
 // NewServiceManager 创建新的服务管理器
 func NewServiceManager(cfg *config.Config) (*ServiceManager, error) {
+	txSigner, err := signer.New(cfg.Signer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create signer: %w", err)
+	}
+
 	mgr := &ServiceManager{
-		config:   cfg,
-		clients:  make(map[string]chain.ChainClient),
-		eventMgr: event.NewEventManager(),
+		config:       cfg,
+		clients:      make(map[string]chain.ChainClient),
+		chainConfigs: make(map[string]config.ChainConfig),
+		signer:       txSigner,
+		mpc:          signer.NewMPCClient(cfg.MPC),
+		abis:         contract.NewRegistry(),
+		signatures:   contract.NewSignatures(),
+		cacheStats:   cache.NewStats(),
+	}
+	mgr.eventMgr = event.NewEventManager(mgr.GetChainClient)
+	mgr.decoder = contract.NewDecoder(mgr.abis, mgr.signatures)
+
+	if cfg.Contracts.ABIDir != "" {
+		if err := mgr.abis.LoadDir(cfg.Contracts.ABIDir); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.Contracts.SignaturesFile != "" {
+		if err := mgr.signatures.LoadFile(cfg.Contracts.SignaturesFile); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.Bridge.RoutesFile != "" {
+		routes, err := bridge.LoadRoutes(cfg.Bridge.RoutesFile)
+		if err != nil {
+			return nil, err
+		}
+		mgr.bridgeRoutes = routes
 	}
 
 	return mgr, nil
@@ -35,29 +97,49 @@ func NewServiceManager(cfg *config.Config) (*ServiceManager, error) {
 func (sm *ServiceManager) Start() error {
 	log.Println("Starting blockchain services...")
 
-	// 启动支持的链客户端
-	chains := []struct {
-		name string
-		cfg  config.ChainConfig
-	}{
-		{"ethereum", sm.config.Chains.Ethereum},
-		{"polygon", sm.config.Chains.Polygon},
-		{"bsc", sm.config.Chains.BSC},
+	if err := sm.startStorage(); err != nil {
+		return err
 	}
+	sm.startCache()
 
-	for _, c := range chains {
-		if c.cfg.Enabled {
-			if err := sm.startChainClient(c.name, c.cfg); err != nil {
-				return fmt.Errorf("failed to start %s client: %w", c.name, err)
+	// 启动配置中所有已启用的EVM链
+	for _, c := range sm.config.Chains.EVM {
+		if c.Enabled {
+			if err := sm.startChainClient("evm", c); err != nil {
+				return fmt.Errorf("failed to start %s client: %w", c.Name, err)
 			}
 		}
 	}
 
+	if sm.config.Chains.Bitcoin.Enabled {
+		if err := sm.startChainClient("bitcoin", sm.config.Chains.Bitcoin); err != nil {
+			return fmt.Errorf("failed to start %s client: %w", sm.config.Chains.Bitcoin.Name, err)
+		}
+	}
+
 	// 启动事件管理器
 	if err := sm.eventMgr.Start(); err != nil {
 		return fmt.Errorf("failed to start event manager: %w", err)
 	}
 
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := sm.tracker.Start(ctx); err != nil {
+		return fmt.Errorf("failed to start transaction tracker: %w", err)
+	}
+	if sm.indexer != nil {
+		if err := sm.indexer.Start(ctx, sm.config.Chains.EVM); err != nil {
+			return fmt.Errorf("failed to start transfer indexer: %w", err)
+		}
+	}
+	if err := sm.bridge.Start(ctx); err != nil {
+		return fmt.Errorf("failed to start bridge engine: %w", err)
+	}
+
+	if err := telemetry.Registry.Register(metricsCollector{sm}); err != nil {
+		return fmt.Errorf("failed to register metrics: %w", err)
+	}
+
 	log.Println("All blockchain services started successfully")
 	return nil
 }
@@ -65,6 +147,17 @@ func (sm *ServiceManager) Start() error {
 // Stop 停止所有服务
 func (sm *ServiceManager) Stop() error {
 	log.Println("Stopping blockchain services...")
+	telemetry.Registry.Unregister(metricsCollector{sm})
+
+	if sm.bridge != nil {
+		sm.bridge.Stop()
+	}
+	if sm.indexer != nil {
+		sm.indexer.Stop()
+	}
+	if sm.tracker != nil {
+		sm.tracker.Stop()
+	}
 
 	// 停止事件管理器
 	if err := sm.eventMgr.Stop(); err != nil {
@@ -80,25 +173,122 @@ func (sm *ServiceManager) Stop() error {
 			log.Printf("Error closing %s client: %v", name, err)
 		}
 		delete(sm.clients, name)
+		delete(sm.chainConfigs, name)
+	}
+
+	if closer, ok := sm.cache.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			log.Printf("Error closing cache: %v", err)
+		}
+	}
+
+	if sm.db != nil {
+		if err := sm.db.Close(); err != nil {
+			log.Printf("Error closing database: %v", err)
+		}
+		sm.db = nil
 	}
 
 	log.Println("All blockchain services stopped")
 	return nil
 }
 
+// startStorage 连接数据库并初始化持久化组件，未启用数据库时使用内存存储
+func (sm *ServiceManager) startStorage() error {
+	if !sm.config.Database.Enabled {
+		log.Println("Database disabled, nonce state will not survive restarts")
+		sm.nonces = nonce.NewManager(nonce.NewMemoryStore())
+		sm.tracker = tracker.NewTracker(sm.config.Tracker, tracker.NewMemoryStore(), sm.GetChainClient, sm.publishTxStatus)
+		sm.checkpoints = checkpoint.NewMemoryStore()
+		if sm.config.Indexer.Enabled {
+			sm.indexer = indexer.NewIndexer(sm.config.Indexer, indexer.NewMemoryStore(), sm.checkpoints, sm.GetChainClient)
+		}
+		sm.bridge = bridge.NewEngine(sm.config.Bridge, sm.bridgeRoutes, bridge.NewMemoryStore(), sm.getChainWithConfig)
+		return nil
+	}
+
+	db, err := storage.OpenPostgres(sm.config.Database)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	nonceStore, err := nonce.NewPostgresStore(ctx, db)
+	if err != nil {
+		db.Close()
+		return err
+	}
+
+	trackerStore, err := tracker.NewPostgresStore(ctx, db)
+	if err != nil {
+		db.Close()
+		return err
+	}
+
+	checkpointStore, err := checkpoint.NewPostgresStore(ctx, db)
+	if err != nil {
+		db.Close()
+		return err
+	}
+
+	var indexerStore *indexer.PostgresStore
+	if sm.config.Indexer.Enabled {
+		if indexerStore, err = indexer.NewPostgresStore(ctx, db); err != nil {
+			db.Close()
+			return err
+		}
+	}
+
+	bridgeStore, err := bridge.NewPostgresStore(ctx, db)
+	if err != nil {
+		db.Close()
+		return err
+	}
+
+	sm.db = db
+	sm.nonces = nonce.NewManager(nonceStore)
+	sm.tracker = tracker.NewTracker(sm.config.Tracker, trackerStore, sm.GetChainClient, sm.publishTxStatus)
+	sm.checkpoints = checkpointStore
+	if indexerStore != nil {
+		sm.indexer = indexer.NewIndexer(sm.config.Indexer, indexerStore, sm.checkpoints, sm.GetChainClient)
+	}
+	sm.bridge = bridge.NewEngine(sm.config.Bridge, sm.bridgeRoutes, bridgeStore, sm.getChainWithConfig)
+	return nil
+}
+
+// startCache 连接Redis缓存，未配置或不可用时使用进程内缓存
+func (sm *ServiceManager) startCache() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	sm.cache = cache.NewStore(ctx, sm.config.Cache)
+	log.Printf("Chain read cache using %s store", sm.cache.Backend())
+}
+
 // startChainClient 启动单个链客户端
-func (sm *ServiceManager) startChainClient(name string, cfg config.ChainConfig) error {
+func (sm *ServiceManager) startChainClient(chainType string, cfg config.ChainConfig) error {
 	factory := &chain.ChainFactory{}
-	client, err := factory.NewClient(name, cfg)
+	client, err := factory.NewClient(chainType, cfg, sm.signer, sm.nonces)
 	if err != nil {
 		return err
 	}
+	if cfg.CacheEnabled {
+		client = cache.NewClient(client, cfg, sm.cache, sm.cacheStats, sm.config.Cache.HeadTTL)
+	}
 
 	sm.mu.Lock()
-	sm.clients[name] = client
-	sm.mu.Unlock()
+	defer sm.mu.Unlock()
+
+	if _, exists := sm.clients[cfg.Name]; exists {
+		client.Close()
+		return fmt.Errorf("duplicate chain name: %s", cfg.Name)
+	}
+	sm.clients[cfg.Name] = client
+	sm.chainConfigs[cfg.Name] = cfg
 
-	log.Printf("%s client started successfully", name)
+	log.Printf("%s client started successfully (chain id %d)", cfg.Name, cfg.ChainID)
 	return nil
 }
 
@@ -115,6 +305,18 @@ func (sm *ServiceManager) GetChainClient(chainName string) (chain.ChainClient, e
 	return client, nil
 }
 
+// getChainWithConfig 获取指定链的客户端和配置
+func (sm *ServiceManager) getChainWithConfig(chainName string) (chain.ChainClient, config.ChainConfig, error) {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+
+	client, exists := sm.clients[chainName]
+	if !exists {
+		return nil, config.ChainConfig{}, fmt.Errorf("chain client not found: %s", chainName)
+	}
+	return client, sm.chainConfigs[chainName], nil
+}
+
 // GetSupportedChains 获取支持的链列表
 func (sm *ServiceManager) GetSupportedChains() []string {
 	sm.mu.RLock()
@@ -129,79 +331,617 @@ func (sm *ServiceManager) GetSupportedChains() []string {
 }
 
 // GetChainInfo 获取链信息
-func (sm *ServiceManager) GetChainInfo(chainName string) (*types.ChainInfo, error) {
+func (sm *ServiceManager) GetChainInfo(ctx context.Context, chainName string) (_ *types.ChainInfo, err error) {
+	ctx, span := startSpan(ctx, "GetChainInfo", chainName)
+	defer func() { telemetry.EndSpan(span, err) }()
+
 	client, err := sm.GetChainClient(chainName)
 	if err != nil {
 		return nil, err
 	}
 
-	blockNumber, err := client.GetBlockNumber()
+	blockNumber, err := client.GetBlockNumber(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	// 获取Gas价格（示例实现）
-	gasPrice := new(types.TransactionRequest)
-	gasPrice.GasPrice = big.NewInt(20000000000) // 20 Gwei
+	sm.mu.RLock()
+	cfg := sm.chainConfigs[chainName]
+	sm.mu.RUnlock()
 
-	return &types.ChainInfo{
+	info := &types.ChainInfo{
 		ChainID:     client.GetChainID(),
 		NetworkName: client.GetNetworkName(),
 		BlockNumber: blockNumber,
-		GasPrice:    gasPrice.GasPrice,
 		IsSyncing:   false, // 简化处理
 		PeerCount:   0,     // 简化处理
-	}, nil
+
+		NativeSymbol:   cfg.NativeSymbol,
+		NativeDecimals: cfg.NativeDecimals,
+		Confirmations:  cfg.Confirmations,
+		EIP1559:        cfg.EIP1559,
+	}
+	if reporter, ok := chain.As[chain.ProviderStatusReporter](client); ok {
+		info.Providers = reporter.ProviderStatus()
+	}
+	if oracle, ok := chain.As[chain.FeeOracle](client); ok {
+		info.GasPrice = standardGasPrice(ctx, chainName, oracle)
+	}
+
+	return info, nil
+}
+
+// standardGasPrice 标准档位的Gas价格，手续费估算失败时使用节点建议的价格，都失败时返回nil
+// 链信息中的Gas价格只是参考，不能因为 eth_feeHistory 不可用而让整个查询失败。
+func standardGasPrice(ctx context.Context, chainName string, oracle chain.FeeOracle) *big.Int {
+	fees, err := oracle.EstimateFees(ctx)
+	if err == nil {
+		return fees.Standard.GasPrice
+	}
+	log.Printf("Failed to estimate fees on %s, using node gas price: %v", chainName, err)
+
+	gasPrice, err := oracle.SuggestGasPrice(ctx)
+	if err != nil {
+		log.Printf("Failed to get gas price on %s: %v", chainName, err)
+		return nil
+	}
+	return gasPrice
+}
+
+// GetFeeEstimates 获取慢速、标准、快速三档手续费估算
+func (sm *ServiceManager) GetFeeEstimates(ctx context.Context, chainName string) (_ *types.FeeEstimates, err error) {
+	ctx, span := startSpan(ctx, "GetFeeEstimates", chainName)
+	defer func() { telemetry.EndSpan(span, err) }()
+
+	client, err := sm.GetChainClient(chainName)
+	if err != nil {
+		return nil, err
+	}
+
+	oracle, ok := chain.As[chain.FeeOracle](client)
+	if !ok {
+		return nil, fmt.Errorf("chain %s does not support fee estimation", chainName)
+	}
+
+	return oracle.EstimateFees(ctx)
 }
 
 // GetBalance 获取账户余额
-func (sm *ServiceManager) GetBalance(chainName, address string) (*big.Int, error) {
+func (sm *ServiceManager) GetBalance(ctx context.Context, chainName, address string) (_ *big.Int, err error) {
+	ctx, span := startSpan(ctx, "GetBalance", chainName)
+	defer func() { telemetry.EndSpan(span, err) }()
+
 	client, err := sm.GetChainClient(chainName)
 	if err != nil {
 		return nil, err
 	}
 
-	return client.GetBalance(address)
+	return client.GetBalance(ctx, address)
 }
 
 // SendTransaction 发送交易
-func (sm *ServiceManager) SendTransaction(chainName string, req *types.TransactionRequest) (string, error) {
+func (sm *ServiceManager) SendTransaction(ctx context.Context, chainName string, req *types.TransactionRequest) (_ string, err error) {
+	ctx, span := startSpan(ctx, "SendTransaction", chainName)
+	defer func() { telemetry.EndSpan(span, err) }()
+
+	client, err := sm.GetChainClient(chainName)
+	if err != nil {
+		return "", err
+	}
+
+	txHash, err := client.SendTransaction(ctx, req)
+	telemetry.ObserveTxSend(chainName, "signed", err)
+	if err != nil {
+		return "", err
+	}
+
+	sm.trackTransaction(ctx, chainName, txHash)
+	return txHash, nil
+}
+
+// SendRawTransaction 校验并广播已签名的原始交易
+func (sm *ServiceManager) SendRawTransaction(ctx context.Context, chainName string, rawTx []byte) (_ string, err error) {
+	ctx, span := startSpan(ctx, "SendRawTransaction", chainName)
+	defer func() { telemetry.EndSpan(span, err) }()
+
 	client, err := sm.GetChainClient(chainName)
 	if err != nil {
 		return "", err
 	}
 
-	return client.SendTransaction(req)
+	txHash, err := client.SendRawTransaction(ctx, rawTx)
+	telemetry.ObserveTxSend(chainName, "raw", err)
+	if err != nil {
+		return "", err
+	}
+
+	sm.trackTransaction(ctx, chainName, txHash)
+	return txHash, nil
+}
+
+// trackTransaction 跟踪已广播交易的生命周期，跟踪失败不影响广播结果
+func (sm *ServiceManager) trackTransaction(ctx context.Context, chainName, txHash string) {
+	sm.mu.RLock()
+	cfg := sm.chainConfigs[chainName]
+	sm.mu.RUnlock()
+
+	if _, err := sm.tracker.Track(ctx, chainName, txHash, cfg.Confirmations); err != nil {
+		log.Printf("Failed to track transaction %s on %s: %v", txHash, chainName, err)
+	}
+}
+
+// TrackTransaction 开始跟踪指定交易（如通过其他渠道广播的交易）
+func (sm *ServiceManager) TrackTransaction(ctx context.Context, chainName, txHash string) (_ *types.TrackedTransaction, err error) {
+	ctx, span := startSpan(ctx, "TrackTransaction", chainName)
+	defer func() { telemetry.EndSpan(span, err) }()
+
+	sm.mu.RLock()
+	cfg, exists := sm.chainConfigs[chainName]
+	sm.mu.RUnlock()
+	if !exists {
+		return nil, fmt.Errorf("chain client not found: %s", chainName)
+	}
+
+	return sm.tracker.Track(ctx, chainName, txHash, cfg.Confirmations)
+}
+
+// GetTransactionStatus 获取交易的生命周期状态
+func (sm *ServiceManager) GetTransactionStatus(ctx context.Context, chainName, txHash string) (*types.TrackedTransaction, error) {
+	return sm.tracker.Get(ctx, chainName, txHash)
+}
+
+// WatchAddress 把地址加入转账索引，fromBlock 早于已索引区块时在后台补齐历史
+func (sm *ServiceManager) WatchAddress(ctx context.Context, chainName, address string, fromBlock uint64) (_ *types.WatchedAddress, err error) {
+	ctx, span := startSpan(ctx, "WatchAddress", chainName)
+	defer func() { telemetry.EndSpan(span, err) }()
+
+	if sm.indexer == nil {
+		return nil, fmt.Errorf("transfer indexer is disabled")
+	}
+	return sm.indexer.Watch(ctx, chainName, address, fromBlock)
+}
+
+// ListTransfers 分页获取监听地址的转账记录
+func (sm *ServiceManager) ListTransfers(ctx context.Context, chainName, address string, query types.TransferQuery) (_ *types.TransferPage, err error) {
+	ctx, span := startSpan(ctx, "ListTransfers", chainName)
+	defer func() { telemetry.EndSpan(span, err) }()
+
+	if sm.indexer == nil {
+		return nil, fmt.Errorf("transfer indexer is disabled")
+	}
+	return sm.indexer.ListTransfers(ctx, chainName, address, query)
+}
+
+// publishTxStatus 把交易状态变更推送给该链的事件订阅者
+func (sm *ServiceManager) publishTxStatus(event types.TxStatusEvent) {
+	telemetry.ObserveTxStatus(event.ChainName, event.State)
+
+	tx := event.Transaction
+	data := map[string]interface{}{
+		"state":                  event.State,
+		"previous_state":         event.PreviousState,
+		"confirmations":          tx.Confirmations,
+		"required_confirmations": tx.RequiredConfirmations,
+	}
+	if tx.Success != nil {
+		data["success"] = *tx.Success
+	}
+
+	sm.eventMgr.Publish(types.BlockchainEvent{
+		ChainName:   event.ChainName,
+		Type:        types.EventTypeTransactionStatus,
+		BlockNumber: tx.BlockNumber,
+		BlockHash:   tx.BlockHash,
+		TxHash:      event.TxHash,
+		Data:        data,
+		Timestamp:   event.Timestamp,
+	})
+}
+
+// NewEventWatcher 创建带持久化检查点的事件监听器
+// watcherID 在同一条链上唯一，重启后使用相同的 watcherID 从上次处理的区块继续。
+func (sm *ServiceManager) NewEventWatcher(chainName, watcherID string) (*chain.EventWatcher, error) {
+	if watcherID == "" {
+		return nil, fmt.Errorf("watcher id is required")
+	}
+
+	client, err := sm.GetChainClient(chainName)
+	if err != nil {
+		return nil, err
+	}
+
+	provider, ok := chain.As[chain.EventWatcherProvider](client)
+	if !ok {
+		return nil, fmt.Errorf("chain %s does not support event watchers", chainName)
+	}
+
+	watcher := provider.NewEventWatcher()
+	watcher.SetCheckpointStore(sm.checkpoints, watcherID)
+	return watcher, nil
 }
 
-// GetTransaction 获取交易信息
-func (sm *ServiceManager) GetTransaction(chainName, txHash string) (*types.Transaction, error) {
+// getNonceReserver 获取支持nonce管理的链客户端
+func (sm *ServiceManager) getNonceReserver(chainName string) (chain.NonceReserver, error) {
 	client, err := sm.GetChainClient(chainName)
 	if err != nil {
 		return nil, err
 	}
 
-	return client.GetTransaction(txHash)
+	reserver, ok := chain.As[chain.NonceReserver](client)
+	if !ok {
+		return nil, fmt.Errorf("chain %s does not use account nonces", chainName)
+	}
+
+	return reserver, nil
+}
+
+// ReserveNonce 为地址预留nonce
+func (sm *ServiceManager) ReserveNonce(ctx context.Context, chainName, address string) (_ uint64, err error) {
+	ctx, span := startSpan(ctx, "ReserveNonce", chainName)
+	defer func() { telemetry.EndSpan(span, err) }()
+
+	reserver, err := sm.getNonceReserver(chainName)
+	if err != nil {
+		return 0, err
+	}
+
+	return reserver.ReserveNonce(ctx, address)
+}
+
+// ReleaseNonce 释放未使用的预留nonce
+func (sm *ServiceManager) ReleaseNonce(ctx context.Context, chainName, address string, n uint64) (err error) {
+	ctx, span := startSpan(ctx, "ReleaseNonce", chainName)
+	defer func() { telemetry.EndSpan(span, err) }()
+
+	reserver, err := sm.getNonceReserver(chainName)
+	if err != nil {
+		return err
+	}
+
+	return reserver.ReleaseNonce(ctx, address, n)
+}
+
+// GetNonceStatus 获取地址的nonce分配状态
+func (sm *ServiceManager) GetNonceStatus(ctx context.Context, chainName, address string) (_ *types.NonceStatus, err error) {
+	ctx, span := startSpan(ctx, "GetNonceStatus", chainName)
+	defer func() { telemetry.EndSpan(span, err) }()
+
+	reserver, err := sm.getNonceReserver(chainName)
+	if err != nil {
+		return nil, err
+	}
+
+	return reserver.NonceStatus(ctx, address)
+}
+
+// GetTransaction 获取交易信息，并按已注册ABI和签名库解码输入数据和日志
+func (sm *ServiceManager) GetTransaction(ctx context.Context, chainName, txHash string) (_ *types.Transaction, err error) {
+	ctx, span := startSpan(ctx, "GetTransaction", chainName)
+	defer func() { telemetry.EndSpan(span, err) }()
+
+	client, err := sm.GetChainClient(chainName)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := client.GetTransaction(ctx, txHash)
+	if err != nil {
+		return nil, err
+	}
+
+	sm.decoder.DecodeTransaction(tx)
+	return tx, nil
 }
 
 // EstimateGas 预估Gas
-func (sm *ServiceManager) EstimateGas(chainName string, req *types.TransactionRequest) (uint64, error) {
+func (sm *ServiceManager) EstimateGas(ctx context.Context, chainName string, req *types.TransactionRequest) (_ uint64, err error) {
+	ctx, span := startSpan(ctx, "EstimateGas", chainName)
+	defer func() { telemetry.EndSpan(span, err) }()
+
 	client, err := sm.GetChainClient(chainName)
 	if err != nil {
 		return 0, err
 	}
 
-	return client.EstimateGas(req)
+	return client.EstimateGas(ctx, req)
+}
+
+// SimulateTransaction 模拟执行交易，并按已注册ABI和签名库解码输入数据、日志和回滚原因
+func (sm *ServiceManager) SimulateTransaction(ctx context.Context, chainName string, req *types.SimulationRequest) (_ *types.SimulationResult, err error) {
+	ctx, span := startSpan(ctx, "SimulateTransaction", chainName)
+	defer func() { telemetry.EndSpan(span, err) }()
+
+	client, err := sm.GetChainClient(chainName)
+	if err != nil {
+		return nil, err
+	}
+
+	simulator, ok := chain.As[chain.TransactionSimulator](client)
+	if !ok {
+		return nil, fmt.Errorf("chain %s does not support transaction simulation", chainName)
+	}
+
+	result, err := simulator.SimulateTransaction(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	sm.decoder.DecodeSimulation(&req.TransactionRequest, result)
+	return result, nil
 }
 
 // CallContract 调用合约
-func (sm *ServiceManager) CallContract(chainName string, req *types.ContractCallRequest) ([]byte, error) {
+func (sm *ServiceManager) CallContract(ctx context.Context, chainName string, req *types.ContractCallRequest) (_ []byte, err error) {
+	ctx, span := startSpan(ctx, "CallContract", chainName)
+	defer func() { telemetry.EndSpan(span, err) }()
+
 	client, err := sm.GetChainClient(chainName)
 	if err != nil {
 		return nil, err
 	}
 
-	return client.CallContract(req)
+	return client.CallContract(ctx, req)
+}
+
+// getTokenProvider 获取支持ERC-20代币的链客户端
+func (sm *ServiceManager) getTokenProvider(chainName string) (chain.TokenBalanceProvider, error) {
+	client, err := sm.GetChainClient(chainName)
+	if err != nil {
+		return nil, err
+	}
+
+	provider, ok := chain.As[chain.TokenBalanceProvider](client)
+	if !ok {
+		return nil, fmt.Errorf("chain %s does not support tokens", chainName)
+	}
+
+	return provider, nil
+}
+
+// GetTokenBalance 获取代币余额及符号、精度
+func (sm *ServiceManager) GetTokenBalance(ctx context.Context, chainName, tokenAddress, holderAddress string) (_ *types.TokenBalance, err error) {
+	ctx, span := startSpan(ctx, "GetTokenBalance", chainName)
+	defer func() { telemetry.EndSpan(span, err) }()
+
+	provider, err := sm.getTokenProvider(chainName)
+	if err != nil {
+		return nil, err
+	}
+
+	return provider.GetTokenBalance(ctx, tokenAddress, holderAddress)
+}
+
+// GetTokenBalances 批量获取代币余额，tokenAddresses 为空时使用链配置的默认代币
+func (sm *ServiceManager) GetTokenBalances(ctx context.Context, chainName, holderAddress string, tokenAddresses []string) (_ []types.TokenBalance, err error) {
+	ctx, span := startSpan(ctx, "GetTokenBalances", chainName)
+	defer func() { telemetry.EndSpan(span, err) }()
+
+	provider, err := sm.getTokenProvider(chainName)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(tokenAddresses) == 0 {
+		sm.mu.RLock()
+		tokenAddresses = sm.chainConfigs[chainName].Tokens
+		sm.mu.RUnlock()
+	}
+	if len(tokenAddresses) == 0 {
+		return []types.TokenBalance{}, nil
+	}
+
+	return provider.GetTokenBalances(ctx, holderAddress, tokenAddresses)
+}
+
+// getNFTProvider 获取支持NFT的链客户端
+func (sm *ServiceManager) getNFTProvider(chainName string) (chain.NFTProvider, error) {
+	client, err := sm.GetChainClient(chainName)
+	if err != nil {
+		return nil, err
+	}
+
+	provider, ok := chain.As[chain.NFTProvider](client)
+	if !ok {
+		return nil, fmt.Errorf("chain %s does not support NFTs", chainName)
+	}
+
+	return provider, nil
+}
+
+// GetNFTContract 检测合约的NFT标准
+func (sm *ServiceManager) GetNFTContract(ctx context.Context, chainName, contractAddress string) (_ *types.NFTContract, err error) {
+	ctx, span := startSpan(ctx, "GetNFTContract", chainName)
+	defer func() { telemetry.EndSpan(span, err) }()
+
+	provider, err := sm.getNFTProvider(chainName)
+	if err != nil {
+		return nil, err
+	}
+
+	return provider.GetNFTContract(ctx, contractAddress)
+}
+
+// GetNFT 获取NFT的元数据地址和持有信息
+func (sm *ServiceManager) GetNFT(ctx context.Context, chainName, contractAddress, tokenID, holderAddress string) (_ *types.NFTToken, err error) {
+	ctx, span := startSpan(ctx, "GetNFT", chainName)
+	defer func() { telemetry.EndSpan(span, err) }()
+
+	provider, err := sm.getNFTProvider(chainName)
+	if err != nil {
+		return nil, err
+	}
+
+	return provider.GetNFT(ctx, contractAddress, tokenID, holderAddress)
+}
+
+// GetNFTs 获取地址持有的NFT，contractAddresses 为空时使用链配置的默认NFT合约
+func (sm *ServiceManager) GetNFTs(ctx context.Context, chainName, holderAddress string, contractAddresses []string, fromBlock uint64) (_ []types.NFTToken, err error) {
+	ctx, span := startSpan(ctx, "GetNFTs", chainName)
+	defer func() { telemetry.EndSpan(span, err) }()
+
+	provider, err := sm.getNFTProvider(chainName)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(contractAddresses) == 0 {
+		sm.mu.RLock()
+		contractAddresses = sm.chainConfigs[chainName].NFTContracts
+		sm.mu.RUnlock()
+	}
+
+	return provider.GetNFTs(ctx, holderAddress, contractAddresses, fromBlock)
+}
+
+// EncodeNFTTransfer 生成NFT转账的调用数据和交易请求
+func (sm *ServiceManager) EncodeNFTTransfer(ctx context.Context, chainName string, req *types.NFTTransferRequest) (_ *types.ContractEncodeResponse, err error) {
+	ctx, span := startSpan(ctx, "EncodeNFTTransfer", chainName)
+	defer func() { telemetry.EndSpan(span, err) }()
+
+	provider, err := sm.getNFTProvider(chainName)
+	if err != nil {
+		return nil, err
+	}
+
+	return provider.EncodeNFTTransfer(ctx, req)
+}
+
+// CallContractMethod 按ABI编码调用数据、调用合约并解码返回值
+// 合约回滚不作为错误返回，回滚原因和自定义错误放在响应的 Revert 中。
+func (sm *ServiceManager) CallContractMethod(ctx context.Context, chainName string, req *types.ContractCallRequest) (_ *types.ContractCallResponse, err error) {
+	ctx, span := startSpan(ctx, "CallContractMethod", chainName)
+	defer func() { telemetry.EndSpan(span, err) }()
+
+	client, err := sm.GetChainClient(chainName)
+	if err != nil {
+		return nil, err
+	}
+
+	contractABI, err := sm.abis.Resolve(req.ABI, req.ABIName)
+	if err != nil {
+		return nil, err
+	}
+	method, err := contract.FindMethod(contractABI, req.Method)
+	if err != nil {
+		return nil, err
+	}
+	data, err := contract.EncodeCall(method, req.Args)
+	if err != nil {
+		return nil, err
+	}
+
+	call := *req
+	call.Data = data
+	result, err := client.CallContract(ctx, &call)
+	if err != nil {
+		revertData, reverted := chain.RevertData(err)
+		if !reverted {
+			return nil, err
+		}
+		revert := contract.DecodeRevert(contractABI, revertData)
+		message := "execution reverted"
+		if revert.Reason != "" {
+			message += ": " + revert.Reason
+		}
+		return &types.ContractCallResponse{Error: message, Revert: revert}, nil
+	}
+
+	outputs, err := contract.DecodeOutputs(method, result)
+	if err != nil {
+		return nil, err
+	}
+	return &types.ContractCallResponse{Result: result, Outputs: outputs}, nil
+}
+
+// EncodeContractCall 按ABI编码调用数据，并生成可提交到发送交易接口的请求
+func (sm *ServiceManager) EncodeContractCall(chainName string, req *types.ContractCallRequest) (*types.ContractEncodeResponse, error) {
+	client, err := sm.GetChainClient(chainName)
+	if err != nil {
+		return nil, err
+	}
+
+	contractABI, err := sm.abis.Resolve(req.ABI, req.ABIName)
+	if err != nil {
+		return nil, err
+	}
+	method, err := contract.FindMethod(contractABI, req.Method)
+	if err != nil {
+		return nil, err
+	}
+	data, err := contract.EncodeCall(method, req.Args)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := &types.TransactionRequest{
+		To:       req.ContractAddress.Hex(),
+		Value:    req.Value,
+		GasLimit: req.GasLimit,
+		GasPrice: req.GasPrice,
+		Data:     data,
+		ChainID:  client.GetChainID(),
+	}
+	if req.From != (common.Address{}) {
+		tx.From = req.From.Hex()
+	}
+
+	return &types.ContractEncodeResponse{
+		Data:        hexutil.Encode(data),
+		Selector:    hexutil.Encode(method.ID),
+		Signature:   method.Sig,
+		Transaction: tx,
+	}, nil
+}
+
+// RegisterABI 注册合约ABI
+func (sm *ServiceManager) RegisterABI(name string, raw json.RawMessage) error {
+	return sm.abis.Register(name, raw)
+}
+
+// GetABI 获取已注册的合约ABI
+func (sm *ServiceManager) GetABI(name string) (json.RawMessage, error) {
+	_, raw, ok := sm.abis.Get(name)
+	if !ok {
+		return nil, fmt.Errorf("abi %s not registered", name)
+	}
+	return raw, nil
+}
+
+// ListABIs 获取所有已注册的ABI名称
+func (sm *ServiceManager) ListABIs() []string {
+	return sm.abis.Names()
+}
+
+// AddSignatures 向签名库添加方法/事件文本签名，返回规范化后的签名
+func (sm *ServiceManager) AddSignatures(signatures []string) ([]string, error) {
+	added := make([]string, 0, len(signatures))
+	for _, sig := range signatures {
+		normalized, err := sm.signatures.Add(sig)
+		if err != nil {
+			return nil, err
+		}
+		added = append(added, normalized)
+	}
+	return added, nil
+}
+
+// LookupSignatures 按4字节方法选择器或32字节事件主题查询签名
+func (sm *ServiceManager) LookupSignatures(hash string) ([]string, error) {
+	b, err := hexutil.Decode(hash)
+	if err != nil {
+		return nil, fmt.Errorf("invalid selector or topic: %w", err)
+	}
+
+	switch len(b) {
+	case 4:
+		var selector [4]byte
+		copy(selector[:], b)
+		return sm.signatures.Methods(selector), nil
+	case common.HashLength:
+		return sm.signatures.Events(common.BytesToHash(b)), nil
+	default:
+		return nil, fmt.Errorf("expected 4-byte selector or 32-byte topic, got %d bytes", len(b))
+	}
 }
 
 // SubscribeEvents 订阅事件
@@ -214,61 +954,122 @@ func (sm *ServiceManager) UnsubscribeEvents(subscriptionID string) error {
 	return sm.eventMgr.Unsubscribe(subscriptionID)
 }
 
+// AttachEventStream 为流式连接占用订阅，连接结束时调用返回的 release
+func (sm *ServiceManager) AttachEventStream(ctx context.Context, chainName, subscriptionID string) (*event.Subscription, context.Context, func(), error) {
+	return sm.eventMgr.Attach(ctx, chainName, subscriptionID)
+}
+
 // GetEventManager 获取事件管理器
 func (sm *ServiceManager) GetEventManager() *event.EventManager {
 	return sm.eventMgr
 }
 
-// CrossChainTransfer 跨链转账
-func (sm *ServiceManager) CrossChainTransfer(req *types.CrossChainRequest) (string, error) {
-	// 跨链转账实现
-	// 1. 在源链锁定资产
-	// 2. 生成证明
-	// 3. 在目标链解锁资产
-	// 这里返回模拟的转账ID
-	
-	transferID := fmt.Sprintf("crosschain_%s_%d", req.FromChain, time.Now().UnixNano())
-	return transferID, nil
+// CrossChainTransfer 跨链转账，提交源链交易后由跨链引擎继续推进
+func (sm *ServiceManager) CrossChainTransfer(ctx context.Context, req *types.CrossChainRequest) (_ *types.CrossChainStatus, err error) {
+	ctx, span := startSpan(ctx, "CrossChainTransfer", req.FromChain)
+	defer func() { telemetry.EndSpan(span, err) }()
+
+	return sm.bridge.Transfer(ctx, req)
 }
 
 // GetCrossChainStatus 获取跨链状态
-func (sm *ServiceManager) GetCrossChainStatus(transferID string) (*types.CrossChainStatus, error) {
-	// 返回模拟的跨链状态
-	return &types.CrossChainStatus{
-		TransferID: transferID,
-		Status:     "completed",
-		SourceTx:   "0x123...",
-		TargetTx:   "0x456...",
-		Amount:     big.NewInt(1000000000000000000), // 1 ETH
-		CreatedAt:  time.Now().Add(-10 * time.Minute),
-		UpdatedAt:  time.Now().Add(-1 * time.Minute),
-	}, nil
+func (sm *ServiceManager) GetCrossChainStatus(ctx context.Context, transferID string) (*types.CrossChainStatus, error) {
+	return sm.bridge.Get(ctx, transferID)
+}
+
+// ListBridgeRoutes 列出已配置的跨链路由
+func (sm *ServiceManager) ListBridgeRoutes() []bridge.Route {
+	return sm.bridge.Routes()
+}
+
+// GetCacheStats 获取缓存后端和各链读取的命中统计
+func (sm *ServiceManager) GetCacheStats() *types.CacheReport {
+	report := &types.CacheReport{Stats: sm.cacheStats.Snapshot()}
+	if sm.cache != nil {
+		report.Backend = sm.cache.Backend()
+	}
+	return report
 }
 
 // MPC相关方法
 
-// SignMPCTransaction MPC签名交易
-func (sm *ServiceManager) SignMPCTransaction(req *types.MPCTransactionRequest) (*types.MPCTransactionResponse, error) {
-	// MPC签名实现
-	// 1. 收集参与者的签名分片
-	// 2. 组合签名
-	// 3. 返回签名结果
-	
+// SignMPCTransaction 构建交易，由mpc-core对签名哈希做门限签名，校验签名者后广播
+func (sm *ServiceManager) SignMPCTransaction(ctx context.Context, req *types.MPCTransactionRequest) (_ *types.MPCTransactionResponse, err error) {
+	ctx, span := startSpan(ctx, "SignMPCTransaction", req.ChainName)
+	defer func() { telemetry.EndSpan(span, err) }()
+
+	if sm.mpc == nil {
+		return nil, fmt.Errorf("mpc signing is not configured")
+	}
+
+	client, err := sm.GetChainClient(req.ChainName)
+	if err != nil {
+		return nil, err
+	}
+	sender, ok := chain.As[chain.SignerSender](client)
+	if !ok {
+		return nil, fmt.Errorf("chain %s does not support mpc signing", req.ChainName)
+	}
+	if !common.IsHexAddress(req.From) {
+		return nil, fmt.Errorf("invalid from address: %s", req.From)
+	}
+
+	txReq := &types.TransactionRequest{
+		From:     req.From,
+		To:       req.To,
+		Value:    req.Value,
+		Data:     req.Data,
+		GasLimit: req.GasLimit,
+		GasPrice: req.GasPrice,
+		Nonce:    req.Nonce,
+
+		MaxFeePerGas:         req.MaxFeePerGas,
+		MaxPriorityFeePerGas: req.MaxPriorityFeePerGas,
+	}
+	if txReq.GasLimit == 0 {
+		gas, err := client.EstimateGas(ctx, txReq)
+		if err != nil {
+			return nil, err
+		}
+		// 预留20%余量，避免状态变化导致gas不足
+		txReq.GasLimit = gas + gas/5
+	}
+
+	mpcSigner := sm.mpc.SessionSigner(req.SessionID, req.Participants)
+	txHash, err := sender.SendTransactionWithSigner(ctx, txReq, mpcSigner)
+	telemetry.ObserveTxSend(req.ChainName, "mpc", err)
+	if err != nil {
+		return nil, err
+	}
+
+	sm.trackTransaction(ctx, req.ChainName, txHash)
 	return &types.MPCTransactionResponse{
 		SessionID: req.SessionID,
-		Signature: []byte("signed_tx_hash"),
-		Status:    "completed",
+		Signature: mpcSigner.Signature(),
+		TxHash:    txHash,
+		Status:    types.TxStatePending,
 	}, nil
 }
 
 // BroadcastMPCTransaction 广播MPC交易
-func (sm *ServiceManager) BroadcastMPCTransaction(req *types.MPCBroadcastRequest) (string, error) {
-	// 广播MPC交易
+func (sm *ServiceManager) BroadcastMPCTransaction(ctx context.Context, req *types.MPCBroadcastRequest) (_ string, err error) {
+	ctx, span := startSpan(ctx, "BroadcastMPCTransaction", req.ChainName)
+	defer func() { telemetry.EndSpan(span, err) }()
+
+	// 广播MPC交易：用MPC签名组装交易，不经过本地签名器
 	client, err := sm.GetChainClient(req.ChainName)
 	if err != nil {
 		return "", err
 	}
 
+	broadcaster, ok := chain.As[chain.SignedTransactionBroadcaster](client)
+	if !ok {
+		return "", fmt.Errorf("chain %s does not support signature broadcast", req.ChainName)
+	}
+	if req.From == "" {
+		return "", fmt.Errorf("from address is required")
+	}
+
 	txReq := &types.TransactionRequest{
 		From:     req.From,
 		To:       req.To,
@@ -276,7 +1077,81 @@ func (sm *ServiceManager) BroadcastMPCTransaction(req *types.MPCBroadcastRequest
 		Data:     req.Data,
 		GasLimit: req.GasLimit,
 		GasPrice: req.GasPrice,
+		Nonce:    req.Nonce,
+
+		MaxFeePerGas:         req.MaxFeePerGas,
+		MaxPriorityFeePerGas: req.MaxPriorityFeePerGas,
+	}
+
+	txHash, err := broadcaster.BroadcastSignedTransaction(ctx, txReq, req.Signature)
+	telemetry.ObserveTxSend(req.ChainName, "mpc_broadcast", err)
+	if err != nil {
+		return "", err
+	}
+
+	sm.trackTransaction(ctx, req.ChainName, txHash)
+	return txHash, nil
+}
+
+// 比特币相关方法
+
+// getBitcoinClient 获取比特币链客户端
+func (sm *ServiceManager) getBitcoinClient(chainName string) (*chain.BitcoinClient, error) {
+	client, err := sm.GetChainClient(chainName)
+	if err != nil {
+		return nil, err
+	}
+
+	btcClient, ok := chain.As[*chain.BitcoinClient](client)
+	if !ok {
+		return nil, fmt.Errorf("chain %s does not support utxo operations", chainName)
 	}
 
-	return client.SendTransaction(txReq)
-}
\ No newline at end of file
+	return btcClient, nil
+}
+
+// ListUTXOs 获取地址的UTXO列表
+func (sm *ServiceManager) ListUTXOs(ctx context.Context, chainName, address string) (_ []types.UTXO, err error) {
+	ctx, span := startSpan(ctx, "ListUTXOs", chainName)
+	defer func() { telemetry.EndSpan(span, err) }()
+
+	client, err := sm.getBitcoinClient(chainName)
+	if err != nil {
+		return nil, err
+	}
+
+	return client.ListUTXOs(ctx, address)
+}
+
+// BuildPSBT 构建待MPC签名的PSBT
+func (sm *ServiceManager) BuildPSBT(ctx context.Context, chainName string, req *types.PSBTRequest) (_ *types.PSBTResponse, err error) {
+	ctx, span := startSpan(ctx, "BuildPSBT", chainName)
+	defer func() { telemetry.EndSpan(span, err) }()
+
+	client, err := sm.getBitcoinClient(chainName)
+	if err != nil {
+		return nil, err
+	}
+
+	return client.BuildPSBT(ctx, req)
+}
+
+// FinalizePSBT 写入签名并广播PSBT交易
+func (sm *ServiceManager) FinalizePSBT(ctx context.Context, chainName string, req *types.PSBTFinalizeRequest) (_ string, err error) {
+	ctx, span := startSpan(ctx, "FinalizePSBT", chainName)
+	defer func() { telemetry.EndSpan(span, err) }()
+
+	client, err := sm.getBitcoinClient(chainName)
+	if err != nil {
+		return "", err
+	}
+
+	txHash, err := client.FinalizePSBT(ctx, req)
+	telemetry.ObserveTxSend(chainName, "psbt", err)
+	if err != nil {
+		return "", err
+	}
+
+	sm.trackTransaction(ctx, chainName, txHash)
+	return txHash, nil
+}
@@ -0,0 +1,48 @@
+package service
+
+import (
+	"blockchain-middleware/pkg/types"
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+)
+
+// fakeOracle 返回预设结果的手续费估算
+type fakeOracle struct {
+	fees        *types.FeeEstimates
+	feesErr     error
+	gasPrice    *big.Int
+	gasPriceErr error
+}
+
+func (o *fakeOracle) EstimateFees(ctx context.Context) (*types.FeeEstimates, error) {
+	return o.fees, o.feesErr
+}
+
+func (o *fakeOracle) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
+	return o.gasPrice, o.gasPriceErr
+}
+
+func TestStandardGasPrice(t *testing.T) {
+	estimates := &types.FeeEstimates{Standard: types.FeeTier{GasPrice: big.NewInt(12)}}
+	unavailable := errors.New("the method eth_feeHistory does not exist")
+
+	tests := []struct {
+		name   string
+		oracle *fakeOracle
+		want   *big.Int
+	}{
+		{"standard tier", &fakeOracle{fees: estimates, gasPrice: big.NewInt(30)}, big.NewInt(12)},
+		{"estimation fails", &fakeOracle{feesErr: unavailable, gasPrice: big.NewInt(30)}, big.NewInt(30)},
+		{"node unavailable", &fakeOracle{feesErr: unavailable, gasPriceErr: errors.New("connection refused")}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := standardGasPrice(context.Background(), "test", tt.oracle)
+			if (got == nil) != (tt.want == nil) || (got != nil && got.Cmp(tt.want) != 0) {
+				t.Fatalf("standardGasPrice() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,191 @@
+package service
+
+import (
+	"blockchain-middleware/internal/config"
+	"blockchain-middleware/pkg/chain"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"reflect"
+	"time"
+)
+
+// Reload 应用重新加载的配置
+// 热更新的范围是链的启用/停用，以及运行中链的RPC节点、认证信息、请求超时、限流和默认代币/NFT列表；
+// 其他字段的变化只记录日志，重启后生效。单条链失败不影响其他链，所有错误合并返回。
+func (sm *ServiceManager) Reload(cfg *config.Config) error {
+	type chainEntry struct {
+		chainType string
+		cfg       config.ChainConfig
+	}
+	entries := make([]chainEntry, 0, len(cfg.Chains.EVM)+1)
+	for _, c := range cfg.Chains.EVM {
+		entries = append(entries, chainEntry{"evm", c})
+	}
+	entries = append(entries, chainEntry{"bitcoin", cfg.Chains.Bitcoin})
+
+	enabled := make(map[string]bool, len(entries))
+	for _, e := range entries {
+		enabled[e.cfg.Name] = e.cfg.Enabled
+	}
+
+	sm.mu.RLock()
+	running := make(map[string]config.ChainConfig, len(sm.chainConfigs))
+	for name, c := range sm.chainConfigs {
+		running[name] = c
+	}
+	sm.mu.RUnlock()
+
+	for name := range running {
+		if !enabled[name] {
+			sm.stopChainClient(name)
+		}
+	}
+
+	var errs []error
+	for _, e := range entries {
+		if !e.cfg.Enabled {
+			continue
+		}
+		current, ok := running[e.cfg.Name]
+		if ok {
+			if err := sm.reloadChain(current, e.cfg); err != nil {
+				errs = append(errs, err)
+			}
+			continue
+		}
+
+		if err := sm.startChainClient(e.chainType, e.cfg); err != nil {
+			errs = append(errs, fmt.Errorf("failed to start %s client: %w", e.cfg.Name, err))
+			continue
+		}
+		if sm.indexer != nil {
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			err := sm.indexer.StartChain(ctx, e.cfg)
+			cancel()
+			if err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	sm.logRestartRequired(cfg)
+	sm.storeApplied(cfg)
+	return errors.Join(errs...)
+}
+
+// storeApplied 把实际生效的链配置记入 sm.config，供下次热更新比较
+// 需要重启的配置段保持原值，链配置取运行中的值，没有运行的链记为停用。
+func (sm *ServiceManager) storeApplied(cfg *config.Config) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	applied := *sm.config
+	applied.Chains.EVM = make([]config.ChainConfig, len(cfg.Chains.EVM))
+	for i, c := range cfg.Chains.EVM {
+		applied.Chains.EVM[i] = sm.appliedChain(c)
+	}
+	applied.Chains.Bitcoin = sm.appliedChain(cfg.Chains.Bitcoin)
+	sm.config = &applied
+}
+
+// appliedChain 链实际生效的配置，调用方持有 sm.mu
+func (sm *ServiceManager) appliedChain(c config.ChainConfig) config.ChainConfig {
+	if running, ok := sm.chainConfigs[c.Name]; ok {
+		return running
+	}
+	c.Enabled = false
+	return c
+}
+
+// reloadChain 把可热更新的字段应用到运行中的链，其余字段保持启动时的值
+func (sm *ServiceManager) reloadChain(current, next config.ChainConfig) error {
+	updated := current
+	updated.RPCURL = next.RPCURL
+	updated.RPCURLs = next.RPCURLs
+	updated.RPCUser = next.RPCUser
+	updated.RPCPassword = next.RPCPassword
+	updated.RequestTimeout = next.RequestTimeout
+	updated.MaxBlockLag = next.MaxBlockLag
+	updated.RPCRateLimit = next.RPCRateLimit
+	updated.Tokens = next.Tokens
+	updated.NFTContracts = next.NFTContracts
+
+	if !reflect.DeepEqual(updated, next) {
+		log.Printf("%s config has changes that require a restart, only rpc settings and token lists were reloaded", current.Name)
+	}
+	if reflect.DeepEqual(updated, current) {
+		return nil
+	}
+
+	client, err := sm.GetChainClient(current.Name)
+	if err != nil {
+		return err
+	}
+	reconfigurable, ok := chain.As[chain.Reconfigurable](client)
+	if !ok {
+		return fmt.Errorf("%s client does not support reloading rpc settings", current.Name)
+	}
+	if err := reconfigurable.Reconfigure(updated); err != nil {
+		return fmt.Errorf("failed to reconfigure %s client: %w", current.Name, err)
+	}
+
+	sm.mu.Lock()
+	if _, exists := sm.clients[current.Name]; exists {
+		sm.chainConfigs[current.Name] = updated
+	}
+	sm.mu.Unlock()
+
+	log.Printf("Reloaded %s config (%d rpc endpoints, rate limit %g/s)", current.Name, len(updated.RPCURLs)+1, updated.RPCRateLimit)
+	return nil
+}
+
+// stopChainClient 停用单条链：停止转账索引、取消日志订阅并关闭客户端
+func (sm *ServiceManager) stopChainClient(name string) {
+	if sm.indexer != nil {
+		sm.indexer.StopChain(name)
+	}
+	if n := sm.eventMgr.UnsubscribeChain(name); n > 0 {
+		log.Printf("Cancelled %d subscriptions on disabled chain %s", n, name)
+	}
+
+	sm.mu.Lock()
+	client, exists := sm.clients[name]
+	delete(sm.clients, name)
+	delete(sm.chainConfigs, name)
+	sm.mu.Unlock()
+
+	if !exists {
+		return
+	}
+	if err := client.Close(); err != nil {
+		log.Printf("Error closing %s client: %v", name, err)
+	}
+	log.Printf("%s client stopped", name)
+}
+
+// logRestartRequired 记录需要重启才能生效的配置段
+func (sm *ServiceManager) logRestartRequired(cfg *config.Config) {
+	sections := []struct {
+		name     string
+		old, new interface{}
+	}{
+		{"environment", sm.config.Environment, cfg.Environment},
+		{"server", sm.config.Server, cfg.Server},
+		{"database", sm.config.Database, cfg.Database},
+		{"cache", sm.config.Cache, cfg.Cache},
+		{"signer", sm.config.Signer, cfg.Signer},
+		{"mpc", sm.config.MPC, cfg.MPC},
+		{"tracker", sm.config.Tracker, cfg.Tracker},
+		{"indexer", sm.config.Indexer, cfg.Indexer},
+		{"bridge", sm.config.Bridge, cfg.Bridge},
+		{"contracts", sm.config.Contracts, cfg.Contracts},
+		{"telemetry", sm.config.Telemetry, cfg.Telemetry},
+	}
+	for _, s := range sections {
+		if !reflect.DeepEqual(s.old, s.new) {
+			log.Printf("Config section %s changed, restart required to apply", s.name)
+		}
+	}
+}
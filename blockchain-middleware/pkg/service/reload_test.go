@@ -0,0 +1,207 @@
+package service
+
+import (
+	"blockchain-middleware/internal/config"
+	"blockchain-middleware/pkg/chain"
+	"blockchain-middleware/pkg/event"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeChainClient 记录热更新和关闭的链客户端
+type fakeChainClient struct {
+	chain.ChainClient
+
+	reconfigured []config.ChainConfig
+	reconfigErr  error
+	closed       bool
+}
+
+func (c *fakeChainClient) Reconfigure(cfg config.ChainConfig) error {
+	c.reconfigured = append(c.reconfigured, cfg)
+	return c.reconfigErr
+}
+
+func (c *fakeChainClient) Close() error {
+	c.closed = true
+	return nil
+}
+
+// staticChainClient 不支持热更新的链客户端
+type staticChainClient struct {
+	chain.ChainClient
+}
+
+// newReloadManager 运行着 running 中各条链的服务管理器，比特币链未启用
+func newReloadManager(running map[string]chain.ChainClient) (*ServiceManager, *config.Config) {
+	cfg := &config.Config{}
+	sm := &ServiceManager{
+		config:       cfg,
+		clients:      make(map[string]chain.ChainClient),
+		chainConfigs: make(map[string]config.ChainConfig),
+	}
+	sm.eventMgr = event.NewEventManager(sm.GetChainClient)
+	for name, client := range running {
+		chainCfg := config.ChainConfig{
+			Name:           name,
+			Enabled:        true,
+			RPCURL:         "https://" + name + ".example.com",
+			RequestTimeout: 15 * time.Second,
+			ChainID:        int64(len(cfg.Chains.EVM) + 1),
+			Confirmations:  12,
+		}
+		cfg.Chains.EVM = append(cfg.Chains.EVM, chainCfg)
+		sm.clients[name] = client
+		sm.chainConfigs[name] = chainCfg
+	}
+	cfg.Chains.Bitcoin = config.ChainConfig{Name: "bitcoin"}
+	return sm, cfg
+}
+
+// nextConfig 复制配置，修改 name 链后返回
+func nextConfig(cfg *config.Config, name string, modify func(c *config.ChainConfig)) *config.Config {
+	next := *cfg
+	next.Chains.EVM = append([]config.ChainConfig(nil), cfg.Chains.EVM...)
+	for i := range next.Chains.EVM {
+		if next.Chains.EVM[i].Name == name {
+			modify(&next.Chains.EVM[i])
+		}
+	}
+	return &next
+}
+
+func TestReloadAppliesRPCSettings(t *testing.T) {
+	client := &fakeChainClient{}
+	sm, cfg := newReloadManager(map[string]chain.ChainClient{"ethereum": client})
+
+	next := nextConfig(cfg, "ethereum", func(c *config.ChainConfig) {
+		c.RPCURL = "https://private.example.com"
+		c.RPCURLs = []string{"https://fallback.example.com"}
+		c.RPCRateLimit = 20
+		c.Tokens = []string{"0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"}
+		// 需要重启才能生效
+		c.ChainID = 5
+		c.Confirmations = 3
+	})
+	if err := sm.Reload(next); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(client.reconfigured) != 1 {
+		t.Fatalf("Reconfigure() called %d times", len(client.reconfigured))
+	}
+	got := client.reconfigured[0]
+	if got.RPCURL != "https://private.example.com" || len(got.RPCURLs) != 1 || got.RPCRateLimit != 20 || len(got.Tokens) != 1 {
+		t.Errorf("reconfigured = %+v", got)
+	}
+	if got.ChainID != 1 || got.Confirmations != 12 {
+		t.Errorf("restart-only fields were reloaded: chain id %d, confirmations %d", got.ChainID, got.Confirmations)
+	}
+	if _, running, _ := sm.getChainWithConfig("ethereum"); running.RPCURL != got.RPCURL || running.ChainID != 1 {
+		t.Errorf("running config = %+v", running)
+	}
+
+	// 只有需要重启的字段变化时不重新配置客户端
+	if err := sm.Reload(nextConfig(next, "ethereum", func(c *config.ChainConfig) { c.Confirmations = 6 })); err != nil {
+		t.Fatal(err)
+	}
+	if len(client.reconfigured) != 1 {
+		t.Errorf("Reconfigure() called %d times without rpc changes", len(client.reconfigured))
+	}
+}
+
+func TestReloadStoresAppliedConfig(t *testing.T) {
+	ethereum, polygon := &fakeChainClient{}, &fakeChainClient{}
+	sm, cfg := newReloadManager(map[string]chain.ChainClient{"ethereum": ethereum, "polygon": polygon})
+	cfg.Server.Port = 8080
+
+	next := nextConfig(cfg, "ethereum", func(c *config.ChainConfig) {
+		c.RPCURL = "https://private.example.com"
+		c.ChainID = 5
+	})
+	next = nextConfig(next, "polygon", func(c *config.ChainConfig) { c.Enabled = false })
+	next.Server.Port = 9090
+	if err := sm.Reload(next); err != nil {
+		t.Fatal(err)
+	}
+
+	// 只记录热更新生效的字段，需要重启的字段和配置段保持原值
+	applied := sm.config
+	if applied == cfg || applied.Server.Port != 8080 {
+		t.Errorf("server port = %d", applied.Server.Port)
+	}
+	_, running, _ := sm.getChainWithConfig("ethereum")
+	for _, got := range applied.Chains.EVM {
+		switch {
+		case got.Name == "ethereum" && (!reflect.DeepEqual(got, running) || got.RPCURL != "https://private.example.com"):
+			t.Errorf("ethereum = %+v", got)
+		case got.Name == "polygon" && got.Enabled:
+			t.Errorf("polygon = %+v", got)
+		}
+	}
+	if applied.Chains.Bitcoin.Enabled {
+		t.Errorf("bitcoin = %+v", applied.Chains.Bitcoin)
+	}
+	for _, c := range cfg.Chains.EVM {
+		if c.Name == "ethereum" && c.RPCURL != "https://ethereum.example.com" {
+			t.Errorf("startup config was modified: %+v", c)
+		}
+	}
+}
+
+func TestReloadDisablesChain(t *testing.T) {
+	ethereum, polygon := &fakeChainClient{}, &fakeChainClient{}
+	sm, cfg := newReloadManager(map[string]chain.ChainClient{"ethereum": ethereum, "polygon": polygon})
+
+	next := nextConfig(cfg, "polygon", func(c *config.ChainConfig) { c.Enabled = false })
+	if err := sm.Reload(next); err != nil {
+		t.Fatal(err)
+	}
+	if !polygon.closed || ethereum.closed {
+		t.Errorf("closed: polygon %v, ethereum %v", polygon.closed, ethereum.closed)
+	}
+	if _, err := sm.GetChainClient("polygon"); err == nil {
+		t.Error("disabled chain is still available")
+	}
+	if _, err := sm.GetChainClient("ethereum"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestReloadErrors(t *testing.T) {
+	failing := &fakeChainClient{reconfigErr: errors.New("no healthy rpc endpoint")}
+	working := &fakeChainClient{}
+	sm, cfg := newReloadManager(map[string]chain.ChainClient{
+		"ethereum": &staticChainClient{},
+		"polygon":  failing,
+		"bsc":      working,
+	})
+
+	next := cfg
+	for _, name := range []string{"ethereum", "polygon", "bsc"} {
+		next = nextConfig(next, name, func(c *config.ChainConfig) { c.RPCURL = "https://new.example.com" })
+	}
+	err := sm.Reload(next)
+	if err == nil {
+		t.Fatal("Reload() succeeded")
+	}
+	for _, want := range []string{
+		"ethereum client does not support reloading rpc settings",
+		"failed to reconfigure polygon client: no healthy rpc endpoint",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Reload() = %v, want %q", err, want)
+		}
+	}
+
+	// 单条链失败不影响其他链，失败的链保留原配置
+	if _, running, _ := sm.getChainWithConfig("bsc"); running.RPCURL != "https://new.example.com" {
+		t.Errorf("bsc rpc url = %s", running.RPCURL)
+	}
+	if _, running, _ := sm.getChainWithConfig("polygon"); running.RPCURL != "https://polygon.example.com" {
+		t.Errorf("polygon rpc url = %s", running.RPCURL)
+	}
+}
@@ -0,0 +1,100 @@
+package service
+
+import (
+	"blockchain-middleware/pkg/chain"
+	"blockchain-middleware/pkg/telemetry"
+	"context"
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	headBlockDesc = prometheus.NewDesc("blockchain_head_block",
+		"Latest block reported by each RPC provider at its last health check.",
+		[]string{"chain", "provider"}, nil)
+	headLagDesc = prometheus.NewDesc("blockchain_head_lag_blocks",
+		"Blocks each RPC provider is behind the highest head in its pool.",
+		[]string{"chain", "provider"}, nil)
+	providerHealthyDesc = prometheus.NewDesc("blockchain_rpc_provider_healthy",
+		"Whether the RPC provider is in rotation (1) or ejected (0).",
+		[]string{"chain", "provider"}, nil)
+	subscriptionsDesc = prometheus.NewDesc("blockchain_event_subscriptions",
+		"Active event subscriptions.",
+		[]string{"chain"}, nil)
+	cacheHitsDesc = prometheus.NewDesc("blockchain_cache_hits_total",
+		"Chain reads served from the cache.",
+		[]string{"chain", "kind"}, nil)
+	cacheMissesDesc = prometheus.NewDesc("blockchain_cache_misses_total",
+		"Chain reads that missed the cache.",
+		[]string{"chain", "kind"}, nil)
+	cacheErrorsDesc = prometheus.NewDesc("blockchain_cache_errors_total",
+		"Cache reads or writes that failed and fell back to the node.",
+		[]string{"chain", "kind"}, nil)
+)
+
+// metricsCollector 抓取时从运行中的服务读取的指标：节点区块高度、订阅数量和缓存命中统计
+// 每次抓取都按当前启用的链生成，停用的链不会留下过期的序列。
+type metricsCollector struct {
+	sm *ServiceManager
+}
+
+// Describe 实现 prometheus.Collector
+func (c metricsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- headBlockDesc
+	ch <- headLagDesc
+	ch <- providerHealthyDesc
+	ch <- subscriptionsDesc
+	ch <- cacheHitsDesc
+	ch <- cacheMissesDesc
+	ch <- cacheErrorsDesc
+}
+
+// Collect 实现 prometheus.Collector
+func (c metricsCollector) Collect(ch chan<- prometheus.Metric) {
+	c.sm.mu.RLock()
+	clients := make(map[string]chain.ChainClient, len(c.sm.clients))
+	for name, client := range c.sm.clients {
+		clients[name] = client
+	}
+	c.sm.mu.RUnlock()
+
+	for name, client := range clients {
+		reporter, ok := chain.As[chain.ProviderStatusReporter](client)
+		if !ok {
+			continue
+		}
+		seen := make(map[string]int)
+		for _, p := range reporter.ProviderStatus() {
+			// 同一主机上的多个节点（如不同API密钥）脱敏后地址相同，加序号区分
+			provider := p.URL
+			if seen[p.URL]++; seen[p.URL] > 1 {
+				provider = fmt.Sprintf("%s#%d", p.URL, seen[p.URL])
+			}
+			healthy := 0.0
+			if p.Healthy {
+				healthy = 1
+			}
+			ch <- prometheus.MustNewConstMetric(headBlockDesc, prometheus.GaugeValue, float64(p.HeadBlock), name, provider)
+			ch <- prometheus.MustNewConstMetric(headLagDesc, prometheus.GaugeValue, float64(p.BlockLag), name, provider)
+			ch <- prometheus.MustNewConstMetric(providerHealthyDesc, prometheus.GaugeValue, healthy, name, provider)
+		}
+	}
+
+	counts := c.sm.eventMgr.SubscriptionCounts()
+	for name := range clients {
+		ch <- prometheus.MustNewConstMetric(subscriptionsDesc, prometheus.GaugeValue, float64(counts[name]), name)
+	}
+
+	for _, s := range c.sm.cacheStats.Snapshot() {
+		ch <- prometheus.MustNewConstMetric(cacheHitsDesc, prometheus.CounterValue, float64(s.Hits), s.Chain, s.Kind)
+		ch <- prometheus.MustNewConstMetric(cacheMissesDesc, prometheus.CounterValue, float64(s.Misses), s.Chain, s.Kind)
+		ch <- prometheus.MustNewConstMetric(cacheErrorsDesc, prometheus.CounterValue, float64(s.Errors), s.Chain, s.Kind)
+	}
+}
+
+// startSpan 为 ServiceManager 的方法创建span，调用方在返回时用 telemetry.EndSpan 结束
+func startSpan(ctx context.Context, method, chainName string) (context.Context, trace.Span) {
+	return telemetry.StartSpan(ctx, "ServiceManager."+method, chainName)
+}
@@ -0,0 +1,140 @@
+package signer
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// bitcoinNets 解析比特币地址时尝试的网络
+var bitcoinNets = []*chaincfg.Params{
+	&chaincfg.MainNetParams,
+	&chaincfg.TestNet3Params,
+	&chaincfg.SigNetParams,
+	&chaincfg.RegressionNetParams,
+}
+
+// KeystoreSigner 基于加密keystore文件的签名器
+// 启动时用口令解密目录下的全部keystore文件，按EVM地址和比特币公钥哈希索引密钥。
+type KeystoreSigner struct {
+	evmKeys     map[common.Address]*ecdsa.PrivateKey
+	bitcoinKeys map[string]*ecdsa.PrivateKey // hash160(压缩公钥) hex -> 私钥
+}
+
+// NewKeystoreSigner 加载keystore目录
+func NewKeystoreSigner(dir, password string) (*KeystoreSigner, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("keystore directory not configured")
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read keystore directory: %w", err)
+	}
+
+	s := &KeystoreSigner{
+		evmKeys:     make(map[common.Address]*ecdsa.PrivateKey),
+		bitcoinKeys: make(map[string]*ecdsa.PrivateKey),
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+
+		keyJSON, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read keystore file %s: %w", entry.Name(), err)
+		}
+		key, err := keystore.DecryptKey(keyJSON, password)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt keystore file %s: %w", entry.Name(), err)
+		}
+
+		s.add(key.PrivateKey)
+	}
+
+	if len(s.evmKeys) == 0 {
+		return nil, fmt.Errorf("no keys found in keystore directory %s", dir)
+	}
+	return s, nil
+}
+
+// NewPrivateKeySigner 使用十六进制明文私钥创建签名器，仅用于本地开发和测试网
+func NewPrivateKeySigner(hexKeys []string) (*KeystoreSigner, error) {
+	if len(hexKeys) == 0 {
+		return nil, fmt.Errorf("no private keys configured")
+	}
+
+	s := &KeystoreSigner{
+		evmKeys:     make(map[common.Address]*ecdsa.PrivateKey),
+		bitcoinKeys: make(map[string]*ecdsa.PrivateKey),
+	}
+	for i, hexKey := range hexKeys {
+		key, err := crypto.HexToECDSA(strings.TrimPrefix(hexKey, "0x"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid private key #%d: %w", i+1, err)
+		}
+		s.add(key)
+	}
+	return s, nil
+}
+
+// add 按EVM地址和比特币公钥哈希索引密钥
+func (s *KeystoreSigner) add(key *ecdsa.PrivateKey) {
+	s.evmKeys[crypto.PubkeyToAddress(key.PublicKey)] = key
+	pubKeyHash := btcutil.Hash160(crypto.CompressPubkey(&key.PublicKey))
+	s.bitcoinKeys[fmt.Sprintf("%x", pubKeyHash)] = key
+}
+
+// SignHash 签名哈希
+func (s *KeystoreSigner) SignHash(ctx context.Context, address string, hash []byte) ([]byte, error) {
+	if len(hash) != 32 {
+		return nil, fmt.Errorf("hash must be 32 bytes, got %d", len(hash))
+	}
+
+	key, err := s.lookup(address)
+	if err != nil {
+		return nil, err
+	}
+
+	sig, err := crypto.Sign(hash, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign hash: %w", err)
+	}
+	return sig, nil
+}
+
+// lookup 按EVM地址或比特币P2PKH/P2WPKH地址查找密钥
+func (s *KeystoreSigner) lookup(address string) (*ecdsa.PrivateKey, error) {
+	if common.IsHexAddress(address) {
+		if key, ok := s.evmKeys[common.HexToAddress(address)]; ok {
+			return key, nil
+		}
+		return nil, fmt.Errorf("%w %s", ErrUnknownKey, address)
+	}
+
+	for _, params := range bitcoinNets {
+		addr, err := btcutil.DecodeAddress(address, params)
+		if err != nil || !addr.IsForNet(params) {
+			continue
+		}
+		switch addr.(type) {
+		case *btcutil.AddressPubKeyHash, *btcutil.AddressWitnessPubKeyHash:
+			if key, ok := s.bitcoinKeys[fmt.Sprintf("%x", addr.ScriptAddress())]; ok {
+				return key, nil
+			}
+		}
+		return nil, fmt.Errorf("%w %s", ErrUnknownKey, address)
+	}
+
+	return nil, fmt.Errorf("invalid signing address: %s", address)
+}
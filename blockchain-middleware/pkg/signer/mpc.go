@@ -0,0 +1,216 @@
+package signer
+
+import (
+	"blockchain-middleware/internal/config"
+	"blockchain-middleware/pkg/telemetry"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// secp256k1N secp256k1曲线阶，用于把高S签名规范化为低S
+var (
+	secp256k1N     = crypto.S256().Params().N
+	secp256k1HalfN = new(big.Int).Rsh(secp256k1N, 1)
+)
+
+// MPCClient mpc-core 门限签名服务客户端
+type MPCClient struct {
+	baseURL      string
+	participants []string
+	httpClient   *http.Client
+}
+
+// NewMPCClient 创建mpc-core客户端，未配置地址时返回nil
+func NewMPCClient(cfg config.MPCConfig) *MPCClient {
+	if cfg.URL == "" {
+		return nil
+	}
+	return &MPCClient{
+		baseURL:      strings.TrimRight(cfg.URL, "/"),
+		participants: cfg.Participants,
+		httpClient:   &http.Client{Timeout: cfg.Timeout},
+	}
+}
+
+// mpcSignRequest mpc-core 签名请求，message_hash 按字节数组编码
+type mpcSignRequest struct {
+	SessionID    string            `json:"session_id"`
+	MessageHash  []int             `json:"message_hash"`
+	Participants []string          `json:"participants"`
+	Metadata     map[string]string `json:"metadata"`
+}
+
+// mpcSignResponse mpc-core 签名响应
+type mpcSignResponse struct {
+	SessionID string `json:"session_id"`
+	Signature *struct {
+		Bytes      []int  `json:"bytes"` // 64字节 [R || S]
+		RecoveryID *uint8 `json:"recovery_id"`
+		CurveType  string `json:"curve_type"`
+	} `json:"signature"`
+	Status string `json:"status"`
+	Error  string `json:"error"`
+}
+
+// Sign 请求密钥会话对32字节哈希做门限签名，返回 [R || S] 和恢复ID（mpc-core 未返回时为nil）
+func (c *MPCClient) Sign(ctx context.Context, sessionID string, participants []string, hash []byte, metadata map[string]string) ([]byte, *uint8, error) {
+	if sessionID == "" {
+		return nil, nil, fmt.Errorf("mpc session_id is required")
+	}
+	if len(participants) == 0 {
+		participants = c.participants
+	}
+	if len(participants) == 0 {
+		return nil, nil, fmt.Errorf("mpc participants are required")
+	}
+
+	messageHash := make([]int, len(hash))
+	for i, b := range hash {
+		messageHash[i] = int(b)
+	}
+	if metadata == nil {
+		metadata = map[string]string{}
+	}
+	body, err := json.Marshal(mpcSignRequest{
+		SessionID:    sessionID,
+		MessageHash:  messageHash,
+		Participants: participants,
+		Metadata:     metadata,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to encode mpc sign request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/api/v1/sign", bytes.NewReader(body))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create mpc sign request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	// 把当前请求的 trace context 传给mpc-core，签名会话与发起交易的请求关联到同一条链路
+	telemetry.InjectHeaders(ctx, req.Header)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to request mpc signature: %w", err)
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read mpc sign response: %w", err)
+	}
+	var result mpcSignResponse
+	if err := json.Unmarshal(raw, &result); err != nil {
+		if resp.StatusCode != http.StatusOK {
+			return nil, nil, fmt.Errorf("mpc-core returned %d: %s", resp.StatusCode, strings.TrimSpace(string(raw)))
+		}
+		return nil, nil, fmt.Errorf("failed to decode mpc sign response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		if result.Error == "" {
+			result.Error = strings.TrimSpace(string(raw))
+		}
+		return nil, nil, fmt.Errorf("mpc-core returned %d: %s", resp.StatusCode, result.Error)
+	}
+
+	if result.Signature == nil {
+		return nil, nil, fmt.Errorf("mpc signing session %s is %s without a combined signature", sessionID, result.Status)
+	}
+	if result.Signature.CurveType != "" && result.Signature.CurveType != "Secp256k1" {
+		return nil, nil, fmt.Errorf("mpc session %s signs with %s, not Secp256k1", sessionID, result.Signature.CurveType)
+	}
+	if len(result.Signature.Bytes) != 64 {
+		return nil, nil, fmt.Errorf("mpc signature must be 64 bytes, got %d", len(result.Signature.Bytes))
+	}
+	sig := make([]byte, 64)
+	for i, b := range result.Signature.Bytes {
+		if b < 0 || b > 255 {
+			return nil, nil, fmt.Errorf("invalid mpc signature byte %d", b)
+		}
+		sig[i] = byte(b)
+	}
+	return sig, result.Signature.RecoveryID, nil
+}
+
+// SessionSigner 返回使用指定密钥会话签名的签名器，participants 为空时使用配置的默认参与者
+func (c *MPCClient) SessionSigner(sessionID string, participants []string) *MPCSigner {
+	return &MPCSigner{client: c, sessionID: sessionID, participants: participants}
+}
+
+// MPCSigner 通过mpc-core门限签名的签名器，一个实例对应一个密钥会话
+type MPCSigner struct {
+	client       *MPCClient
+	sessionID    string
+	participants []string
+
+	signature []byte // 最近一次返回的签名
+}
+
+// SignHash 请求门限签名并组装为 [R || S || V]
+// 签名规范化为低S。mpc-core 返回的恢复ID目前固定为0，只作为首选：恢复出的地址不是 address 时再尝试另一个。
+func (s *MPCSigner) SignHash(ctx context.Context, address string, hash []byte) ([]byte, error) {
+	if len(hash) != 32 {
+		return nil, fmt.Errorf("hash must be 32 bytes, got %d", len(hash))
+	}
+	if !common.IsHexAddress(address) {
+		return nil, fmt.Errorf("invalid signing address: %s", address)
+	}
+
+	rs, recoveryID, err := s.client.Sign(ctx, s.sessionID, s.participants, hash, map[string]string{"address": address})
+	if err != nil {
+		return nil, err
+	}
+
+	sig := make([]byte, crypto.SignatureLength)
+	copy(sig, rs)
+	candidates := []byte{0, 1}
+	if recoveryID != nil {
+		v := *recoveryID
+		if v >= 27 {
+			v -= 27
+		}
+		if v > 1 {
+			return nil, fmt.Errorf("invalid mpc recovery id %d", *recoveryID)
+		}
+		candidates = []byte{v, v ^ 1}
+	}
+
+	// 高S签名取 N-S，对应的恢复ID翻转
+	flip := byte(0)
+	if sv := new(big.Int).SetBytes(sig[32:64]); sv.Cmp(secp256k1HalfN) > 0 {
+		new(big.Int).Sub(secp256k1N, sv).FillBytes(sig[32:64])
+		flip = 1
+	}
+
+	want := common.HexToAddress(address)
+	var recovered common.Address
+	for _, v := range candidates {
+		sig[64] = v ^ flip
+		pub, err := crypto.SigToPub(hash, sig)
+		if err != nil {
+			continue
+		}
+		if recovered = crypto.PubkeyToAddress(*pub); recovered == want {
+			s.signature = sig
+			return sig, nil
+		}
+	}
+	if recovered == (common.Address{}) {
+		return nil, fmt.Errorf("mpc session %s returned an invalid signature", s.sessionID)
+	}
+	return nil, fmt.Errorf("mpc session %s signed as %s, not %s", s.sessionID, recovered.Hex(), want.Hex())
+}
+
+// Signature 返回最近一次签名，尚未签名时为nil
+func (s *MPCSigner) Signature() []byte {
+	return s.signature
+}
@@ -0,0 +1,128 @@
+package signer
+
+import (
+	"blockchain-middleware/internal/config"
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// fakeMPCCore 用本地私钥模拟 mpc-core 的门限签名
+type fakeMPCCore struct {
+	key        *ecdsa.PrivateKey
+	highS      bool   // 返回高S形式的签名
+	recoveryID *uint8 // 响应中的恢复ID，nil表示不返回
+}
+
+func (m *fakeMPCCore) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req mpcSignRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	hash := make([]byte, len(req.MessageHash))
+	for i, b := range req.MessageHash {
+		hash[i] = byte(b)
+	}
+	sig, err := crypto.Sign(hash, m.key)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if m.highS {
+		new(big.Int).Sub(secp256k1N, new(big.Int).SetBytes(sig[32:64])).FillBytes(sig[32:64])
+	}
+
+	rs := make([]int, 64)
+	for i := range rs {
+		rs[i] = int(sig[i])
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"session_id": req.SessionID,
+		"status":     "completed",
+		"signature":  map[string]interface{}{"bytes": rs, "recovery_id": m.recoveryID, "curve_type": "Secp256k1"},
+	})
+}
+
+// hashWithRecoveryID 找到一个由 key 签名后恢复ID为 v 的哈希
+func hashWithRecoveryID(t *testing.T, key *ecdsa.PrivateKey, v byte) ([]byte, []byte) {
+	t.Helper()
+	for i := 0; i < 256; i++ {
+		hash := crypto.Keccak256([]byte(fmt.Sprintf("message %d", i)))
+		sig, err := crypto.Sign(hash, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sig[64] == v {
+			return hash, sig
+		}
+	}
+	t.Fatalf("no signature with recovery id %d", v)
+	return nil, nil
+}
+
+func recoveryID(v uint8) *uint8 { return &v }
+
+func TestMPCSignerSignHash(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := crypto.PubkeyToAddress(key.PublicKey).Hex()
+
+	tests := []struct {
+		name    string
+		v       byte // 真实的恢复ID
+		core    fakeMPCCore
+		wantErr string
+	}{
+		{"reported recovery id is correct", 1, fakeMPCCore{key: key, recoveryID: recoveryID(1)}, ""},
+		// mpc-core 固定返回0
+		{"reported recovery id has the wrong parity", 1, fakeMPCCore{key: key, recoveryID: recoveryID(0)}, ""},
+		{"reported as 27", 0, fakeMPCCore{key: key, recoveryID: recoveryID(27)}, ""},
+		{"no recovery id", 1, fakeMPCCore{key: key}, ""},
+		{"high s with wrong parity", 1, fakeMPCCore{key: key, highS: true, recoveryID: recoveryID(0)}, ""},
+		{"high s without recovery id", 0, fakeMPCCore{key: key, highS: true}, ""},
+		{"invalid recovery id", 0, fakeMPCCore{key: key, recoveryID: recoveryID(2)}, "invalid mpc recovery id 2"},
+		{"another key", 0, fakeMPCCore{key: other, recoveryID: recoveryID(0)}, "not " + address},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core := tt.core
+			srv := httptest.NewServer(&core)
+			defer srv.Close()
+			client := NewMPCClient(config.MPCConfig{URL: srv.URL, Timeout: 5 * time.Second, Participants: []string{"p1", "p2"}})
+			s := client.SessionSigner("session-1", nil)
+
+			hash, want := hashWithRecoveryID(t, key, tt.v)
+			sig, err := s.SignHash(context.Background(), address, hash)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("SignHash() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			// 本地签名已是低S，规范化后应完全一致
+			if !bytes.Equal(sig, want) || !bytes.Equal(s.Signature(), want) {
+				t.Errorf("SignHash() = %x, want %x", sig, want)
+			}
+		})
+	}
+}
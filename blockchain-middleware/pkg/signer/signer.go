@@ -0,0 +1,33 @@
+package signer
+
+import (
+	"blockchain-middleware/internal/config"
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrUnknownKey 签名器中没有对应地址的密钥
+var ErrUnknownKey = errors.New("no signing key for address")
+
+// Signer 交易签名器接口
+// 中间件不再持有明文私钥，未签名交易的签名全部委托给签名器完成。
+type Signer interface {
+	// SignHash 使用地址对应的secp256k1密钥签名32字节哈希
+	// 返回65字节 [R || S || V] 格式的签名，V 为 0 或 1。
+	SignHash(ctx context.Context, address string, hash []byte) ([]byte, error)
+}
+
+// New 根据配置创建签名器，类型为 none 时返回nil，表示只接受外部签名的交易
+func New(cfg config.SignerConfig) (Signer, error) {
+	switch cfg.Type {
+	case "", "none":
+		return nil, nil
+	case "keystore":
+		return NewKeystoreSigner(cfg.KeystoreDir, cfg.KeystorePassword)
+	case "private_key":
+		return NewPrivateKeySigner(cfg.PrivateKeys)
+	default:
+		return nil, fmt.Errorf("unsupported signer type: %s", cfg.Type)
+	}
+}
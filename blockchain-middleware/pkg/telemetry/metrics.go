@@ -0,0 +1,128 @@
+package telemetry
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// namespace 指标名前缀，与 monitoring/alert_rules.yml 中的告警规则一致
+const namespace = "blockchain"
+
+// RPC调用结果
+const (
+	RPCSuccess = "success" // 调用成功（包括查询对象不存在）
+	RPCError   = "error"   // 节点返回的业务错误，如交易回滚、nonce过低
+	RPCFailure = "failure" // 节点本身的故障，如网络错误、超时、限流，会触发切换节点
+)
+
+// 交易发送结果
+const (
+	TxSent   = "sent"
+	TxFailed = "failed"
+)
+
+// Registry 中间件自己的指标注册表，避免引入全局注册表中第三方库注册的指标
+var Registry = prometheus.NewRegistry()
+
+var (
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by route template.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	rpcRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rpc_requests_total",
+		Help:      "Chain RPC requests by chain, method and result.",
+	}, []string{"chain", "method", "result"})
+
+	rpcFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rpc_failures_total",
+		Help:      "Chain RPC requests that failed because of the provider (network errors, timeouts, rate limits).",
+	}, []string{"chain", "method"})
+
+	rpcDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "rpc_request_duration_seconds",
+		Help:      "Chain RPC latency of a single provider request.",
+		Buckets:   []float64{.01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30},
+	}, []string{"chain", "method"})
+
+	eventsDropped = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "event_dropped_total",
+		Help:      "Events dropped because the subscription channel was full.",
+	}, []string{"chain"})
+
+	webhooksDropped = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "webhook_dropped_total",
+		Help:      "Transaction status webhook events dropped because the delivery queue was full.",
+	}, []string{"chain"})
+
+	txSent = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "tx_sent_total",
+		Help:      "Transaction submissions by kind and outcome.",
+	}, []string{"chain", "kind", "outcome"})
+
+	txStatus = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "tx_status_total",
+		Help:      "Tracked transaction status transitions.",
+	}, []string{"chain", "status"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpDuration, rpcRequests, rpcFailures, rpcDuration,
+		eventsDropped, webhooksDropped, txSent, txStatus,
+	)
+}
+
+// Handler 返回 /metrics 处理器
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
+
+// ObserveRPC 记录一次发往节点的RPC请求，result 为 RPCSuccess、RPCError 或 RPCFailure
+func ObserveRPC(chain, method, result string, elapsed time.Duration) {
+	rpcRequests.WithLabelValues(chain, method, result).Inc()
+	rpcDuration.WithLabelValues(chain, method).Observe(elapsed.Seconds())
+	if result == RPCFailure {
+		rpcFailures.WithLabelValues(chain, method).Inc()
+	}
+}
+
+// EventDropped 记录一个因订阅通道已满被丢弃的事件
+func EventDropped(chain string) {
+	eventsDropped.WithLabelValues(chain).Inc()
+}
+
+// WebhookDropped 记录一个因推送队列已满被丢弃的交易状态webhook事件
+func WebhookDropped(chain string) {
+	webhooksDropped.WithLabelValues(chain).Inc()
+}
+
+// ObserveTxSend 记录一次交易发送，kind 为发送方式（如 signed、raw、mpc）
+func ObserveTxSend(chain, kind string, err error) {
+	outcome := TxSent
+	if err != nil {
+		outcome = TxFailed
+	}
+	txSent.WithLabelValues(chain, kind, outcome).Inc()
+}
+
+// ObserveTxStatus 记录一次交易状态变更
+func ObserveTxStatus(chain, status string) {
+	txStatus.WithLabelValues(chain, status).Inc()
+}
@@ -0,0 +1,131 @@
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName 本服务创建的span所属的instrumentation scope
+const instrumentationName = "blockchain-middleware"
+
+// ChainKey span中的链名称属性
+const ChainKey = attribute.Key("blockchain.chain")
+
+// 追踪导出方式
+const (
+	ExporterNone    = "none"    // 不导出，仍然解析并向下游传递 traceparent
+	ExporterConsole = "console" // 以JSON格式写到标准输出或文件，用于调试
+)
+
+// TracingConfig 追踪配置
+type TracingConfig struct {
+	ServiceName string
+	Exporter    string
+	File        string  // console 导出的目标文件，为空时写到标准输出
+	SampleRatio float64 // 根span的采样比例，上游已采样的请求始终采样
+}
+
+// InitTracing 设置全局的 TracerProvider 和 W3C TraceContext/Baggage 传播器
+// 返回的函数在退出时调用，把缓冲中的span导出后关闭。
+func InitTracing(cfg TracingConfig) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	if cfg.Exporter == "" || cfg.Exporter == ExporterNone {
+		return func(context.Context) error { return nil }, nil
+	}
+	if cfg.Exporter != ExporterConsole {
+		return nil, fmt.Errorf("unsupported traces exporter: %s", cfg.Exporter)
+	}
+
+	var out io.Writer = os.Stdout
+	var file *os.File
+	if cfg.File != "" {
+		f, err := os.OpenFile(cfg.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open tracing file: %w", err)
+		}
+		out, file = f, f
+	}
+
+	exporter, err := stdouttrace.New(stdouttrace.WithWriter(out))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create trace exporter: %w", err)
+	}
+
+	res, err := resource.New(context.Background(),
+		resource.WithAttributes(semconv.ServiceName(cfg.ServiceName)),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create trace resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return func(ctx context.Context) error {
+		err := provider.Shutdown(ctx)
+		if file != nil {
+			file.Close()
+		}
+		return err
+	}, nil
+}
+
+// Tracer 返回本服务的 tracer，未初始化追踪时为空实现
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// StartSpan 创建内部span，chain 为空时不记录链名称
+func StartSpan(ctx context.Context, name, chain string) (context.Context, trace.Span) {
+	if chain == "" {
+		return Tracer().Start(ctx, name)
+	}
+	return Tracer().Start(ctx, name, trace.WithAttributes(ChainKey.String(chain)))
+}
+
+// StartRPCSpan 为发往链节点的JSON-RPC调用创建客户端span，span名称为RPC方法名
+// ctx 中没有span的调用（事件监听、交易跟踪等后台轮询）不单独成为链路，只记录指标。
+func StartRPCSpan(ctx context.Context, chain, method string) (context.Context, trace.Span) {
+	if parent := trace.SpanFromContext(ctx); !parent.SpanContext().IsValid() {
+		return ctx, parent
+	}
+	return Tracer().Start(ctx, method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.RPCSystemKey.String("jsonrpc"),
+			semconv.RPCMethod(method),
+			ChainKey.String(chain),
+		),
+	)
+}
+
+// EndSpan 记录错误并结束span
+func EndSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,111 @@
+package tracker
+
+import (
+	"blockchain-middleware/pkg/types"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+)
+
+// PostgresStore 基于PostgreSQL的跟踪记录存储，服务重启后继续跟踪在途交易
+type PostgresStore struct {
+	db *sql.DB
+}
+
+// NewPostgresStore 创建PostgreSQL存储并确保表结构存在
+func NewPostgresStore(ctx context.Context, db *sql.DB) (*PostgresStore, error) {
+	_, err := db.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS middleware_tracked_transactions (
+			chain_name VARCHAR(64) NOT NULL,
+			tx_hash VARCHAR(128) NOT NULL,
+			state VARCHAR(32) NOT NULL,
+			data JSONB NOT NULL,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (chain_name, tx_hash)
+		)`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create tracked transaction table: %w", err)
+	}
+
+	_, err = db.ExecContext(ctx, `
+		CREATE INDEX IF NOT EXISTS idx_middleware_tracked_transactions_state
+		ON middleware_tracked_transactions (state)`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create tracked transaction index: %w", err)
+	}
+
+	return &PostgresStore{db: db}, nil
+}
+
+// Save 保存跟踪记录
+func (s *PostgresStore) Save(ctx context.Context, tx *types.TrackedTransaction) error {
+	raw, err := json.Marshal(tx)
+	if err != nil {
+		return fmt.Errorf("failed to encode tracked transaction: %w", err)
+	}
+
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO middleware_tracked_transactions (chain_name, tx_hash, state, data, updated_at)
+		VALUES ($1, $2, $3, $4, CURRENT_TIMESTAMP)
+		ON CONFLICT (chain_name, tx_hash)
+		DO UPDATE SET state = EXCLUDED.state, data = EXCLUDED.data, updated_at = EXCLUDED.updated_at`,
+		tx.ChainName, normalizeHash(tx.TxHash), tx.State, string(raw))
+	if err != nil {
+		return fmt.Errorf("failed to save tracked transaction: %w", err)
+	}
+	return nil
+}
+
+// Get 获取跟踪记录
+func (s *PostgresStore) Get(ctx context.Context, chainName, txHash string) (*types.TrackedTransaction, error) {
+	var raw []byte
+	err := s.db.QueryRowContext(ctx,
+		`SELECT data FROM middleware_tracked_transactions WHERE chain_name = $1 AND tx_hash = $2`,
+		chainName, normalizeHash(txHash)).Scan(&raw)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load tracked transaction: %w", err)
+	}
+	return decodeTracked(raw)
+}
+
+// ListActive 列出尚未进入最终状态的跟踪记录
+func (s *PostgresStore) ListActive(ctx context.Context) ([]*types.TrackedTransaction, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT data FROM middleware_tracked_transactions WHERE state NOT IN ($1, $2, $3)`,
+		types.TxStateConfirmed, types.TxStateDropped, types.TxStateReplaced)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tracked transactions: %w", err)
+	}
+	defer rows.Close()
+
+	var active []*types.TrackedTransaction
+	for rows.Next() {
+		var raw []byte
+		if err := rows.Scan(&raw); err != nil {
+			return nil, fmt.Errorf("failed to scan tracked transaction: %w", err)
+		}
+		tx, err := decodeTracked(raw)
+		if err != nil {
+			return nil, err
+		}
+		active = append(active, tx)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list tracked transactions: %w", err)
+	}
+	return active, nil
+}
+
+// decodeTracked 解码跟踪记录
+func decodeTracked(raw []byte) (*types.TrackedTransaction, error) {
+	var tx types.TrackedTransaction
+	if err := json.Unmarshal(raw, &tx); err != nil {
+		return nil, fmt.Errorf("failed to decode tracked transaction: %w", err)
+	}
+	return &tx, nil
+}
@@ -0,0 +1,88 @@
+package tracker
+
+import (
+	"blockchain-middleware/pkg/types"
+	"context"
+	"strings"
+	"sync"
+)
+
+// Store 交易跟踪记录持久化接口
+type Store interface {
+	// Save 保存跟踪记录
+	Save(ctx context.Context, tx *types.TrackedTransaction) error
+	// Get 获取跟踪记录，不存在时返回nil
+	Get(ctx context.Context, chainName, txHash string) (*types.TrackedTransaction, error)
+	// ListActive 列出尚未进入最终状态的跟踪记录，用于重启后恢复跟踪
+	ListActive(ctx context.Context) ([]*types.TrackedTransaction, error)
+}
+
+// MemoryStore 内存存储，仅用于未启用数据库的开发环境
+type MemoryStore struct {
+	mu  sync.Mutex
+	txs map[string]*types.TrackedTransaction
+}
+
+// NewMemoryStore 创建内存存储
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{txs: make(map[string]*types.TrackedTransaction)}
+}
+
+// Save 保存跟踪记录
+func (s *MemoryStore) Save(ctx context.Context, tx *types.TrackedTransaction) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.txs[txKey(tx.ChainName, tx.TxHash)] = clone(tx)
+	return nil
+}
+
+// Get 获取跟踪记录
+func (s *MemoryStore) Get(ctx context.Context, chainName, txHash string) (*types.TrackedTransaction, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx, ok := s.txs[txKey(chainName, txHash)]
+	if !ok {
+		return nil, nil
+	}
+	return clone(tx), nil
+}
+
+// ListActive 列出尚未进入最终状态的跟踪记录
+func (s *MemoryStore) ListActive(ctx context.Context) ([]*types.TrackedTransaction, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var active []*types.TrackedTransaction
+	for _, tx := range s.txs {
+		if !tx.IsFinal() {
+			active = append(active, clone(tx))
+		}
+	}
+	return active, nil
+}
+
+// clone 深拷贝跟踪记录
+func clone(tx *types.TrackedTransaction) *types.TrackedTransaction {
+	c := *tx
+	if tx.Nonce != nil {
+		nonce := *tx.Nonce
+		c.Nonce = &nonce
+	}
+	if tx.Success != nil {
+		success := *tx.Success
+		c.Success = &success
+	}
+	return &c
+}
+
+// txKey 交易在内存中的键
+func txKey(chainName, txHash string) string {
+	return chainName + ":" + normalizeHash(txHash)
+}
+
+// normalizeHash 交易哈希统一小写
+func normalizeHash(txHash string) string {
+	return strings.ToLower(txHash)
+}
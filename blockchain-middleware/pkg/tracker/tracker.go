@@ -0,0 +1,368 @@
+package tracker
+
+import (
+	"blockchain-middleware/internal/config"
+	"blockchain-middleware/pkg/chain"
+	"blockchain-middleware/pkg/types"
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+const (
+	defaultPollInterval = 5 * time.Second
+	defaultDropTimeout  = 15 * time.Minute
+	// finishedRetention 进入最终状态的交易在内存中保留的时间，之后从存储查询
+	finishedRetention = time.Hour
+)
+
+// ClientLookup 按链名称获取链客户端
+type ClientLookup func(chainName string) (chain.ChainClient, error)
+
+// Tracker 交易生命周期跟踪器
+// 定期向节点查询在途交易，推进 pending → included → confirmed 状态，
+// 并识别被替换、被丢弃以及所在区块被重组的交易；每次状态变更推送给订阅者和webhook。
+type Tracker struct {
+	pollInterval time.Duration
+	dropTimeout  time.Duration
+	store        Store
+	clients      ClientLookup
+	notify       func(types.TxStatusEvent)
+	webhooks     *webhookDispatcher
+
+	mu  sync.Mutex
+	txs map[string]*types.TrackedTransaction
+
+	wake   chan struct{}
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewTracker 创建交易跟踪器，notify 在每次状态变更时调用（可为nil）
+func NewTracker(cfg config.TrackerConfig, store Store, clients ClientLookup, notify func(types.TxStatusEvent)) *Tracker {
+	pollInterval := cfg.PollInterval
+	if pollInterval <= 0 {
+		pollInterval = defaultPollInterval
+	}
+	dropTimeout := cfg.DropTimeout
+	if dropTimeout <= 0 {
+		dropTimeout = defaultDropTimeout
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Tracker{
+		pollInterval: pollInterval,
+		dropTimeout:  dropTimeout,
+		store:        store,
+		clients:      clients,
+		notify:       notify,
+		webhooks:     newWebhookDispatcher(cfg.WebhookURLs, cfg.WebhookSecret),
+		txs:          make(map[string]*types.TrackedTransaction),
+		wake:         make(chan struct{}, 1),
+		ctx:          ctx,
+		cancel:       cancel,
+	}
+}
+
+// Start 从存储恢复在途交易并启动轮询
+func (t *Tracker) Start(ctx context.Context) error {
+	active, err := t.store.ListActive(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to restore tracked transactions: %w", err)
+	}
+
+	now := time.Now()
+	t.mu.Lock()
+	for _, tx := range active {
+		// 停机期间无法观察交易，丢弃计时从启动时重新开始
+		tx.LastSeenAt = now
+		t.txs[txKey(tx.ChainName, tx.TxHash)] = tx
+	}
+	t.mu.Unlock()
+
+	t.wg.Add(1)
+	go t.run()
+
+	if t.webhooks != nil {
+		t.wg.Add(1)
+		go func() {
+			defer t.wg.Done()
+			t.webhooks.run(t.ctx)
+		}()
+	}
+
+	log.Printf("Transaction tracker started, %d transactions restored", len(active))
+	return nil
+}
+
+// Stop 停止轮询和webhook推送
+func (t *Tracker) Stop() {
+	t.cancel()
+	t.wg.Wait()
+}
+
+// Track 开始跟踪交易，已在跟踪的交易直接返回当前状态
+func (t *Tracker) Track(ctx context.Context, chainName, txHash string, requiredConfirmations uint64) (*types.TrackedTransaction, error) {
+	client, err := t.clients(chainName)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := chain.As[chain.TransactionStatusProvider](client); !ok {
+		return nil, fmt.Errorf("chain %s does not support transaction tracking", chainName)
+	}
+
+	key := txKey(chainName, txHash)
+	t.mu.Lock()
+	existing, ok := t.txs[key]
+	t.mu.Unlock()
+	if ok {
+		return t.snapshot(existing), nil
+	}
+
+	stored, err := t.store.Get(ctx, chainName, txHash)
+	if err != nil {
+		return nil, err
+	}
+	if stored != nil {
+		return stored, nil
+	}
+
+	if requiredConfirmations == 0 {
+		requiredConfirmations = 1
+	}
+	now := time.Now()
+	tx := &types.TrackedTransaction{
+		ChainName:             chainName,
+		TxHash:                txHash,
+		State:                 types.TxStatePending,
+		RequiredConfirmations: requiredConfirmations,
+		LastSeenAt:            now,
+		CreatedAt:             now,
+		UpdatedAt:             now,
+	}
+	if err := t.store.Save(ctx, tx); err != nil {
+		return nil, err
+	}
+
+	t.mu.Lock()
+	if existing, ok := t.txs[key]; ok {
+		// 并发的 Track 调用已登记
+		t.mu.Unlock()
+		return t.snapshot(existing), nil
+	}
+	t.txs[key] = tx
+	t.mu.Unlock()
+
+	t.publish(types.TxStatusEvent{
+		ChainName:   chainName,
+		TxHash:      txHash,
+		State:       tx.State,
+		Transaction: clone(tx),
+		Timestamp:   now,
+	})
+
+	// 立即查询一次，不必等到下一个轮询周期
+	select {
+	case t.wake <- struct{}{}:
+	default:
+	}
+	return clone(tx), nil
+}
+
+// Get 获取交易的跟踪状态
+func (t *Tracker) Get(ctx context.Context, chainName, txHash string) (*types.TrackedTransaction, error) {
+	t.mu.Lock()
+	tx, ok := t.txs[txKey(chainName, txHash)]
+	t.mu.Unlock()
+	if ok {
+		return t.snapshot(tx), nil
+	}
+
+	stored, err := t.store.Get(ctx, chainName, txHash)
+	if err != nil {
+		return nil, err
+	}
+	if stored == nil {
+		return nil, fmt.Errorf("transaction %s is not tracked on %s", txHash, chainName)
+	}
+	return stored, nil
+}
+
+// snapshot 在锁保护下复制跟踪记录
+func (t *Tracker) snapshot(tx *types.TrackedTransaction) *types.TrackedTransaction {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return clone(tx)
+}
+
+// run 轮询循环
+func (t *Tracker) run() {
+	defer t.wg.Done()
+
+	ticker := time.NewTicker(t.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-t.ctx.Done():
+			return
+		case <-ticker.C:
+		case <-t.wake:
+		}
+		t.poll()
+	}
+}
+
+// poll 按链并发查询所有在途交易，并清理已结束较久的记录
+func (t *Tracker) poll() {
+	now := time.Now()
+	byChain := make(map[string][]*types.TrackedTransaction)
+
+	t.mu.Lock()
+	for key, tx := range t.txs {
+		if tx.IsFinal() {
+			if now.Sub(tx.UpdatedAt) > finishedRetention {
+				delete(t.txs, key)
+			}
+			continue
+		}
+		byChain[tx.ChainName] = append(byChain[tx.ChainName], clone(tx))
+	}
+	t.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for chainName, txs := range byChain {
+		wg.Add(1)
+		go func(chainName string, txs []*types.TrackedTransaction) {
+			defer wg.Done()
+			t.pollChain(chainName, txs)
+		}(chainName, txs)
+	}
+	wg.Wait()
+}
+
+// pollChain 查询单条链上的在途交易
+func (t *Tracker) pollChain(chainName string, txs []*types.TrackedTransaction) {
+	client, err := t.clients(chainName)
+	if err != nil {
+		log.Printf("Failed to track transactions on %s: %v", chainName, err)
+		return
+	}
+	provider, ok := chain.As[chain.TransactionStatusProvider](client)
+	if !ok {
+		return
+	}
+
+	for _, tx := range txs {
+		if t.ctx.Err() != nil {
+			return
+		}
+		status, err := provider.TransactionStatus(t.ctx, tx.TxHash, tx.From)
+		if err != nil {
+			log.Printf("Failed to check transaction %s on %s: %v", tx.TxHash, chainName, err)
+			continue
+		}
+		t.update(tx, status, time.Now())
+	}
+}
+
+// update 应用节点状态，持久化后推送状态变更
+func (t *Tracker) update(tx *types.TrackedTransaction, status *types.TxChainStatus, now time.Time) {
+	before := *tx
+	events := advance(tx, status, now, t.dropTimeout)
+
+	changed := len(events) > 0 ||
+		tx.Confirmations != before.Confirmations ||
+		tx.BlockHash != before.BlockHash ||
+		tx.From != before.From
+	if changed {
+		tx.UpdatedAt = now
+		// 保存失败时保留内存中的旧状态，下次轮询重新推进
+		if err := t.store.Save(t.ctx, tx); err != nil {
+			log.Printf("Failed to save transaction %s on %s: %v", tx.TxHash, tx.ChainName, err)
+			return
+		}
+	}
+
+	t.mu.Lock()
+	t.txs[txKey(tx.ChainName, tx.TxHash)] = tx
+	t.mu.Unlock()
+
+	for _, event := range events {
+		t.publish(event)
+	}
+}
+
+// publish 推送状态变更
+func (t *Tracker) publish(event types.TxStatusEvent) {
+	log.Printf("Transaction %s on %s is %s", event.TxHash, event.ChainName, event.State)
+
+	if t.notify != nil {
+		t.notify(event)
+	}
+	if t.webhooks != nil {
+		t.webhooks.enqueue(event)
+	}
+}
+
+// advance 根据节点状态推进交易状态，返回依次发生的状态变更
+func advance(tx *types.TrackedTransaction, status *types.TxChainStatus, now time.Time, dropTimeout time.Duration) []types.TxStatusEvent {
+	var events []types.TxStatusEvent
+	transition := func(state string) {
+		if tx.State == state {
+			return
+		}
+		previous := tx.State
+		tx.State = state
+		tx.UpdatedAt = now
+		events = append(events, types.TxStatusEvent{
+			ChainName:     tx.ChainName,
+			TxHash:        tx.TxHash,
+			PreviousState: previous,
+			State:         state,
+			Transaction:   clone(tx),
+			Timestamp:     now,
+		})
+	}
+
+	if status.From != "" {
+		tx.From = status.From
+		tx.Nonce = status.Nonce
+	}
+
+	// 已打包的交易不再位于原区块，说明该区块被重组出主链
+	if tx.BlockHash != "" && (!status.Found || status.Pending || status.BlockHash != tx.BlockHash) {
+		tx.BlockNumber, tx.BlockHash, tx.Success, tx.Confirmations = 0, "", nil, 0
+		transition(types.TxStateReorged)
+	}
+
+	switch {
+	case status.Found && !status.Pending:
+		success := status.Success
+		tx.BlockNumber = status.BlockNumber
+		tx.BlockHash = status.BlockHash
+		tx.Success = &success
+		tx.Confirmations = 0
+		if status.HeadBlock >= status.BlockNumber {
+			tx.Confirmations = status.HeadBlock - status.BlockNumber + 1
+		}
+		tx.LastSeenAt = now
+		if tx.Confirmations >= tx.RequiredConfirmations {
+			transition(types.TxStateConfirmed)
+		} else {
+			transition(types.TxStateIncluded)
+		}
+	case status.Found:
+		tx.LastSeenAt = now
+		transition(types.TxStatePending)
+	case tx.Nonce != nil && status.AccountNonce > *tx.Nonce:
+		// 同一nonce的其他交易已上链
+		transition(types.TxStateReplaced)
+	case now.Sub(tx.LastSeenAt) > dropTimeout:
+		transition(types.TxStateDropped)
+	}
+	return events
+}
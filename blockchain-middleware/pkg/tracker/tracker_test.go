@@ -0,0 +1,140 @@
+package tracker
+
+import (
+	"blockchain-middleware/pkg/types"
+	"testing"
+	"time"
+)
+
+func uint64Ptr(n uint64) *uint64 { return &n }
+
+func TestAdvance(t *testing.T) {
+	now := time.Now()
+	const dropTimeout = 15 * time.Minute
+
+	tests := []struct {
+		name       string
+		tx         types.TrackedTransaction
+		status     types.TxChainStatus
+		wantStates []string // 依次发生的状态变更
+		wantState  string
+		wantConfs  uint64
+		wantBlock  string
+	}{
+		{
+			name:      "still pending",
+			tx:        types.TrackedTransaction{State: types.TxStatePending, RequiredConfirmations: 3, LastSeenAt: now.Add(-time.Minute)},
+			status:    types.TxChainStatus{Found: true, Pending: true},
+			wantState: types.TxStatePending,
+		},
+		{
+			name:       "included below required confirmations",
+			tx:         types.TrackedTransaction{State: types.TxStatePending, RequiredConfirmations: 3, LastSeenAt: now},
+			status:     types.TxChainStatus{Found: true, BlockNumber: 100, BlockHash: "0xaa", Success: true, HeadBlock: 101},
+			wantStates: []string{types.TxStateIncluded},
+			wantState:  types.TxStateIncluded,
+			wantConfs:  2,
+			wantBlock:  "0xaa",
+		},
+		{
+			name:       "confirmed directly",
+			tx:         types.TrackedTransaction{State: types.TxStatePending, RequiredConfirmations: 3, LastSeenAt: now},
+			status:     types.TxChainStatus{Found: true, BlockNumber: 100, BlockHash: "0xaa", Success: true, HeadBlock: 110},
+			wantStates: []string{types.TxStateConfirmed},
+			wantState:  types.TxStateConfirmed,
+			wantConfs:  11,
+			wantBlock:  "0xaa",
+		},
+		{
+			name:       "included then confirmed",
+			tx:         types.TrackedTransaction{State: types.TxStateIncluded, RequiredConfirmations: 3, BlockNumber: 100, BlockHash: "0xaa", Confirmations: 2, LastSeenAt: now},
+			status:     types.TxChainStatus{Found: true, BlockNumber: 100, BlockHash: "0xaa", Success: true, HeadBlock: 102},
+			wantStates: []string{types.TxStateConfirmed},
+			wantState:  types.TxStateConfirmed,
+			wantConfs:  3,
+			wantBlock:  "0xaa",
+		},
+		{
+			name:       "reorg after confirm back to the mempool",
+			tx:         types.TrackedTransaction{State: types.TxStateConfirmed, RequiredConfirmations: 1, BlockNumber: 100, BlockHash: "0xaa", Confirmations: 1, LastSeenAt: now},
+			status:     types.TxChainStatus{Found: true, Pending: true},
+			wantStates: []string{types.TxStateReorged, types.TxStatePending},
+			wantState:  types.TxStatePending,
+		},
+		{
+			name:       "reorg after confirm into another block",
+			tx:         types.TrackedTransaction{State: types.TxStateConfirmed, RequiredConfirmations: 1, BlockNumber: 100, BlockHash: "0xaa", Confirmations: 1, LastSeenAt: now},
+			status:     types.TxChainStatus{Found: true, BlockNumber: 101, BlockHash: "0xbb", Success: true, HeadBlock: 101},
+			wantStates: []string{types.TxStateReorged, types.TxStateConfirmed},
+			wantState:  types.TxStateConfirmed,
+			wantConfs:  1,
+			wantBlock:  "0xbb",
+		},
+		{
+			name:       "reorg after confirm out of the node",
+			tx:         types.TrackedTransaction{State: types.TxStateConfirmed, RequiredConfirmations: 1, BlockNumber: 100, BlockHash: "0xaa", Confirmations: 1, LastSeenAt: now},
+			status:     types.TxChainStatus{},
+			wantStates: []string{types.TxStateReorged},
+			wantState:  types.TxStateReorged,
+		},
+		{
+			name:       "replaced by same nonce while pending",
+			tx:         types.TrackedTransaction{State: types.TxStatePending, From: "0x01", Nonce: uint64Ptr(5), RequiredConfirmations: 1, LastSeenAt: now},
+			status:     types.TxChainStatus{AccountNonce: 6},
+			wantStates: []string{types.TxStateReplaced},
+			wantState:  types.TxStateReplaced,
+		},
+		{
+			name:       "reorged out and replaced by same nonce",
+			tx:         types.TrackedTransaction{State: types.TxStateIncluded, From: "0x01", Nonce: uint64Ptr(5), RequiredConfirmations: 3, BlockNumber: 100, BlockHash: "0xaa", Confirmations: 1, LastSeenAt: now},
+			status:     types.TxChainStatus{AccountNonce: 6},
+			wantStates: []string{types.TxStateReorged, types.TxStateReplaced},
+			wantState:  types.TxStateReplaced,
+		},
+		{
+			name:      "nonce not yet used is not a replacement",
+			tx:        types.TrackedTransaction{State: types.TxStatePending, Nonce: uint64Ptr(5), RequiredConfirmations: 1, LastSeenAt: now},
+			status:    types.TxChainStatus{AccountNonce: 5},
+			wantState: types.TxStatePending,
+		},
+		{
+			name:       "dropped after timeout",
+			tx:         types.TrackedTransaction{State: types.TxStatePending, RequiredConfirmations: 1, LastSeenAt: now.Add(-dropTimeout - time.Second)},
+			status:     types.TxChainStatus{},
+			wantStates: []string{types.TxStateDropped},
+			wantState:  types.TxStateDropped,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tx := tt.tx
+			events := advance(&tx, &tt.status, now, dropTimeout)
+
+			var states []string
+			for _, e := range events {
+				states = append(states, e.State)
+			}
+			if len(states) != len(tt.wantStates) {
+				t.Fatalf("transitions = %v, want %v", states, tt.wantStates)
+			}
+			for i := range states {
+				if states[i] != tt.wantStates[i] {
+					t.Fatalf("transitions = %v, want %v", states, tt.wantStates)
+				}
+			}
+			if len(events) > 0 && events[0].PreviousState != tt.tx.State {
+				t.Errorf("first event previous state = %s, want %s", events[0].PreviousState, tt.tx.State)
+			}
+
+			if tx.State != tt.wantState {
+				t.Errorf("state = %s, want %s", tx.State, tt.wantState)
+			}
+			if tx.Confirmations != tt.wantConfs {
+				t.Errorf("confirmations = %d, want %d", tx.Confirmations, tt.wantConfs)
+			}
+			if tx.BlockHash != tt.wantBlock {
+				t.Errorf("block hash = %q, want %q", tx.BlockHash, tt.wantBlock)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package tracker
+
+import (
+	"blockchain-middleware/pkg/telemetry"
+	"blockchain-middleware/pkg/types"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+const (
+	// webhookQueueSize 待推送事件队列长度，队列满时丢弃新事件
+	webhookQueueSize = 1000
+	// webhookAttempts 单个地址的最大推送次数
+	webhookAttempts = 3
+	// webhookTimeout 单次推送超时
+	webhookTimeout = 10 * time.Second
+	// webhookSignatureHeader 推送内容的HMAC-SHA256签名，格式为 sha256=<hex>
+	webhookSignatureHeader = "X-Webhook-Signature"
+)
+
+// webhookDispatcher 按顺序把状态变更事件推送到配置的地址
+type webhookDispatcher struct {
+	urls       []string
+	secret     string
+	httpClient *http.Client
+	queue      chan types.TxStatusEvent
+}
+
+// newWebhookDispatcher 创建webhook推送器，未配置地址时返回nil
+func newWebhookDispatcher(urls []string, secret string) *webhookDispatcher {
+	if len(urls) == 0 {
+		return nil
+	}
+	return &webhookDispatcher{
+		urls:       urls,
+		secret:     secret,
+		httpClient: &http.Client{Timeout: webhookTimeout},
+		queue:      make(chan types.TxStatusEvent, webhookQueueSize),
+	}
+}
+
+// enqueue 加入推送队列，不阻塞状态轮询；队列已满时丢弃事件并计入指标，返回是否入队
+func (d *webhookDispatcher) enqueue(event types.TxStatusEvent) bool {
+	select {
+	case d.queue <- event:
+		return true
+	default:
+		telemetry.WebhookDropped(event.ChainName)
+		log.Printf("Webhook queue full (%d events), dropping %s event for %s on %s", cap(d.queue), event.State, event.TxHash, event.ChainName)
+		return false
+	}
+}
+
+// run 依次推送队列中的事件，直到ctx取消
+func (d *webhookDispatcher) run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event := <-d.queue:
+			body, err := json.Marshal(event)
+			if err != nil {
+				log.Printf("Failed to encode webhook event for %s: %v", event.TxHash, err)
+				continue
+			}
+			for _, url := range d.urls {
+				if err := d.deliver(ctx, url, body); err != nil {
+					log.Printf("Failed to deliver %s event for %s to %s: %v", event.State, event.TxHash, url, err)
+				}
+			}
+		}
+	}
+}
+
+// deliver 推送到单个地址，失败时指数退避重试
+func (d *webhookDispatcher) deliver(ctx context.Context, url string, body []byte) error {
+	var lastErr error
+	for i := 0; i < webhookAttempts; i++ {
+		if i > 0 {
+			timer := time.NewTimer(time.Second << (i - 1))
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return ctx.Err()
+			case <-timer.C:
+			}
+		}
+
+		if lastErr = d.post(ctx, url, body); lastErr == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("after %d attempts, last error: %w", webhookAttempts, lastErr)
+}
+
+// post 发送一次推送请求
+func (d *webhookDispatcher) post(ctx context.Context, url string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if d.secret != "" {
+		mac := hmac.New(sha256.New, []byte(d.secret))
+		mac.Write(body)
+		req.Header.Set(webhookSignatureHeader, "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := d.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package tracker
+
+import (
+	"blockchain-middleware/pkg/telemetry"
+	"blockchain-middleware/pkg/types"
+	"testing"
+)
+
+// droppedWebhooks 读取链上被丢弃的webhook事件数
+func droppedWebhooks(t *testing.T, chainName string) float64 {
+	t.Helper()
+	families, err := telemetry.Registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, family := range families {
+		if family.GetName() != "blockchain_webhook_dropped_total" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				if label.GetName() == "chain" && label.GetValue() == chainName {
+					return metric.GetCounter().GetValue()
+				}
+			}
+		}
+	}
+	return 0
+}
+
+func TestWebhookEnqueueCountsDrops(t *testing.T) {
+	d := newWebhookDispatcher([]string{"http://127.0.0.1:0/hook"}, "")
+	d.queue = make(chan types.TxStatusEvent, 2)
+
+	before := droppedWebhooks(t, "webhook-test")
+	event := types.TxStatusEvent{ChainName: "webhook-test", TxHash: "0x01", State: types.TxStateConfirmed}
+	for i := 0; i < 2; i++ {
+		if !d.enqueue(event) {
+			t.Fatalf("event %d dropped before the queue was full", i)
+		}
+	}
+	if d.enqueue(event) {
+		t.Fatal("event enqueued into a full queue")
+	}
+
+	if got := droppedWebhooks(t, "webhook-test") - before; got != 1 {
+		t.Errorf("dropped webhook events = %v, want 1", got)
+	}
+}
@@ -1,6 +1,7 @@
 package types
 
 import (
+	"encoding/json"
 	"math/big"
 	"time"
 
@@ -15,47 +16,68 @@ type TransactionRequest struct {
 	Value    *big.Int `json:"value"`
 	GasLimit uint64   `json:"gas_limit"`
 	GasPrice *big.Int `json:"gas_price"`
-	Nonce    uint64   `json:"nonce"`
+	Nonce    *uint64  `json:"nonce,omitempty"` // 为空时由nonce管理器分配
 	Data     []byte   `json:"data"`
 	ChainID  int64    `json:"chain_id"`
+
+	// EIP-1559 动态手续费，链支持时优先使用；都为空时按 GasPrice 或节点建议值填充
+	MaxFeePerGas         *big.Int `json:"max_fee_per_gas,omitempty"`
+	MaxPriorityFeePerGas *big.Int `json:"max_priority_fee_per_gas,omitempty"`
 }
 
 // Transaction 交易信息
 type Transaction struct {
-	Hash              string           `json:"hash"`
-	From              string           `json:"from"`
-	To                string           `json:"to"`
-	Value             *big.Int         `json:"value"`
-	GasPrice          *big.Int         `json:"gas_price"`
-	GasLimit          uint64           `json:"gas_limit"`
-	Nonce             uint64           `json:"nonce"`
-	Data              []byte           `json:"data"`
-	ChainID           int64            `json:"chain_id"`
-	Status            uint64           `json:"status"` // 0=失败, 1=成功
-	BlockNumber       uint64           `json:"block_number"`
-	TransactionIndex  uint             `json:"transaction_index"`
-	GasUsed           uint64           `json:"gas_used"`
-	CumulativeGasUsed uint64           `json:"cumulative_gas_used"`
-	Logs              []*types.Log     `json:"logs"`
-	Timestamp         uint64           `json:"timestamp"`
+	Hash              string       `json:"hash"`
+	From              string       `json:"from"`
+	To                string       `json:"to"`
+	Value             *big.Int     `json:"value"`
+	GasPrice          *big.Int     `json:"gas_price"`
+	GasLimit          uint64       `json:"gas_limit"`
+	Nonce             uint64       `json:"nonce"`
+	Data              []byte       `json:"data"`
+	ChainID           int64        `json:"chain_id"`
+	Status            uint64       `json:"status"` // 0=失败, 1=成功
+	BlockNumber       uint64       `json:"block_number"`
+	TransactionIndex  uint         `json:"transaction_index"`
+	GasUsed           uint64       `json:"gas_used"`
+	CumulativeGasUsed uint64       `json:"cumulative_gas_used"`
+	Logs              []*types.Log `json:"logs"`
+	Timestamp         uint64       `json:"timestamp"` // 所在区块时间，待打包交易为0
+	BlockHash         string       `json:"block_hash,omitempty"`
+	ContractAddress   string       `json:"contract_address,omitempty"` // 合约部署交易创建的合约地址
+
+	EffectiveGasPrice *big.Int `json:"effective_gas_price,omitempty"` // 实际支付的单位Gas价格
+	Fee               *big.Int `json:"fee,omitempty"`                 // 实际手续费 gas_used * effective_gas_price
+
+	// 按已注册ABI和签名库解码的调用和事件，无法识别时只有选择器/主题
+	DecodedInput *DecodedCall `json:"decoded_input,omitempty"`
+	DecodedLogs  []DecodedLog `json:"decoded_logs,omitempty"`
+
+	// 交易类型及 EIP-1559 手续费字段（仅动态手续费交易有值）
+	Type                 uint8    `json:"type"` // 0=传统交易, 2=EIP-1559
+	MaxFeePerGas         *big.Int `json:"max_fee_per_gas,omitempty"`
+	MaxPriorityFeePerGas *big.Int `json:"max_priority_fee_per_gas,omitempty"`
+
+	Pending bool `json:"pending"` // 仍在交易池中，尚无收据
 }
 
 // Block 区块信息
 type Block struct {
-	Number       uint64   `json:"number"`
-	Hash         string   `json:"hash"`
-	ParentHash   string   `json:"parent_hash"`
-	Timestamp    uint64   `json:"timestamp"`
-	Transactions []string `json:"transactions"` // 交易哈希列表
-	GasUsed      uint64   `json:"gas_used"`
-	GasLimit     uint64   `json:"gas_limit"`
-	Difficulty   *big.Int `json:"difficulty"`
+	Number          uint64   `json:"number"`
+	Hash            string   `json:"hash"`
+	ParentHash      string   `json:"parent_hash"`
+	Timestamp       uint64   `json:"timestamp"`
+	Transactions    []string `json:"transactions"` // 交易哈希列表
+	GasUsed         uint64   `json:"gas_used"`
+	GasLimit        uint64   `json:"gas_limit"`
+	Difficulty      *big.Int `json:"difficulty"`
 	TotalDifficulty *big.Int `json:"total_difficulty"`
-	Size         uint64   `json:"size"`
-	Miner        string   `json:"miner"`
+	Size            uint64   `json:"size"`
+	Miner           string   `json:"miner"`
 }
 
 // ContractCallRequest 合约调用请求
+// 设置 Method 时按ABI编码调用数据并解码返回值，ABI 和 ABIName 二选一；否则直接使用 Data。
 type ContractCallRequest struct {
 	From            common.Address `json:"from"`
 	ContractAddress common.Address `json:"contract_address"`
@@ -64,13 +86,121 @@ type ContractCallRequest struct {
 	GasLimit        uint64         `json:"gas_limit"`
 	GasPrice        *big.Int       `json:"gas_price"`
 	BlockNumber     uint64         `json:"block_number"`
+
+	ABI     json.RawMessage   `json:"abi,omitempty"`      // ABI片段，JSON数组或单个定义
+	ABIName string            `json:"abi_name,omitempty"` // 已注册的ABI名称
+	Method  string            `json:"method,omitempty"`   // 方法名或签名，如 transfer(address,uint256)
+	Args    []json.RawMessage `json:"args,omitempty"`     // 按顺序的JSON参数，整数可用字符串，bytes用0x十六进制
 }
 
 // ContractCallResponse 合约调用响应
 type ContractCallResponse struct {
-	Result []byte `json:"result"`
-	GasUsed uint64 `json:"gas_used"`
-	Error   string `json:"error,omitempty"`
+	Result  []byte          `json:"result"`
+	GasUsed uint64          `json:"gas_used"`
+	Error   string          `json:"error,omitempty"`
+	Outputs []ABIValue      `json:"outputs,omitempty"` // 按ABI解码的返回值
+	Revert  *ContractRevert `json:"revert,omitempty"`  // 调用被回滚时的原因
+}
+
+// ABIValue 按ABI解码的值
+// 整数超过32位时为十进制字符串，bytes为0x十六进制，tuple为按字段名的对象。
+type ABIValue struct {
+	Name  string      `json:"name,omitempty"`
+	Type  string      `json:"type"`
+	Value interface{} `json:"value"`
+}
+
+// ContractRevert 合约回滚信息
+type ContractRevert struct {
+	Reason    string     `json:"reason,omitempty"`     // require/revert 的原因或panic说明
+	PanicCode *uint64    `json:"panic_code,omitempty"` // assert等触发的Panic(uint256)错误码
+	Error     string     `json:"error,omitempty"`      // 自定义错误签名，如 InsufficientBalance(uint256,uint256)
+	Args      []ABIValue `json:"args,omitempty"`       // 自定义错误参数
+	Data      string     `json:"data,omitempty"`       // 原始回滚数据
+}
+
+// DecodedCall 解码后的方法调用
+type DecodedCall struct {
+	Selector  string     `json:"selector"`            // 4字节方法选择器
+	Signature string     `json:"signature,omitempty"` // 未识别时为空
+	Name      string     `json:"name,omitempty"`
+	Args      []ABIValue `json:"args,omitempty"`
+}
+
+// DecodedLog 解码后的事件日志
+type DecodedLog struct {
+	Address   string     `json:"address"`
+	LogIndex  uint       `json:"log_index"`
+	Topic     string     `json:"topic,omitempty"`     // 事件签名哈希，匿名事件为空
+	Signature string     `json:"signature,omitempty"` // 未识别时为空
+	Name      string     `json:"name,omitempty"`
+	Args      []ABIValue `json:"args,omitempty"` // 索引参数为动态类型时值为其哈希
+}
+
+// ContractEncodeResponse 合约调用数据编码结果
+type ContractEncodeResponse struct {
+	Data        string              `json:"data"`        // 0x十六进制调用数据
+	Selector    string              `json:"selector"`    // 4字节方法选择器
+	Signature   string              `json:"signature"`   // 方法签名
+	Transaction *TransactionRequest `json:"transaction"` // 可直接提交到发送交易接口的请求
+}
+
+// SimulationRequest 交易模拟请求，交易字段与发送交易请求相同
+type SimulationRequest struct {
+	TransactionRequest
+	BlockNumber    uint64                   `json:"block_number"`              // 在该区块结束时的状态上执行，0为最新区块
+	StateOverrides map[string]StateOverride `json:"state_overrides,omitempty"` // 按地址临时覆盖账户状态
+}
+
+// StateOverride 模拟时覆盖的账户状态，State 和 StateDiff 二选一
+type StateOverride struct {
+	Balance   *big.Int          `json:"balance,omitempty"`
+	Nonce     *uint64           `json:"nonce,omitempty"`
+	Code      string            `json:"code,omitempty"`       // 0x十六进制运行时代码
+	State     map[string]string `json:"state,omitempty"`      // 替换全部存储，存储槽到值，均为0x十六进制
+	StateDiff map[string]string `json:"state_diff,omitempty"` // 只修改指定存储槽
+}
+
+// SimulationResult 交易模拟结果
+// 余额变化和授权来自节点的 debug_traceCall，节点不支持追踪时 Traced 为false。
+type SimulationResult struct {
+	Success     bool            `json:"success"`
+	BlockNumber uint64          `json:"block_number"`
+	GasUsed     uint64          `json:"gas_used"` // 有追踪时为实际消耗，否则为 eth_estimateGas 的估算值（执行失败时为0）
+	Result      []byte          `json:"result,omitempty"`
+	Error       string          `json:"error,omitempty"`
+	Revert      *ContractRevert `json:"revert,omitempty"`
+
+	DecodedInput *DecodedCall `json:"decoded_input,omitempty"`
+	Logs         []*types.Log `json:"logs,omitempty"`
+	DecodedLogs  []DecodedLog `json:"decoded_logs,omitempty"`
+
+	Traced         bool            `json:"traced"`
+	TraceError     string          `json:"trace_error,omitempty"`
+	BalanceChanges []BalanceChange `json:"balance_changes,omitempty"`
+	Approvals      []TokenApproval `json:"approvals,omitempty"`
+}
+
+// BalanceChange 交易引起的地址余额净变化，原生币不含手续费
+type BalanceChange struct {
+	Address   string   `json:"address"`
+	Token     string   `json:"token,omitempty"` // ERC-20合约地址，原生币为空
+	Symbol    string   `json:"symbol,omitempty"`
+	Decimals  uint8    `json:"decimals"`
+	Delta     *big.Int `json:"delta"`               // 负数为减少
+	Formatted string   `json:"formatted,omitempty"` // 按精度换算，如 -500 USDC；查询不到代币精度时为空
+}
+
+// TokenApproval 交易设置的ERC-20授权，同一授权多次设置时为最终值
+type TokenApproval struct {
+	Token     string   `json:"token"`
+	Symbol    string   `json:"symbol,omitempty"`
+	Decimals  uint8    `json:"decimals"`
+	Owner     string   `json:"owner"`
+	Spender   string   `json:"spender"`
+	Amount    *big.Int `json:"amount"`
+	Unlimited bool     `json:"unlimited"` // 授权额度为 uint256 最大值
+	Formatted string   `json:"formatted,omitempty"`
 }
 
 // TokenBalance 代币余额
@@ -79,25 +209,202 @@ type TokenBalance struct {
 	Symbol          string   `json:"symbol"`
 	Decimals        uint8    `json:"decimals"`
 	Balance         *big.Int `json:"balance"`
+	Error           string   `json:"error,omitempty"` // 查询失败原因，如地址不是ERC-20合约
+}
+
+// NFT标准
+const (
+	NFTStandardERC721  = "ERC721"
+	NFTStandardERC1155 = "ERC1155"
+)
+
+// NFTContract NFT合约信息，标准通过 ERC-165 supportsInterface 检测
+type NFTContract struct {
+	ContractAddress    string `json:"contract_address"`
+	Standard           string `json:"standard"` // ERC721、ERC1155，都不支持时为空
+	Name               string `json:"name,omitempty"`
+	Symbol             string `json:"symbol,omitempty"`
+	SupportsMetadata   bool   `json:"supports_metadata"`   // ERC721Metadata 或 ERC1155MetadataURI
+	SupportsEnumerable bool   `json:"supports_enumerable"` // ERC721Enumerable
+}
+
+// NFTToken 单个NFT及其持有信息
+type NFTToken struct {
+	ContractAddress string `json:"contract_address"`
+	TokenID         string `json:"token_id"` // 十进制
+	Standard        string `json:"standard"`
+	Owner           string `json:"owner,omitempty"`     // ERC-721持有者，ERC-1155为查询的地址
+	Balance         string `json:"balance,omitempty"`   // 持有数量，ERC-721为1
+	TokenURI        string `json:"token_uri,omitempty"` // ERC-1155 uri 中的 {id} 已替换为代币ID
+}
+
+// NFTTransferRequest NFT转账请求，用于生成 safeTransferFrom 调用数据
+type NFTTransferRequest struct {
+	ContractAddress string `json:"contract_address"`
+	From            string `json:"from"`
+	To              string `json:"to"`
+	TokenID         string `json:"token_id"`         // 十进制或0x十六进制
+	Amount          string `json:"amount,omitempty"` // ERC-1155转账数量，默认1
+	Data            string `json:"data,omitempty"`   // 0x十六进制，传给接收合约的 onERC721Received/onERC1155Received
+	GasLimit        uint64 `json:"gas_limit,omitempty"`
+}
+
+// 转账类型
+const (
+	TransferNative = "native"
+	TransferERC20  = "erc20"
+	TransferERC721 = "erc721"
+)
+
+// 转账方向，相对查询的地址
+const (
+	TransferIn   = "in"
+	TransferOut  = "out"
+	TransferSelf = "self"
+)
+
+// Transfer 转账索引器记录的一笔转账
+// 原生币转账来自区块中交易的 value（不含合约内部转账），代币转账来自 Transfer 日志。
+type Transfer struct {
+	ChainName   string   `json:"chain"`
+	TxHash      string   `json:"tx_hash"`
+	LogIndex    int64    `json:"log_index"` // 原生币转账为-1
+	BlockNumber uint64   `json:"block_number"`
+	Timestamp   uint64   `json:"timestamp"`
+	Type        string   `json:"type"` // native、erc20、erc721
+	From        string   `json:"from"`
+	To          string   `json:"to"`
+	Value       *big.Int `json:"value,omitempty"`    // 原生币和ERC-20的金额
+	Token       string   `json:"token,omitempty"`    // 代币合约地址
+	TokenID     string   `json:"token_id,omitempty"` // ERC-721代币ID，十进制
+	Symbol      string   `json:"symbol,omitempty"`
+	Decimals    uint8    `json:"decimals"`
+	Success     bool     `json:"success"`             // 所在交易是否执行成功，代币转账总是成功
+	Direction   string   `json:"direction,omitempty"` // 查询时相对查询地址的方向：in、out、self
+}
+
+// TransferQuery 地址转账记录查询条件
+type TransferQuery struct {
+	Type      string // 为空时不限类型
+	Token     string // 为空时不限代币
+	FromBlock uint64 // 只列出该区块及之后的转账
+	Limit     int
+	Offset    int
+}
+
+// TransferPage 分页的地址转账记录，按区块倒序
+type TransferPage struct {
+	ChainName    string          `json:"chain"`
+	Address      string          `json:"address"`
+	Transfers    []Transfer      `json:"transfers"`
+	Total        int             `json:"total"`
+	Limit        int             `json:"limit"`
+	Offset       int             `json:"offset"`
+	IndexedBlock uint64          `json:"indexed_block"` // 已索引到的区块，之后的转账尚未记录
+	Watch        *WatchedAddress `json:"watch"`
+}
+
+// WatchedAddress 转账索引器记录其转账的地址
+// 从 FromBlock 到开始监听时已索引区块之间的历史由后台补齐，BackfillNext 超过 BackfillEnd 时完成。
+type WatchedAddress struct {
+	ChainName    string    `json:"chain"`
+	Address      string    `json:"address"`
+	FromBlock    uint64    `json:"from_block"`
+	BackfillNext uint64    `json:"backfill_next,omitempty"`
+	BackfillEnd  uint64    `json:"backfill_end,omitempty"`
+	Backfilling  bool      `json:"backfilling"` // BackfillNext <= BackfillEnd，不持久化
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// WatchRequest 把地址加入转账索引的请求
+type WatchRequest struct {
+	FromBlock uint64 `json:"from_block,omitempty"` // 补齐历史的起始区块，0表示只记录之后的转账
 }
 
 // AccountInfo 账户信息
 type AccountInfo struct {
-	Address      string          `json:"address"`
-	ETHBalance   *big.Int        `json:"eth_balance"`
-	TokenBalances []TokenBalance `json:"token_balances"`
-	Nonce        uint64          `json:"nonce"`
-	TransactionCount uint64      `json:"transaction_count"`
+	Address          string         `json:"address"`
+	ETHBalance       *big.Int       `json:"eth_balance"`
+	TokenBalances    []TokenBalance `json:"token_balances"`
+	Nonce            uint64         `json:"nonce"`
+	TransactionCount uint64         `json:"transaction_count"`
 }
 
 // ChainInfo 链信息
 type ChainInfo struct {
-	ChainID      int64  `json:"chain_id"`
-	NetworkName  string `json:"network_name"`
-	BlockNumber  uint64 `json:"block_number"`
-	GasPrice     *big.Int `json:"gas_price"`
-	IsSyncing    bool   `json:"is_syncing"`
-	PeerCount    int    `json:"peer_count"`
+	ChainID     int64    `json:"chain_id"`
+	NetworkName string   `json:"network_name"`
+	BlockNumber uint64   `json:"block_number"`
+	GasPrice    *big.Int `json:"gas_price"` // 标准档位的Gas价格，估算失败时为节点建议价格，链不支持或节点不可用时为空
+	IsSyncing   bool     `json:"is_syncing"`
+	PeerCount   int      `json:"peer_count"`
+
+	NativeSymbol   string `json:"native_symbol"`
+	NativeDecimals uint8  `json:"native_decimals"`
+	Confirmations  uint64 `json:"confirmations"`
+	EIP1559        bool   `json:"eip1559"`
+
+	Providers []ProviderStatus `json:"providers,omitempty"`
+}
+
+// 基础费用趋势
+const (
+	BaseFeeRising  = "rising"
+	BaseFeeFalling = "falling"
+	BaseFeeStable  = "stable"
+)
+
+// FeeEstimates 手续费估算，按最近区块的 eth_feeHistory（传统链为 eth_gasPrice）计算
+type FeeEstimates struct {
+	BlockNumber  uint64   `json:"block_number"` // 估算基于的最新区块
+	EIP1559      bool     `json:"eip1559"`
+	BaseFee      *big.Int `json:"base_fee,omitempty"`       // 最新区块的 baseFee
+	NextBaseFee  *big.Int `json:"next_base_fee,omitempty"`  // 下一区块的 baseFee
+	BaseFeeTrend string   `json:"base_fee_trend,omitempty"` // 下一区块 baseFee 相对采样区块均值：rising、falling、stable
+	GasUsedRatio float64  `json:"gas_used_ratio,omitempty"` // 采样区块的平均Gas使用率
+	BlockTime    float64  `json:"block_time"`               // 采样区块的平均出块间隔（秒）
+
+	Slow     FeeTier `json:"slow"`
+	Standard FeeTier `json:"standard"`
+	Fast     FeeTier `json:"fast"`
+}
+
+// FeeTier 单个速度档位的手续费
+type FeeTier struct {
+	MaxPriorityFeePerGas *big.Int `json:"max_priority_fee_per_gas,omitempty"` // 仅 EIP-1559
+	MaxFeePerGas         *big.Int `json:"max_fee_per_gas,omitempty"`          // 仅 EIP-1559
+	GasPrice             *big.Int `json:"gas_price"`                          // 传统交易价格；EIP-1559 下为预计实际价格 nextBaseFee+小费
+	EstimatedBlocks      uint64   `json:"estimated_blocks"`                   // 预计被打包前经过的区块数
+	EstimatedSeconds     uint64   `json:"estimated_seconds"`
+}
+
+// ProviderStatus RPC节点状态
+type ProviderStatus struct {
+	URL         string  `json:"url"`
+	Healthy     bool    `json:"healthy"`
+	LatencyMs   float64 `json:"latency_ms"`
+	ErrorRate   float64 `json:"error_rate"`
+	HeadBlock   uint64  `json:"head_block"`
+	BlockLag    uint64  `json:"block_lag"`
+	Score       float64 `json:"score"`
+	EjectReason string  `json:"eject_reason,omitempty"`
+	LastError   string  `json:"last_error,omitempty"`
+}
+
+// CacheStats 链上读取缓存的命中统计
+type CacheStats struct {
+	Chain   string  `json:"chain"`
+	Kind    string  `json:"kind"` // balance, nonce, block_number, block, transaction, token_balance
+	Hits    uint64  `json:"hits"`
+	Misses  uint64  `json:"misses"`
+	Errors  uint64  `json:"errors"` // 缓存读写失败次数，失败时直接查询节点
+	HitRate float64 `json:"hit_rate"`
+}
+
+// CacheReport 缓存后端及命中统计
+type CacheReport struct {
+	Backend string       `json:"backend"` // redis 或 memory
+	Stats   []CacheStats `json:"stats"`
 }
 
 // EventFilter 事件过滤器
@@ -111,55 +418,189 @@ type EventFilter struct {
 
 // MPCTransactionRequest MPC交易请求
 type MPCTransactionRequest struct {
-	SessionID string           `json:"session_id"`
-	ChainName string           `json:"chain_name"`
-	From      string           `json:"from"`
-	To        string           `json:"to"`
-	Value     *big.Int         `json:"value"`
-	Data      []byte           `json:"data"`
-	GasLimit  uint64           `json:"gas_limit"`
-	GasPrice  *big.Int         `json:"gas_price"`
+	SessionID    string   `json:"session_id"`   // mpc-core 密钥生成会话，其公钥对应 From
+	Participants []string `json:"participants"` // 签名参与者，为空时使用 MPC_PARTICIPANTS
+	ChainName    string   `json:"chain_name"`
+	From         string   `json:"from"`
+	To           string   `json:"to"`
+	Value        *big.Int `json:"value"`
+	Data         []byte   `json:"data"`
+	GasLimit     uint64   `json:"gas_limit"` // 为0时按预估值加20%余量
+	GasPrice     *big.Int `json:"gas_price"`
+	Nonce        *uint64  `json:"nonce,omitempty"` // 为空时由nonce管理器分配
+
+	MaxFeePerGas         *big.Int `json:"max_fee_per_gas,omitempty"`
+	MaxPriorityFeePerGas *big.Int `json:"max_priority_fee_per_gas,omitempty"`
 }
 
 // MPCTransactionResponse MPC交易响应
 type MPCTransactionResponse struct {
 	SessionID string `json:"session_id"`
-	Signature []byte `json:"signature"`
+	Signature []byte `json:"signature"` // 65字节 [R || S || V]
+	TxHash    string `json:"tx_hash"`
 	Status    string `json:"status"`
 }
 
 // MPCBroadcastRequest MPC广播请求
 type MPCBroadcastRequest struct {
-	SessionID string           `json:"session_id"`
-	ChainName string           `json:"chain_name"`
-	From      string           `json:"from"`
-	To        string           `json:"to"`
-	Value     *big.Int         `json:"value"`
-	Data      []byte           `json:"data"`
-	GasLimit  uint64           `json:"gas_limit"`
-	GasPrice  *big.Int         `json:"gas_price"`
-	Signature []byte           `json:"signature"`
+	SessionID string   `json:"session_id"`
+	ChainName string   `json:"chain_name"`
+	From      string   `json:"from"`
+	To        string   `json:"to"`
+	Value     *big.Int `json:"value"`
+	Data      []byte   `json:"data"`
+	GasLimit  uint64   `json:"gas_limit"`
+	GasPrice  *big.Int `json:"gas_price"`
+	Nonce     *uint64  `json:"nonce"`     // 签名时使用的nonce，可通过 nonce/reserve 预留
+	Signature []byte   `json:"signature"` // 65字节 [R || S || V]
+
+	MaxFeePerGas         *big.Int `json:"max_fee_per_gas,omitempty"`
+	MaxPriorityFeePerGas *big.Int `json:"max_priority_fee_per_gas,omitempty"`
+}
+
+// NonceStatus nonce分配状态
+type NonceStatus struct {
+	ChainName string   `json:"chain_name"`
+	Address   string   `json:"address"`
+	Next      uint64   `json:"next_nonce"`
+	InFlight  []uint64 `json:"inflight_nonces"`
+	Gaps      []uint64 `json:"gap_nonces"`
+}
+
+// NonceReleaseRequest 释放预留nonce的请求
+type NonceReleaseRequest struct {
+	Nonce uint64 `json:"nonce"`
+}
+
+// RawTransactionRequest 原始交易广播请求
+type RawTransactionRequest struct {
+	RawTransaction string `json:"raw_transaction"` // hex编码的已签名交易（EVM为RLP/类型化编码）
 }
 
+// 交易生命周期状态
+const (
+	TxStatePending   = "pending"   // 已广播，在交易池中等待打包
+	TxStateIncluded  = "included"  // 已打包，确认数未达到要求
+	TxStateConfirmed = "confirmed" // 确认数已达到链配置的 Confirmations，最终状态
+	TxStateDropped   = "dropped"   // 节点中长时间查不到该交易，最终状态
+	TxStateReplaced  = "replaced"  // 同一nonce的其他交易已上链，最终状态
+	TxStateReorged   = "reorged"   // 所在区块被重组出主链，等待重新打包
+)
+
+// TrackedTransaction 被跟踪的交易及其生命周期状态
+type TrackedTransaction struct {
+	ChainName             string    `json:"chain_name"`
+	TxHash                string    `json:"tx_hash"`
+	State                 string    `json:"state"`
+	From                  string    `json:"from,omitempty"`
+	Nonce                 *uint64   `json:"nonce,omitempty"`
+	BlockNumber           uint64    `json:"block_number,omitempty"`
+	BlockHash             string    `json:"block_hash,omitempty"`
+	Success               *bool     `json:"success,omitempty"` // 执行结果，打包后才有值
+	Confirmations         uint64    `json:"confirmations"`
+	RequiredConfirmations uint64    `json:"required_confirmations"`
+	LastSeenAt            time.Time `json:"last_seen_at"` // 最近一次在节点中查到该交易的时间
+	CreatedAt             time.Time `json:"created_at"`
+	UpdatedAt             time.Time `json:"updated_at"`
+}
+
+// IsFinal 是否已进入最终状态，不再需要跟踪
+func (t *TrackedTransaction) IsFinal() bool {
+	switch t.State {
+	case TxStateConfirmed, TxStateDropped, TxStateReplaced:
+		return true
+	}
+	return false
+}
+
+// TxStatusEvent 交易状态变更事件，推送给订阅者和webhook
+type TxStatusEvent struct {
+	ChainName     string              `json:"chain_name"`
+	TxHash        string              `json:"tx_hash"`
+	PreviousState string              `json:"previous_state,omitempty"`
+	State         string              `json:"state"`
+	Transaction   *TrackedTransaction `json:"transaction"`
+	Timestamp     time.Time           `json:"timestamp"`
+}
+
+// TxChainStatus 节点中查询到的交易状态，供生命周期跟踪使用
+type TxChainStatus struct {
+	Found        bool   // 节点交易池或链上存在该交易
+	Pending      bool   // 仍在交易池中
+	BlockNumber  uint64 // 所在区块，Pending 为false时有效
+	BlockHash    string
+	Success      bool
+	HeadBlock    uint64 // 查询时的最新区块
+	From         string // 发送方及nonce，仅账户模型的链有值
+	Nonce        *uint64
+	AccountNonce uint64 // 发送方已上链的交易数，用于判断交易是否被替换
+}
+
+// 跨链转账状态
+const (
+	BridgeStateCreated         = "created"          // 已登记，尚未提交源链交易
+	BridgeStateSourceSubmitted = "source_submitted" // 源链锁定或销毁交易已提交
+	BridgeStateSourceConfirmed = "source_confirmed" // 源链交易已达到确认数
+	BridgeStateAttested        = "attested"         // 已从中继器取得证明
+	BridgeStateTargetSubmitted = "target_submitted" // 目标链铸造或释放交易已提交
+	BridgeStateCompleted       = "completed"
+	BridgeStateRefunding       = "refunding" // 目标链交易失败或证明超时，等待提交源链退款交易
+	BridgeStateRefundSubmitted = "refund_submitted"
+	BridgeStateRefunded        = "refunded"
+	BridgeStateFailed          = "failed" // 源链交易失败（资产未转出）或退款失败，需要人工处理
+)
+
 // CrossChainRequest 跨链请求
 type CrossChainRequest struct {
-	FromChain  string           `json:"from_chain"`
-	ToChain    string           `json:"to_chain"`
-	From       string           `json:"from"`
-	To         string           `json:"to"`
-	Amount     *big.Int         `json:"amount"`
-	Token      string           `json:"token"`
+	FromChain string   `json:"from_chain"`
+	ToChain   string   `json:"to_chain"`
+	From      string   `json:"from"`
+	To        string   `json:"to"`
+	Amount    *big.Int `json:"amount"`
+	Token     string   `json:"token"`           // 源链代币地址，为空表示原生币
+	Route     string   `json:"route,omitempty"` // 路由名称，为空时按链和代币匹配
 }
 
-// CrossChainStatus 跨链状态
+// CrossChainStatus 跨链转账状态
 type CrossChainStatus struct {
-	TransferID string    `json:"transfer_id"`
-	Status     string    `json:"status"`
-	SourceTx   string    `json:"source_tx"`
-	TargetTx   string    `json:"target_tx"`
-	Amount     *big.Int  `json:"amount"`
-	CreatedAt  time.Time `json:"created_at"`
-	UpdatedAt  time.Time `json:"updated_at"`
+	TransferID  string       `json:"transfer_id"` // 32字节十六进制，也是桥合约中的转账ID
+	Status      string       `json:"status"`
+	Route       string       `json:"route"`
+	Adapter     string       `json:"adapter"`
+	FromChain   string       `json:"from_chain"`
+	ToChain     string       `json:"to_chain"`
+	From        string       `json:"from"`
+	To          string       `json:"to"`
+	Token       string       `json:"token,omitempty"`
+	TargetToken string       `json:"target_token,omitempty"`
+	Amount      *big.Int     `json:"amount"`
+	ApproveTx   string       `json:"approve_tx,omitempty"` // 授权桥合约转移ERC-20代币的交易
+	SourceTx    string       `json:"source_tx"`
+	SourceBlock uint64       `json:"source_block,omitempty"`
+	Attestation string       `json:"attestation,omitempty"`
+	TargetTx    string       `json:"target_tx"`
+	RefundTx    string       `json:"refund_tx,omitempty"`
+	Error       string       `json:"error,omitempty"`
+	History     []BridgeStep `json:"history"`
+
+	SourceScanFrom uint64    `json:"source_scan_from"` // 在源链查找桥合约事件的起始区块
+	TargetScanFrom uint64    `json:"target_scan_from,omitempty"`
+	StateAt        time.Time `json:"state_at"` // 进入当前状态的时间
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// IsFinal 是否已进入最终状态
+func (s *CrossChainStatus) IsFinal() bool {
+	return s.Status == BridgeStateCompleted || s.Status == BridgeStateRefunded || s.Status == BridgeStateFailed
+}
+
+// BridgeStep 跨链转账的一次状态变更
+type BridgeStep struct {
+	State  string    `json:"state"`
+	TxHash string    `json:"tx_hash,omitempty"`
+	Error  string    `json:"error,omitempty"`
+	At     time.Time `json:"at"`
 }
 
 // HealthResponse 健康检查响应
@@ -169,14 +610,14 @@ type HealthResponse struct {
 
 // SessionStatusResponse 会话状态响应
 type SessionStatusResponse struct {
-	SessionID string `json:"session_id"`
+	SessionID string        `json:"session_id"`
 	Status    SessionStatus `json:"status"`
 }
 
 // PublicKeyResponse 公钥响应
 type PublicKeyResponse struct {
-	SessionID  string `json:"session_id"`
-	PublicKey  string `json:"public_key"`
+	SessionID string `json:"session_id"`
+	PublicKey string `json:"public_key"`
 }
 
 // VerifyResponse 验证响应
@@ -195,14 +636,74 @@ const (
 	SessionCancelled
 )
 
+// 事件类型
+const (
+	// EventTypeLog 合约日志事件，Data 中包含 contract_address、topics 和 data
+	EventTypeLog = "Log"
+	// EventTypeTransactionStatus 交易状态变更事件，Data 中包含 state、previous_state 和确认数
+	EventTypeTransactionStatus = "TransactionStatus"
+)
+
 // BlockchainEvent 区块链事件
 type BlockchainEvent struct {
+	ID          uint64                 `json:"id"` // 订阅内递增的序号，用作断线续传游标
 	ChainName   string                 `json:"chain_name"`
 	Type        string                 `json:"type"`
 	BlockNumber uint64                 `json:"block_number"`
 	BlockHash   string                 `json:"block_hash"`
 	TxHash      string                 `json:"tx_hash,omitempty"`
 	LogIndex    uint                   `json:"log_index,omitempty"`
+	Removed     bool                   `json:"removed"` // 链重组后回撤之前投递的事件，接收方需要撤销其影响
 	Data        map[string]interface{} `json:"data"`
 	Timestamp   time.Time              `json:"timestamp"`
-}
\ No newline at end of file
+}
+
+// UTXO 比特币未花费输出
+type UTXO struct {
+	TxID         string `json:"txid"`
+	Vout         uint32 `json:"vout"`
+	Address      string `json:"address"`
+	Amount       int64  `json:"amount"` // 单位: satoshi
+	ScriptPubKey string `json:"script_pubkey"`
+	Height       int64  `json:"height"`
+	Locked       bool   `json:"locked"` // 已被未完成的PSBT占用
+}
+
+// PSBTRequest 构建比特币PSBT请求
+type PSBTRequest struct {
+	From          string `json:"from"`
+	To            string `json:"to"`
+	Amount        int64  `json:"amount"`   // 单位: satoshi
+	FeeRate       int64  `json:"fee_rate"` // 单位: sat/vB，为0时自动估算
+	ChangeAddress string `json:"change_address,omitempty"`
+}
+
+// PSBTSighash PSBT输入的待签名哈希
+type PSBTSighash struct {
+	InputIndex int    `json:"input_index"`
+	Sighash    string `json:"sighash"` // hex编码，直接交给MPC签名
+	Amount     int64  `json:"amount"`
+}
+
+// PSBTResponse 构建PSBT响应
+type PSBTResponse struct {
+	PSBT      string        `json:"psbt"` // base64编码
+	Sighashes []PSBTSighash `json:"sighashes"`
+	Fee       int64         `json:"fee"`
+	FeeRate   int64         `json:"fee_rate"`
+	VSize     int64         `json:"vsize"`
+	Change    int64         `json:"change"`
+}
+
+// PSBTSignature PSBT输入签名
+type PSBTSignature struct {
+	InputIndex int    `json:"input_index"`
+	Signature  string `json:"signature"` // hex编码，64字节r||s或DER格式
+}
+
+// PSBTFinalizeRequest 完成PSBT签名并广播的请求
+type PSBTFinalizeRequest struct {
+	PSBT       string          `json:"psbt"`
+	PubKey     string          `json:"pub_key"` // 压缩公钥（hex）
+	Signatures []PSBTSignature `json:"signatures"`
+}